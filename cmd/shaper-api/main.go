@@ -15,26 +15,42 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/alexandremahdhaoui/shaper/internal/util/httputil"
 	"github.com/alexandremahdhaoui/shaper/internal/util/tlsutil"
 	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/dynamic"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlcache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	"github.com/alexandremahdhaoui/shaper/pkg/generated/shaperserver"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/alexandremahdhaoui/shaper/internal/adapter"
@@ -49,6 +65,9 @@ import (
 const (
 	Name             = "shaper-api"
 	ConfigPathEnvKey = "IPXER_CONFIG_PATH"
+
+	defaultContentURLTTL   = time.Hour
+	defaultContentCacheTTL = 5 * time.Minute
 )
 
 var (
@@ -69,10 +88,21 @@ func init() {
 type Config struct {
 	// Adapters
 
+	// Namespaces is the set of namespaces the Assignment and Profile resources are watched in.
+	// All namespaces are watched when empty. ClusterProfiles are always watched.
+	Namespaces []string `json:"namespaces,omitempty"`
+
 	// AssignmentNamespace is the namespace where the Assignment resources are located.
-	AssignmentNamespace string `json:"assignmentNamespace"`
+	//
+	// Deprecated: use Namespaces. Only used as the default of MachineNamespace.
+	AssignmentNamespace string `json:"assignmentNamespace,omitempty"`
 	// ProfileNamespace is the namespace where the Profile resources are located.
-	ProfileNamespace string `json:"profileNamespace"`
+	//
+	// Deprecated: use Namespaces. A Profile is looked up in the namespace of the Assignment referencing it.
+	ProfileNamespace string `json:"profileNamespace,omitempty"`
+	// MachineNamespace is the namespace where the Machine resources are recorded.
+	// Defaults to AssignmentNamespace. It is added to Namespaces when Namespaces is not empty.
+	MachineNamespace string `json:"machineNamespace,omitempty"`
 
 	// Kubeconfig
 
@@ -115,6 +145,72 @@ type Config struct {
 		Port int `json:"port"`
 	} `json:"apiServer"`
 
+	// ExternalBaseURL is the base URL of the API server as reached by the machines, e.g. "https://shaper.example.com".
+	// It is used in the URLs of the exposed content. When empty, it is derived from the Host and X-Forwarded-* headers
+	// of each request. A Profile may override it.
+	ExternalBaseURL string `json:"externalBaseURL,omitempty"`
+
+	// ContentURLSigning is the configuration of the signature of the exposed content URLs. A signed URL is bound to the
+	// UUID and buildarch of the machine and expires. URLs are not signed when SecretName is empty.
+	ContentURLSigning struct {
+		// SecretName is the name of the Secret holding the signing keys. Each key of its data is a key ID: the key whose
+		// ID sorts last signs new URLs and all keys verify them, so keys are rotated by adding a key with a greater ID.
+		SecretName string `json:"secretName,omitempty"`
+		// SecretNamespace is the namespace of the Secret. Defaults to MachineNamespace.
+		SecretNamespace string `json:"secretNamespace,omitempty"`
+		// TTL is the duration a signed URL is valid for, e.g. "30m". Defaults to 1h.
+		TTL string `json:"ttl,omitempty"`
+	} `json:"contentURLSigning,omitempty"`
+
+	// ContentCache is the configuration of the cache of the resolved and transformed content. Cached content is
+	// invalidated when a ConfigMap or Secret it is resolved from, or its Profile, changes.
+	ContentCache struct {
+		// MaxEntries is the maximum number of cached content. Content is not cached when 0.
+		MaxEntries int `json:"maxEntries,omitempty"`
+		// TTL is the duration content is cached for, e.g. "1m". It bounds the staleness of the content resolved from
+		// webhooks or from objects other than ConfigMaps and Secrets. Defaults to 5m.
+		TTL string `json:"ttl,omitempty"`
+	} `json:"contentCache,omitempty"`
+
+	// ContentResolution is the configuration of the resolution of the additional content of an iPXE script.
+	ContentResolution struct {
+		// Concurrency is the maximum number of additional content resolved concurrently. Defaults to 4.
+		Concurrency int `json:"concurrency,omitempty"`
+		// Timeout is the duration after which the resolution of an additional content is canceled, e.g. "10s".
+		// Defaults to 30s.
+		Timeout string `json:"timeout,omitempty"`
+	} `json:"contentResolution,omitempty"`
+
+	// ObjectRefs is the configuration of the resolution of the objects referenced by profiles, e.g. by objectRef
+	// content or parameters, or by the mTLS and basic auth references of webhooks.
+	ObjectRefs struct {
+		// Allowed lists the resources whose objects may be resolved. When set, objects are read from informers started
+		// the first time an object of their resource is resolved, and objects of other resources or namespaces are not
+		// resolved. When empty, every object is read from the API server on each request.
+		Allowed []ObjectRefRule `json:"allowed,omitempty"`
+		// Policy lists the objects the profiles and assignments of each namespace may reference. When set, an object
+		// is resolved only if a rule applying to the namespace of its referrer allows it. Every object may be
+		// referenced when empty. The objectRefPolicy of shaper-webhook should match it.
+		Policy []ObjectRefPolicyRule `json:"policy,omitempty"`
+	} `json:"objectRefs,omitempty"`
+
+	// Auth is the configuration of the authentication of the requests to the API server.
+	Auth struct {
+		// AllowedCIDRs are the source CIDRs of the clients allowed to reach the API server, e.g. "10.0.0.0/16".
+		// All clients are allowed when empty.
+		AllowedCIDRs []string `json:"allowedCIDRs,omitempty"`
+		// TrustedProxies are the CIDRs of the proxies in front of the API server. The IP of a client is read from the
+		// X-Forwarded-For or X-Real-IP headers only when its peer is a trusted proxy; it is the address of the peer
+		// otherwise. The base URL of the API server is likewise derived from the X-Forwarded-Proto, X-Forwarded-Host
+		// and X-Forwarded-Prefix headers only for trusted proxies. Forwarded headers are ignored when empty.
+		TrustedProxies []string `json:"trustedProxies,omitempty"`
+		// BearerTokenPath is the path to the file holding the tokens accepted by the iPXE and content endpoints, one
+		// per line. The first token is embedded in the bootstrap script; the others are still accepted, e.g. while
+		// rotating the token. Tokens are not required when empty. As the bootstrap script is served to any client and
+		// the token is shared by every machine, it requires AllowedCIDRs or mTLS (tls.clientAuth "require").
+		BearerTokenPath string `json:"bearerTokenPath,omitempty"`
+	} `json:"auth,omitempty"`
+
 	// TLS is the configuration for TLS/mTLS support.
 	TLS struct {
 		// Enabled enables TLS for the API server.
@@ -131,6 +227,27 @@ type Config struct {
 	} `json:"tls,omitempty"`
 }
 
+// ObjectRefPolicyRule allows the profiles and assignments of some namespaces to reference objects.
+type ObjectRefPolicyRule struct {
+	// ProfileNamespaces are the namespaces of the profiles and assignments the rule applies to; "" matches the
+	// ClusterProfiles. The rule applies to every referrer when empty.
+	ProfileNamespaces []string `json:"profileNamespaces,omitempty"`
+	// Allowed are the objects the referrers may reference.
+	Allowed []ObjectRefRule `json:"allowed"`
+}
+
+// ObjectRefRule allows the objects of a resource to be resolved.
+type ObjectRefRule struct {
+	// Group is the group of the resource, e.g. "" for ConfigMaps.
+	Group string `json:"group,omitempty"`
+	// Version is the version of the resource, e.g. "v1".
+	Version string `json:"version"`
+	// Resource is the plural name of the resource, e.g. "configmaps".
+	Resource string `json:"resource"`
+	// Namespaces are the namespaces of the allowed objects. Objects of every namespace are allowed when empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
 // ------------------------------------------------- Main ----------------------------------------------------------- //
 
 func main() {
@@ -188,6 +305,61 @@ func main() {
 		slog.Info("TLS enabled for API server", "clientAuth", config.TLS.ClientAuth)
 	}
 
+	// --------------------------------------------- Auth ----------------------------------------------------------- //
+
+	authenticators := make([]server.Authenticator, 0, 2)
+
+	trustedProxies, err := parseCIDRs(config.Auth.TrustedProxies)
+	if err != nil {
+		slog.ErrorContext(ctx, "parsing auth.trustedProxies", "error", err.Error())
+		gs.Shutdown(1)
+	}
+
+	if len(config.Auth.AllowedCIDRs) > 0 {
+		allowedCIDRs, err := parseCIDRs(config.Auth.AllowedCIDRs)
+		if err != nil {
+			slog.ErrorContext(ctx, "parsing auth.allowedCIDRs", "error", err.Error())
+			gs.Shutdown(1)
+		}
+
+		authenticators = append(authenticators, server.NewCIDRAuthenticator(allowedCIDRs))
+	}
+
+	var bearerTokens []string
+	if config.Auth.BearerTokenPath != "" {
+		b, err := os.ReadFile(config.Auth.BearerTokenPath)
+		if err != nil {
+			slog.ErrorContext(ctx, "reading auth.bearerTokenPath", "error", err.Error())
+			gs.Shutdown(1)
+		}
+
+		for _, line := range strings.Split(string(b), "\n") {
+			if token := strings.TrimSpace(line); token != "" {
+				bearerTokens = append(bearerTokens, token)
+			}
+		}
+
+		if len(bearerTokens) == 0 {
+			slog.ErrorContext(ctx, "auth.bearerTokenPath does not contain any token")
+			gs.Shutdown(1)
+		}
+
+		authenticators = append(authenticators, server.NewBearerTokenAuthenticator(bearerTokens))
+	}
+
+	// The first token is served by /boot.ipxe to any client and is shared by every machine: it cannot authenticate the
+	// machines on its own.
+	mTLS := tlsConfig != nil && tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert
+	if len(bearerTokens) > 0 && len(config.Auth.AllowedCIDRs) == 0 && !mTLS {
+		slog.ErrorContext(ctx, "auth.bearerTokenPath requires auth.allowedCIDRs or tls.clientAuth \"require\"")
+		gs.Shutdown(1)
+	}
+
+	bootstrapToken := ""
+	if len(bearerTokens) > 0 {
+		bootstrapToken = bearerTokens[0]
+	}
+
 	// --------------------------------------------- Client --------------------------------------------------------- //
 
 	restConfig, err := k8s.NewKubeRestConfig(config.KubeconfigPath)
@@ -196,9 +368,29 @@ func main() {
 		gs.Shutdown(1)
 	}
 
+	machineNamespace := config.MachineNamespace
+	if machineNamespace == "" {
+		machineNamespace = config.AssignmentNamespace
+	}
+
+	if machineNamespace == "" {
+		slog.ErrorContext(ctx, "machineNamespace or assignmentNamespace must be set")
+		gs.Shutdown(1)
+	}
+
+	signingSecret := k8stypes.NamespacedName{
+		Namespace: config.ContentURLSigning.SecretNamespace,
+		Name:      config.ContentURLSigning.SecretName,
+	}
+
+	if signingSecret.Namespace == "" {
+		signingSecret.Namespace = machineNamespace
+	}
+
 	// Create controller-runtime Manager for automatic caching
 	// Metrics and health probes are disabled because we use custom servers
 	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
+		Cache:  cacheOptions(config.Namespaces, machineNamespace, signingSecret),
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
 			BindAddress: "0", // Disable built-in metrics server (we have custom)
@@ -206,7 +398,6 @@ func main() {
 		HealthProbeBindAddress: "", // Disable built-in health probes (we have custom)
 		LeaderElection:         config.LeaderElection,
 		LeaderElectionID:       config.LeaderElectionID,
-		// Cache configuration will be added in Task 6
 	})
 	if err != nil {
 		slog.ErrorContext(ctx, "creating controller-runtime manager", "error", err.Error())
@@ -225,18 +416,111 @@ func main() {
 
 	// --------------------------------------------- Adapter -------------------------------------------------------- //
 
-	assignment := adapter.NewAssignment(cl, config.AssignmentNamespace)
-	profile := adapter.NewProfile(cl, config.ProfileNamespace)
+	assignment := adapter.NewAssignment(cl)
+	profile := adapter.NewProfile(cl)
+	machine := adapter.NewMachine(cl, machineNamespace)
 
 	inlineResolver := adapter.NewInlineResolver()
-	objectRefResolver := adapter.NewObjectRefResolver(dynCl)
+	objectRefPolicy := types.ObjectRefPolicy{Rules: make([]types.ObjectRefPolicyRule, 0, len(config.ObjectRefs.Policy))}
+	for _, rule := range config.ObjectRefs.Policy {
+		allowed := make([]types.ObjectRefRule, 0, len(rule.Allowed))
+		for _, r := range rule.Allowed {
+			allowed = append(allowed, types.ObjectRefRule(r))
+		}
+
+		objectRefPolicy.Rules = append(objectRefPolicy.Rules, types.ObjectRefPolicyRule{
+			ProfileNamespaces: rule.ProfileNamespaces,
+			Allowed:           allowed,
+		})
+	}
+
+	if len(objectRefPolicy.Rules) > 0 {
+		slog.Info("Object refs restricted by policy", "rules", len(objectRefPolicy.Rules))
+	}
+
+	objectRefResolver := adapter.NewObjectRefResolver(dynCl, adapter.WithObjectRefPolicy(objectRefPolicy))
+	if len(config.ObjectRefs.Allowed) > 0 {
+		rules := make([]types.ObjectRefRule, 0, len(config.ObjectRefs.Allowed))
+		for _, rule := range config.ObjectRefs.Allowed {
+			if rule.Version == "" || rule.Resource == "" {
+				slog.ErrorContext(ctx, "objectRefs.allowed must specify a version and a resource",
+					"group", rule.Group, "version", rule.Version, "resource", rule.Resource)
+				gs.Shutdown(1)
+			}
+
+			rules = append(rules, types.ObjectRefRule(rule))
+		}
+
+		objectRefResolver = adapter.NewCachedObjectRefResolver(ctx, dynCl, rules,
+			adapter.WithObjectRefPolicy(objectRefPolicy))
+
+		slog.Info("Object refs resolved from informers", "allowed", len(rules))
+	}
+
 	webhookResolver := adapter.NewWebhookResolver(objectRefResolver)
 
 	butaneTransformer := adapter.NewButaneTransformer()
 	webhookTransformer := adapter.NewWebhookTransformer(objectRefResolver)
 
 	// --------------------------------------------- Controller ----------------------------------------------------- //
-	var baseURL string
+
+	baseURL := config.ExternalBaseURL
+	if baseURL != "" {
+		if u, err := url.Parse(baseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			slog.ErrorContext(ctx, "externalBaseURL must be an absolute URL", "externalBaseURL", baseURL)
+			gs.Shutdown(1)
+		}
+	}
+
+	var signer controller.ContentURLSigner
+	if signingSecret.Name != "" {
+		ttl := defaultContentURLTTL
+		if config.ContentURLSigning.TTL != "" {
+			if ttl, err = time.ParseDuration(config.ContentURLSigning.TTL); err != nil || ttl <= 0 {
+				slog.ErrorContext(ctx, "contentURLSigning.ttl must be a positive duration",
+					"ttl", config.ContentURLSigning.TTL)
+				gs.Shutdown(1)
+			}
+		}
+
+		signer = controller.NewContentURLSigner(
+			adapter.NewSigningKeys(cl, signingSecret.Namespace, signingSecret.Name),
+			ttl,
+		)
+
+		slog.Info("Content URL signing enabled", "secret", signingSecret.String(), "ttl", ttl.String())
+	}
+
+	var contentCache controller.ContentCache
+	if config.ContentCache.MaxEntries > 0 {
+		ttl := defaultContentCacheTTL
+		if config.ContentCache.TTL != "" {
+			if ttl, err = time.ParseDuration(config.ContentCache.TTL); err != nil || ttl <= 0 {
+				slog.ErrorContext(ctx, "contentCache.ttl must be a positive duration", "ttl", config.ContentCache.TTL)
+				gs.Shutdown(1)
+			}
+		}
+
+		contentCache = controller.NewContentCache(config.ContentCache.MaxEntries, ttl)
+		prometheus.MustRegister(contentCache)
+
+		slog.Info("Content cache enabled", "maxEntries", config.ContentCache.MaxEntries, "ttl", ttl.String())
+	}
+
+	muxOptions := []controller.ResolveTransformerMuxOption{
+		controller.WithBatchConcurrency(config.ContentResolution.Concurrency),
+	}
+
+	if config.ContentResolution.Timeout != "" {
+		timeout, err := time.ParseDuration(config.ContentResolution.Timeout)
+		if err != nil || timeout <= 0 {
+			slog.ErrorContext(ctx, "contentResolution.timeout must be a positive duration",
+				"timeout", config.ContentResolution.Timeout)
+			gs.Shutdown(1)
+		}
+
+		muxOptions = append(muxOptions, controller.WithContentTimeout(timeout))
+	}
 
 	mux := controller.NewResolveTransformerMux(
 		baseURL,
@@ -249,20 +533,37 @@ func main() {
 			types.ButaneTransformerKind:  butaneTransformer,
 			types.WebhookTransformerKind: webhookTransformer,
 		},
+		signer,
+		contentCache,
+		muxOptions...,
 	)
 
-	ipxe := controller.NewIPXE(assignment, profile, mux)
-	content := controller.NewContent(profile, mux)
+	ipxe := controller.NewIPXE(assignment, profile, machine, mux, baseURL, bootstrapToken)
+	content := controller.NewContent(assignment, profile, machine, mux, baseURL)
+	machineEvents := controller.NewMachine(machine)
 
 	// --------------------------------------------- App ------------------------------------------------------------ //
 
 	shaperHandler := shaperserver.Handler(shaperserver.NewStrictHandler(
-		server.New(ipxe, content),
+		server.New(ipxe, content, machineEvents, signer),
 		nil, // TODO: prometheus middleware
 	))
 
+	// Wrap with BaseURLMiddleware to render absolute URLs to the exposed content
+	handlerWithMiddleware := server.BaseURLMiddleware(baseURL, tlsConfig != nil, trustedProxies)(shaperHandler)
+
+	// Wrap with MachineIdentityMiddleware when client certificates are required, to bind them to the machine UUID
+	if tlsConfig != nil && tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert {
+		handlerWithMiddleware = server.MachineIdentityMiddleware(handlerWithMiddleware)
+	}
+
+	// Wrap with AuthMiddleware to authenticate the requests; it reads the client IP injected by ClientIPMiddleware
+	if len(authenticators) > 0 {
+		handlerWithMiddleware = server.AuthMiddleware(authenticators...)(handlerWithMiddleware)
+	}
+
 	// Wrap with ClientIPMiddleware to inject client IP into context for logging
-	handlerWithMiddleware := server.ClientIPMiddleware(shaperHandler)
+	handlerWithMiddleware = server.ClientIPMiddleware(trustedProxies)(handlerWithMiddleware)
 
 	// Wrap with TLSLoggingMiddleware when TLS is enabled to log mTLS client connections
 	if tlsConfig != nil {
@@ -334,21 +635,39 @@ func main() {
 		}
 	}()
 
-	// Pre-register informers for Profile and Assignment types.
+	// Pre-register informers for Profile, ClusterProfile and Assignment types.
 	// This ensures the informers are created before we wait for cache sync.
 	// Without this, the dynamic cache client creates informers on-demand when first used,
 	// which can cause the first requests to hang waiting for the informer to sync.
-	slog.Info("Pre-registering informers for Profile and Assignment...")
+	slog.Info("Pre-registering informers for Profile, ClusterProfile and Assignment...")
 	cache := mgr.GetCache()
 	if _, err := cache.GetInformer(ctx, &v1alpha1.Profile{}); err != nil {
 		slog.ErrorContext(ctx, "failed to get Profile informer", "error", err.Error())
 		gs.Shutdown(1)
 	}
+	if _, err := cache.GetInformer(ctx, &v1alpha1.ClusterProfile{}); err != nil {
+		slog.ErrorContext(ctx, "failed to get ClusterProfile informer", "error", err.Error())
+		gs.Shutdown(1)
+	}
 	if _, err := cache.GetInformer(ctx, &v1alpha1.Assignment{}); err != nil {
 		slog.ErrorContext(ctx, "failed to get Assignment informer", "error", err.Error())
 		gs.Shutdown(1)
 	}
 
+	if signingSecret.Name != "" {
+		if _, err := cache.GetInformer(ctx, &corev1.Secret{}); err != nil {
+			slog.ErrorContext(ctx, "failed to get Secret informer", "error", err.Error())
+			gs.Shutdown(1)
+		}
+	}
+
+	if contentCache != nil {
+		if err := watchContentCacheInvalidation(ctx, restConfig, cache, contentCache); err != nil {
+			slog.ErrorContext(ctx, "watching content cache invalidation", "error", err.Error())
+			gs.Shutdown(1)
+		}
+	}
+
 	// Wait for cache to be synced before starting HTTP servers
 	slog.Info("Waiting for cache to sync...")
 	if !cache.WaitForCacheSync(ctx) {
@@ -367,3 +686,141 @@ func main() {
 
 	slog.Info("✅ gracefully stopped", "binary", Name)
 }
+
+// parseCIDRs parses the CIDRs of the configuration, e.g. "10.0.0.0/16".
+func parseCIDRs(cidrs []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("parsing CIDR %q: %w", cidr, err)
+		}
+
+		out = append(out, prefix.Masked())
+	}
+
+	return out, nil
+}
+
+// cacheOptions restricts the cache of the manager to the configured namespaces and to the namespace where machines
+// are recorded. The cache watches all namespaces when no namespace is configured. Cluster-scoped resources, such as
+// ClusterProfiles, are not affected by the restriction. Secrets are only cached for the secret holding the signing keys
+// of the content URLs, if any.
+func cacheOptions(
+	namespaces []string,
+	machineNamespace string,
+	signingSecret k8stypes.NamespacedName,
+) ctrlcache.Options {
+	opts := ctrlcache.Options{}
+
+	if signingSecret.Name != "" {
+		opts.ByObject = map[client.Object]ctrlcache.ByObject{
+			&corev1.Secret{}: {
+				Namespaces: map[string]ctrlcache.Config{signingSecret.Namespace: {}},
+				Field:      fields.OneTermEqualSelector("metadata.name", signingSecret.Name),
+			},
+		}
+	}
+
+	if len(namespaces) == 0 {
+		return opts
+	}
+
+	defaultNamespaces := make(map[string]ctrlcache.Config, len(namespaces)+1)
+	for _, namespace := range append(namespaces, machineNamespace) {
+		defaultNamespaces[namespace] = ctrlcache.Config{}
+	}
+
+	opts.DefaultNamespaces = defaultNamespaces
+
+	return opts
+}
+
+// watchContentCacheInvalidation invalidates the content cache when a ConfigMap, a Secret, or the spec of a Profile or
+// ClusterProfile changes. ConfigMaps and Secrets of every namespace are watched, as objectRefs may reference any
+// namespace; only their metadata is cached.
+func watchContentCacheInvalidation(
+	ctx context.Context,
+	restConfig *rest.Config,
+	cache ctrlcache.Cache,
+	contentCache controller.ContentCache,
+) error {
+	metadataCl, err := metadata.NewForConfig(restConfig)
+	if err != nil {
+		return err // TODO: wrap err
+	}
+
+	factory := metadatainformer.NewSharedInformerFactory(metadataCl, 0)
+
+	for _, resource := range []string{"configmaps", "secrets"} {
+		informer := factory.ForResource(corev1.SchemeGroupVersion.WithResource(resource)).Informer()
+
+		if _, err := informer.AddEventHandler(invalidationHandler(
+			func(oldObj, newObj metav1.Object) bool {
+				return oldObj.GetResourceVersion() != newObj.GetResourceVersion()
+			},
+			func(namespace, name string) {
+				contentCache.InvalidateObject(corev1.GroupName, resource, namespace, name)
+			},
+		)); err != nil {
+			return err // TODO: wrap err
+		}
+	}
+
+	for _, obj := range []client.Object{&v1alpha1.Profile{}, &v1alpha1.ClusterProfile{}} {
+		informer, err := cache.GetInformer(ctx, obj)
+		if err != nil {
+			return err // TODO: wrap err
+		}
+
+		if _, err := informer.AddEventHandler(invalidationHandler(
+			func(oldObj, newObj metav1.Object) bool {
+				return oldObj.GetGeneration() != newObj.GetGeneration()
+			},
+			contentCache.InvalidateProfile,
+		)); err != nil {
+			return err // TODO: wrap err
+		}
+	}
+
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	return nil
+}
+
+// invalidationHandler returns an event handler calling invalidate with the namespace and name of the deleted objects,
+// and of the updated objects that changed.
+func invalidationHandler(
+	changed func(oldObj, newObj metav1.Object) bool,
+	invalidate func(namespace, name string),
+) toolscache.ResourceEventHandlerFuncs {
+	return toolscache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj any) {
+			oldMeta, err := meta.Accessor(oldObj)
+			if err != nil {
+				return
+			}
+
+			newMeta, err := meta.Accessor(newObj)
+			if err != nil || !changed(oldMeta, newMeta) {
+				return
+			}
+
+			invalidate(newMeta.GetNamespace(), newMeta.GetName())
+		},
+		DeleteFunc: func(obj any) {
+			key, err := toolscache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return
+			}
+
+			namespace, name, err := toolscache.SplitMetaNamespaceKey(key)
+			if err != nil {
+				return
+			}
+
+			invalidate(namespace, name)
+		},
+	}
+}
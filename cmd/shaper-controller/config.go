@@ -45,6 +45,9 @@ type Config struct {
 
 	// Namespace is the namespace to watch (empty means all namespaces)
 	Namespace string `json:"namespace,omitempty"`
+
+	// MachineNamespace is the namespace shaper-api records the Machine resources in (empty means all namespaces)
+	MachineNamespace string `json:"machineNamespace,omitempty"`
 }
 
 // NewDefaultConfig returns a Config with sensible defaults
@@ -107,6 +110,9 @@ func (c *Config) applyEnvironmentOverrides() {
 	if val := os.Getenv("SHAPER_CONTROLLER_NAMESPACE"); val != "" {
 		c.Namespace = val
 	}
+	if val := os.Getenv("SHAPER_CONTROLLER_MACHINE_NAMESPACE"); val != "" {
+		c.MachineNamespace = val
+	}
 }
 
 // Validate checks if the configuration is valid
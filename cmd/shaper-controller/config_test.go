@@ -113,6 +113,7 @@ func TestLoadConfig_EnvironmentOverrides(t *testing.T) {
 	os.Setenv("SHAPER_CONTROLLER_LEADER_ELECTION", "true")
 	os.Setenv("SHAPER_CONTROLLER_DEV_MODE", "yes")
 	os.Setenv("SHAPER_CONTROLLER_NAMESPACE", "env-namespace")
+	os.Setenv("SHAPER_CONTROLLER_MACHINE_NAMESPACE", "env-machines")
 	defer func() {
 		os.Unsetenv("SHAPER_CONTROLLER_METRICS_ADDR")
 		os.Unsetenv("SHAPER_CONTROLLER_HEALTH_ADDR")
@@ -120,6 +121,7 @@ func TestLoadConfig_EnvironmentOverrides(t *testing.T) {
 		os.Unsetenv("SHAPER_CONTROLLER_LEADER_ELECTION")
 		os.Unsetenv("SHAPER_CONTROLLER_DEV_MODE")
 		os.Unsetenv("SHAPER_CONTROLLER_NAMESPACE")
+		os.Unsetenv("SHAPER_CONTROLLER_MACHINE_NAMESPACE")
 	}()
 
 	// Load config with empty path (env vars only)
@@ -133,6 +135,7 @@ func TestLoadConfig_EnvironmentOverrides(t *testing.T) {
 	assert.True(t, config.LeaderElection)
 	assert.True(t, config.DevelopmentMode)
 	assert.Equal(t, "env-namespace", config.Namespace)
+	assert.Equal(t, "env-machines", config.MachineNamespace)
 }
 
 func TestLoadConfig_EnvironmentOverridesJSON(t *testing.T) {
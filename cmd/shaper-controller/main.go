@@ -18,14 +18,19 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/alexandremahdhaoui/shaper/internal/adapter"
+	"github.com/alexandremahdhaoui/shaper/internal/controller"
 	"github.com/alexandremahdhaoui/shaper/internal/controller/reconciler"
+	"github.com/alexandremahdhaoui/shaper/internal/types"
 	"github.com/alexandremahdhaoui/shaper/internal/util/logging"
 	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 )
@@ -76,7 +81,7 @@ func main() {
 	}
 
 	// Setup controllers
-	if err := setupControllers(mgr, ctrl.Log); err != nil {
+	if err := setupControllers(mgr, config, ctrl.Log); err != nil {
 		setupLog.Error(err, "unable to setup controllers")
 		os.Exit(1)
 	}
@@ -99,28 +104,78 @@ func main() {
 }
 
 // setupControllers registers all reconcilers with the manager
-func setupControllers(mgr ctrl.Manager, log logr.Logger) error {
+func setupControllers(mgr ctrl.Manager, config *Config, log logr.Logger) error {
+	// Dynamic client is needed by the ObjectRefResolver to verify the content of profiles resolves
+	dynCl, err := dynamic.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	objectRefResolver := adapter.NewObjectRefResolver(dynCl)
+
+	resolvers := map[types.ResolverKind]adapter.Resolver{
+		types.InlineResolverKind:    adapter.NewInlineResolver(),
+		types.ObjectRefResolverKind: objectRefResolver,
+		types.WebhookResolverKind:   adapter.NewWebhookResolver(objectRefResolver),
+	}
+
+	transformers := map[types.TransformerKind]adapter.Transformer{
+		types.ButaneTransformerKind:  adapter.NewButaneTransformer(),
+		types.WebhookTransformerKind: adapter.NewWebhookTransformer(objectRefResolver),
+	}
+
 	// Setup ProfileReconciler
 	profileReconciler := &reconciler.ProfileReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 		Log:    log.WithName("controllers").WithName("Profile"),
+		// The base URL is only used to template exposed content, which is not done by the dry-run.
+		Mux: controller.NewResolveTransformerMux("", resolvers, transformers, nil, nil),
 	}
 	if err := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.Profile{}).
+		Watches(&v1alpha1.Profile{}, handler.EnqueueRequestsFromMapFunc(profileReconciler.MapBaseProfileToChildren)).
 		Complete(profileReconciler); err != nil {
 		return fmt.Errorf("failed to create Profile controller: %w", err)
 	}
 	log.Info("Profile controller registered")
 
-	// Setup AssignmentReconciler
-	assignmentReconciler := &reconciler.AssignmentReconciler{
+	// Setup ClusterProfileReconciler
+	clusterProfileReconciler := &reconciler.ClusterProfileReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
-		Log:    log.WithName("controllers").WithName("Assignment"),
+		Log:    log.WithName("controllers").WithName("ClusterProfile"),
+		Mux:    profileReconciler.Mux,
+	}
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.ClusterProfile{}).
+		Watches(&v1alpha1.ClusterProfile{}, handler.EnqueueRequestsFromMapFunc(
+			clusterProfileReconciler.MapBaseClusterProfileToChildren,
+		)).
+		Complete(clusterProfileReconciler); err != nil {
+		return fmt.Errorf("failed to create ClusterProfile controller: %w", err)
+	}
+	log.Info("ClusterProfile controller registered")
+
+	// Setup AssignmentReconciler
+	assignmentReconciler := &reconciler.AssignmentReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Log:       log.WithName("controllers").WithName("Assignment"),
+		Resolvers: resolvers,
+		// Machines are recorded by shaper-api in its machine namespace, whatever the namespace of their assignment.
+		MachineNamespace: config.MachineNamespace,
 	}
 	if err := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.Assignment{}).
+		Watches(&v1alpha1.Assignment{}, handler.EnqueueRequestsFromMapFunc(
+			assignmentReconciler.MapAssignmentToTiedAssignments,
+		)).
+		Watches(&v1alpha1.Profile{}, handler.EnqueueRequestsFromMapFunc(assignmentReconciler.MapProfileToAssignments)).
+		Watches(&v1alpha1.ClusterProfile{}, handler.EnqueueRequestsFromMapFunc(
+			assignmentReconciler.MapProfileToAssignments,
+		)).
+		Watches(&v1alpha1.Machine{}, handler.EnqueueRequestsFromMapFunc(assignmentReconciler.MapMachineToAssignment)).
 		Complete(assignmentReconciler); err != nil {
 		return fmt.Errorf("failed to create Assignment controller: %w", err)
 	}
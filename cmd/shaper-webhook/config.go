@@ -20,6 +20,8 @@ import (
 	"os"
 
 	"sigs.k8s.io/yaml"
+
+	"github.com/alexandremahdhaoui/shaper/internal/types"
 )
 
 const (
@@ -58,10 +60,28 @@ type Config struct {
 	// Adapters
 
 	// AssignmentNamespace is the namespace where the Assignment resources are located.
+	//
+	// Deprecated: the webhooks admit the objects of every namespace and look up the profiles of an assignment in its
+	// namespace. It is ignored.
 	AssignmentNamespace string `json:"assignmentNamespace"`
 	// ProfileNamespace is the namespace where the Profile resources are located.
+	//
+	// Deprecated: the webhooks admit the objects of every namespace and look up the profiles of an assignment in its
+	// namespace. It is ignored.
 	ProfileNamespace string `json:"profileNamespace"`
 
+	// ProfileDeletionPolicy is either "Warn" or "Block". It defines whether deleting a profile still referenced by
+	// assignments is admitted with a warning or rejected. Defaults to "Warn".
+	ProfileDeletionPolicy string `json:"profileDeletionPolicy"`
+	// AssignmentConflictPolicy is either "Reject" or "Warn". It defines whether an assignment selecting the same UUID,
+	// or being the default for the same buildarch, as another assignment of the same priority is rejected or admitted
+	// with a warning. Defaults to "Reject".
+	AssignmentConflictPolicy string `json:"assignmentConflictPolicy"`
+	// ObjectRefPolicy lists the objects the profiles and assignments of each namespace may reference. When set, a
+	// profile or assignment referencing an object not allowed by a rule applying to its namespace is rejected. Every
+	// object may be referenced when empty. It should match the objectRefs.policy of shaper-api.
+	ObjectRefPolicy []ObjectRefPolicyRule `json:"objectRefPolicy,omitempty"`
+
 	// Kubeconfig
 
 	// KubeconfigPath is the path to the kubeconfig file.
@@ -99,3 +119,43 @@ type Config struct {
 		Port int `json:"port"`
 	} `json:"metricsServer"`
 }
+
+// ObjectRefPolicyRule allows the profiles of some namespaces to reference objects.
+type ObjectRefPolicyRule struct {
+	// ProfileNamespaces are the namespaces of the profiles the rule applies to; "" matches the ClusterProfiles. The
+	// rule applies to every profile when empty.
+	ProfileNamespaces []string `json:"profileNamespaces,omitempty"`
+	// Allowed are the objects the profiles may reference.
+	Allowed []ObjectRefRule `json:"allowed"`
+}
+
+// ObjectRefRule allows the objects of a resource to be referenced.
+type ObjectRefRule struct {
+	// Group is the group of the resource, e.g. "" for ConfigMaps.
+	Group string `json:"group,omitempty"`
+	// Version is the version of the resource, e.g. "v1".
+	Version string `json:"version"`
+	// Resource is the plural name of the resource, e.g. "configmaps".
+	Resource string `json:"resource"`
+	// Namespaces are the namespaces of the allowed objects. Objects of every namespace are allowed when empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// objectRefPolicy converts the rules of the configuration into a types.ObjectRefPolicy.
+func objectRefPolicy(rules []ObjectRefPolicyRule) types.ObjectRefPolicy {
+	policy := types.ObjectRefPolicy{Rules: make([]types.ObjectRefPolicyRule, 0, len(rules))}
+
+	for _, rule := range rules {
+		allowed := make([]types.ObjectRefRule, 0, len(rule.Allowed))
+		for _, r := range rule.Allowed {
+			allowed = append(allowed, types.ObjectRefRule(r))
+		}
+
+		policy.Rules = append(policy.Rules, types.ObjectRefPolicyRule{
+			ProfileNamespaces: rule.ProfileNamespaces,
+			Allowed:           allowed,
+		})
+	}
+
+	return policy
+}
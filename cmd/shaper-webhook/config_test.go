@@ -37,6 +37,14 @@ func TestLoadConfig(t *testing.T) {
 			configYAML: `
 assignmentNamespace: "default"
 profileNamespace: "default"
+profileDeletionPolicy: "Block"
+assignmentConflictPolicy: "Warn"
+objectRefPolicy:
+  - profileNamespaces: ["tenant-a"]
+    allowed:
+      - version: v1
+        resource: secrets
+        namespaces: ["tenant-a"]
 kubeconfigPath: "in-cluster"
 webhookServer:
   port: 9443
@@ -52,9 +60,19 @@ metricsServer:
   path: "/metrics"
 `,
 			expectedConfig: &Config{
-				AssignmentNamespace: "default",
-				ProfileNamespace:    "default",
-				KubeconfigPath:      "in-cluster",
+				AssignmentNamespace:      "default",
+				ProfileNamespace:         "default",
+				ProfileDeletionPolicy:    "Block",
+				AssignmentConflictPolicy: "Warn",
+				ObjectRefPolicy: []ObjectRefPolicyRule{{
+					ProfileNamespaces: []string{"tenant-a"},
+					Allowed: []ObjectRefRule{{
+						Version:    "v1",
+						Resource:   "secrets",
+						Namespaces: []string{"tenant-a"},
+					}},
+				}},
+				KubeconfigPath: "in-cluster",
 				WebhookServer: struct {
 					Port     int    `json:"port"`
 					CertDir  string `json:"certDir"`
@@ -132,6 +150,9 @@ metricsServer:
 			// Verify basic fields
 			assert.Equal(t, tt.expectedConfig.AssignmentNamespace, config.AssignmentNamespace)
 			assert.Equal(t, tt.expectedConfig.ProfileNamespace, config.ProfileNamespace)
+			assert.Equal(t, tt.expectedConfig.ProfileDeletionPolicy, config.ProfileDeletionPolicy)
+			assert.Equal(t, tt.expectedConfig.AssignmentConflictPolicy, config.AssignmentConflictPolicy)
+			assert.Equal(t, tt.expectedConfig.ObjectRefPolicy, config.ObjectRefPolicy)
 			assert.Equal(t, tt.expectedConfig.KubeconfigPath, config.KubeconfigPath)
 
 			// Verify webhook server config
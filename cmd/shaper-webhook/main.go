@@ -19,6 +19,7 @@ import (
 	"log/slog"
 	"os"
 
+	"k8s.io/client-go/dynamic"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -84,10 +85,18 @@ func main() {
 		gs.Shutdown(1)
 	}
 
+	// Dynamic client is needed by the ObjectRefResolver to verify the objects referenced by profiles exist
+	dynCl, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		slog.ErrorContext(ctx, "creating dynamic client", "error", err.Error())
+		gs.Shutdown(1)
+	}
+
 	// --------------------------------------------- Adapter -------------------------------------------------------- //
 
-	assignment := adapter.NewAssignment(cl, config.AssignmentNamespace)
-	profile := adapter.NewProfile(cl, config.ProfileNamespace)
+	assignment := adapter.NewAssignment(cl)
+	profile := adapter.NewProfile(cl)
+	objectRefResolver := adapter.NewObjectRefResolver(dynCl)
 
 	// --------------------------------------------- Manager -------------------------------------------------------- //
 
@@ -120,11 +129,23 @@ func main() {
 	// --------------------------------------------- Webhooks ------------------------------------------------------- //
 
 	// Create webhook instances
-	assignmentWebhook := driverwebhook.NewAssignment(assignment, profile)
-	profileWebhook := driverwebhook.NewProfile()
+	assignmentWebhook := driverwebhook.NewAssignment(
+		assignment,
+		profile,
+		objectRefPolicy(config.ObjectRefPolicy),
+		driverwebhook.AssignmentConflictPolicy(config.AssignmentConflictPolicy),
+	)
+	profileWebhook := driverwebhook.NewProfile(
+		assignment,
+		profile,
+		objectRefResolver,
+		objectRefPolicy(config.ObjectRefPolicy),
+		driverwebhook.ProfileDeletionPolicy(config.ProfileDeletionPolicy),
+	)
+	clusterProfileWebhook := driverwebhook.NewClusterProfile(profileWebhook)
 
 	// Set up webhook server
-	if err := setupWebhookServer(mgr, assignmentWebhook, profileWebhook); err != nil {
+	if err := setupWebhookServer(mgr, assignmentWebhook, profileWebhook, clusterProfileWebhook); err != nil {
 		slog.ErrorContext(ctx, "setting up webhook server", "error", err.Error())
 		gs.Shutdown(1)
 	}
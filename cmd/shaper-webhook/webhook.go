@@ -28,6 +28,7 @@ func setupWebhookServer(
 	mgr ctrl.Manager,
 	assignmentWebhook *driverwebhook.Assignment,
 	profileWebhook *driverwebhook.Profile,
+	clusterProfileWebhook *driverwebhook.ClusterProfile,
 ) error {
 	server := mgr.GetWebhookServer()
 
@@ -79,5 +80,29 @@ func setupWebhookServer(
 		},
 	)
 
+	// Register ClusterProfile validation webhook
+	server.Register(
+		"/validate-shaper-amahdha-com-v1alpha1-clusterprofile",
+		&webhook.Admission{
+			Handler: admission.WithCustomValidator(
+				mgr.GetScheme(),
+				&v1alpha1.ClusterProfile{},
+				clusterProfileWebhook,
+			),
+		},
+	)
+
+	// Register ClusterProfile mutation webhook
+	server.Register(
+		"/mutate-shaper-amahdha-com-v1alpha1-clusterprofile",
+		&webhook.Admission{
+			Handler: admission.WithCustomDefaulter(
+				mgr.GetScheme(),
+				&v1alpha1.ClusterProfile{},
+				clusterProfileWebhook,
+			),
+		},
+	)
+
 	return nil
 }
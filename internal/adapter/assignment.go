@@ -15,10 +15,16 @@
 package adapter
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"maps"
+	"net/netip"
+	"slices"
 
 	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/alexandremahdhaoui/shaper/internal/types"
 	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
@@ -28,36 +34,50 @@ import (
 var (
 	ErrAssignmentNotFound = errors.New("assignment not found")
 
-	errAssignmentFindDefault     = errors.New("finding default assignment")
-	errAssignmentFindBySelectors = errors.New("error finding assignment by selectors")
-	errAssignmentList            = errors.New("listing assignment")
+	errAssignmentFindDefault       = errors.New("finding default assignment")
+	errAssignmentFindBySelectors   = errors.New("error finding assignment by selectors")
+	errAssignmentList              = errors.New("listing assignment")
+	errAssignmentListByProfileName = errors.New("listing assignment by profile name")
+	errAssignmentListByUUID        = errors.New("listing assignment by uuid")
+	errAssignmentListDefault       = errors.New("listing default assignment by buildarch")
+	errConvertingAssignment        = errors.New("converting assignment")
 )
 
+// MatchedByMachineSelector is returned by FindBySelectors when the machine selector of the assignment matched.
+const MatchedByMachineSelector = "machineSelector"
+
 // --------------------------------------------------- INTERFACES --------------------------------------------------- //
 
 // Assignment is an interface for finding assignments.
 type Assignment interface {
 	// FindDefaultByBuildarch finds the default assignment for a given build architecture.
 	FindDefaultByBuildarch(ctx context.Context, buildarch string) (types.Assignment, error)
-	// FindBySelectors finds an assignment by a given set of selectors.
-	FindBySelectors(ctx context.Context, selectors types.IPXESelectors) (types.Assignment, error)
+	// FindBySelectors finds an assignment by a given set of selectors. It also returns how the assignment matched the
+	// selectors: the subject prefix of the matching attribute, or MatchedByMachineSelector.
+	FindBySelectors(ctx context.Context, selectors types.IPXESelectors) (types.Assignment, string, error)
+	// ListByProfileName lists the assignments of a namespace referencing a profile.
+	// An empty namespace lists the assignments of every namespace referencing the ClusterProfile.
+	ListByProfileName(ctx context.Context, profileName, namespace string) ([]types.Assignment, error)
+	// ListByUUID lists the assignments selecting a UUID, sorted by namespace and name.
+	ListByUUID(ctx context.Context, id uuid.UUID) ([]types.Assignment, error)
+	// ListDefaultByBuildarch lists the default assignments for a given build architecture, sorted by namespace and
+	// name.
+	ListDefaultByBuildarch(ctx context.Context, buildarch string) ([]types.Assignment, error)
 }
 
 // --------------------------------------------------- CONSTRUCTORS ------------------------------------------------- //
 
-// NewAssignment returns a new Assignment.
-func NewAssignment(c client.Client, namespace string) Assignment {
+// NewAssignment returns a new Assignment. It finds the assignments of every namespace visible to the client.
+func NewAssignment(c client.Client) Assignment {
 	return &assignment{
-		client:    c,
-		namespace: namespace,
+		client: c,
 	}
 }
 
 // --------------------------------------------- CONCRETE IMPLEMENTATION -------------------------------------------- //
 
 type assignment struct {
-	client    client.Client
-	namespace string
+	client client.Client
 }
 
 // --------------------------------------------- FindDefaultByBuildarch ------------------------------------------------- //
@@ -82,75 +102,267 @@ func (a *assignment) FindDefaultByBuildarch(ctx context.Context, buildarch strin
 		return types.Assignment{}, errors.Join(ErrAssignmentNotFound, errAssignmentFindDefault)
 	}
 
-	var subjectSelectors map[string][]string
-	if len(list.Items[0].Spec.SubjectSelectors.BuildarchList) > 0 || len(list.Items[0].Spec.SubjectSelectors.UUIDList) > 0 {
-		subjectSelectors = make(map[string][]string)
-		if len(list.Items[0].Spec.SubjectSelectors.BuildarchList) > 0 {
-			buildarchStrings := make([]string, len(list.Items[0].Spec.SubjectSelectors.BuildarchList))
-			for i, ba := range list.Items[0].Spec.SubjectSelectors.BuildarchList {
-				buildarchStrings[i] = string(ba)
-			}
-			subjectSelectors["buildarch"] = buildarchStrings
+	candidates := make([]candidate, 0, len(list.Items))
+	for _, item := range list.Items {
+		candidates = append(candidates, candidate{assignment: item})
+	}
+
+	winner := selectCandidate(candidates)
+
+	out, err := toTypesAssignment(winner.assignment)
+	if err != nil {
+		return types.Assignment{}, errors.Join(err, errAssignmentFindDefault)
+	}
+
+	return out, nil
+}
+
+// --------------------------------------------- FindBySelectors --------------------------------------------- //
+
+// FindBySelectors evaluates every assignment matching the selectors and returns the one with the highest priority.
+//
+// Candidates are assignments matching any subject attribute of the selectors, i.e. UUID, MAC, serial number, etc., and
+// assignments whose machine selector matches the attributes of the machine. When priorities are equal, the most
+// specific match wins following the order defined by v1alpha1.SubjectPrefixes; machine selectors come last. Remaining
+// ties are broken by name; the AssignmentReconciler reports them in the Ambiguous condition of the assignments.
+func (a *assignment) FindBySelectors(
+	ctx context.Context,
+	selectors types.IPXESelectors,
+) (types.Assignment, string, error) {
+	queries := append(subjectQueries(selectors), assignmentQuery{
+		matchedBy: MatchedByMachineSelector,
+		opt:       machineSelectorLabelSelector(),
+	})
+	machine := machineLabels(selectors)
+
+	candidates := make(map[string]candidate)
+	for rank, query := range queries {
+		// list assignment
+		list := new(v1alpha1.AssignmentList)
+
+		// Build list options, filtering out nil values
+		opts := make([]client.ListOption, 0, 2)
+		if buildarchOpt := buildarchLabelSelector(selectors.Buildarch); buildarchOpt != nil {
+			opts = append(opts, buildarchOpt)
 		}
-		if len(list.Items[0].Spec.SubjectSelectors.UUIDList) > 0 {
-			subjectSelectors["uuid"] = list.Items[0].Spec.SubjectSelectors.UUIDList
+		opts = append(opts, query.opt)
+
+		if err := a.client.List(ctx, list, opts...); err != nil {
+			return types.Assignment{}, "", errors.Join(err, errAssignmentList, errAssignmentFindBySelectors)
+		}
+
+		for _, item := range list.Items {
+			key := client.ObjectKeyFromObject(&item).String()
+			if _, ok := candidates[key]; ok {
+				continue // a more specific query already matched this assignment.
+			}
+
+			if rank == len(queries)-1 && !machineSelectorMatches(item, machine) {
+				continue
+			}
+
+			candidates[key] = candidate{assignment: item, rank: rank}
 		}
 	}
 
-	return types.Assignment{
-		Name:             list.Items[0].Name,
-		Namespace:        list.Items[0].Namespace,
-		ProfileName:      list.Items[0].Spec.ProfileName,
-		SubjectSelectors: subjectSelectors,
-	}, nil
+	if len(candidates) == 0 {
+		return types.Assignment{}, "", errors.Join(ErrAssignmentNotFound, errAssignmentFindBySelectors)
+	}
+
+	winner := selectCandidate(slices.Collect(maps.Values(candidates)))
+
+	out, err := toTypesAssignment(winner.assignment)
+	if err != nil {
+		return types.Assignment{}, "", errors.Join(err, errAssignmentFindBySelectors)
+	}
+
+	return out, queries[winner.rank].matchedBy, nil
 }
 
-// --------------------------------------------- FindBySelectors --------------------------------------------- //
+// --------------------------------------------- ListByProfileName ------------------------------------------------- //
+
+func (a *assignment) ListByProfileName(
+	ctx context.Context,
+	profileName, namespace string,
+) ([]types.Assignment, error) {
+	kind := types.NamespacedProfileKind
+	if namespace == "" {
+		kind = types.ClusterProfileKind
+	}
 
-func (a *assignment) FindBySelectors(ctx context.Context, selectors types.IPXESelectors) (types.Assignment, error) {
-	// list assignment
 	list := new(v1alpha1.AssignmentList)
+	if err := a.client.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Join(err, errAssignmentList, errAssignmentListByProfileName)
+	}
 
-	// Build list options, filtering out nil values
-	opts := make([]client.ListOption, 0, 2)
-	if opt := buildarchLabelSelector(selectors.Buildarch); opt != nil {
-		opts = append(opts, opt)
+	out := make([]types.Assignment, 0)
+	for _, item := range list.Items {
+		if item.Spec.ProfileName != profileName || toTypesProfileKind(item.Spec.ProfileKind) != kind {
+			continue
+		}
+
+		assignment, err := toTypesAssignment(item)
+		if err != nil {
+			return nil, errors.Join(err, errAssignmentListByProfileName)
+		}
+
+		out = append(out, assignment)
 	}
-	if opt := uuidLabelSelector(selectors.UUID); opt != nil {
+
+	return out, nil
+}
+
+// --------------------------------------------- ListByUUID -------------------------------------------------------- //
+
+func (a *assignment) ListByUUID(ctx context.Context, id uuid.UUID) ([]types.Assignment, error) {
+	out, err := a.listByLabels(ctx, uuidLabelSelector(id))
+	if err != nil {
+		return nil, errors.Join(err, errAssignmentListByUUID)
+	}
+
+	return out, nil
+}
+
+// --------------------------------------------- ListDefaultByBuildarch --------------------------------------------- //
+
+func (a *assignment) ListDefaultByBuildarch(ctx context.Context, buildarch string) ([]types.Assignment, error) {
+	opts := []client.ListOption{defaultAssignmentLabelSelector()}
+	if opt := buildarchLabelSelector(buildarch); opt != nil {
 		opts = append(opts, opt)
 	}
 
+	out, err := a.listByLabels(ctx, opts...)
+	if err != nil {
+		return nil, errors.Join(err, errAssignmentListDefault)
+	}
+
+	return out, nil
+}
+
+// listByLabels lists the assignments matching the list options, sorted by namespace and name.
+func (a *assignment) listByLabels(ctx context.Context, opts ...client.ListOption) ([]types.Assignment, error) {
+	list := new(v1alpha1.AssignmentList)
 	if err := a.client.List(ctx, list, opts...); err != nil {
-		return types.Assignment{}, errors.Join(err, errAssignmentList, errAssignmentFindBySelectors)
+		return nil, errors.Join(err, errAssignmentList)
 	}
 
-	if list == nil || len(list.Items) == 0 {
-		return types.Assignment{}, errors.Join(ErrAssignmentNotFound, errAssignmentFindBySelectors)
+	slices.SortFunc(list.Items, func(a, b v1alpha1.Assignment) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+
+	out := make([]types.Assignment, 0, len(list.Items))
+	for _, item := range list.Items {
+		assignment, err := toTypesAssignment(item)
+		if err != nil {
+			return nil, errors.Join(err, errConvertingAssignment)
+		}
+
+		out = append(out, assignment)
 	}
 
-	var subjectSelectors map[string][]string
-	if len(list.Items[0].Spec.SubjectSelectors.BuildarchList) > 0 || len(list.Items[0].Spec.SubjectSelectors.UUIDList) > 0 {
-		subjectSelectors = make(map[string][]string)
-		if len(list.Items[0].Spec.SubjectSelectors.BuildarchList) > 0 {
-			buildarchStrings := make([]string, len(list.Items[0].Spec.SubjectSelectors.BuildarchList))
-			for i, ba := range list.Items[0].Spec.SubjectSelectors.BuildarchList {
-				buildarchStrings[i] = string(ba)
-			}
-			subjectSelectors["buildarch"] = buildarchStrings
+	return out, nil
+}
+
+// --------------------------------------------- SELECTION ---------------------------------------------------------- //
+
+// candidate is an assignment matching a machine. The rank is the index of the query that matched it: the lower, the
+// more specific.
+type candidate struct {
+	assignment v1alpha1.Assignment
+	rank       int
+}
+
+// selectCandidate deterministically selects the candidate with the highest priority, then the lowest rank, then the
+// lowest name and namespace.
+func selectCandidate(candidates []candidate) candidate {
+	return slices.MinFunc(candidates, func(a, b candidate) int {
+		return cmp.Or(
+			cmp.Compare(b.assignment.Spec.Priority, a.assignment.Spec.Priority),
+			cmp.Compare(a.rank, b.rank),
+			cmp.Compare(a.assignment.Name, b.assignment.Name),
+			cmp.Compare(a.assignment.Namespace, b.assignment.Namespace),
+		)
+	})
+}
+
+// --------------------------------------------- CONVERSION --------------------------------------------------------- //
+
+func toTypesAssignment(input v1alpha1.Assignment) (types.Assignment, error) {
+	subjectSelectors := input.Spec.SubjectSelectors.ByPrefix()
+	if len(input.Spec.SubjectSelectors.BuildarchList) > 0 {
+		buildarchStrings := make([]string, len(input.Spec.SubjectSelectors.BuildarchList))
+		for i, ba := range input.Spec.SubjectSelectors.BuildarchList {
+			buildarchStrings[i] = string(ba)
 		}
-		if len(list.Items[0].Spec.SubjectSelectors.UUIDList) > 0 {
-			subjectSelectors["uuid"] = list.Items[0].Spec.SubjectSelectors.UUIDList
+		subjectSelectors[v1alpha1.BuildarchPrefix] = buildarchStrings
+	}
+
+	if len(subjectSelectors) == 0 {
+		subjectSelectors = nil
+	}
+
+	var userLabels map[string]string
+	for k, v := range input.Labels {
+		if v1alpha1.IsInternalLabel(k) {
+			continue
 		}
+
+		if userLabels == nil {
+			userLabels = make(map[string]string)
+		}
+
+		userLabels[k] = v
+	}
+
+	parameters, err := fromV1alpha1.toParameters(input.Spec.Parameters)
+	if err != nil {
+		return types.Assignment{}, errors.Join(err, errConvertingAssignment)
+	}
+
+	setReferrerNamespace(parameters, input.Namespace)
+
+	var allowedCIDRs []netip.Prefix
+	for _, cidr := range input.Spec.AllowedCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return types.Assignment{}, errors.Join(err, errConvertingAssignment)
+		}
+
+		allowedCIDRs = append(allowedCIDRs, prefix.Masked())
 	}
 
 	return types.Assignment{
-		Name:             list.Items[0].Name,
-		Namespace:        list.Items[0].Namespace,
-		ProfileName:      list.Items[0].Spec.ProfileName,
+		Name:             input.Name,
+		Namespace:        input.Namespace,
+		Labels:           userLabels,
+		ProfileName:      input.Spec.ProfileName,
+		ProfileKind:      toTypesProfileKind(input.Spec.ProfileKind),
 		SubjectSelectors: subjectSelectors,
+		BootMode:         toTypesBootMode(input.Spec.BootMode),
+		Priority:         input.Spec.Priority,
+		Parameters:       parameters,
+		AllowedCIDRs:     allowedCIDRs,
 	}, nil
 }
 
+func toTypesProfileKind(input v1alpha1.ProfileKind) types.ProfileKind {
+	switch input {
+	case v1alpha1.ProfileKindClusterProfile:
+		return types.ClusterProfileKind
+	default:
+		return types.NamespacedProfileKind
+	}
+}
+
+func toTypesBootMode(input v1alpha1.BootMode) types.BootMode {
+	switch input {
+	case v1alpha1.BootModeProvisionOnce:
+		return types.ProvisionOnceBootMode
+	default:
+		return types.AlwaysBootMode
+	}
+}
+
 // --------------------------------------------- UTILS -------------------------------------------------------------- //
 
 func buildarchLabelSelector(buildarch string) client.ListOption {
@@ -176,3 +388,80 @@ func defaultAssignmentLabelSelector() client.ListOption {
 func uuidLabelSelector(id uuid.UUID) client.ListOption {
 	return client.HasLabels{v1alpha1.NewUUIDLabelSelector(id)}
 }
+
+// assignmentQuery lists the assignments matching the selectors of a machine by one of its attributes.
+type assignmentQuery struct {
+	// matchedBy is the subject prefix of the attribute, or MatchedByMachineSelector.
+	matchedBy string
+	opt       client.ListOption
+}
+
+// subjectQueries returns one query per subject attribute set in the selectors, ordered by precedence.
+func subjectQueries(selectors types.IPXESelectors) []assignmentQuery {
+	values := map[string]string{
+		v1alpha1.MACPrefix:          selectors.MAC,
+		v1alpha1.SerialPrefix:       selectors.Serial,
+		v1alpha1.HostnamePrefix:     selectors.Hostname,
+		v1alpha1.AssetPrefix:        selectors.Asset,
+		v1alpha1.ProductPrefix:      selectors.Product,
+		v1alpha1.ManufacturerPrefix: selectors.Manufacturer,
+	}
+
+	out := []assignmentQuery{{matchedBy: v1alpha1.UUIDPrefix, opt: uuidLabelSelector(selectors.UUID)}}
+	for _, prefix := range v1alpha1.SubjectPrefixes {
+		if value := values[prefix]; value != "" {
+			out = append(out, assignmentQuery{
+				matchedBy: prefix,
+				opt:       client.HasLabels{v1alpha1.NewSubjectLabelSelector(prefix, value)},
+			})
+		}
+	}
+
+	return out
+}
+
+// machineSelectorLabelSelector returns a list option matching assignments specifying a machine selector.
+func machineSelectorLabelSelector() client.ListOption {
+	return client.HasLabels{v1alpha1.MachineSelectorAssignmentLabel}
+}
+
+// machineSelectorMatches returns true if the machine selector of the assignment matches the machine. Invalid selectors
+// never match.
+func machineSelectorMatches(item v1alpha1.Assignment, machine labels.Set) bool {
+	if item.Spec.MachineSelector == nil {
+		return false
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(item.Spec.MachineSelector)
+	if err != nil {
+		return false
+	}
+
+	return selector.Matches(machine)
+}
+
+// machineLabels returns the attributes of the machine as a label set that can be matched by a machine selector. The
+// labels of the Machine resource are included; attributes take precedence over them.
+func machineLabels(selectors types.IPXESelectors) labels.Set {
+	out := labels.Set{}
+	maps.Copy(out, selectors.Labels)
+
+	out[v1alpha1.UUIDPrefix] = selectors.UUID.String()
+	out[v1alpha1.BuildarchPrefix] = selectors.Buildarch
+
+	for key, value := range map[string]string{
+		v1alpha1.MACPrefix:          v1alpha1.NormalizeMAC(selectors.MAC),
+		v1alpha1.SerialPrefix:       selectors.Serial,
+		v1alpha1.HostnamePrefix:     selectors.Hostname,
+		v1alpha1.AssetPrefix:        selectors.Asset,
+		v1alpha1.ProductPrefix:      selectors.Product,
+		v1alpha1.ManufacturerPrefix: selectors.Manufacturer,
+		v1alpha1.PlatformKey:        selectors.Platform,
+	} {
+		if value != "" {
+			out[key] = value
+		}
+	}
+
+	return out
+}
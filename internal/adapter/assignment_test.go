@@ -18,16 +18,20 @@ package adapter_test
 
 import (
 	"context"
+	"net/netip"
 	"testing"
 
 	"github.com/alexandremahdhaoui/shaper/internal/types"
 
 	"github.com/alexandremahdhaoui/shaper/internal/adapter"
 	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockclient"
+	"github.com/alexandremahdhaoui/shaper/internal/util/testutil"
 	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -56,7 +60,7 @@ func TestAssignment(t *testing.T) {
 		expectedBuildarchLabelSelector = v1alpha1.Arm64BuildarchLabelSelector
 
 		cl = mockclient.NewMockClient(t)
-		assignment = adapter.NewAssignment(cl, namespace)
+		assignment = adapter.NewAssignment(cl)
 
 		return func() {
 			t.Helper()
@@ -79,6 +83,25 @@ func TestAssignment(t *testing.T) {
 			})
 	}
 
+	noMachineSelectorAssignment := func(t *testing.T) {
+		t.Helper()
+
+		cl.EXPECT().List(ctx, mock.Anything,
+			client.HasLabels{expectedBuildarchLabelSelector},
+			client.HasLabels{v1alpha1.MachineSelectorAssignmentLabel},
+		).Return(nil).Once()
+	}
+
+	listItems := func(t *testing.T, opts []any, items ...v1alpha1.Assignment) {
+		t.Helper()
+
+		cl.EXPECT().List(ctx, mock.Anything, opts...).
+			RunAndReturn(func(_ context.Context, objList client.ObjectList, _ ...client.ListOption) error {
+				objList.(*v1alpha1.AssignmentList).Items = items
+				return nil
+			}).Once()
+	}
+
 	t.Run("FindDefaultByBuildarch", func(t *testing.T) {
 		t.Run("Success", func(t *testing.T) {
 			defer setup(t)()
@@ -145,19 +168,154 @@ func TestAssignment(t *testing.T) {
 			}
 
 			list(t)
+			noMachineSelectorAssignment(t)
+
+			actual, matchedBy, err := assignment.FindBySelectors(ctx, selectors)
+			assert.NoError(t, err)
+			assert.Equal(t, v1alpha1.UUIDPrefix, matchedBy)
+			assert.Equal(t, expectedAssignment, actual)
+		})
+
+		t.Run("FallbackToMAC", func(t *testing.T) {
+			defer setup(t)()
+
+			expectedAssignment = types.Assignment{
+				Name:        "",
+				ProfileName: uuid.New().String(),
+			}
+
+			id := uuid.New()
+			selectors := types.IPXESelectors{
+				UUID:      id,
+				Buildarch: inputBuildarch,
+				MAC:       "52:54:00:12:34:56",
+			}
+
+			// No assignment matches the UUID.
+			cl.EXPECT().List(ctx, mock.Anything,
+				client.HasLabels{expectedBuildarchLabelSelector},
+				client.HasLabels{v1alpha1.NewUUIDLabelSelector(id)},
+			).Return(nil).Once()
+
+			expectedListOptions = []any{
+				client.HasLabels{expectedBuildarchLabelSelector},
+				client.HasLabels{"mac.shaper.amahdha.com/52-54-00-12-34-56"},
+			}
+
+			list(t)
+			noMachineSelectorAssignment(t)
 
-			actual, err := assignment.FindBySelectors(ctx, selectors)
+			actual, matchedBy, err := assignment.FindBySelectors(ctx, selectors)
 			assert.NoError(t, err)
+			assert.Equal(t, v1alpha1.MACPrefix, matchedBy)
 			assert.Equal(t, expectedAssignment, actual)
 		})
 
+		t.Run("HighestPriorityWins", func(t *testing.T) {
+			defer setup(t)()
+
+			id := uuid.New()
+			selectors := types.IPXESelectors{
+				UUID:      id,
+				Buildarch: inputBuildarch,
+				Platform:  "efi",
+			}
+
+			byUUID := v1alpha1.Assignment{}
+			byUUID.Name = "by-uuid"
+			byUUID.Spec.ProfileName = "uuid-profile"
+
+			bySelector := v1alpha1.Assignment{}
+			bySelector.Name = "by-selector"
+			bySelector.Spec.ProfileName = "selector-profile"
+			bySelector.Spec.Priority = 10
+			bySelector.Spec.MachineSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{v1alpha1.PlatformKey: "efi"},
+			}
+
+			notMatching := v1alpha1.Assignment{}
+			notMatching.Name = "not-matching"
+			notMatching.Spec.Priority = 100
+			notMatching.Spec.MachineSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{v1alpha1.PlatformKey: "pcbios"},
+			}
+
+			listItems(t, []any{
+				client.HasLabels{expectedBuildarchLabelSelector},
+				client.HasLabels{v1alpha1.NewUUIDLabelSelector(id)},
+			}, byUUID)
+			listItems(t, []any{
+				client.HasLabels{expectedBuildarchLabelSelector},
+				client.HasLabels{v1alpha1.MachineSelectorAssignmentLabel},
+			}, bySelector, notMatching)
+
+			actual, matchedBy, err := assignment.FindBySelectors(ctx, selectors)
+			assert.NoError(t, err)
+			assert.Equal(t, adapter.MatchedByMachineSelector, matchedBy)
+			assert.Equal(t, "by-selector", actual.Name)
+			assert.Equal(t, "selector-profile", actual.ProfileName)
+		})
+
+		t.Run("TieIsBrokenByName", func(t *testing.T) {
+			defer setup(t)()
+
+			id := uuid.New()
+			selectors := types.IPXESelectors{
+				UUID:      id,
+				Buildarch: inputBuildarch,
+			}
+
+			b := v1alpha1.Assignment{}
+			b.Name = "b"
+			a := v1alpha1.Assignment{}
+			a.Name = "a"
+
+			listItems(t, []any{
+				client.HasLabels{expectedBuildarchLabelSelector},
+				client.HasLabels{v1alpha1.NewUUIDLabelSelector(id)},
+			}, b, a)
+			noMachineSelectorAssignment(t)
+
+			// The status of the assignments is not patched: FindBySelectors is free of side effects.
+			actual, _, err := assignment.FindBySelectors(ctx, selectors)
+			assert.NoError(t, err)
+			assert.Equal(t, "a", actual.Name)
+		})
+
+		t.Run("AllowedCIDRs", func(t *testing.T) {
+			defer setup(t)()
+
+			id := uuid.New()
+			selectors := types.IPXESelectors{
+				UUID:      id,
+				Buildarch: inputBuildarch,
+			}
+
+			item := v1alpha1.Assignment{}
+			item.Name = "restricted"
+			item.Spec.AllowedCIDRs = []string{"10.0.1.42/24", "fd00::/64"}
+
+			listItems(t, []any{
+				client.HasLabels{expectedBuildarchLabelSelector},
+				client.HasLabels{v1alpha1.NewUUIDLabelSelector(id)},
+			}, item)
+			noMachineSelectorAssignment(t)
+
+			actual, _, err := assignment.FindBySelectors(ctx, selectors)
+			assert.NoError(t, err)
+			assert.Equal(t, []netip.Prefix{
+				netip.MustParsePrefix("10.0.1.0/24"),
+				netip.MustParsePrefix("fd00::/64"),
+			}, actual.AllowedCIDRs)
+		})
+
 		t.Run("Failure", func(t *testing.T) {
 			t.Run("ListError", func(t *testing.T) {
 				defer setup(t)()
 
 				cl.EXPECT().List(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(assert.AnError)
 
-				actual, err := assignment.FindBySelectors(ctx, types.IPXESelectors{})
+				actual, _, err := assignment.FindBySelectors(ctx, types.IPXESelectors{})
 				assert.ErrorIs(t, err, assert.AnError)
 				assert.Empty(t, actual)
 			})
@@ -168,10 +326,164 @@ func TestAssignment(t *testing.T) {
 				// No assignment found.
 				cl.EXPECT().List(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-				actual, err := assignment.FindBySelectors(ctx, types.IPXESelectors{})
+				actual, _, err := assignment.FindBySelectors(ctx, types.IPXESelectors{})
 				assert.ErrorIs(t, err, adapter.ErrAssignmentNotFound)
 				assert.Empty(t, actual)
 			})
 		})
 	})
+	t.Run("ListByProfileName", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			defer setup(t)()
+
+			listItems(t, []any{client.InNamespace("other-namespace")},
+				v1alpha1.Assignment{
+					ObjectMeta: metav1.ObjectMeta{Name: "referencing", Namespace: "other-namespace"},
+					Spec:       v1alpha1.AssignmentSpec{ProfileName: "a-profile"},
+				},
+				v1alpha1.Assignment{
+					ObjectMeta: metav1.ObjectMeta{Name: "not-referencing", Namespace: "other-namespace"},
+					Spec:       v1alpha1.AssignmentSpec{ProfileName: "another-profile"},
+				},
+			)
+
+			actual, err := assignment.ListByProfileName(ctx, "a-profile", "other-namespace")
+			assert.NoError(t, err)
+			assert.Equal(t, []types.Assignment{{
+				Name:        "referencing",
+				Namespace:   "other-namespace",
+				ProfileName: "a-profile",
+			}}, actual)
+		})
+
+		t.Run("ClusterProfile", func(t *testing.T) {
+			defer setup(t)()
+
+			listItems(t, []any{client.InNamespace("")},
+				v1alpha1.Assignment{
+					ObjectMeta: metav1.ObjectMeta{Name: "referencing", Namespace: "tenant-a"},
+					Spec: v1alpha1.AssignmentSpec{
+						ProfileName: "a-profile",
+						ProfileKind: v1alpha1.ProfileKindClusterProfile,
+					},
+				},
+				v1alpha1.Assignment{
+					ObjectMeta: metav1.ObjectMeta{Name: "namespaced", Namespace: "tenant-b"},
+					Spec:       v1alpha1.AssignmentSpec{ProfileName: "a-profile"},
+				},
+			)
+
+			actual, err := assignment.ListByProfileName(ctx, "a-profile", "")
+			assert.NoError(t, err)
+			assert.Equal(t, []types.Assignment{{
+				Name:        "referencing",
+				Namespace:   "tenant-a",
+				ProfileName: "a-profile",
+				ProfileKind: types.ClusterProfileKind,
+			}}, actual)
+		})
+
+		t.Run("ListError", func(t *testing.T) {
+			defer setup(t)()
+
+			cl.EXPECT().List(ctx, mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+			actual, err := assignment.ListByProfileName(ctx, "a-profile", namespace)
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.Empty(t, actual)
+		})
+	})
+
+	t.Run("ListByUUID", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			defer setup(t)()
+
+			id := uuid.New()
+			listItems(t, []any{client.HasLabels{v1alpha1.NewUUIDLabelSelector(id)}},
+				v1alpha1.Assignment{
+					ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: namespace},
+					Spec: v1alpha1.AssignmentSpec{ProfileName: "b-profile", Priority: 10, Parameters: []v1alpha1.Parameter{
+						{Name: "channel", Value: ptr.To("beta")},
+					}},
+				},
+				v1alpha1.Assignment{
+					ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: namespace, Labels: map[string]string{
+						"site":                            "dc1",
+						v1alpha1.NewUUIDLabelSelector(id): "",
+					}},
+					Spec: v1alpha1.AssignmentSpec{ProfileName: "a-profile"},
+				},
+			)
+
+			actual, err := assignment.ListByUUID(ctx, id)
+			assert.NoError(t, err)
+
+			for i := range actual {
+				for name, param := range actual[i].Parameters {
+					actual[i].Parameters[name] = testutil.MakeContentComparable(param)
+				}
+			}
+
+			assert.Equal(t, []types.Assignment{
+				{Name: "a", Namespace: namespace, Labels: map[string]string{"site": "dc1"}, ProfileName: "a-profile"},
+				{Name: "b", Namespace: namespace, ProfileName: "b-profile", Priority: 10, Parameters: map[string]types.Content{
+					"channel": {Name: "channel", ResolverKind: types.InlineResolverKind, Inline: "beta"},
+				}},
+			}, actual)
+		})
+
+		t.Run("ConversionError", func(t *testing.T) {
+			defer setup(t)()
+
+			id := uuid.New()
+			listItems(t, []any{client.HasLabels{v1alpha1.NewUUIDLabelSelector(id)}}, v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: namespace},
+				Spec: v1alpha1.AssignmentSpec{ProfileName: "a-profile", Parameters: []v1alpha1.Parameter{
+					{Name: "token", ObjectRef: &v1alpha1.ObjectRef{JSONPath: "{.data"}},
+				}},
+			})
+
+			actual, err := assignment.ListByUUID(ctx, id)
+			assert.Error(t, err)
+			assert.Empty(t, actual)
+		})
+
+		t.Run("ListError", func(t *testing.T) {
+			defer setup(t)()
+
+			cl.EXPECT().List(ctx, mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+			actual, err := assignment.ListByUUID(ctx, uuid.New())
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.Empty(t, actual)
+		})
+	})
+
+	t.Run("ListDefaultByBuildarch", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			defer setup(t)()
+
+			listItems(t, []any{
+				client.HasLabels{v1alpha1.DefaultAssignmentLabel},
+				client.HasLabels{expectedBuildarchLabelSelector},
+			}, v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: namespace},
+				Spec:       v1alpha1.AssignmentSpec{ProfileName: "a-profile", IsDefault: true},
+			})
+
+			actual, err := assignment.ListDefaultByBuildarch(ctx, inputBuildarch)
+			assert.NoError(t, err)
+			assert.Equal(t, []types.Assignment{{Name: "default", Namespace: namespace, ProfileName: "a-profile"}}, actual)
+		})
+
+		t.Run("ListError", func(t *testing.T) {
+			defer setup(t)()
+
+			cl.EXPECT().List(ctx, mock.Anything, mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+			actual, err := assignment.ListDefaultByBuildarch(ctx, inputBuildarch)
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.Empty(t, actual)
+		})
+	})
 }
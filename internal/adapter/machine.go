@@ -0,0 +1,305 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"errors"
+
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
+	"github.com/google/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	ErrMachineNotFound = errors.New("machine not found")
+
+	errMachineGet             = errors.New("getting machine")
+	errMachineUpsert          = errors.New("upserting machine")
+	errMachineCreate          = errors.New("creating machine")
+	errMachineUpdate          = errors.New("updating machine")
+	errMachineUpdateStatus    = errors.New("updating machine status")
+	errMachineMarkProvisioned = errors.New("marking machine as provisioned")
+	errMachineRecordEvent     = errors.New("recording machine event")
+	errMachineWithoutUUID     = errors.New("machine must have a UUID")
+)
+
+// --------------------------------------------------- INTERFACES --------------------------------------------------- //
+
+// Machine is an interface for recording the machines that booted through shaper.
+type Machine interface {
+	// Get gets a machine by its UUID.
+	Get(ctx context.Context, id uuid.UUID) (types.Machine, error)
+	// Upsert creates the machine if it does not exist and records its last boot.
+	Upsert(ctx context.Context, machine types.Machine) error
+	// MarkProvisioned marks a machine in the provisioning phase as provisioned.
+	MarkProvisioned(ctx context.Context, id uuid.UUID) error
+	// RecordEvent records a provisioning event reported by a machine as a condition.
+	RecordEvent(ctx context.Context, id uuid.UUID, event types.MachineEvent) error
+}
+
+// --------------------------------------------------- CONSTRUCTORS ------------------------------------------------- //
+
+// NewMachine returns a new Machine.
+func NewMachine(c client.Client, namespace string) Machine {
+	return &machine{
+		client:    c,
+		namespace: namespace,
+	}
+}
+
+// --------------------------------------------- CONCRETE IMPLEMENTATION -------------------------------------------- //
+
+type machine struct {
+	client    client.Client
+	namespace string
+}
+
+// --------------------------------------------- Get ---------------------------------------------------------------- //
+
+func (m *machine) Get(ctx context.Context, id uuid.UUID) (types.Machine, error) {
+	obj := new(v1alpha1.Machine)
+
+	if err := m.client.Get(ctx, m.key(id), obj); apierrors.IsNotFound(err) {
+		return types.Machine{}, errors.Join(err, ErrMachineNotFound, errMachineGet)
+	} else if err != nil {
+		return types.Machine{}, errors.Join(err, errMachineGet)
+	}
+
+	return toTypesMachine(obj), nil
+}
+
+// --------------------------------------------- Upsert ------------------------------------------------------------- //
+
+// Upsert creates the Machine resource named after the UUID of the machine if it does not exist yet, then updates its
+// status. The first boot time is only set once.
+func (m *machine) Upsert(ctx context.Context, input types.Machine) error {
+	id := input.Selectors.UUID
+	if id == uuid.Nil {
+		return errors.Join(errMachineWithoutUUID, errMachineUpsert)
+	}
+
+	obj := new(v1alpha1.Machine)
+	if err := m.client.Get(ctx, m.key(id), obj); apierrors.IsNotFound(err) {
+		obj = &v1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      id.String(),
+				Namespace: m.namespace,
+			},
+		}
+
+		if err := m.client.Create(ctx, obj); err != nil {
+			return errors.Join(err, errMachineCreate, errMachineUpsert)
+		}
+	} else if err != nil {
+		return errors.Join(err, errMachineGet, errMachineUpsert)
+	}
+
+	// The reprovision annotation is consumed when the machine enters the provisioning phase again.
+	if _, ok := obj.Annotations[v1alpha1.ReprovisionAnnotation]; ok && input.Phase == types.ProvisioningMachinePhase {
+		original := obj.DeepCopy()
+		delete(obj.Annotations, v1alpha1.ReprovisionAnnotation)
+
+		if err := m.client.Patch(ctx, obj, client.MergeFrom(original)); err != nil {
+			return errors.Join(err, errMachineUpdate, errMachineUpsert)
+		}
+	}
+
+	original := obj.DeepCopy()
+	now := metav1.Now()
+
+	if obj.Status.FirstBootTime == nil {
+		obj.Status.FirstBootTime = &now
+	}
+
+	obj.Status.LastBootTime = &now
+	obj.Status.Attributes = toV1alpha1MachineAttributes(input.Selectors)
+	obj.Status.ClientIP = input.Selectors.ClientIP
+	obj.Status.AssignmentName = input.AssignmentName
+	obj.Status.AssignmentNamespace = input.AssignmentNamespace
+	obj.Status.ProfileName = input.ProfileName
+
+	if input.Phase != types.UnknownMachinePhase {
+		obj.Status.Phase = toV1alpha1MachinePhase(input.Phase)
+	}
+
+	if err := m.client.Status().Patch(ctx, obj, client.MergeFrom(original)); err != nil {
+		return errors.Join(err, errMachineUpdateStatus, errMachineUpsert)
+	}
+
+	return nil
+}
+
+// --------------------------------------------- MarkProvisioned ---------------------------------------------------- //
+
+// MarkProvisioned transitions the machine from the provisioning phase to the provisioned phase. Machines in any other
+// phase are left untouched.
+func (m *machine) MarkProvisioned(ctx context.Context, id uuid.UUID) error {
+	obj := new(v1alpha1.Machine)
+	if err := m.client.Get(ctx, m.key(id), obj); apierrors.IsNotFound(err) {
+		return errors.Join(err, ErrMachineNotFound, errMachineMarkProvisioned)
+	} else if err != nil {
+		return errors.Join(err, errMachineGet, errMachineMarkProvisioned)
+	}
+
+	if obj.Status.Phase != v1alpha1.MachinePhaseProvisioning {
+		return nil
+	}
+
+	original := obj.DeepCopy()
+	obj.Status.Phase = v1alpha1.MachinePhaseProvisioned
+
+	if err := m.client.Status().Patch(ctx, obj, client.MergeFrom(original)); err != nil {
+		return errors.Join(err, errMachineUpdateStatus, errMachineMarkProvisioned)
+	}
+
+	return nil
+}
+
+// --------------------------------------------- RecordEvent -------------------------------------------------------- //
+
+// RecordEvent sets the Installed condition of the machine according to the event. A machine in the provisioning phase
+// reporting a successful installation is marked as provisioned.
+func (m *machine) RecordEvent(ctx context.Context, id uuid.UUID, event types.MachineEvent) error {
+	obj := new(v1alpha1.Machine)
+	if err := m.client.Get(ctx, m.key(id), obj); apierrors.IsNotFound(err) {
+		return errors.Join(err, ErrMachineNotFound, errMachineRecordEvent)
+	} else if err != nil {
+		return errors.Join(err, errMachineGet, errMachineRecordEvent)
+	}
+
+	original := obj.DeepCopy()
+
+	condition := metav1.Condition{
+		Type:               v1alpha1.MachineConditionInstalled,
+		Message:            event.Message,
+		ObservedGeneration: obj.Generation,
+	}
+
+	switch event.Type {
+	case types.InstallStartedMachineEvent:
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = v1alpha1.MachineReasonInstallStarted
+	case types.InstallSucceededMachineEvent:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = v1alpha1.MachineReasonInstallSucceeded
+
+		if obj.Status.Phase == v1alpha1.MachinePhaseProvisioning {
+			obj.Status.Phase = v1alpha1.MachinePhaseProvisioned
+		}
+	case types.InstallFailedMachineEvent:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = v1alpha1.MachineReasonInstallFailed
+	}
+
+	meta.SetStatusCondition(&obj.Status.Conditions, condition)
+
+	if err := m.client.Status().Patch(ctx, obj, client.MergeFrom(original)); err != nil {
+		return errors.Join(err, errMachineUpdateStatus, errMachineRecordEvent)
+	}
+
+	return nil
+}
+
+// --------------------------------------------- UTILS -------------------------------------------------------------- //
+
+func (m *machine) key(id uuid.UUID) k8stypes.NamespacedName {
+	return k8stypes.NamespacedName{
+		Name:      id.String(),
+		Namespace: m.namespace,
+	}
+}
+
+// --------------------------------------------- CONVERSION --------------------------------------------------------- //
+
+func toTypesMachine(input *v1alpha1.Machine) types.Machine {
+	id, _ := uuid.Parse(input.Status.Attributes.UUID) // an invalid UUID is reported as uuid.Nil.
+
+	out := types.Machine{
+		Name:      input.Name,
+		Namespace: input.Namespace,
+		Labels:    input.Labels,
+		Selectors: types.IPXESelectors{
+			Buildarch:    input.Status.Attributes.Buildarch,
+			UUID:         id,
+			MAC:          input.Status.Attributes.MAC,
+			Serial:       input.Status.Attributes.Serial,
+			Hostname:     input.Status.Attributes.Hostname,
+			Asset:        input.Status.Attributes.Asset,
+			Product:      input.Status.Attributes.Product,
+			Manufacturer: input.Status.Attributes.Manufacturer,
+			Platform:     input.Status.Attributes.Platform,
+			ClientIP:     input.Status.ClientIP,
+			Labels:       input.Labels,
+		},
+		AssignmentName:      input.Status.AssignmentName,
+		AssignmentNamespace: input.Status.AssignmentNamespace,
+		ProfileName:         input.Status.ProfileName,
+		Phase:               toTypesMachinePhase(input.Status.Phase),
+	}
+
+	_, out.Reprovision = input.Annotations[v1alpha1.ReprovisionAnnotation]
+
+	if input.Status.FirstBootTime != nil {
+		out.FirstBootTime = input.Status.FirstBootTime.Time
+	}
+
+	if input.Status.LastBootTime != nil {
+		out.LastBootTime = input.Status.LastBootTime.Time
+	}
+
+	return out
+}
+
+func toV1alpha1MachineAttributes(selectors types.IPXESelectors) v1alpha1.MachineAttributes {
+	return v1alpha1.MachineAttributes{
+		UUID:         selectors.UUID.String(),
+		Buildarch:    selectors.Buildarch,
+		MAC:          v1alpha1.NormalizeMAC(selectors.MAC),
+		Platform:     selectors.Platform,
+		Manufacturer: selectors.Manufacturer,
+		Product:      selectors.Product,
+		Serial:       selectors.Serial,
+		Hostname:     selectors.Hostname,
+		Asset:        selectors.Asset,
+	}
+}
+
+func toTypesMachinePhase(input v1alpha1.MachinePhase) types.MachinePhase {
+	switch input {
+	case v1alpha1.MachinePhaseProvisioning:
+		return types.ProvisioningMachinePhase
+	case v1alpha1.MachinePhaseProvisioned:
+		return types.ProvisionedMachinePhase
+	default:
+		return types.UnknownMachinePhase
+	}
+}
+
+func toV1alpha1MachinePhase(input types.MachinePhase) v1alpha1.MachinePhase {
+	switch input {
+	case types.ProvisioningMachinePhase:
+		return v1alpha1.MachinePhaseProvisioning
+	case types.ProvisionedMachinePhase:
+		return v1alpha1.MachinePhaseProvisioned
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,428 @@
+//go:build unit
+
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alexandremahdhaoui/shaper/internal/adapter"
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockclient"
+	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	types2 "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestMachine(t *testing.T) {
+	var (
+		ctx       context.Context
+		namespace string
+
+		inputUUID uuid.UUID
+
+		v1alpha1Machine *v1alpha1.Machine
+		expectedErr     error
+
+		cl      *mockclient.MockClient
+		machine adapter.Machine
+	)
+
+	setup := func(t *testing.T) func() {
+		t.Helper()
+
+		ctx = context.Background()
+		namespace = "test-machine"
+
+		inputUUID = uuid.New()
+
+		v1alpha1Machine = nil
+		expectedErr = nil
+
+		cl = mockclient.NewMockClient(t)
+		machine = adapter.NewMachine(cl, namespace)
+
+		return func() {
+			t.Helper()
+
+			cl.AssertExpectations(t)
+		}
+	}
+
+	get := func(t *testing.T) {
+		t.Helper()
+
+		cl.EXPECT().
+			Get(ctx, types2.NamespacedName{
+				Namespace: namespace,
+				Name:      inputUUID.String(),
+			}, mock.Anything).
+			RunAndReturn(func(_ context.Context, _ types2.NamespacedName, obj client.Object, _ ...client.GetOption) error {
+				if v1alpha1Machine != nil {
+					*obj.(*v1alpha1.Machine) = *v1alpha1Machine
+				}
+
+				return expectedErr
+			}).
+			Once()
+	}
+
+	notFound := apierrors.NewNotFound(schema.GroupResource{
+		Group:    v1alpha1.Group,
+		Resource: "machines",
+	}, "")
+
+	t.Run("Get", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			defer setup(t)()
+
+			firstBoot := metav1.NewTime(time.Unix(1700000000, 0))
+			v1alpha1Machine = &v1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      inputUUID.String(),
+					Namespace: namespace,
+					Labels:    map[string]string{"rack": "a1"},
+				},
+				Status: v1alpha1.MachineStatus{
+					Attributes: v1alpha1.MachineAttributes{
+						UUID:      inputUUID.String(),
+						Buildarch: "arm64",
+						MAC:       "52:54:00:12:34:56",
+					},
+					FirstBootTime:       &firstBoot,
+					AssignmentName:      "an-assignment",
+					AssignmentNamespace: "other",
+					ProfileName:         "a-profile",
+				},
+			}
+
+			get(t)
+
+			actual, err := machine.Get(ctx, inputUUID)
+			assert.NoError(t, err)
+			assert.Equal(t, types.Machine{
+				Name:      inputUUID.String(),
+				Namespace: namespace,
+				Labels:    map[string]string{"rack": "a1"},
+				Selectors: types.IPXESelectors{
+					Buildarch: "arm64",
+					UUID:      inputUUID,
+					MAC:       "52:54:00:12:34:56",
+					Labels:    map[string]string{"rack": "a1"},
+				},
+				AssignmentName:      "an-assignment",
+				AssignmentNamespace: "other",
+				ProfileName:         "a-profile",
+				FirstBootTime:       firstBoot.Time,
+			}, actual)
+		})
+
+		t.Run("Failure", func(t *testing.T) {
+			t.Run("NotFound", func(t *testing.T) {
+				defer setup(t)()
+
+				expectedErr = notFound
+				get(t)
+
+				_, err := machine.Get(ctx, inputUUID)
+				assert.ErrorIs(t, err, adapter.ErrMachineNotFound)
+			})
+
+			t.Run("Get error", func(t *testing.T) {
+				defer setup(t)()
+
+				expectedErr = assert.AnError
+				get(t)
+
+				_, err := machine.Get(ctx, inputUUID)
+				assert.ErrorIs(t, err, assert.AnError)
+				assert.NotErrorIs(t, err, adapter.ErrMachineNotFound)
+			})
+		})
+	})
+
+	t.Run("Upsert", func(t *testing.T) {
+		var (
+			input types.Machine
+			sw    *mockclient.MockSubResourceWriter
+		)
+
+		patch := func(t *testing.T, assertion func(obj *v1alpha1.Machine)) {
+			t.Helper()
+
+			sw = mockclient.NewMockSubResourceWriter(t)
+			cl.EXPECT().Status().Return(sw).Once()
+			sw.EXPECT().Patch(ctx, mock.Anything, mock.Anything).
+				RunAndReturn(func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.SubResourcePatchOption) error {
+					assertion(obj.(*v1alpha1.Machine))
+					return nil
+				}).Once()
+		}
+
+		setupUpsert := func(t *testing.T) func() {
+			t.Helper()
+
+			teardown := setup(t)
+			input = types.Machine{
+				Selectors: types.IPXESelectors{
+					UUID:      inputUUID,
+					Buildarch: "x86_64",
+					MAC:       "52:54:00:12:34:56",
+					ClientIP:  "10.0.0.42",
+				},
+				AssignmentName:      "an-assignment",
+				AssignmentNamespace: "other",
+				ProfileName:         "a-profile",
+			}
+
+			return teardown
+		}
+
+		t.Run("Create", func(t *testing.T) {
+			defer setupUpsert(t)()
+
+			expectedErr = notFound
+			get(t)
+
+			cl.EXPECT().Create(ctx, mock.Anything).
+				RunAndReturn(func(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+					assert.Equal(t, inputUUID.String(), obj.GetName())
+					assert.Equal(t, namespace, obj.GetNamespace())
+					return nil
+				}).Once()
+
+			patch(t, func(obj *v1alpha1.Machine) {
+				assert.Equal(t, inputUUID.String(), obj.Status.Attributes.UUID)
+				assert.Equal(t, "52-54-00-12-34-56", obj.Status.Attributes.MAC)
+				assert.Equal(t, "10.0.0.42", obj.Status.ClientIP)
+				assert.Equal(t, "an-assignment", obj.Status.AssignmentName)
+				assert.Equal(t, "other", obj.Status.AssignmentNamespace)
+				assert.Equal(t, "a-profile", obj.Status.ProfileName)
+				assert.NotNil(t, obj.Status.FirstBootTime)
+				assert.Equal(t, obj.Status.FirstBootTime, obj.Status.LastBootTime)
+			})
+
+			assert.NoError(t, machine.Upsert(ctx, input))
+		})
+
+		t.Run("Update", func(t *testing.T) {
+			defer setupUpsert(t)()
+
+			firstBoot := metav1.NewTime(time.Unix(1700000000, 0))
+			v1alpha1Machine = &v1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: inputUUID.String(), Namespace: namespace},
+				Status:     v1alpha1.MachineStatus{FirstBootTime: &firstBoot},
+			}
+			get(t)
+
+			patch(t, func(obj *v1alpha1.Machine) {
+				assert.Equal(t, &firstBoot, obj.Status.FirstBootTime)
+				assert.True(t, obj.Status.LastBootTime.After(firstBoot.Time))
+				assert.Equal(t, "a-profile", obj.Status.ProfileName)
+			})
+
+			assert.NoError(t, machine.Upsert(ctx, input))
+		})
+
+		t.Run("Reprovision", func(t *testing.T) {
+			defer setupUpsert(t)()
+
+			v1alpha1Machine = &v1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        inputUUID.String(),
+					Namespace:   namespace,
+					Annotations: map[string]string{v1alpha1.ReprovisionAnnotation: ""},
+				},
+				Status: v1alpha1.MachineStatus{Phase: v1alpha1.MachinePhaseProvisioned},
+			}
+			get(t)
+
+			input.Phase = types.ProvisioningMachinePhase
+
+			cl.EXPECT().Patch(ctx, mock.Anything, mock.Anything).
+				RunAndReturn(func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+					assert.NotContains(t, obj.GetAnnotations(), v1alpha1.ReprovisionAnnotation)
+					return nil
+				}).Once()
+
+			patch(t, func(obj *v1alpha1.Machine) {
+				assert.Equal(t, v1alpha1.MachinePhaseProvisioning, obj.Status.Phase)
+			})
+
+			assert.NoError(t, machine.Upsert(ctx, input))
+		})
+
+		t.Run("Failure", func(t *testing.T) {
+			t.Run("Without UUID", func(t *testing.T) {
+				defer setupUpsert(t)()
+
+				input.Selectors.UUID = uuid.Nil
+
+				assert.Error(t, machine.Upsert(ctx, input))
+			})
+
+			t.Run("Create error", func(t *testing.T) {
+				defer setupUpsert(t)()
+
+				expectedErr = notFound
+				get(t)
+
+				cl.EXPECT().Create(ctx, mock.Anything).Return(assert.AnError).Once()
+
+				assert.ErrorIs(t, machine.Upsert(ctx, input), assert.AnError)
+			})
+
+			t.Run("Patch error", func(t *testing.T) {
+				defer setupUpsert(t)()
+
+				get(t)
+
+				sw = mockclient.NewMockSubResourceWriter(t)
+				cl.EXPECT().Status().Return(sw).Once()
+				sw.EXPECT().Patch(ctx, mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+				assert.ErrorIs(t, machine.Upsert(ctx, input), assert.AnError)
+			})
+		})
+	})
+
+	t.Run("MarkProvisioned", func(t *testing.T) {
+		t.Run("Provisioning", func(t *testing.T) {
+			defer setup(t)()
+
+			v1alpha1Machine = &v1alpha1.Machine{
+				Status: v1alpha1.MachineStatus{Phase: v1alpha1.MachinePhaseProvisioning},
+			}
+			get(t)
+
+			sw := mockclient.NewMockSubResourceWriter(t)
+			cl.EXPECT().Status().Return(sw).Once()
+			sw.EXPECT().Patch(ctx, mock.Anything, mock.Anything).
+				RunAndReturn(func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.SubResourcePatchOption) error {
+					assert.Equal(t, v1alpha1.MachinePhaseProvisioned, obj.(*v1alpha1.Machine).Status.Phase)
+					return nil
+				}).Once()
+
+			assert.NoError(t, machine.MarkProvisioned(ctx, inputUUID))
+		})
+
+		t.Run("Not provisioning", func(t *testing.T) {
+			defer setup(t)()
+
+			// Machines served a profile with the "always" boot mode have no phase.
+			v1alpha1Machine = &v1alpha1.Machine{}
+			get(t)
+
+			assert.NoError(t, machine.MarkProvisioned(ctx, inputUUID))
+		})
+
+		t.Run("NotFound", func(t *testing.T) {
+			defer setup(t)()
+
+			expectedErr = notFound
+			get(t)
+
+			assert.ErrorIs(t, machine.MarkProvisioned(ctx, inputUUID), adapter.ErrMachineNotFound)
+		})
+	})
+
+	t.Run("RecordEvent", func(t *testing.T) {
+		for _, tt := range []struct {
+			Name           string
+			Event          types.MachineEvent
+			Phase          v1alpha1.MachinePhase
+			ExpectedStatus metav1.ConditionStatus
+			ExpectedReason string
+			ExpectedPhase  v1alpha1.MachinePhase
+		}{
+			{
+				Name:           "Started",
+				Event:          types.MachineEvent{Type: types.InstallStartedMachineEvent},
+				Phase:          v1alpha1.MachinePhaseProvisioning,
+				ExpectedStatus: metav1.ConditionUnknown,
+				ExpectedReason: v1alpha1.MachineReasonInstallStarted,
+				ExpectedPhase:  v1alpha1.MachinePhaseProvisioning,
+			},
+			{
+				Name:           "Succeeded",
+				Event:          types.MachineEvent{Type: types.InstallSucceededMachineEvent},
+				Phase:          v1alpha1.MachinePhaseProvisioning,
+				ExpectedStatus: metav1.ConditionTrue,
+				ExpectedReason: v1alpha1.MachineReasonInstallSucceeded,
+				ExpectedPhase:  v1alpha1.MachinePhaseProvisioned,
+			},
+			{
+				Name:           "Succeeded without phase",
+				Event:          types.MachineEvent{Type: types.InstallSucceededMachineEvent},
+				ExpectedStatus: metav1.ConditionTrue,
+				ExpectedReason: v1alpha1.MachineReasonInstallSucceeded,
+			},
+			{
+				Name:           "Failed",
+				Event:          types.MachineEvent{Type: types.InstallFailedMachineEvent, Message: "disk not found"},
+				Phase:          v1alpha1.MachinePhaseProvisioning,
+				ExpectedStatus: metav1.ConditionFalse,
+				ExpectedReason: v1alpha1.MachineReasonInstallFailed,
+				ExpectedPhase:  v1alpha1.MachinePhaseProvisioning,
+			},
+		} {
+			t.Run(tt.Name, func(t *testing.T) {
+				defer setup(t)()
+
+				v1alpha1Machine = &v1alpha1.Machine{
+					Status: v1alpha1.MachineStatus{Phase: tt.Phase},
+				}
+				get(t)
+
+				sw := mockclient.NewMockSubResourceWriter(t)
+				cl.EXPECT().Status().Return(sw).Once()
+				sw.EXPECT().Patch(ctx, mock.Anything, mock.Anything).
+					RunAndReturn(func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.SubResourcePatchOption) error {
+						status := obj.(*v1alpha1.Machine).Status
+						condition := meta.FindStatusCondition(status.Conditions, v1alpha1.MachineConditionInstalled)
+						assert.NotNil(t, condition)
+						assert.Equal(t, tt.ExpectedStatus, condition.Status)
+						assert.Equal(t, tt.ExpectedReason, condition.Reason)
+						assert.Equal(t, tt.Event.Message, condition.Message)
+						assert.Equal(t, tt.ExpectedPhase, status.Phase)
+
+						return nil
+					}).Once()
+
+				assert.NoError(t, machine.RecordEvent(ctx, inputUUID, tt.Event))
+			})
+		}
+
+		t.Run("NotFound", func(t *testing.T) {
+			defer setup(t)()
+
+			expectedErr = notFound
+			get(t)
+
+			err := machine.RecordEvent(ctx, inputUUID, types.MachineEvent{})
+			assert.ErrorIs(t, err, adapter.ErrMachineNotFound)
+		})
+	})
+}
@@ -16,7 +16,11 @@ package adapter
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 
 	"k8s.io/utils/ptr"
 
@@ -35,6 +39,12 @@ var (
 
 	errProfileListByContentID = errors.New("listing profile by content id")
 
+	// Inheritance
+
+	ErrBaseProfileNotFound     = errors.New("base profile not found")
+	ErrProfileInheritanceCycle = errors.New("profile inheritance cycle")
+	errProfileMerge            = errors.New("merging profile with its base profiles")
+
 	// Conversions
 
 	errConvertingProfile       = errors.New("converting profile")
@@ -46,52 +56,60 @@ var (
 
 // --------------------------------------------------- INTERFACES --------------------------------------------------- //
 
-// Profile is an interface for getting profiles.
+// Profile is an interface for getting profiles. A ClusterProfile is handled as a Profile without namespace.
 type Profile interface {
-	// Get gets a profile by name in the adapter's configured namespace.
-	Get(ctx context.Context, name string) (types.Profile, error)
 	// GetInNamespace gets a profile by name in a specific namespace.
 	GetInNamespace(ctx context.Context, name, namespace string) (types.Profile, error)
-	// ListByContentID lists profiles by content ID.
+	// GetClusterProfile gets a ClusterProfile by name.
+	GetClusterProfile(ctx context.Context, name string) (types.Profile, error)
+	// ListByContentID lists the profiles and cluster profiles by content ID.
 	ListByContentID(ctx context.Context, configID uuid.UUID) ([]types.Profile, error)
+	// Merge returns a copy of the profile merged with its base profiles, i.e. the profile served to machines. It fails
+	// if a base profile does not exist or if the profile inherits from itself. The base profiles of a profile without
+	// namespace are ClusterProfiles.
+	Merge(ctx context.Context, profile *v1alpha1.Profile) (*v1alpha1.Profile, error)
 }
 
 // --------------------------------------------------- CONSTRUCTORS ------------------------------------------------- //
 
-// NewProfile returns a new Profile.
-func NewProfile(c client.Client, namespace string) Profile {
+// NewProfile returns a new Profile. It gets the profiles of every namespace visible to the client.
+func NewProfile(c client.Client) Profile {
 	return &v1a1Profile{
-		client:    c,
-		namespace: namespace,
+		client: c,
 	}
 }
 
 // --------------------------------------------- CONCRETE IMPLEMENTATION -------------------------------------------- //
 
 type v1a1Profile struct {
-	client    client.Client
-	namespace string
+	client client.Client
 }
 
 // --------------------------------------------- Get ----------------------------------------------------------- //
 
-func (p *v1a1Profile) Get(ctx context.Context, name string) (types.Profile, error) {
-	return p.GetInNamespace(ctx, name, p.namespace)
+func (p *v1a1Profile) GetInNamespace(ctx context.Context, name, namespace string) (types.Profile, error) {
+	return p.get(ctx, name, namespace)
 }
 
-func (p *v1a1Profile) GetInNamespace(ctx context.Context, name, namespace string) (types.Profile, error) {
-	obj := new(v1alpha1.Profile)
+func (p *v1a1Profile) GetClusterProfile(ctx context.Context, name string) (types.Profile, error) {
+	return p.get(ctx, name, "")
+}
 
-	if err := p.client.Get(ctx, k8stypes.NamespacedName{
-		Name:      name,
-		Namespace: namespace,
-	}, obj); apierrors.IsNotFound(err) {
+// get gets a profile, or a ClusterProfile if the namespace is empty, merged with its base profiles.
+func (p *v1a1Profile) get(ctx context.Context, name, namespace string) (types.Profile, error) {
+	obj, err := p.getObject(ctx, name, namespace)
+	if apierrors.IsNotFound(err) {
 		return types.Profile{}, errors.Join(err, ErrProfileNotFound, errProfileGet)
 	} else if err != nil {
 		return types.Profile{}, errors.Join(err, errProfileGet)
 	}
 
-	out, err := fromV1alpha1.toProfile(obj)
+	merged, err := p.Merge(ctx, obj)
+	if err != nil {
+		return types.Profile{}, errors.Join(err, errProfileGet)
+	}
+
+	out, err := fromV1alpha1.toProfile(merged)
 	if err != nil {
 		return types.Profile{}, errors.Join(err, errProfileGet)
 	}
@@ -101,24 +119,45 @@ func (p *v1a1Profile) GetInNamespace(ctx context.Context, name, namespace string
 
 // --------------------------------------------- ListByContentID ------------------------------------------------------ //
 
-// ListByContentID retrieve at most one Profile by a config ID. The nature of UUIDs and the defaulting webhook driver
-// ensures the list contains at most 1 ID.
+// ListByContentID retrieve at most one Profile or ClusterProfile by a config ID. The nature of UUIDs and the defaulting
+// webhook driver ensures the list contains at most 1 ID, unless the content is inherited from a base profile.
 func (p *v1a1Profile) ListByContentID(
 	ctx context.Context,
 	configID uuid.UUID,
 ) ([]types.Profile, error) {
 	// list profiles
 	obj := new(v1alpha1.ProfileList)
-	if err := p.client.List(ctx, obj, uuidLabelSelector(configID)); apierrors.IsNotFound(err) ||
-		len(obj.Items) == 0 {
-		return nil, errors.Join(err, ErrProfileNotFound, errProfileListByContentID)
-	} else if err != nil {
+	if err := p.client.List(ctx, obj, uuidLabelSelector(configID)); err != nil && !apierrors.IsNotFound(err) {
 		return nil, errors.Join(err, errProfileListByContentID)
 	}
 
-	out := make([]types.Profile, 0, len(obj.Items))
+	// list cluster profiles
+	clusterObj := new(v1alpha1.ClusterProfileList)
+	if err := p.client.List(ctx, clusterObj, uuidLabelSelector(configID)); err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Join(err, errProfileListByContentID)
+	}
+
+	items := make([]*v1alpha1.Profile, 0, len(obj.Items)+len(clusterObj.Items))
 	for i := range obj.Items {
-		profile, err := fromV1alpha1.toProfile(&obj.Items[i])
+		items = append(items, &obj.Items[i])
+	}
+
+	for i := range clusterObj.Items {
+		items = append(items, clusterObj.Items[i].AsProfile())
+	}
+
+	if len(items) == 0 {
+		return nil, errors.Join(ErrProfileNotFound, errProfileListByContentID)
+	}
+
+	out := make([]types.Profile, 0, len(items))
+	for _, item := range items {
+		merged, err := p.Merge(ctx, item)
+		if err != nil {
+			return nil, errors.Join(err, errProfileListByContentID)
+		}
+
+		profile, err := fromV1alpha1.toProfile(merged)
 		if err != nil {
 			return nil, errors.Join(err, errProfileListByContentID)
 		}
@@ -129,8 +168,135 @@ func (p *v1a1Profile) ListByContentID(
 	return out, nil
 }
 
+// --------------------------------------------- Merge ------------------------------------------------------------ //
+
+// Merge walks the chain of base profiles of the profile, which all live in its namespace, and merges them from the
+// root to the profile. The profile itself is never fetched: an object being admitted is merged with the stored base
+// profiles, and a cycle is detected as soon as the chain leads back to it.
+func (p *v1a1Profile) Merge(ctx context.Context, profile *v1alpha1.Profile) (*v1alpha1.Profile, error) {
+	chain := []*v1alpha1.Profile{profile}
+	visited := map[string]struct{}{profile.Name: {}}
+
+	for current := profile; current.Spec.BaseProfileRef != nil; {
+		name := current.Spec.BaseProfileRef.Name
+		if _, ok := visited[name]; ok {
+			return nil, errors.Join(
+				fmt.Errorf("%w: %s", ErrProfileInheritanceCycle, inheritancePath(chain, name)),
+				errProfileMerge,
+			)
+		}
+
+		visited[name] = struct{}{}
+
+		base, err := p.getObject(ctx, name, profile.Namespace)
+		if apierrors.IsNotFound(err) {
+			return nil, errors.Join(err, fmt.Errorf("%w: %q", ErrBaseProfileNotFound, name), errProfileMerge)
+		} else if err != nil {
+			return nil, errors.Join(err, errProfileMerge)
+		}
+
+		chain = append(chain, base)
+		current = base
+	}
+
+	out := chain[len(chain)-1].DeepCopy()
+	for i := len(chain) - 2; i >= 0; i-- {
+		out = mergeProfile(out, chain[i])
+	}
+
+	return out, nil
+}
+
+// getObject gets a profile, or a ClusterProfile as a Profile without namespace if the namespace is empty.
+func (p *v1a1Profile) getObject(ctx context.Context, name, namespace string) (*v1alpha1.Profile, error) {
+	if namespace == "" {
+		obj := new(v1alpha1.ClusterProfile)
+		if err := p.client.Get(ctx, k8stypes.NamespacedName{Name: name}, obj); err != nil {
+			return nil, err // TODO: wrap err
+		}
+
+		return obj.AsProfile(), nil
+	}
+
+	obj := new(v1alpha1.Profile)
+	if err := p.client.Get(ctx, k8stypes.NamespacedName{Name: name, Namespace: namespace}, obj); err != nil {
+		return nil, err // TODO: wrap err
+	}
+
+	return obj, nil
+}
+
+// mergeProfile returns a copy of the child merged with its base:
+//   - the IPXETemplate of the child overrides the one of the base.
+//   - the additional content and parameters are merged by name: the ones of the child override the ones of the base.
+//     Inherited ones are appended after the ones of the child.
+//   - the UUID labels of the inherited exposed content are copied from the base, hence the content is exposed with the
+//     same UUID as the base.
+func mergeProfile(base, child *v1alpha1.Profile) *v1alpha1.Profile {
+	out := child.DeepCopy()
+
+	if out.Spec.IPXETemplate == "" {
+		out.Spec.IPXETemplate = base.Spec.IPXETemplate
+	}
+
+	if out.Spec.ExternalBaseURL == "" {
+		out.Spec.ExternalBaseURL = base.Spec.ExternalBaseURL
+	}
+
+	declared := make(map[string]struct{}, len(out.Spec.AdditionalContent))
+	for _, content := range out.Spec.AdditionalContent {
+		declared[content.Name] = struct{}{}
+	}
+
+	for _, content := range base.Spec.AdditionalContent {
+		if _, ok := declared[content.Name]; !ok {
+			out.Spec.AdditionalContent = append(out.Spec.AdditionalContent, *content.DeepCopy())
+		}
+	}
+
+	params := make(map[string]struct{}, len(out.Spec.Parameters))
+	for _, param := range out.Spec.Parameters {
+		params[param.Name] = struct{}{}
+	}
+
+	for _, param := range base.Spec.Parameters {
+		if _, ok := params[param.Name]; !ok {
+			out.Spec.Parameters = append(out.Spec.Parameters, *param.DeepCopy())
+		}
+	}
+
+	for k, name := range base.Labels {
+		if _, ok := declared[name]; ok || !v1alpha1.IsUUIDLabelSelector(k) {
+			continue
+		}
+
+		if out.Labels == nil {
+			out.Labels = make(map[string]string)
+		}
+
+		out.Labels[k] = name
+	}
+
+	return out
+}
+
+// inheritancePath formats the chain of profiles leading back to the named profile, e.g. `a -> b -> a`.
+func inheritancePath(chain []*v1alpha1.Profile, name string) string {
+	out := ""
+	for _, profile := range chain {
+		out += profile.Name + " -> "
+	}
+
+	return out + name
+}
+
 // --------------------------------------------------- CONVERSION --------------------------------------------------- //
 
+// ConvertProfile converts a v1alpha1.Profile into a types.Profile.
+func ConvertProfile(input *v1alpha1.Profile) (types.Profile, error) {
+	return fromV1alpha1.toProfile(input)
+}
+
 var fromV1alpha1 ipxev1a1
 
 type ipxev1a1 struct{}
@@ -145,6 +311,7 @@ func (ipxev1a1) toProfile(input *v1alpha1.Profile) (types.Profile, error) {
 		Name:               input.Name,
 		Namespace:          input.Namespace,
 		IPXETemplate:       input.Spec.IPXETemplate,
+		ExternalBaseURL:    input.Spec.ExternalBaseURL,
 		AdditionalContent:  make(map[string]types.Content),
 		ContentIDToNameMap: idNameMap,
 	}
@@ -152,6 +319,7 @@ func (ipxev1a1) toProfile(input *v1alpha1.Profile) (types.Profile, error) {
 	for _, c := range input.Spec.AdditionalContent {
 		content := types.Content{}
 		content.Name = c.Name
+		content.SpecHash = specHash(c)
 
 		// 1. Is content exposed?
 		if c.Exposed {
@@ -203,9 +371,96 @@ func (ipxev1a1) toProfile(input *v1alpha1.Profile) (types.Profile, error) {
 		out.AdditionalContent[c.Name] = content
 	}
 
+	parameters, err := fromV1alpha1.toParameters(input.Spec.Parameters)
+	if err != nil {
+		return types.Profile{}, errors.Join(err, errConvertingProfile)
+	}
+
+	out.Parameters = parameters
+
+	setReferrerNamespace(out.AdditionalContent, input.Namespace)
+	setReferrerNamespace(out.Parameters, input.Namespace)
+
 	return out, nil
 }
 
+// setReferrerNamespace sets the namespace of the profile or assignment referencing the objects of the content, so the
+// ObjectRefPolicy can be enforced when they are resolved.
+func setReferrerNamespace(contents map[string]types.Content, namespace string) {
+	for _, content := range contents {
+		if content.ObjectRef != nil {
+			content.ObjectRef.ReferrerNamespace = namespace
+		}
+
+		webhooks := make([]*types.WebhookConfig, 0, len(content.PostTransformers)+1)
+		webhooks = append(webhooks, content.WebhookConfig)
+
+		for _, cfg := range content.PostTransformers {
+			webhooks = append(webhooks, cfg.Webhook)
+		}
+
+		for _, cfg := range webhooks {
+			if cfg == nil {
+				continue
+			}
+
+			if cfg.MTLSObjectRef != nil {
+				cfg.MTLSObjectRef.ReferrerNamespace = namespace
+			}
+
+			if cfg.BasicAuthObjectRef != nil {
+				cfg.BasicAuthObjectRef.ReferrerNamespace = namespace
+			}
+		}
+	}
+}
+
+var errConvertingParameters = errors.New("converting parameters")
+
+// toParameters converts the parameters into inline or objectRef content keyed by name. It returns nil if there are no
+// parameters.
+func (ipxev1a1) toParameters(input []v1alpha1.Parameter) (map[string]types.Content, error) {
+	if len(input) == 0 {
+		return nil, nil
+	}
+
+	out := make(map[string]types.Content, len(input))
+	for _, param := range input {
+		content := types.Content{Name: param.Name, SpecHash: specHash(param)}
+
+		switch {
+		case param.Value != nil:
+			content.ResolverKind = types.InlineResolverKind
+			content.Inline = *param.Value
+		case param.ObjectRef != nil:
+			ref, err := fromV1alpha1.toObjectRef(param.ObjectRef)
+			if err != nil {
+				return nil, errors.Join(err, errConvertingParameters)
+			}
+
+			content.ResolverKind = types.ObjectRefResolverKind
+			content.ObjectRef = &ref
+		}
+
+		out[param.Name] = content
+	}
+
+	return out, nil
+}
+
+// specHash returns the hex-encoded SHA-256 of the JSON encoding of a content or parameter spec. It returns an empty
+// string if the spec cannot be encoded, so the content is not cached.
+func specHash(spec any) string {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:])
+}
+
 var errConvertingObjectRef = errors.New("converting object ref")
 
 func (ipxev1a1) toObjectRef(objectRef *v1alpha1.ObjectRef) (types.ObjectRef, error) {
@@ -341,6 +596,11 @@ func (ipxev1a1) toBasicAuthObjectRef(
 
 var errConvertingStringToJSONPath = errors.New("converting string to JSONPath")
 
+// ParseJSONPath parses a JSONPath as written in v1alpha1 resources, e.g. `.data.key`.
+func ParseJSONPath(s string) (*jsonpath.JSONPath, error) {
+	return toJSONPath(s)
+}
+
 func toJSONPath(s string) (*jsonpath.JSONPath, error) {
 	// The Kubernetes JSONPath library requires expressions to be wrapped in curly braces.
 	// Without braces, it returns the literal string instead of evaluating the path.
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/alexandremahdhaoui/shaper/internal/adapter"
+	"github.com/alexandremahdhaoui/shaper/internal/types"
 	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockclient"
 	"github.com/alexandremahdhaoui/shaper/internal/util/testutil"
 	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
@@ -28,6 +29,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	types2 "k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
@@ -42,9 +44,10 @@ func TestProfile(t *testing.T) {
 		inputProfileName string
 		inputContentID   uuid.UUID
 
-		v1alpha1Profile     v1alpha1.Profile
-		v1alpha1ProfileList v1alpha1.ProfileList
-		expectedErr         error
+		v1alpha1Profile            v1alpha1.Profile
+		v1alpha1ProfileList        v1alpha1.ProfileList
+		v1alpha1ClusterProfileList v1alpha1.ClusterProfileList
+		expectedErr                error
 
 		cl      *mockclient.MockClient
 		profile adapter.Profile
@@ -61,10 +64,11 @@ func TestProfile(t *testing.T) {
 
 		v1alpha1Profile = testutil.NewV1alpha1Profile()
 		v1alpha1ProfileList = v1alpha1.ProfileList{}
+		v1alpha1ClusterProfileList = v1alpha1.ClusterProfileList{}
 		expectedErr = nil // Reset error to nil for each test
 
 		cl = mockclient.NewMockClient(t)
-		profile = adapter.NewProfile(cl, namespace)
+		profile = adapter.NewProfile(cl)
 
 		return func() {
 			t.Helper()
@@ -95,14 +99,18 @@ func TestProfile(t *testing.T) {
 		cl.EXPECT().
 			List(ctx, mock.Anything, mock.Anything).
 			RunAndReturn(func(_ context.Context, obj client.ObjectList, opts ...client.ListOption) error {
-				list := obj.(*v1alpha1.ProfileList)
-				*list = v1alpha1ProfileList
+				switch list := obj.(type) {
+				case *v1alpha1.ProfileList:
+					*list = v1alpha1ProfileList
+				case *v1alpha1.ClusterProfileList:
+					*list = v1alpha1ClusterProfileList
+				}
 
 				return expectedErr
 			})
 	}
 
-	t.Run("Get", func(t *testing.T) {
+	t.Run("GetInNamespace", func(t *testing.T) {
 		t.Run("Success", func(t *testing.T) {
 			defer setup(t)()
 
@@ -110,11 +118,93 @@ func TestProfile(t *testing.T) {
 
 			get(t)
 
-			actual, err := profile.Get(ctx, inputProfileName)
+			actual, err := profile.GetInNamespace(ctx, inputProfileName, namespace)
 			assert.NoError(t, err)
 			assert.Equal(t, expected, testutil.MakeProfileComparable(actual))
 		})
 
+		t.Run("Parameters", func(t *testing.T) {
+			defer setup(t)()
+
+			v1alpha1Profile.Spec.Parameters = []v1alpha1.Parameter{
+				{Name: "channel", Value: ptr.To("stable")},
+				{Name: "token", ObjectRef: &v1alpha1.ObjectRef{
+					ResourceRef: v1alpha1.ResourceRef{Version: "v1", Resource: "secrets", Name: "a-secret"},
+					JSONPath:    ".data.token",
+				}},
+			}
+
+			get(t)
+
+			actual, err := profile.GetInNamespace(ctx, inputProfileName, namespace)
+			assert.NoError(t, err)
+			assert.Equal(t, types.Content{
+				Name:         "channel",
+				ResolverKind: types.InlineResolverKind,
+				Inline:       "stable",
+			}, testutil.MakeContentComparable(actual.Parameters["channel"]))
+			assert.Equal(t, types.ObjectRefResolverKind, actual.Parameters["token"].ResolverKind)
+			assert.Equal(t, "a-secret", actual.Parameters["token"].ObjectRef.Name)
+			assert.NotNil(t, actual.Parameters["token"].ObjectRef.JSONPath)
+		})
+
+		t.Run("ReferrerNamespace", func(t *testing.T) {
+			defer setup(t)()
+
+			v1alpha1Profile.Namespace = "tenant-a"
+			v1alpha1Profile.Spec.Parameters = []v1alpha1.Parameter{
+				{Name: "token", ObjectRef: &v1alpha1.ObjectRef{
+					ResourceRef: v1alpha1.ResourceRef{Version: "v1", Resource: "secrets", Name: "a-secret"},
+					JSONPath:    ".data.token",
+				}},
+			}
+
+			get(t)
+
+			actual, err := profile.GetInNamespace(ctx, inputProfileName, namespace)
+			assert.NoError(t, err)
+			assert.Equal(t, "tenant-a", actual.Parameters["token"].ObjectRef.ReferrerNamespace)
+
+			for name, content := range actual.AdditionalContent {
+				if content.ObjectRef != nil {
+					assert.Equal(t, "tenant-a", content.ObjectRef.ReferrerNamespace, name)
+				}
+
+				if content.WebhookConfig != nil {
+					assert.Equal(t, "tenant-a", content.WebhookConfig.MTLSObjectRef.ReferrerNamespace, name)
+					assert.Equal(t, "tenant-a", content.WebhookConfig.BasicAuthObjectRef.ReferrerNamespace, name)
+				}
+			}
+		})
+
+		t.Run("SpecHash", func(t *testing.T) {
+			defer setup(t)()
+
+			v1alpha1Profile.Spec.Parameters = []v1alpha1.Parameter{
+				{Name: "a", Value: ptr.To("stable")},
+				{Name: "b", Value: ptr.To("stable")},
+				{Name: "c", Value: ptr.To("beta")},
+			}
+
+			get(t)
+
+			actual, err := profile.GetInNamespace(ctx, inputProfileName, namespace)
+			assert.NoError(t, err)
+
+			again, err := profile.GetInNamespace(ctx, inputProfileName, namespace)
+			assert.NoError(t, err)
+
+			// The hash is stable and changes with any field of the spec.
+			assert.NotEmpty(t, actual.Parameters["a"].SpecHash)
+			assert.Equal(t, actual.Parameters["a"].SpecHash, again.Parameters["a"].SpecHash)
+			assert.NotEqual(t, actual.Parameters["a"].SpecHash, actual.Parameters["b"].SpecHash)
+			assert.NotEqual(t, actual.Parameters["a"].SpecHash, actual.Parameters["c"].SpecHash)
+
+			for name, content := range actual.AdditionalContent {
+				assert.NotEmpty(t, content.SpecHash, name)
+			}
+		})
+
 		t.Run("Failure", func(t *testing.T) {
 			t.Run("Get error", func(t *testing.T) {
 				defer setup(t)()
@@ -122,14 +212,76 @@ func TestProfile(t *testing.T) {
 				expectedErr = assert.AnError
 				get(t)
 
-				_, err := profile.Get(ctx, inputProfileName)
+				_, err := profile.GetInNamespace(ctx, inputProfileName, namespace)
 				assert.ErrorIs(t, err, assert.AnError)
 			})
 		})
 	})
 
+	t.Run("GetClusterProfile", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			defer setup(t)()
+
+			expected := testutil.NewTypesProfile()
+
+			cl.EXPECT().
+				Get(ctx, types2.NamespacedName{Name: inputProfileName}, mock.Anything).
+				RunAndReturn(func(_ context.Context, _ types2.NamespacedName, obj client.Object, _ ...client.GetOption) error {
+					p := obj.(*v1alpha1.ClusterProfile)
+					p.ObjectMeta = v1alpha1Profile.ObjectMeta
+					p.Spec = v1alpha1Profile.Spec
+
+					return nil
+				}).
+				Once()
+
+			actual, err := profile.GetClusterProfile(ctx, inputProfileName)
+			assert.NoError(t, err)
+			assert.Empty(t, actual.Namespace)
+			assert.Equal(t, expected, testutil.MakeProfileComparable(actual))
+		})
+
+		t.Run("NotFound", func(t *testing.T) {
+			defer setup(t)()
+
+			cl.EXPECT().
+				Get(ctx, types2.NamespacedName{Name: inputProfileName}, mock.Anything).
+				Return(apierrors.NewNotFound(schema.GroupResource{Resource: "clusterprofiles"}, inputProfileName)).
+				Once()
+
+			_, err := profile.GetClusterProfile(ctx, inputProfileName)
+			assert.ErrorIs(t, err, adapter.ErrProfileNotFound)
+		})
+	})
+
 	t.Run("ListByContentID", func(t *testing.T) {
 		t.Run("Success", func(t *testing.T) {
+			t.Run("ClusterProfile found", func(t *testing.T) {
+				defer setup(t)()
+
+				testUUID := uuid.New()
+				inputContentID = testUUID
+
+				clusterProfile := testutil.NewV1alpha1ProfileWithExposedContent("cluster-profile",
+					[]testutil.ExposedContentItem{{Name: "config1", UUID: testUUID, Body: "content1", Exposed: true}})
+
+				v1alpha1ClusterProfileList = v1alpha1.ClusterProfileList{
+					Items: []v1alpha1.ClusterProfile{{
+						ObjectMeta: metav1.ObjectMeta{Name: clusterProfile.Name, Labels: clusterProfile.Labels},
+						Spec:       clusterProfile.Spec,
+					}},
+				}
+
+				listByContentID(t)
+
+				result, err := profile.ListByContentID(ctx, inputContentID)
+				assert.NoError(t, err)
+				assert.Len(t, result, 1)
+				assert.Equal(t, "cluster-profile", result[0].Name)
+				assert.Empty(t, result[0].Namespace)
+				assert.Equal(t, "config1", result[0].ContentIDToNameMap[testUUID])
+			})
+
 			t.Run("Single profile found", func(t *testing.T) {
 				defer setup(t)()
 
@@ -316,4 +468,135 @@ func TestProfile(t *testing.T) {
 			})
 		})
 	})
+
+	t.Run("Merge", func(t *testing.T) {
+		var stored map[string]v1alpha1.Profile
+
+		// getStored serves the stored profiles and reports the other ones as not found.
+		getStored := func(t *testing.T) {
+			t.Helper()
+
+			cl.EXPECT().
+				Get(ctx, mock.Anything, mock.Anything).
+				RunAndReturn(func(_ context.Context, key types2.NamespacedName, obj client.Object, _ ...client.GetOption) error {
+					p, ok := stored[key.Name]
+					if !ok || key.Namespace != namespace {
+						return apierrors.NewNotFound(schema.GroupResource{Resource: "profiles"}, key.Name)
+					}
+
+					*obj.(*v1alpha1.Profile) = p
+
+					return nil
+				}).
+				Maybe()
+		}
+
+		newProfile := func(name, base string, spec v1alpha1.ProfileSpec) v1alpha1.Profile {
+			p := v1alpha1.Profile{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}, Spec: spec}
+			if base != "" {
+				p.Spec.BaseProfileRef = &v1alpha1.ProfileReference{Name: base}
+			}
+
+			return p
+		}
+
+		baseID := uuid.New()
+
+		t.Run("Success", func(t *testing.T) {
+			defer setup(t)()
+
+			root := newProfile("root", "", v1alpha1.ProfileSpec{
+				IPXETemplate: "#!ipxe root",
+				AdditionalContent: []v1alpha1.AdditionalContent{
+					{Name: "config", Exposed: true, Inline: ptr.To("root")},
+					{Name: "kernel", Inline: ptr.To("root")},
+				},
+				Parameters:      []v1alpha1.Parameter{{Name: "channel", Value: ptr.To("stable")}},
+				ExternalBaseURL: "https://boot.example.com",
+			})
+			root.Labels = map[string]string{v1alpha1.NewUUIDLabelSelector(baseID): "config"}
+
+			stored = map[string]v1alpha1.Profile{
+				"root": root,
+				"base": newProfile("base", "root", v1alpha1.ProfileSpec{
+					IPXETemplate:      "#!ipxe base",
+					AdditionalContent: []v1alpha1.AdditionalContent{{Name: "kernel", Inline: ptr.To("base")}},
+				}),
+			}
+
+			getStored(t)
+
+			child := newProfile("child", "base", v1alpha1.ProfileSpec{
+				AdditionalContent: []v1alpha1.AdditionalContent{{Name: "initrd", Inline: ptr.To("child")}},
+				Parameters:        []v1alpha1.Parameter{{Name: "channel", Value: ptr.To("beta")}},
+			})
+
+			actual, err := profile.Merge(ctx, &child)
+			assert.NoError(t, err)
+
+			assert.Equal(t, "child", actual.Name)
+			assert.Equal(t, "#!ipxe base", actual.Spec.IPXETemplate)
+			assert.Equal(t, []v1alpha1.AdditionalContent{
+				{Name: "initrd", Inline: ptr.To("child")},
+				{Name: "kernel", Inline: ptr.To("base")},
+				{Name: "config", Exposed: true, Inline: ptr.To("root")},
+			}, actual.Spec.AdditionalContent)
+			assert.Equal(t, []v1alpha1.Parameter{{Name: "channel", Value: ptr.To("beta")}}, actual.Spec.Parameters)
+			assert.Equal(t, "https://boot.example.com", actual.Spec.ExternalBaseURL)
+
+			// The inherited exposed content keeps the UUID of the base profile.
+			assert.Equal(t, map[string]string{v1alpha1.NewUUIDLabelSelector(baseID): "config"}, actual.Labels)
+
+			// The child is not modified.
+			assert.Empty(t, child.Spec.IPXETemplate)
+			assert.Len(t, child.Spec.AdditionalContent, 1)
+		})
+
+		t.Run("Failure", func(t *testing.T) {
+			t.Run("Inheritance cycle", func(t *testing.T) {
+				defer setup(t)()
+
+				stored = map[string]v1alpha1.Profile{
+					"a": newProfile("a", "b", v1alpha1.ProfileSpec{}),
+					"b": newProfile("b", "child", v1alpha1.ProfileSpec{}),
+				}
+
+				getStored(t)
+
+				child := newProfile("child", "a", v1alpha1.ProfileSpec{})
+
+				actual, err := profile.Merge(ctx, &child)
+				assert.ErrorIs(t, err, adapter.ErrProfileInheritanceCycle)
+				assert.ErrorContains(t, err, "child -> a -> b -> child")
+				assert.Nil(t, actual)
+			})
+
+			t.Run("Base profile not found", func(t *testing.T) {
+				defer setup(t)()
+
+				stored = map[string]v1alpha1.Profile{}
+
+				getStored(t)
+
+				child := newProfile("child", "missing", v1alpha1.ProfileSpec{})
+
+				actual, err := profile.Merge(ctx, &child)
+				assert.ErrorIs(t, err, adapter.ErrBaseProfileNotFound)
+				assert.Nil(t, actual)
+			})
+
+			t.Run("Get error", func(t *testing.T) {
+				defer setup(t)()
+
+				cl.EXPECT().Get(ctx, mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+				child := newProfile("child", "base", v1alpha1.ProfileSpec{})
+
+				actual, err := profile.Merge(ctx, &child)
+				assert.ErrorIs(t, err, assert.AnError)
+				assert.NotErrorIs(t, err, adapter.ErrBaseProfileNotFound)
+				assert.Nil(t, actual)
+			})
+		})
+	})
 }
@@ -24,19 +24,28 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/jsonpath"
 
 	"github.com/alexandremahdhaoui/shaper/internal/types"
 )
 
 var (
-	ErrResolverResolve   = errors.New("resolving content")
-	ErrObjectRefResolver = errors.New("resolving object ref")
-	ErrWebhookResolver   = errors.New("resolving webhook")
+	ErrResolverResolve     = errors.New("resolving content")
+	ErrObjectRefResolver   = errors.New("resolving object ref")
+	ErrObjectRefNotAllowed = errors.New("object ref not allowed")
+	ErrWebhookResolver     = errors.New("resolving webhook")
 
 	errObjectRefMustBeSpecified = errors.New("object ref must be specified")
 	errResolvingMTLSConfig      = errors.New("resolving mTLS config")
@@ -88,13 +97,51 @@ func (r *inlineResolver) Resolve(
 
 // ---------------------------------------------- OBJECT REF RESOLVER ----------------------------------------------- //
 
-// NewObjectRefResolver returns a new object ref resolver.
-func NewObjectRefResolver(k8sClient dynamic.Interface) ObjectRefResolver {
-	return &objectRefResolver{k8s: k8sClient}
+// NewObjectRefResolver returns a new object ref resolver getting the objects from the API server.
+func NewObjectRefResolver(k8sClient dynamic.Interface, options ...ObjectRefResolverOption) ObjectRefResolver {
+	opts := new(ObjectRefResolverOptions).apply(options...)
+
+	return &objectRefResolver{
+		get: func(ctx context.Context, ref types.ObjectRef) (*unstructured.Unstructured, error) {
+			return k8sClient.
+				Resource(schema.GroupVersionResource{
+					Group:    ref.Group,
+					Version:  ref.Version,
+					Resource: ref.Resource,
+				}).
+				Namespace(ref.Namespace).
+				Get(ctx, ref.Name, metav1.GetOptions{})
+		},
+		policy: opts.policy,
+	}
+}
+
+// NewCachedObjectRefResolver returns a new object ref resolver reading the objects from the caches of dynamic
+// informers. An informer is started for a resource the first time one of its objects is resolved, and runs until ctx
+// is done. Only the objects allowed by the rules are resolved: other object refs fail with ErrObjectRefNotAllowed. The
+// informer of a rule restricted to namespaces only watches the namespace of the resolved object.
+func NewCachedObjectRefResolver(
+	ctx context.Context,
+	k8sClient dynamic.Interface,
+	rules []types.ObjectRefRule,
+	options ...ObjectRefResolverOption,
+) ObjectRefResolver {
+	opts := new(ObjectRefResolverOptions).apply(options...)
+
+	informers := &objectInformers{
+		ctx:       ctx,
+		client:    k8sClient,
+		rules:     rules,
+		informers: make(map[objectInformerKey]*objectInformer),
+	}
+
+	return &objectRefResolver{get: informers.get, policy: opts.policy}
 }
 
 type objectRefResolver struct {
-	k8s dynamic.Interface
+	// get gets the referenced object. The object may be shared with a cache, hence it must not be modified.
+	get    func(ctx context.Context, ref types.ObjectRef) (*unstructured.Unstructured, error)
+	policy types.ObjectRefPolicy
 }
 
 func (r *objectRefResolver) Resolve(
@@ -125,21 +172,19 @@ func (r *objectRefResolver) ResolvePaths(
 	paths []*jsonpath.JSONPath,
 	ref types.ObjectRef,
 ) ([][]byte, error) { //nolint:lll
-	obj, err := r.k8s.
-		Resource(schema.GroupVersionResource{
-			Group:    ref.Group,
-			Version:  ref.Version,
-			Resource: ref.Resource,
-		}).
-		Namespace(ref.Namespace).
-		Get(ctx, ref.Name, metav1.GetOptions{})
+	if err := CheckObjectRefPolicy(r.policy, ref); err != nil {
+		return nil, errors.Join(err, ErrObjectRefResolver)
+	}
+
+	obj, err := r.get(ctx, ref)
 	if err != nil {
 		return nil, errors.Join(err, ErrObjectRefResolver)
 	}
 
 	// Kubernetes Secrets store .data values as base64-encoded strings in the API.
-	// Decode them in-place so JSONPath returns the actual plaintext values.
+	// Decode them in a copy of the object so JSONPath returns the actual plaintext values.
 	if ref.Resource == "secrets" {
+		obj = obj.DeepCopy()
 		decodeSecretData(obj.Object)
 	}
 
@@ -181,6 +226,187 @@ func decodeSecretData(obj map[string]interface{}) {
 	}
 }
 
+// ------------------------------------------------- OBJECT INFORMERS ----------------------------------------------- //
+
+// objectInformers lazily starts a dynamic informer per resource and namespace allowed by its rules.
+type objectInformers struct {
+	ctx    context.Context
+	client dynamic.Interface
+	rules  []types.ObjectRefRule
+
+	mu        sync.Mutex
+	informers map[objectInformerKey]*objectInformer
+}
+
+// objectInformerKey identifies an informer. The namespace is empty for an informer watching every namespace.
+type objectInformerKey struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+}
+
+type objectInformer struct {
+	informer informers.GenericInformer
+	synced   chan struct{}
+
+	// err is the last error listing or watching the objects, which is returned while the informer is not synced.
+	err atomic.Pointer[error]
+}
+
+func (o *objectInformers) get(ctx context.Context, ref types.ObjectRef) (*unstructured.Unstructured, error) {
+	key, err := o.key(ref)
+	if err != nil {
+		return nil, err // TODO: wrap err
+	}
+
+	informer := o.informer(key)
+
+	select {
+	case <-informer.synced:
+	case <-ctx.Done():
+		if err := informer.err.Load(); err != nil {
+			return nil, errors.Join(ctx.Err(), *err)
+		}
+
+		return nil, ctx.Err() // TODO: wrap err
+	}
+
+	var obj runtime.Object
+	if ref.Namespace != "" {
+		obj, err = informer.informer.Lister().ByNamespace(ref.Namespace).Get(ref.Name)
+	} else {
+		obj, err = informer.informer.Lister().Get(ref.Name)
+	}
+
+	if err != nil {
+		return nil, err // TODO: wrap err
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T", obj) // TODO: err + wrap err
+	}
+
+	return u, nil
+}
+
+// key returns the key of the informer caching the referenced object, or ErrObjectRefNotAllowed if no rule allows it.
+func (o *objectInformers) key(ref types.ObjectRef) (objectInformerKey, error) {
+	gvr := schema.GroupVersionResource{Group: ref.Group, Version: ref.Version, Resource: ref.Resource}
+
+	for _, rule := range o.rules {
+		if !objectRefRuleAllows(rule, ref) {
+			continue
+		}
+
+		if len(rule.Namespaces) == 0 {
+			return objectInformerKey{gvr: gvr}, nil
+		}
+
+		return objectInformerKey{gvr: gvr, namespace: ref.Namespace}, nil
+	}
+
+	return objectInformerKey{}, errObjectRefNotAllowed(ref)
+}
+
+// informer returns the informer of the key, starting it if needed.
+func (o *objectInformers) informer(key objectInformerKey) *objectInformer {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if informer, ok := o.informers[key]; ok {
+		return informer
+	}
+
+	informer := &objectInformer{
+		informer: dynamicinformer.NewFilteredDynamicInformer(o.client, key.gvr, key.namespace, 0,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, nil),
+		synced: make(chan struct{}),
+	}
+
+	_ = informer.informer.Informer().SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		informer.err.Store(&err)
+	})
+
+	go informer.informer.Informer().Run(o.ctx.Done())
+
+	go func() {
+		if cache.WaitForCacheSync(o.ctx.Done(), informer.informer.Informer().HasSynced) {
+			close(informer.synced)
+		}
+	}()
+
+	o.informers[key] = informer
+
+	return informer
+}
+
+// ------------------------------------------------- OBJECT REF POLICY ---------------------------------------------- //
+
+// CheckObjectRefPolicy returns ErrObjectRefNotAllowed if the policy has rules and none of the rules applying to the
+// namespace of the referrer of the object ref allows it.
+func CheckObjectRefPolicy(policy types.ObjectRefPolicy, ref types.ObjectRef) error {
+	if len(policy.Rules) == 0 {
+		return nil
+	}
+
+	for _, rule := range policy.Rules {
+		if len(rule.ProfileNamespaces) > 0 && !slices.Contains(rule.ProfileNamespaces, ref.ReferrerNamespace) {
+			continue
+		}
+
+		for _, allowed := range rule.Allowed {
+			if objectRefRuleAllows(allowed, ref) {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w from namespace %q", errObjectRefNotAllowed(ref), ref.ReferrerNamespace)
+}
+
+// objectRefRuleAllows returns true if the rule allows the referenced object.
+func objectRefRuleAllows(rule types.ObjectRefRule, ref types.ObjectRef) bool {
+	if rule.Group != ref.Group || rule.Version != ref.Version || rule.Resource != ref.Resource {
+		return false
+	}
+
+	return len(rule.Namespaces) == 0 || slices.Contains(rule.Namespaces, ref.Namespace)
+}
+
+func errObjectRefNotAllowed(ref types.ObjectRef) error {
+	gvr := schema.GroupVersionResource{Group: ref.Group, Version: ref.Version, Resource: ref.Resource}
+
+	return fmt.Errorf("%w: %s %s/%s", ErrObjectRefNotAllowed, gvr.String(), ref.Namespace, ref.Name)
+}
+
+// -------------------------------------------------- OPTIONS ------------------------------------------------------- //
+
+type (
+	// ObjectRefResolverOptions contains options for the object ref resolvers.
+	ObjectRefResolverOptions struct {
+		policy types.ObjectRefPolicy
+	}
+
+	// ObjectRefResolverOption is a function that sets an option of an object ref resolver.
+	ObjectRefResolverOption func(options *ObjectRefResolverOptions)
+)
+
+func (o *ObjectRefResolverOptions) apply(options ...ObjectRefResolverOption) *ObjectRefResolverOptions {
+	for _, f := range options {
+		f(o)
+	}
+
+	return o
+}
+
+// WithObjectRefPolicy restricts the objects resolved to the ones the policy allows the referrer to reference. Other
+// object refs fail with ErrObjectRefNotAllowed.
+func WithObjectRefPolicy(policy types.ObjectRefPolicy) ObjectRefResolverOption {
+	return func(options *ObjectRefResolverOptions) {
+		options.policy = policy
+	}
+}
+
 // ------------------------------------------------ WEBHOOK RESOLVER ------------------------------------------------ //
 
 const (
@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -36,6 +37,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -195,6 +197,176 @@ func TestObjectRefResolver(t *testing.T) {
 	})
 }
 
+func TestCachedObjectRefResolver(t *testing.T) {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+
+		cl       *fake.FakeDynamicClient
+		resolver adapter.ObjectRefResolver
+		rules    []types.ObjectRefRule
+	)
+
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+	newObject := func(kind, namespace, name string, data map[string]any) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"metadata":   map[string]any{"name": name, "namespace": namespace},
+			"data":       data,
+		}}
+	}
+
+	newContent := func(t *testing.T, gvr schema.GroupVersionResource, namespace, name string) types.Content {
+		t.Helper()
+
+		content := testutil.NewTypesContentObjectRef()
+		content.ObjectRef.Group = gvr.Group
+		content.ObjectRef.Version = gvr.Version
+		content.ObjectRef.Resource = gvr.Resource
+		content.ObjectRef.Namespace = namespace
+		content.ObjectRef.Name = name
+		require.NoError(t, content.ObjectRef.JSONPath.Parse("{.data.key}"))
+
+		return content
+	}
+
+	setup := func(t *testing.T) func() {
+		t.Helper()
+
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+
+		cl = fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+			configMaps: "ConfigMapList",
+			secrets:    "SecretList",
+		},
+			newObject("ConfigMap", "shaper", "a-cm", map[string]any{"key": "qwe"}),
+			newObject("ConfigMap", "kube-system", "another-cm", map[string]any{"key": "asd"}),
+			newObject("Secret", "shaper", "a-secret", map[string]any{"key": base64.StdEncoding.EncodeToString([]byte("zxc"))}),
+		)
+
+		rules = []types.ObjectRefRule{
+			{Version: "v1", Resource: "configmaps"},
+			{Version: "v1", Resource: "secrets", Namespaces: []string{"shaper"}},
+		}
+
+		resolver = adapter.NewCachedObjectRefResolver(ctx, cl, rules)
+
+		return cancel
+	}
+
+	t.Run("Resolve", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			defer setup(t)()
+
+			for range 2 {
+				actual, err := resolver.Resolve(ctx, newContent(t, configMaps, "shaper", "a-cm"), types.IPXESelectors{})
+				assert.NoError(t, err)
+				assert.Equal(t, []byte("qwe"), actual)
+			}
+
+			actual, err := resolver.Resolve(ctx, newContent(t, configMaps, "kube-system", "another-cm"),
+				types.IPXESelectors{})
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("asd"), actual)
+
+			// Objects are read from the cache: each resource is only listed and watched once.
+			var gets, lists int
+			for _, action := range cl.Actions() {
+				switch action.GetVerb() {
+				case "get":
+					gets++
+				case "list":
+					lists++
+				}
+			}
+
+			assert.Zero(t, gets)
+			assert.Equal(t, 1, lists)
+		})
+
+		t.Run("Secret", func(t *testing.T) {
+			defer setup(t)()
+
+			content := newContent(t, secrets, "shaper", "a-secret")
+
+			for range 2 {
+				actual, err := resolver.Resolve(ctx, content, types.IPXESelectors{})
+				assert.NoError(t, err)
+				assert.Equal(t, []byte("zxc"), actual)
+			}
+		})
+
+		t.Run("Not found", func(t *testing.T) {
+			defer setup(t)()
+
+			_, err := resolver.Resolve(ctx, newContent(t, configMaps, "shaper", "missing"), types.IPXESelectors{})
+			assert.True(t, apierrors.IsNotFound(err))
+			assert.ErrorIs(t, err, adapter.ErrObjectRefResolver)
+		})
+
+		t.Run("Not allowed", func(t *testing.T) {
+			defer setup(t)()
+
+			for _, content := range []types.Content{
+				newContent(t, secrets, "kube-system", "a-secret"),
+				newContent(t, schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+					"shaper", "a-deployment"),
+			} {
+				_, err := resolver.Resolve(ctx, content, types.IPXESelectors{})
+				assert.ErrorIs(t, err, adapter.ErrObjectRefNotAllowed)
+			}
+
+			assert.Empty(t, cl.Actions())
+		})
+
+		t.Run("Policy", func(t *testing.T) {
+			defer setup(t)()
+
+			policy := types.ObjectRefPolicy{Rules: []types.ObjectRefPolicyRule{
+				{
+					ProfileNamespaces: []string{"shaper"},
+					Allowed: []types.ObjectRefRule{
+						{Version: "v1", Resource: "configmaps", Namespaces: []string{"shaper"}},
+					},
+				},
+				{
+					Allowed: []types.ObjectRefRule{
+						{Version: "v1", Resource: "configmaps", Namespaces: []string{"kube-system"}},
+					},
+				},
+			}}
+
+			resolver = adapter.NewCachedObjectRefResolver(ctx, cl, rules, adapter.WithObjectRefPolicy(policy))
+
+			for _, tt := range []struct {
+				referrer  string
+				namespace string
+				name      string
+				allowed   bool
+			}{
+				{referrer: "shaper", namespace: "shaper", name: "a-cm", allowed: true},
+				{referrer: "shaper", namespace: "kube-system", name: "another-cm", allowed: true},
+				{referrer: "tenant-a", namespace: "kube-system", name: "another-cm", allowed: true},
+				{referrer: "tenant-a", namespace: "shaper", name: "a-cm"},
+				{referrer: "", namespace: "shaper", name: "a-cm"},
+			} {
+				content := newContent(t, configMaps, tt.namespace, tt.name)
+				content.ObjectRef.ReferrerNamespace = tt.referrer
+
+				_, err := resolver.Resolve(ctx, content, types.IPXESelectors{})
+				if tt.allowed {
+					assert.NoError(t, err, tt)
+				} else {
+					assert.ErrorIs(t, err, adapter.ErrObjectRefNotAllowed, tt)
+				}
+			}
+		})
+	})
+}
+
 func TestWebhookResolver(t *testing.T) {
 	var (
 		ctx context.Context
@@ -0,0 +1,92 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	ErrSigningKeysNotFound = errors.New("signing keys not found")
+
+	errSigningKeysGet = errors.New("getting signing keys")
+)
+
+// --------------------------------------------------- INTERFACES --------------------------------------------------- //
+
+// SigningKeys is an interface for getting the keys signing the URLs of the exposed content.
+type SigningKeys interface {
+	// Get gets the signing keys.
+	Get(ctx context.Context) (types.SigningKeys, error)
+}
+
+// --------------------------------------------------- CONSTRUCTORS ------------------------------------------------- //
+
+// NewSigningKeys returns a new SigningKeys reading the keys from the data of a Secret. Each key of the Secret data is a
+// key ID. The key whose ID sorts last signs new URLs: keys are rotated by adding a key with a greater ID, e.g. a date,
+// and removing the previous key once the URLs it signed have expired.
+func NewSigningKeys(c client.Client, namespace, name string) SigningKeys {
+	return &signingKeys{
+		client:    c,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// --------------------------------------------- CONCRETE IMPLEMENTATION -------------------------------------------- //
+
+type signingKeys struct {
+	client    client.Client
+	namespace string
+	name      string
+}
+
+func (s *signingKeys) Get(ctx context.Context) (types.SigningKeys, error) {
+	secret := new(corev1.Secret)
+
+	key := k8stypes.NamespacedName{Namespace: s.namespace, Name: s.name}
+	if err := s.client.Get(ctx, key, secret); apierrors.IsNotFound(err) {
+		return types.SigningKeys{}, errors.Join(err, ErrSigningKeysNotFound, errSigningKeysGet)
+	} else if err != nil {
+		return types.SigningKeys{}, errors.Join(err, errSigningKeysGet)
+	}
+
+	out := types.SigningKeys{Keys: make(map[string][]byte, len(secret.Data))}
+
+	for id, value := range secret.Data {
+		if len(value) == 0 {
+			continue
+		}
+
+		out.Keys[id] = value
+	}
+
+	if len(out.Keys) == 0 {
+		return types.SigningKeys{}, errors.Join(ErrSigningKeysNotFound, errSigningKeysGet)
+	}
+
+	out.CurrentID = slices.Max(slices.Collect(maps.Keys(out.Keys)))
+
+	return out, nil
+}
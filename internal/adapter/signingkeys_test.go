@@ -0,0 +1,132 @@
+//go:build unit
+
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alexandremahdhaoui/shaper/internal/adapter"
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	types2 "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestSigningKeys(t *testing.T) {
+	var (
+		ctx context.Context
+
+		secretData  map[string][]byte
+		expectedErr error
+
+		cl          *mockclient.MockClient
+		signingKeys adapter.SigningKeys
+	)
+
+	setup := func(t *testing.T) func() {
+		t.Helper()
+
+		ctx = context.Background()
+
+		secretData = nil
+		expectedErr = nil
+
+		cl = mockclient.NewMockClient(t)
+		signingKeys = adapter.NewSigningKeys(cl, "shaper", "content-url-signing")
+
+		return func() {
+			t.Helper()
+
+			cl.AssertExpectations(t)
+		}
+	}
+
+	get := func(t *testing.T) {
+		t.Helper()
+
+		cl.EXPECT().
+			Get(ctx, types2.NamespacedName{Namespace: "shaper", Name: "content-url-signing"}, mock.Anything).
+			RunAndReturn(func(_ context.Context, _ types2.NamespacedName, obj client.Object, _ ...client.GetOption) error {
+				obj.(*corev1.Secret).Data = secretData
+
+				return expectedErr
+			}).
+			Once()
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			defer setup(t)()
+
+			secretData = map[string][]byte{
+				"2024-01": []byte("previous-key"),
+				"2024-02": []byte("current-key"),
+				"2024-03": {},
+			}
+
+			get(t)
+
+			actual, err := signingKeys.Get(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, types.SigningKeys{
+				CurrentID: "2024-02",
+				Keys: map[string][]byte{
+					"2024-01": []byte("previous-key"),
+					"2024-02": []byte("current-key"),
+				},
+			}, actual)
+		})
+
+		t.Run("Failure", func(t *testing.T) {
+			t.Run("Secret not found", func(t *testing.T) {
+				defer setup(t)()
+
+				expectedErr = apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "content-url-signing")
+				get(t)
+
+				_, err := signingKeys.Get(ctx)
+				assert.ErrorIs(t, err, adapter.ErrSigningKeysNotFound)
+			})
+
+			t.Run("Empty secret", func(t *testing.T) {
+				defer setup(t)()
+
+				get(t)
+
+				_, err := signingKeys.Get(ctx)
+				assert.ErrorIs(t, err, adapter.ErrSigningKeysNotFound)
+			})
+
+			t.Run("Get error", func(t *testing.T) {
+				defer setup(t)()
+
+				expectedErr = assert.AnError
+				get(t)
+
+				_, err := signingKeys.Get(ctx)
+				assert.ErrorIs(t, err, assert.AnError)
+				assert.NotErrorIs(t, err, adapter.ErrSigningKeysNotFound)
+			})
+		})
+	})
+}
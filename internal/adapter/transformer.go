@@ -32,7 +32,12 @@ import (
 	"k8s.io/client-go/util/jsonpath"
 )
 
-var ErrTransformerTransform = errors.New("transforming content")
+var (
+	ErrTransformerTransform = errors.New("transforming content")
+
+	// ErrButaneTranslate is returned when a butane document cannot be translated to ignition.
+	ErrButaneTranslate = errors.New("translating butane to ignition")
+)
 
 // --------------------------------------------------- INTERFACE ---------------------------------------------------- //
 
@@ -59,7 +64,7 @@ func (t *butaneTransformer) Transform(
 ) ([]byte, error) {
 	b, _, err := butaneconfig.TranslateBytes(content, butanecommon.TranslateBytesOptions{Raw: true})
 	if err != nil {
-		return nil, errors.Join(err, ErrTransformerTransform)
+		return nil, errors.Join(err, ErrButaneTranslate, ErrTransformerTransform)
 	}
 
 	return b, nil
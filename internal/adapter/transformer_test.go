@@ -72,6 +72,17 @@ passwd:
 		assert.NoError(t, err)
 		assert.Equal(t, expected, actual)
 	})
+
+	t.Run("Invalid butane", func(t *testing.T) {
+		setup(t)
+
+		inputCfg := types.TransformerConfig{Kind: types.ButaneTransformerKind}
+		inputContent := []byte("variant: fcos\nversion: 0.0.0\n")
+
+		_, err := transformer.Transform(context.Background(), inputCfg, inputContent, types.IPXESelectors{})
+		assert.ErrorIs(t, err, adapter.ErrButaneTranslate)
+		assert.ErrorIs(t, err, adapter.ErrTransformerTransform)
+	})
 }
 
 func TestWebhookTransformer(t *testing.T) {
@@ -0,0 +1,79 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/google/uuid"
+)
+
+var ErrClientNotAllowed = errors.New("client is not allowed")
+
+type authTokenContextKey struct{}
+
+// WithAuthToken returns a copy of the context carrying the token that authenticated the current request. The token is
+// carried by the URLs of the exposed content, so the machine can authenticate when fetching them.
+func WithAuthToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, authTokenContextKey{}, token)
+}
+
+// AuthTokenFromContext returns the token carried by the context, or an empty string.
+func AuthTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(authTokenContextKey{}).(string)
+	return token
+}
+
+type verifiedMachineContextKey struct{}
+
+// WithVerifiedMachine returns a copy of the context carrying the UUID of the machine the current request was
+// authenticated for, i.e. the UUID covered by the signature of the content URL or certified by the client certificate.
+func WithVerifiedMachine(ctx context.Context, machineID uuid.UUID) context.Context {
+	return context.WithValue(ctx, verifiedMachineContextKey{}, machineID)
+}
+
+// VerifiedMachineFromContext returns the UUID of the verified machine carried by the context, or uuid.Nil.
+func VerifiedMachineFromContext(ctx context.Context) uuid.UUID {
+	machineID, _ := ctx.Value(verifiedMachineContextKey{}).(uuid.UUID)
+	return machineID
+}
+
+// checkClientAllowed returns ErrClientNotAllowed if the assignment restricts the clients it serves to source CIDRs that
+// do not contain the IP of the client.
+func checkClientAllowed(assignment types.Assignment, clientIP string) error {
+	if len(assignment.AllowedCIDRs) == 0 {
+		return nil
+	}
+
+	ip, err := netip.ParseAddr(clientIP)
+	if err == nil {
+		ip = ip.Unmap()
+
+		for _, prefix := range assignment.AllowedCIDRs {
+			if prefix.Contains(ip) {
+				return nil
+			}
+		}
+	}
+
+	return errors.Join(ErrClientNotAllowed, fmt.Errorf(
+		"client %q is not in the allowed CIDRs of assignment %s/%s",
+		clientIP, assignment.Namespace, assignment.Name,
+	))
+}
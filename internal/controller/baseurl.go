@@ -0,0 +1,51 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+)
+
+type baseURLContextKey struct{}
+
+// WithBaseURL returns a copy of the context carrying the base URL of the shaper API as seen by the client of the
+// current request.
+func WithBaseURL(ctx context.Context, baseURL string) context.Context {
+	return context.WithValue(ctx, baseURLContextKey{}, baseURL)
+}
+
+// BaseURLFromContext returns the base URL of the shaper API carried by the context, or an empty string.
+func BaseURLFromContext(ctx context.Context) string {
+	baseURL, _ := ctx.Value(baseURLContextKey{}).(string)
+	return baseURL
+}
+
+// resolveBaseURL returns the base URL of the shaper API used to render the profile. The base URL of the profile takes
+// precedence over the one carried by the context, which takes precedence over the fallback.
+func resolveBaseURL(ctx context.Context, p types.Profile, fallback string) string {
+	baseURL := p.ExternalBaseURL
+	if baseURL == "" {
+		baseURL = BaseURLFromContext(ctx)
+	}
+
+	if baseURL == "" {
+		baseURL = fallback
+	}
+
+	return strings.TrimSuffix(baseURL, "/")
+}
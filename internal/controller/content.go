@@ -46,18 +46,30 @@ type Content interface {
 // --------------------------------------------------- CONSTRUCTORS ------------------------------------------------- //
 
 // NewContent returns a new Content.
-func NewContent(profile adapter.Profile, mux ResolveTransformerMux) Content {
+func NewContent(
+	assignment adapter.Assignment,
+	profile adapter.Profile,
+	machine adapter.Machine,
+	mux ResolveTransformerMux,
+	baseURL string,
+) Content {
 	return &content{
-		profile: profile,
-		mux:     mux,
+		assignment: assignment,
+		profile:    profile,
+		machine:    machine,
+		mux:        mux,
+		baseURL:    baseURL,
 	}
 }
 
 // ---------------------------------------------------- CONTENT ----------------------------------------------------- //
 
 type content struct {
-	profile adapter.Profile
-	mux     ResolveTransformerMux
+	assignment adapter.Assignment
+	profile    adapter.Profile
+	machine    adapter.Machine
+	mux        ResolveTransformerMux
+	baseURL    string
 }
 
 func (c *content) GetByID(
@@ -74,14 +86,35 @@ func (c *content) GetByID(
 		return nil, errors.Join(err, ErrContentNotFound, ErrContentGetById)
 	}
 
-	contentName := list[0].ContentIDToNameMap[contentID]
-	cont := list[0].AdditionalContent[contentName]
+	selectors, assignment := c.selectorsAndAssignment(ctx, attributes)
+
+	p, assigned := c.assignedProfile(ctx, list, assignment, contentID)
+	if assigned {
+		err = checkClientAllowed(assignment, selectors.ClientIP)
+	} else {
+		// The assignment of the machine, if any, does not serve the content: the client is checked against the
+		// assignments referencing it, else any machine of an unrestricted assignment could fetch restricted content.
+		assignment = types.Assignment{}
+		err = c.checkClientAllowedByReferrers(ctx, list, selectors.ClientIP)
+	}
+
+	if err != nil {
+		return nil, errors.Join(err, ErrContentGetById)
+	}
+
+	contentName := p.ContentIDToNameMap[contentID]
+	cont := p.AdditionalContent[contentName]
+
+	data, err := c.templateData(ctx, p, selectors, assignment)
+	if err != nil {
+		return nil, errors.Join(err, ErrContentGetById)
+	}
+
 	// NB: mux.ResolveAndTransform will always render the content. Please call ResolveAndTransformBatch
 	// with the mux.ReturnExposedContentURL option to return a URL instead.
-	out, err := c.mux.ResolveAndTransform(ctx, cont, types.IPXESelectors{
-		UUID:      contentID, // the contentID takes precedence, thus should always overwrite the attribute uuid.
-		Buildarch: attributes.Buildarch,
-	})
+	// The webhook resolvers and transformers receive the attributes of the machine, not the content ID.
+	// The content referenced by the content are rendered from the same profile.
+	out, err := c.mux.ResolveAndTransform(ctx, cont, p.AdditionalContent, selectors, data)
 	if err != nil {
 		return nil, errors.Join(err, ErrContentGetById)
 	}
@@ -104,5 +137,153 @@ func (c *content) GetByID(
 		"size_bytes", len(out),
 	)
 
+	c.markProvisioned(ctx, attributes.UUID)
+
 	return out, nil
 }
+
+// checkClientAllowedByReferrers checks the client against the assignments referencing the profiles of the content, as
+// the content cannot be tied to an assignment, e.g. the `uuid` query parameter is omitted, no machine was recorded or
+// the assignment of the machine does not serve the content.
+// The client is allowed if one of the assignments allows it, or if no assignment references the profiles. It fails
+// closed: the allowedCIDRs of the assignments cannot be skipped by omitting the uuid.
+func (c *content) checkClientAllowedByReferrers(ctx context.Context, profiles []types.Profile, clientIP string) error {
+	var errs []error
+
+	for _, p := range profiles {
+		assignments, err := c.assignment.ListByProfileName(ctx, p.Name, p.Namespace)
+		if err != nil {
+			return err // TODO: wrap err
+		}
+
+		for _, assignment := range assignments {
+			err := checkClientAllowed(assignment, clientIP)
+			if err == nil {
+				return nil
+			}
+
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// assignedProfile returns the profile serving the content through the assignment of the machine: the profile of the
+// content referenced by the assignment, or the assigned profile if it inherits the content, e.g. from a base profile,
+// so the content is rendered with the parameters of the assigned profile. If the assignment does not serve the content,
+// or on error, it returns the first profile of the content and false. Errors are only logged.
+func (c *content) assignedProfile(
+	ctx context.Context,
+	profiles []types.Profile,
+	assignment types.Assignment,
+	contentID uuid.UUID,
+) (types.Profile, bool) {
+	if assignment.ProfileName == "" {
+		return profiles[0], false
+	}
+
+	for _, p := range profiles {
+		if references(assignment, p) {
+			return p, true
+		}
+	}
+
+	assigned, err := getAssignedProfile(ctx, c.profile, assignment)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get assigned profile",
+			"profile", assignment.ProfileName,
+			"error", err.Error(),
+		)
+
+		return profiles[0], false
+	}
+
+	if _, ok := assigned.ContentIDToNameMap[contentID]; !ok {
+		return profiles[0], false
+	}
+
+	return assigned, true
+}
+
+// templateData returns the data available to the template of the content. The parameters of the profile are overridden
+// by the ones of the selected assignment.
+func (c *content) templateData(
+	ctx context.Context,
+	p types.Profile,
+	selectors types.IPXESelectors,
+	assignment types.Assignment,
+) (types.TemplateData, error) {
+	data := newTemplateData(selectors, assignment, p, resolveBaseURL(ctx, p, c.baseURL))
+
+	params, err := ResolveParameters(ctx, c.mux, selectors, p.Parameters, assignment.Parameters)
+	if err != nil {
+		return types.TemplateData{}, err // TODO: wrap err
+	}
+
+	data.Params = params
+
+	return data, nil
+}
+
+// selectorsAndAssignment completes the attributes of the machine with the ones recorded in its Machine resource during
+// its last boot, and selects the assignment again from them. Failing to do so must not prevent the machine from
+// getting its content, hence errors are only logged.
+func (c *content) selectorsAndAssignment(
+	ctx context.Context,
+	attributes types.IPXESelectors,
+) (types.IPXESelectors, types.Assignment) {
+	if attributes.UUID == uuid.Nil {
+		return attributes, types.Assignment{}
+	}
+
+	m, err := c.machine.Get(ctx, attributes.UUID)
+	if errors.Is(err, adapter.ErrMachineNotFound) {
+		return attributes, types.Assignment{}
+	} else if err != nil {
+		slog.WarnContext(ctx, "failed to get machine",
+			"uuid", attributes.UUID,
+			"error", err.Error(),
+		)
+
+		return attributes, types.Assignment{}
+	}
+
+	selectors := m.Selectors
+	selectors.Labels = m.Labels
+	if attributes.Buildarch != "" {
+		selectors.Buildarch = attributes.Buildarch
+	}
+
+	// The client fetching the content is not necessarily at the address recorded during the last boot.
+	selectors.ClientIP = attributes.ClientIP
+
+	assignment, _, err := selectAssignment(ctx, c.assignment, selectors)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to select assignment",
+			"uuid", attributes.UUID,
+			"error", err.Error(),
+		)
+	}
+
+	return selectors, assignment
+}
+
+// markProvisioned marks the machine fetching an exposed content as provisioned. The `uuid` query parameter can be set
+// by anyone, so the machine is only marked if the request was verified for it, i.e. the content URL was signed for it
+// or the client certificate certifies it. Other machines are only marked by their provisioned events. Failing to mark
+// the machine must not prevent it from getting its content, hence errors are only logged.
+func (c *content) markProvisioned(ctx context.Context, machineID uuid.UUID) {
+	if machineID == uuid.Nil || VerifiedMachineFromContext(ctx) != machineID {
+		return
+	}
+
+	if err := c.machine.MarkProvisioned(ctx, machineID); errors.Is(err, adapter.ErrMachineNotFound) {
+		return
+	} else if err != nil {
+		slog.WarnContext(ctx, "failed to mark machine as provisioned",
+			"uuid", machineID,
+			"error", err.Error(),
+		)
+	}
+}
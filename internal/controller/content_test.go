@@ -18,8 +18,10 @@ package controller_test
 
 import (
 	"context"
+	"net/netip"
 	"testing"
 
+	"github.com/alexandremahdhaoui/shaper/internal/adapter"
 	"github.com/alexandremahdhaoui/shaper/internal/controller"
 	"github.com/alexandremahdhaoui/shaper/internal/types"
 	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockadapter"
@@ -47,9 +49,11 @@ func TestContent(t *testing.T) {
 		expectedMuxResult []byte
 		expectedMuxErr    error
 
-		profile *mockadapter.MockProfile
-		mux     *mockcontroller.MockResolveTransformerMux
-		content controller.Content
+		assignment *mockadapter.MockAssignment
+		profile    *mockadapter.MockProfile
+		machine    *mockadapter.MockMachine
+		mux        *mockcontroller.MockResolveTransformerMux
+		content    controller.Content
 	)
 
 	setup := func(t *testing.T) func() {
@@ -60,9 +64,11 @@ func TestContent(t *testing.T) {
 		inputConfigID = uuid.New()
 		ipxeSelectors = types.IPXESelectors{}
 
+		assignment = mockadapter.NewMockAssignment(t)
 		profile = mockadapter.NewMockProfile(t)
+		machine = mockadapter.NewMockMachine(t)
 		mux = mockcontroller.NewMockResolveTransformerMux(t)
-		content = controller.NewContent(profile, mux)
+		content = controller.NewContent(assignment, profile, machine, mux, "https://shaper.example.com")
 
 		expectedProfileResult = nil
 		expectedProfileErr = nil
@@ -73,7 +79,9 @@ func TestContent(t *testing.T) {
 		return func() {
 			t.Helper()
 
+			assignment.AssertExpectations(t)
 			profile.AssertExpectations(t)
+			machine.AssertExpectations(t)
 			mux.AssertExpectations(t)
 		}
 	}
@@ -83,11 +91,17 @@ func TestContent(t *testing.T) {
 			ListByContentID(ctx, inputConfigID).
 			Return(expectedProfileResult, expectedProfileErr).
 			Once()
+
+		// The client is checked against the assignments referencing the profile when no assignment is selected.
+		assignment.EXPECT().
+			ListByProfileName(ctx, mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Maybe()
 	}
 
 	expectMux := func() {
 		mux.EXPECT().
-			ResolveAndTransform(mock.Anything, mock.Anything, mock.Anything).
+			ResolveAndTransform(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 			Return(expectedMuxResult, expectedMuxErr).
 			Once()
 	}
@@ -122,7 +136,415 @@ func TestContent(t *testing.T) {
 			assert.Equal(t, expected, actual)
 		})
 
+		t.Run("MarkProvisioned", func(t *testing.T) {
+			for _, tt := range []struct {
+				Name       string
+				Err        error
+				Unverified bool
+				OtherUUID  bool
+			}{
+				{Name: "Success"},
+				{Name: "Machine not found", Err: adapter.ErrMachineNotFound},
+				{Name: "Failure is ignored", Err: assert.AnError},
+				{Name: "Unverified uuid is not marked", Unverified: true},
+				{Name: "Uuid verified for another machine is not marked", OtherUUID: true},
+			} {
+				t.Run(tt.Name, func(t *testing.T) {
+					defer setup(t)()
+
+					expected := []byte("qwe")
+					expectedProfileResult = []types.Profile{
+						{
+							AdditionalContent: map[string]types.Content{
+								mustBeReturned: {
+									Name:        mustBeReturned,
+									ExposedUUID: inputConfigID,
+								},
+							},
+							ContentIDToNameMap: map[uuid.UUID]string{inputConfigID: mustBeReturned},
+						},
+					}
+
+					expectedMuxResult = expected
+					ipxeSelectors.UUID = uuid.New()
+
+					switch {
+					case tt.OtherUUID:
+						ctx = controller.WithVerifiedMachine(ctx, uuid.New())
+					case !tt.Unverified:
+						ctx = controller.WithVerifiedMachine(ctx, ipxeSelectors.UUID)
+					}
+
+					expectProfile()
+					expectMux()
+
+					machine.EXPECT().
+						Get(ctx, ipxeSelectors.UUID).
+						Return(types.Machine{}, adapter.ErrMachineNotFound).
+						Once()
+
+					if !tt.Unverified && !tt.OtherUUID {
+						machine.EXPECT().
+							MarkProvisioned(ctx, ipxeSelectors.UUID).
+							Return(tt.Err).
+							Once()
+					}
+
+					actual, err := content.GetByID(ctx, inputConfigID, ipxeSelectors)
+					assert.NoError(t, err)
+					assert.Equal(t, expected, actual)
+				})
+			}
+		})
+
+		t.Run("TemplateData", func(t *testing.T) {
+			defer setup(t)()
+
+			machineID := uuid.New()
+			expectedProfileResult = []types.Profile{
+				{
+					Name:      "a-profile",
+					Namespace: "shaper",
+					AdditionalContent: map[string]types.Content{
+						mustBeReturned: {
+							Name:         mustBeReturned,
+							ExposedUUID:  inputConfigID,
+							ResolverKind: types.InlineResolverKind,
+						},
+					},
+					ContentIDToNameMap: map[uuid.UUID]string{inputConfigID: mustBeReturned},
+				},
+			}
+
+			recorded := types.IPXESelectors{
+				UUID:      machineID,
+				Buildarch: "arm64",
+				MAC:       "52-54-00-12-34-56",
+			}
+
+			expectedSelectors := recorded
+			expectedSelectors.Buildarch = "x86_64"
+			expectedSelectors.Labels = map[string]string{"rack": "r1"}
+
+			expectProfile()
+
+			machine.EXPECT().
+				Get(ctx, machineID).
+				Return(types.Machine{Selectors: recorded, Labels: map[string]string{"rack": "r1"}}, nil).
+				Once()
+
+			assignment.EXPECT().
+				FindBySelectors(ctx, expectedSelectors).
+				Return(types.Assignment{
+					Name:        "an-assignment",
+					Namespace:   "shaper",
+					Labels:      map[string]string{"site": "dc1"},
+					ProfileName: "a-profile",
+				}, "uuid", nil).
+				Once()
+
+			// The resolvers and transformers receive the attributes of the machine, not the content ID.
+			mux.EXPECT().
+				ResolveAndTransform(ctx, mock.Anything, mock.Anything, expectedSelectors, types.TemplateData{
+					Machine: expectedSelectors,
+					Assignment: types.AssignmentMetadata{
+						Name:      "an-assignment",
+						Namespace: "shaper",
+						Labels:    map[string]string{"site": "dc1"},
+					},
+					Profile: types.ProfileMetadata{Name: "a-profile", Namespace: "shaper"},
+					BaseURL: "https://shaper.example.com",
+				}).
+				Return([]byte("qwe"), nil).
+				Once()
+
+			actual, err := content.GetByID(ctx, inputConfigID, types.IPXESelectors{UUID: machineID, Buildarch: "x86_64"})
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("qwe"), actual)
+		})
+
+		t.Run("Params", func(t *testing.T) {
+			defer setup(t)()
+
+			machineID := uuid.New()
+			profileParams := map[string]types.Content{
+				"channel": {Name: "channel", Inline: "stable"},
+				"ntp":     {Name: "ntp", Inline: "pool.ntp.org"},
+			}
+
+			expectedProfileResult = []types.Profile{
+				{
+					Name: "a-profile",
+					AdditionalContent: map[string]types.Content{
+						mustBeReturned: {Name: mustBeReturned, ExposedUUID: inputConfigID},
+					},
+					ContentIDToNameMap: map[uuid.UUID]string{inputConfigID: mustBeReturned},
+					Parameters:         profileParams,
+				},
+			}
+
+			recorded := types.IPXESelectors{UUID: machineID, Buildarch: "x86_64"}
+
+			expectProfile()
+
+			machine.EXPECT().
+				Get(ctx, machineID).
+				Return(types.Machine{Selectors: recorded}, nil).
+				Once()
+
+			assignment.EXPECT().
+				FindBySelectors(ctx, recorded).
+				Return(types.Assignment{
+					Name:        "an-assignment",
+					ProfileName: "a-profile",
+					Parameters:  map[string]types.Content{"channel": {Name: "channel", Inline: "beta"}},
+				}, "uuid", nil).
+				Once()
+
+			// The parameters of the assignment override the ones of the profile.
+			mux.EXPECT().
+				ResolveAndTransformBatch(
+					ctx,
+					map[string]types.Content{
+						"channel": {Name: "channel", Inline: "beta"},
+						"ntp":     {Name: "ntp", Inline: "pool.ntp.org"},
+					},
+					recorded,
+					types.TemplateData{},
+					mock.AnythingOfType("controller.ResolveTransformBatchOption"),
+				).
+				Return(map[string][]byte{"channel": []byte("beta"), "ntp": []byte("pool.ntp.org")}, nil).
+				Once()
+
+			mux.EXPECT().
+				ResolveAndTransform(ctx, mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(data types.TemplateData) bool {
+					return data.Params["channel"] == "beta" && data.Params["ntp"] == "pool.ntp.org"
+				})).
+				Return([]byte("qwe"), nil).
+				Once()
+
+			actual, err := content.GetByID(ctx, inputConfigID, types.IPXESelectors{UUID: machineID})
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("qwe"), actual)
+		})
+
+		t.Run("Inherited", func(t *testing.T) {
+			defer setup(t)()
+
+			machineID := uuid.New()
+			recorded := types.IPXESelectors{UUID: machineID, Buildarch: "x86_64"}
+
+			// The content is exposed by the base profile, but the machine is assigned to the child profile.
+			expectedProfileResult = []types.Profile{
+				{
+					Name: "base",
+					AdditionalContent: map[string]types.Content{
+						mustBeReturned: {Name: mustBeReturned, Inline: "base", ExposedUUID: inputConfigID},
+					},
+					ContentIDToNameMap: map[uuid.UUID]string{inputConfigID: mustBeReturned},
+				},
+			}
+
+			child := types.Profile{
+				Name: "child",
+				AdditionalContent: map[string]types.Content{
+					mustBeReturned: {Name: mustBeReturned, Inline: "child", ExposedUUID: inputConfigID},
+				},
+				ContentIDToNameMap: map[uuid.UUID]string{inputConfigID: mustBeReturned},
+			}
+
+			expectProfile()
+
+			machine.EXPECT().
+				Get(ctx, machineID).
+				Return(types.Machine{Selectors: recorded}, nil).
+				Once()
+
+			assignment.EXPECT().
+				FindBySelectors(ctx, recorded).
+				Return(types.Assignment{Name: "an-assignment", ProfileName: "child"}, "uuid", nil).
+				Once()
+
+			profile.EXPECT().GetInNamespace(ctx, "child", "").Return(child, nil).Once()
+
+			mux.EXPECT().
+				ResolveAndTransform(ctx, child.AdditionalContent[mustBeReturned], child.AdditionalContent, recorded,
+					mock.MatchedBy(func(data types.TemplateData) bool { return data.Profile.Name == "child" }),
+				).
+				Return([]byte("qwe"), nil).
+				Once()
+
+			actual, err := content.GetByID(ctx, inputConfigID, types.IPXESelectors{UUID: machineID})
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("qwe"), actual)
+		})
+
 		t.Run("Failure", func(t *testing.T) {
+			t.Run("Params Err", func(t *testing.T) {
+				defer setup(t)()
+
+				expectedProfileResult = []types.Profile{
+					{
+						AdditionalContent: map[string]types.Content{
+							mustBeReturned: {Name: mustBeReturned, ExposedUUID: inputConfigID},
+						},
+						ContentIDToNameMap: map[uuid.UUID]string{inputConfigID: mustBeReturned},
+						Parameters:         map[string]types.Content{"token": {Name: "token"}},
+					},
+				}
+
+				expectProfile()
+
+				mux.EXPECT().
+					ResolveAndTransformBatch(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, assert.AnError).
+					Once()
+
+				_, err := content.GetByID(ctx, inputConfigID, ipxeSelectors)
+				assert.ErrorIs(t, err, assert.AnError)
+				assert.ErrorIs(t, err, controller.ErrResolveParameters)
+				assert.ErrorIs(t, err, controller.ErrContentGetById)
+			})
+
+			t.Run("Client not allowed", func(t *testing.T) {
+				defer setup(t)()
+
+				machineID := uuid.New()
+				recorded := types.IPXESelectors{UUID: machineID, Buildarch: "x86_64"}
+
+				expectedProfileResult = []types.Profile{
+					{
+						Name: "a-profile",
+						AdditionalContent: map[string]types.Content{
+							mustBeReturned: {Name: mustBeReturned, ExposedUUID: inputConfigID},
+						},
+						ContentIDToNameMap: map[uuid.UUID]string{inputConfigID: mustBeReturned},
+					},
+				}
+
+				expectProfile()
+
+				machine.EXPECT().
+					Get(ctx, machineID).
+					Return(types.Machine{Selectors: recorded}, nil).
+					Once()
+
+				assignment.EXPECT().
+					FindBySelectors(ctx, types.IPXESelectors{UUID: machineID, Buildarch: "x86_64", ClientIP: "10.0.2.42"}).
+					Return(types.Assignment{
+						Name:         "an-assignment",
+						ProfileName:  "a-profile",
+						AllowedCIDRs: []netip.Prefix{netip.MustParsePrefix("10.0.1.0/24")},
+					}, "uuid", nil).
+					Once()
+
+				_, err := content.GetByID(ctx, inputConfigID, types.IPXESelectors{UUID: machineID, ClientIP: "10.0.2.42"})
+				assert.ErrorIs(t, err, controller.ErrClientNotAllowed)
+				assert.ErrorIs(t, err, controller.ErrContentGetById)
+			})
+
+			t.Run("Client not allowed by the referencing assignments", func(t *testing.T) {
+				for _, tt := range []struct {
+					Name      string
+					Selectors types.IPXESelectors
+				}{
+					{Name: "uuid omitted", Selectors: types.IPXESelectors{ClientIP: "10.0.2.42"}},
+					{Name: "machine not found", Selectors: types.IPXESelectors{UUID: uuid.New(), ClientIP: "10.0.2.42"}},
+				} {
+					t.Run(tt.Name, func(t *testing.T) {
+						defer setup(t)()
+
+						profile.EXPECT().
+							ListByContentID(ctx, inputConfigID).
+							Return([]types.Profile{{
+								Name:      "a-profile",
+								Namespace: "shaper",
+								AdditionalContent: map[string]types.Content{
+									mustBeReturned: {Name: mustBeReturned, ExposedUUID: inputConfigID},
+								},
+								ContentIDToNameMap: map[uuid.UUID]string{inputConfigID: mustBeReturned},
+							}}, nil).
+							Once()
+
+						if tt.Selectors.UUID != uuid.Nil {
+							machine.EXPECT().
+								Get(ctx, tt.Selectors.UUID).
+								Return(types.Machine{}, adapter.ErrMachineNotFound).
+								Once()
+						}
+
+						assignment.EXPECT().
+							ListByProfileName(ctx, "a-profile", "shaper").
+							Return([]types.Assignment{
+								{
+									Name:         "an-assignment",
+									AllowedCIDRs: []netip.Prefix{netip.MustParsePrefix("10.0.1.0/24")},
+								},
+								{
+									Name:         "another-assignment",
+									AllowedCIDRs: []netip.Prefix{netip.MustParsePrefix("10.0.3.0/24")},
+								},
+							}, nil).
+							Once()
+
+						_, err := content.GetByID(ctx, inputConfigID, tt.Selectors)
+						assert.ErrorIs(t, err, controller.ErrClientNotAllowed)
+						assert.ErrorIs(t, err, controller.ErrContentGetById)
+					})
+				}
+			})
+
+			t.Run("Client not allowed by the assignment referencing the content", func(t *testing.T) {
+				defer setup(t)()
+
+				machineID := uuid.New()
+				recorded := types.IPXESelectors{UUID: machineID, Buildarch: "x86_64"}
+				clientSelectors := types.IPXESelectors{UUID: machineID, Buildarch: "x86_64", ClientIP: "10.0.2.42"}
+
+				// The content is only referenced by a restricted assignment.
+				profile.EXPECT().
+					ListByContentID(ctx, inputConfigID).
+					Return([]types.Profile{{
+						Name:      "restricted-profile",
+						Namespace: "shaper",
+						AdditionalContent: map[string]types.Content{
+							mustBeReturned: {Name: mustBeReturned, ExposedUUID: inputConfigID},
+						},
+						ContentIDToNameMap: map[uuid.UUID]string{inputConfigID: mustBeReturned},
+					}}, nil).
+					Once()
+
+				// The machine was registered through an unrestricted assignment.
+				machine.EXPECT().
+					Get(ctx, machineID).
+					Return(types.Machine{Selectors: recorded}, nil).
+					Once()
+
+				assignment.EXPECT().
+					FindBySelectors(ctx, clientSelectors).
+					Return(types.Assignment{Name: "default", Namespace: "shaper", ProfileName: "default-profile"}, "default", nil).
+					Once()
+
+				profile.EXPECT().
+					GetInNamespace(ctx, "default-profile", "shaper").
+					Return(types.Profile{Name: "default-profile", Namespace: "shaper"}, nil).
+					Once()
+
+				assignment.EXPECT().
+					ListByProfileName(ctx, "restricted-profile", "shaper").
+					Return([]types.Assignment{{
+						Name:         "restricted",
+						Namespace:    "shaper",
+						ProfileName:  "restricted-profile",
+						AllowedCIDRs: []netip.Prefix{netip.MustParsePrefix("10.0.1.0/24")},
+					}}, nil).
+					Once()
+
+				_, err := content.GetByID(ctx, inputConfigID, types.IPXESelectors{UUID: machineID, ClientIP: "10.0.2.42"})
+				assert.ErrorIs(t, err, controller.ErrClientNotAllowed)
+				assert.ErrorIs(t, err, controller.ErrContentGetById)
+			})
+
 			t.Run("Content not found", func(t *testing.T) {
 				defer setup(t)()
 
@@ -0,0 +1,269 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+const (
+	resolveCacheStage   = "resolve"
+	transformCacheStage = "transform"
+)
+
+// ---------------------------------------------------- INTERFACE --------------------------------------------------- //
+
+// ContentCache is an interface for caching the output of the resolvers and transformers. An output is cached until it
+// expires, is evicted as the least recently used, or is invalidated by a change of an object or profile it was computed
+// from. The cache exports its hit and miss metrics as a prometheus.Collector.
+type ContentCache interface {
+	prometheus.Collector
+
+	// GetOrAdd returns the output cached under the key, else computes it with fn and caches it unless fn fails.
+	// Concurrent calls for the same key compute the output once.
+	GetOrAdd(key ContentCacheKey, fn func() ([]byte, error)) ([]byte, error)
+	// InvalidateObject removes the outputs computed from the object.
+	InvalidateObject(group, resource, namespace, name string)
+	// InvalidateProfile removes the outputs computed for the profile. The namespace of a ClusterProfile is empty.
+	InvalidateProfile(namespace, name string)
+}
+
+// ContentCacheKey identifies a cached output.
+type ContentCacheKey struct {
+	// Hash is the hash of everything the output is computed from.
+	Hash string
+	// Profile is the "namespace/name" of the profile the output was computed for, if any.
+	Profile string
+	// Objects are the "group/resource/namespace/name" of the objects the output is computed from, separated by
+	// newlines.
+	Objects string
+}
+
+// --------------------------------------------------- CONSTRUCTORS ------------------------------------------------- //
+
+// NewContentCache returns a new ContentCache holding at most maxEntries outputs for the given TTL.
+func NewContentCache(maxEntries int, ttl time.Duration) ContentCache {
+	c := &contentCache{
+		entries: cache.NewLRUExpireCache(maxEntries),
+		ttl:     ttl,
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "shaper_content_cache_hits_total",
+			Help: "Number of resolved or transformed content served from the cache.",
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "shaper_content_cache_misses_total",
+			Help: "Number of resolved or transformed content computed as they were not cached.",
+		}),
+		invalidations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "shaper_content_cache_invalidations_total",
+			Help: "Number of cached content removed as an object or profile they were computed from changed.",
+		}),
+	}
+
+	c.size = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "shaper_content_cache_entries",
+		Help: "Number of cached content.",
+	}, func() float64 { return float64(len(c.entries.Keys())) })
+
+	return c
+}
+
+// ---------------------------------------------------- CACHE ------------------------------------------------------- //
+
+type contentCache struct {
+	entries *cache.LRUExpireCache
+	ttl     time.Duration
+	group   singleflight.Group
+
+	// generation is incremented by each invalidation, so an output computed while an object it is computed from
+	// changed is not cached.
+	generation atomic.Uint64
+
+	hits          prometheus.Counter
+	misses        prometheus.Counter
+	invalidations prometheus.Counter
+	size          prometheus.GaugeFunc
+}
+
+func (c *contentCache) GetOrAdd(key ContentCacheKey, fn func() ([]byte, error)) ([]byte, error) {
+	if out, ok := c.entries.Get(key); ok {
+		c.hits.Inc()
+		return out.([]byte), nil
+	}
+
+	c.misses.Inc()
+
+	out, err, _ := c.group.Do(key.Hash, func() (any, error) {
+		generation := c.generation.Load()
+
+		out, err := fn()
+		if err != nil {
+			return nil, err
+		}
+
+		if c.generation.Load() == generation {
+			c.entries.Add(key, out, c.ttl)
+		}
+
+		return out, nil
+	})
+	if err != nil {
+		return nil, err // TODO: wrap err
+	}
+
+	return out.([]byte), nil
+}
+
+func (c *contentCache) InvalidateObject(group, resource, namespace, name string) {
+	object := objectCacheKey(group, resource, namespace, name)
+
+	c.invalidate(func(key ContentCacheKey) bool {
+		return slices.Contains(strings.Split(key.Objects, "\n"), object)
+	})
+}
+
+func (c *contentCache) InvalidateProfile(namespace, name string) {
+	profile := namespace + "/" + name
+
+	c.invalidate(func(key ContentCacheKey) bool {
+		return key.Profile == profile
+	})
+}
+
+func (c *contentCache) invalidate(predicate func(key ContentCacheKey) bool) {
+	c.generation.Add(1)
+
+	c.entries.RemoveAll(func(key any) bool {
+		if !predicate(key.(ContentCacheKey)) {
+			return false
+		}
+
+		c.invalidations.Inc()
+
+		return true
+	})
+}
+
+func (c *contentCache) Describe(ch chan<- *prometheus.Desc) {
+	c.hits.Describe(ch)
+	c.misses.Describe(ch)
+	c.invalidations.Describe(ch)
+	c.size.Describe(ch)
+}
+
+func (c *contentCache) Collect(ch chan<- prometheus.Metric) {
+	c.hits.Collect(ch)
+	c.misses.Collect(ch)
+	c.invalidations.Collect(ch)
+	c.size.Collect(ch)
+}
+
+// -------------------------------------------------- KEYS ---------------------------------------------------------- //
+
+// newContentCacheKey returns the key of the output of a stage of the content: the resolve stage, or the transform stage
+// of the given input. The attributes of the machine are part of the key only when the stage calls a webhook, which
+// receives them; other outputs are shared by every machine. The namespaces of the referrers of the objects are part of
+// the key, as the ObjectRefPolicy may allow an object to be referenced from one namespace but not from another.
+func newContentCacheKey(
+	stage string,
+	content types.Content,
+	selectors types.IPXESelectors,
+	profile types.ProfileMetadata,
+	input []byte,
+) ContentCacheKey {
+	var (
+		webhook   bool
+		objects   []string
+		referrers []string
+	)
+
+	addObjectRef := func(ref types.ObjectRef) {
+		objects = append(objects, objectRefCacheKey(ref))
+		referrers = append(referrers, ref.ReferrerNamespace)
+	}
+
+	addWebhook := func(cfg *types.WebhookConfig) {
+		webhook = true
+
+		if cfg.MTLSObjectRef != nil {
+			addObjectRef(cfg.MTLSObjectRef.ObjectRef)
+		}
+
+		if cfg.BasicAuthObjectRef != nil {
+			addObjectRef(cfg.BasicAuthObjectRef.ObjectRef)
+		}
+	}
+
+	switch stage {
+	case resolveCacheStage:
+		if content.ObjectRef != nil {
+			addObjectRef(*content.ObjectRef)
+		}
+
+		if content.ResolverKind == types.WebhookResolverKind && content.WebhookConfig != nil {
+			addWebhook(content.WebhookConfig)
+		}
+	case transformCacheStage:
+		for _, cfg := range content.PostTransformers {
+			if cfg.Kind == types.WebhookTransformerKind && cfg.Webhook != nil {
+				addWebhook(cfg.Webhook)
+			}
+		}
+	}
+
+	inputSum := sha256.Sum256(input)
+
+	h := sha256.New()
+	h.Write([]byte(stage + "\x00" + content.SpecHash + "\x00"))
+	h.Write(inputSum[:])
+
+	slices.Sort(referrers)
+	h.Write([]byte(strings.Join(slices.Compact(referrers), "\x00")))
+
+	if webhook {
+		b, _ := json.Marshal(selectors)
+		h.Write(b)
+	}
+
+	key := ContentCacheKey{Hash: hex.EncodeToString(h.Sum(nil))}
+
+	if profile.Name != "" {
+		key.Profile = profile.Namespace + "/" + profile.Name
+	}
+
+	slices.Sort(objects)
+	key.Objects = strings.Join(slices.Compact(objects), "\n")
+
+	return key
+}
+
+func objectRefCacheKey(ref types.ObjectRef) string {
+	return objectCacheKey(ref.Group, ref.Resource, ref.Namespace, ref.Name)
+}
+
+func objectCacheKey(group, resource, namespace, name string) string {
+	return strings.Join([]string{group, resource, namespace, name}, "/")
+}
@@ -0,0 +1,152 @@
+//go:build unit
+
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexandremahdhaoui/shaper/internal/controller"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentCache(t *testing.T) {
+	var (
+		cache controller.ContentCache
+		calls int
+	)
+
+	setup := func(t *testing.T) {
+		t.Helper()
+
+		cache = controller.NewContentCache(10, time.Hour)
+		calls = 0
+	}
+
+	compute := func(out string, err error) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			calls++
+			if err != nil {
+				return nil, err
+			}
+
+			return []byte(out), nil
+		}
+	}
+
+	cmKey := controller.ContentCacheKey{Hash: "cm", Objects: "/configmaps/shaper/a-cm\n/secrets/shaper/a-secret"}
+	profileKey := controller.ContentCacheKey{Hash: "profile", Profile: "shaper/a-profile"}
+
+	t.Run("GetOrAdd", func(t *testing.T) {
+		setup(t)
+
+		for range 3 {
+			actual, err := cache.GetOrAdd(cmKey, compute("qwe", nil))
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("qwe"), actual)
+		}
+
+		assert.Equal(t, 1, calls)
+		assert.NoError(t, testutil.CollectAndCompare(cache, strings.NewReader(`
+# HELP shaper_content_cache_hits_total Number of resolved or transformed content served from the cache.
+# TYPE shaper_content_cache_hits_total counter
+shaper_content_cache_hits_total 2
+# HELP shaper_content_cache_misses_total Number of resolved or transformed content computed as they were not cached.
+# TYPE shaper_content_cache_misses_total counter
+shaper_content_cache_misses_total 1
+# HELP shaper_content_cache_entries Number of cached content.
+# TYPE shaper_content_cache_entries gauge
+shaper_content_cache_entries 1
+`), "shaper_content_cache_hits_total", "shaper_content_cache_misses_total", "shaper_content_cache_entries"))
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		setup(t)
+
+		_, err := cache.GetOrAdd(cmKey, compute("", assert.AnError))
+		assert.ErrorIs(t, err, assert.AnError)
+
+		actual, err := cache.GetOrAdd(cmKey, compute("qwe", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("qwe"), actual)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("InvalidateObject", func(t *testing.T) {
+		setup(t)
+
+		_, _ = cache.GetOrAdd(cmKey, compute("qwe", nil))
+		_, _ = cache.GetOrAdd(profileKey, compute("qwe", nil))
+
+		cache.InvalidateObject("", "configmaps", "shaper", "another-cm")
+		_, _ = cache.GetOrAdd(cmKey, compute("qwe", nil))
+		assert.Equal(t, 2, calls)
+
+		cache.InvalidateObject("", "secrets", "shaper", "a-secret")
+		_, _ = cache.GetOrAdd(cmKey, compute("qwe", nil))
+		_, _ = cache.GetOrAdd(profileKey, compute("qwe", nil))
+		assert.Equal(t, 3, calls)
+		assert.NoError(t, testutil.CollectAndCompare(cache, strings.NewReader(`
+# HELP shaper_content_cache_invalidations_total Number of cached content removed as an object or profile they were computed from changed.
+# TYPE shaper_content_cache_invalidations_total counter
+shaper_content_cache_invalidations_total 1
+`), "shaper_content_cache_invalidations_total"))
+	})
+
+	t.Run("InvalidateProfile", func(t *testing.T) {
+		setup(t)
+
+		_, _ = cache.GetOrAdd(cmKey, compute("qwe", nil))
+		_, _ = cache.GetOrAdd(profileKey, compute("qwe", nil))
+
+		cache.InvalidateProfile("", "a-profile")
+		_, _ = cache.GetOrAdd(profileKey, compute("qwe", nil))
+		assert.Equal(t, 2, calls)
+
+		cache.InvalidateProfile("shaper", "a-profile")
+		_, _ = cache.GetOrAdd(cmKey, compute("qwe", nil))
+		_, _ = cache.GetOrAdd(profileKey, compute("qwe", nil))
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("Invalidated while computing", func(t *testing.T) {
+		setup(t)
+
+		_, _ = cache.GetOrAdd(cmKey, func() ([]byte, error) {
+			calls++
+			cache.InvalidateObject("", "configmaps", "shaper", "a-cm")
+
+			return []byte("stale"), nil
+		})
+
+		actual, _ := cache.GetOrAdd(cmKey, compute("qwe", nil))
+		assert.Equal(t, []byte("qwe"), actual)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		cache = controller.NewContentCache(10, time.Nanosecond)
+		calls = 0
+
+		_, _ = cache.GetOrAdd(cmKey, compute("qwe", nil))
+		time.Sleep(time.Millisecond)
+		_, _ = cache.GetOrAdd(cmKey, compute("qwe", nil))
+		assert.Equal(t, 2, calls)
+	})
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/alexandremahdhaoui/shaper/internal/adapter"
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrContentURLSignatureInvalid = errors.New("content url signature is invalid")
+	ErrContentURLExpired          = errors.New("content url is expired")
+
+	ErrContentURLSign   = errors.New("signing content url")
+	ErrContentURLVerify = errors.New("verifying content url")
+)
+
+// ---------------------------------------------------- INTERFACE --------------------------------------------------- //
+
+// ContentURLSigner is an interface for signing the URLs of the exposed content. A signed URL is bound to the content,
+// the UUID and buildarch of the machine, and expires.
+type ContentURLSigner interface {
+	// Sign signs the URL of the content for the machine.
+	Sign(ctx context.Context, contentID uuid.UUID, selectors types.IPXESelectors) (types.ContentURLSignature, error)
+	// Verify verifies the signature of the URL of the content requested by the machine. It returns
+	// ErrContentURLSignatureInvalid if the signature does not match and ErrContentURLExpired if the URL expired.
+	Verify(
+		ctx context.Context,
+		contentID uuid.UUID,
+		selectors types.IPXESelectors,
+		signature types.ContentURLSignature,
+	) error
+}
+
+// --------------------------------------------------- CONSTRUCTORS ------------------------------------------------- //
+
+// NewContentURLSigner returns a new ContentURLSigner signing URLs valid for the given TTL.
+func NewContentURLSigner(keys adapter.SigningKeys, ttl time.Duration) ContentURLSigner {
+	return &contentURLSigner{
+		keys: keys,
+		ttl:  ttl,
+	}
+}
+
+// ---------------------------------------------------- SIGNER ------------------------------------------------------ //
+
+type contentURLSigner struct {
+	keys adapter.SigningKeys
+	ttl  time.Duration
+}
+
+func (s *contentURLSigner) Sign(
+	ctx context.Context,
+	contentID uuid.UUID,
+	selectors types.IPXESelectors,
+) (types.ContentURLSignature, error) {
+	keys, err := s.keys.Get(ctx)
+	if err != nil {
+		return types.ContentURLSignature{}, errors.Join(err, ErrContentURLSign)
+	}
+
+	// The expiry is truncated to the second as it is carried by the URL as a Unix timestamp.
+	expires := time.Now().Add(s.ttl).Truncate(time.Second)
+
+	return types.ContentURLSignature{
+		Expires:   expires,
+		KeyID:     keys.CurrentID,
+		Signature: sign(keys.Keys[keys.CurrentID], contentID, selectors, expires),
+	}, nil
+}
+
+func (s *contentURLSigner) Verify(
+	ctx context.Context,
+	contentID uuid.UUID,
+	selectors types.IPXESelectors,
+	signature types.ContentURLSignature,
+) error {
+	keys, err := s.keys.Get(ctx)
+	if err != nil {
+		return errors.Join(err, ErrContentURLVerify)
+	}
+
+	// The signature is verified before the expiry, so a URL whose expiry was tampered with is rejected as invalid.
+	key, ok := keys.Keys[signature.KeyID]
+	if !ok {
+		return errors.Join(ErrContentURLSignatureInvalid, ErrContentURLVerify)
+	}
+
+	expected := sign(key, contentID, selectors, signature.Expires)
+	if !hmac.Equal([]byte(expected), []byte(signature.Signature)) {
+		return errors.Join(ErrContentURLSignatureInvalid, ErrContentURLVerify)
+	}
+
+	if !time.Now().Before(signature.Expires) {
+		return errors.Join(ErrContentURLExpired, ErrContentURLVerify)
+	}
+
+	return nil
+}
+
+// sign returns the base64url-encoded HMAC-SHA256 of the content ID, the UUID and buildarch of the machine and the
+// expiry of the URL.
+func sign(key []byte, contentID uuid.UUID, selectors types.IPXESelectors, expires time.Time) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(contentID.String() + "\n" +
+		selectors.UUID.String() + "\n" +
+		selectors.Buildarch + "\n" +
+		strconv.FormatInt(expires.Unix(), 10)))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
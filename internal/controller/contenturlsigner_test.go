@@ -0,0 +1,193 @@
+//go:build unit
+
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alexandremahdhaoui/shaper/internal/controller"
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockadapter"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentURLSigner(t *testing.T) {
+	var (
+		ctx context.Context
+
+		inputContentID uuid.UUID
+		inputSelectors types.IPXESelectors
+
+		keys        types.SigningKeys
+		signingKeys *mockadapter.MockSigningKeys
+		signer      controller.ContentURLSigner
+	)
+
+	setup := func(t *testing.T) func() {
+		t.Helper()
+
+		ctx = context.Background()
+
+		inputContentID = uuid.New()
+		inputSelectors = types.IPXESelectors{UUID: uuid.New(), Buildarch: "x86_64"}
+
+		keys = types.SigningKeys{
+			CurrentID: "2024-02",
+			Keys: map[string][]byte{
+				"2024-01": []byte("previous-key"),
+				"2024-02": []byte("current-key"),
+			},
+		}
+
+		signingKeys = mockadapter.NewMockSigningKeys(t)
+		signingKeys.EXPECT().Get(ctx).RunAndReturn(func(context.Context) (types.SigningKeys, error) {
+			return keys, nil
+		}).Maybe()
+
+		signer = controller.NewContentURLSigner(signingKeys, time.Hour)
+
+		return func() {
+			t.Helper()
+
+			signingKeys.AssertExpectations(t)
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		defer setup(t)()
+
+		signature, err := signer.Sign(ctx, inputContentID, inputSelectors)
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-02", signature.KeyID)
+		assert.NotEmpty(t, signature.Signature)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), signature.Expires, 2*time.Second)
+
+		assert.NoError(t, signer.Verify(ctx, inputContentID, inputSelectors, signature))
+	})
+
+	t.Run("Rotated key", func(t *testing.T) {
+		defer setup(t)()
+
+		signature, err := signer.Sign(ctx, inputContentID, inputSelectors)
+		assert.NoError(t, err)
+
+		// A new key signs the URLs while the previous one still verifies the URLs it signed.
+		keys.CurrentID = "2024-03"
+		keys.Keys["2024-03"] = []byte("next-key")
+
+		assert.NoError(t, signer.Verify(ctx, inputContentID, inputSelectors, signature))
+
+		rotated, err := signer.Sign(ctx, inputContentID, inputSelectors)
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-03", rotated.KeyID)
+		assert.NotEqual(t, signature.Signature, rotated.Signature)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		t.Run("Another machine", func(t *testing.T) {
+			defer setup(t)()
+
+			signature, err := signer.Sign(ctx, inputContentID, inputSelectors)
+			assert.NoError(t, err)
+
+			other := inputSelectors
+			other.UUID = uuid.New()
+
+			err = signer.Verify(ctx, inputContentID, other, signature)
+			assert.ErrorIs(t, err, controller.ErrContentURLSignatureInvalid)
+		})
+
+		t.Run("Another buildarch", func(t *testing.T) {
+			defer setup(t)()
+
+			signature, err := signer.Sign(ctx, inputContentID, inputSelectors)
+			assert.NoError(t, err)
+
+			other := inputSelectors
+			other.Buildarch = "arm64"
+
+			err = signer.Verify(ctx, inputContentID, other, signature)
+			assert.ErrorIs(t, err, controller.ErrContentURLSignatureInvalid)
+		})
+
+		t.Run("Another content", func(t *testing.T) {
+			defer setup(t)()
+
+			signature, err := signer.Sign(ctx, inputContentID, inputSelectors)
+			assert.NoError(t, err)
+
+			err = signer.Verify(ctx, uuid.New(), inputSelectors, signature)
+			assert.ErrorIs(t, err, controller.ErrContentURLSignatureInvalid)
+		})
+
+		t.Run("Extended expiry", func(t *testing.T) {
+			defer setup(t)()
+
+			signature, err := signer.Sign(ctx, inputContentID, inputSelectors)
+			assert.NoError(t, err)
+
+			signature.Expires = signature.Expires.Add(time.Hour)
+
+			err = signer.Verify(ctx, inputContentID, inputSelectors, signature)
+			assert.ErrorIs(t, err, controller.ErrContentURLSignatureInvalid)
+		})
+
+		t.Run("Removed key", func(t *testing.T) {
+			defer setup(t)()
+
+			signature, err := signer.Sign(ctx, inputContentID, inputSelectors)
+			assert.NoError(t, err)
+
+			delete(keys.Keys, signature.KeyID)
+
+			err = signer.Verify(ctx, inputContentID, inputSelectors, signature)
+			assert.ErrorIs(t, err, controller.ErrContentURLSignatureInvalid)
+		})
+
+		t.Run("Expired", func(t *testing.T) {
+			defer setup(t)()
+
+			signer = controller.NewContentURLSigner(signingKeys, -time.Minute)
+
+			signature, err := signer.Sign(ctx, inputContentID, inputSelectors)
+			assert.NoError(t, err)
+
+			err = signer.Verify(ctx, inputContentID, inputSelectors, signature)
+			assert.ErrorIs(t, err, controller.ErrContentURLExpired)
+			assert.NotErrorIs(t, err, controller.ErrContentURLSignatureInvalid)
+		})
+
+		t.Run("Signing keys error", func(t *testing.T) {
+			defer setup(t)()
+
+			signingKeys = mockadapter.NewMockSigningKeys(t)
+			signingKeys.EXPECT().Get(ctx).Return(types.SigningKeys{}, assert.AnError).Twice()
+			signer = controller.NewContentURLSigner(signingKeys, time.Hour)
+
+			_, err := signer.Sign(ctx, inputContentID, inputSelectors)
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.ErrorIs(t, err, controller.ErrContentURLSign)
+
+			err = signer.Verify(ctx, inputContentID, inputSelectors, types.ContentURLSignature{})
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.ErrorIs(t, err, controller.ErrContentURLVerify)
+		})
+	})
+}
@@ -20,16 +20,19 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"text/template"
+	"net/url"
 
 	"github.com/alexandremahdhaoui/shaper/internal/adapter"
 	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/internal/util/templateutil"
+	"github.com/google/uuid"
 )
 
 var (
 	ErrIPXEFindProfileAndRender = errors.New("finding and rendering ipxe profile")
 
 	errFallbackToDefaultAssignment = errors.New("fallback to default assignment")
+	errGettingMachine              = errors.New("getting machine")
 	errSelectingAssignment         = errors.New("selecting assignment")
 	errTemplatingIPXEProfile       = errors.New("templating ipxe profile")
 
@@ -48,16 +51,22 @@ type IPXE interface {
 
 // --------------------------------------------------- CONSTRUCTORS ------------------------------------------------- //
 
-// NewIPXE returns a new IPXE.
+// NewIPXE returns a new IPXE. The bootstrap script carries the bootstrap token, if any, to authenticate the machines.
 func NewIPXE(
 	assignment adapter.Assignment,
 	profile adapter.Profile,
+	machine adapter.Machine,
 	mux ResolveTransformerMux,
+	baseURL string,
+	bootstrapToken string,
 ) IPXE {
 	return &ipxe{
-		assignment: assignment,
-		profile:    profile,
-		mux:        mux,
+		assignment:     assignment,
+		profile:        profile,
+		machine:        machine,
+		mux:            mux,
+		baseURL:        baseURL,
+		bootstrapToken: bootstrapToken,
 	}
 }
 
@@ -66,8 +75,12 @@ func NewIPXE(
 type ipxe struct {
 	assignment adapter.Assignment
 	profile    adapter.Profile
+	machine    adapter.Machine
 	mux        ResolveTransformerMux
+	baseURL    string
 
+	// bootstrapToken is the token the bootstrap script authenticates the machines with, if any.
+	bootstrapToken  string
 	cachedBootstrap []byte
 }
 
@@ -77,32 +90,21 @@ func (i *ipxe) FindProfileAndRender(
 	ctx context.Context,
 	selectors types.IPXESelectors,
 ) ([]byte, error) {
-	assignment, err := i.assignment.FindBySelectors(ctx, selectors)
-	matchedBy := "uuid"
-	if errors.Is(err, adapter.ErrAssignmentNotFound) {
-		// fallback to default profile
-		defaultAssignment, defaultErr := i.assignment.FindDefaultByBuildarch(
-			ctx,
-			selectors.Buildarch,
-		)
-		if defaultErr != nil {
-			return nil, errors.Join(
-				defaultErr,
-				fmt.Errorf(
-					fmtCannotSelectAssignmentWithSelectors,
-					selectors.UUID,
-					selectors.Buildarch,
-				),
-				errFallbackToDefaultAssignment,
-				errSelectingAssignment,
-				ErrIPXEFindProfileAndRender,
-			)
+	// Labels of the Machine resource can be matched by machine selectors.
+	var m types.Machine
+	if selectors.UUID != uuid.Nil {
+		var err error
+		m, err = i.machine.Get(ctx, selectors.UUID)
+		if err != nil && !errors.Is(err, adapter.ErrMachineNotFound) {
+			return nil, errors.Join(err, errGettingMachine, ErrIPXEFindProfileAndRender)
 		}
 
-		assignment = defaultAssignment
-		matchedBy = "default"
-	} else if err != nil {
-		return nil, errors.Join(err, errSelectingAssignment, ErrIPXEFindProfileAndRender)
+		selectors.Labels = m.Labels
+	}
+
+	assignment, matchedBy, err := selectAssignment(ctx, i.assignment, selectors)
+	if err != nil {
+		return nil, errors.Join(err, ErrIPXEFindProfileAndRender)
 	}
 
 	// Log assignment selection
@@ -113,7 +115,28 @@ func (i *ipxe) FindProfileAndRender(
 		"matched_by", matchedBy,
 	)
 
-	p, err := i.profile.Get(ctx, assignment.ProfileName)
+	if err := checkClientAllowed(assignment, selectors.ClientIP); err != nil {
+		return nil, errors.Join(err, ErrIPXEFindProfileAndRender)
+	}
+
+	// Provisioned machines boot from their local disk unless they are requested to be provisioned again.
+	phase := types.UnknownMachinePhase
+	if assignment.BootMode == types.ProvisionOnceBootMode {
+		if m.Phase == types.ProvisionedMachinePhase && !m.Reprovision {
+			slog.InfoContext(ctx, "local_boot_selected",
+				"uuid", selectors.UUID,
+				"assignment", assignment.Name,
+			)
+
+			i.recordMachine(ctx, selectors, assignment, types.UnknownMachinePhase)
+
+			return localBootScript(selectors.Platform), nil
+		}
+
+		phase = types.ProvisioningMachinePhase
+	}
+
+	p, err := getAssignedProfile(ctx, i.profile, assignment)
 	if err != nil {
 		return nil, errors.Join(err, ErrIPXEFindProfileAndRender)
 	}
@@ -125,41 +148,159 @@ func (i *ipxe) FindProfileAndRender(
 		"assignment", assignment.Name,
 	)
 
-	data, err := i.mux.ResolveAndTransformBatch(
+	data := newTemplateData(selectors, assignment, p, resolveBaseURL(ctx, p, i.baseURL))
+
+	data.Params, err = ResolveParameters(ctx, i.mux, selectors, p.Parameters, assignment.Parameters)
+	if err != nil {
+		return nil, errors.Join(err, ErrIPXEFindProfileAndRender)
+	}
+
+	resolved, err := i.mux.ResolveAndTransformBatch(
 		ctx,
 		p.AdditionalContent,
 		selectors,
+		data,
 		ReturnExposedContentURL,
 	)
 	if err != nil {
 		return nil, errors.Join(err, ErrIPXEFindProfileAndRender)
 	}
 
-	out, err := templateIPXEProfile(p.IPXETemplate, data)
+	data.AdditionalContent = make(map[string]string, len(resolved))
+	for k, v := range resolved {
+		data.AdditionalContent[k] = string(v)
+	}
+
+	out, err := templateIPXEProfile(ctx, p.IPXETemplate, data)
 	if err != nil {
 		return nil, errors.Join(err, ErrIPXEFindProfileAndRender)
 	}
 
+	i.recordMachine(ctx, selectors, assignment, phase)
+
 	return out, nil
 }
 
-func templateIPXEProfile(ipxeTemplate string, data map[string][]byte) ([]byte, error) {
-	tpl, err := template.New("").Parse(ipxeTemplate)
-	if err != nil {
-		return nil, errors.Join(err, errTemplatingIPXEProfile)
+// selectAssignment selects the assignment matching the selectors, falling back to the default assignment of the
+// buildarch. It also returns how the assignment was matched: a subject prefix, adapter.MatchedByMachineSelector or
+// "default".
+func selectAssignment(
+	ctx context.Context,
+	a adapter.Assignment,
+	selectors types.IPXESelectors,
+) (types.Assignment, string, error) {
+	assignment, matchedBy, err := a.FindBySelectors(ctx, selectors)
+	if errors.Is(err, adapter.ErrAssignmentNotFound) {
+		// fallback to default profile
+		defaultAssignment, defaultErr := a.FindDefaultByBuildarch(
+			ctx,
+			selectors.Buildarch,
+		)
+		if defaultErr != nil {
+			return types.Assignment{}, "", errors.Join(
+				defaultErr,
+				fmt.Errorf(
+					fmtCannotSelectAssignmentWithSelectors,
+					selectors.UUID,
+					selectors.Buildarch,
+				),
+				errFallbackToDefaultAssignment,
+				errSelectingAssignment,
+			)
+		}
+
+		return defaultAssignment, "default", nil
+	} else if err != nil {
+		return types.Assignment{}, "", errors.Join(err, errSelectingAssignment)
+	}
+
+	return assignment, matchedBy, nil
+}
+
+// getAssignedProfile gets the profile referenced by the assignment: a Profile of the namespace of the assignment or a
+// ClusterProfile. An assignment never references a Profile of another namespace.
+func getAssignedProfile(ctx context.Context, p adapter.Profile, assignment types.Assignment) (types.Profile, error) {
+	if assignment.ProfileKind == types.ClusterProfileKind {
+		return p.GetClusterProfile(ctx, assignment.ProfileName) // TODO: wrap err
 	}
 
-	stringData := make(map[string]string)
-	for k, v := range data {
-		stringData[k] = string(v)
+	return p.GetInNamespace(ctx, assignment.ProfileName, assignment.Namespace) // TODO: wrap err
+}
+
+// references returns true if the assignment references the profile.
+func references(assignment types.Assignment, p types.Profile) bool {
+	if assignment.ProfileKind == types.ClusterProfileKind {
+		return p.Namespace == "" && p.Name == assignment.ProfileName
 	}
 
-	buf := bytes.NewBuffer(make([]byte, 0))
-	if err := tpl.Execute(buf, stringData); err != nil {
+	return p.Namespace == assignment.Namespace && p.Name == assignment.ProfileName
+}
+
+// newTemplateData returns the data available to the templates of a profile rendered for a machine.
+func newTemplateData(
+	selectors types.IPXESelectors,
+	assignment types.Assignment,
+	p types.Profile,
+	baseURL string,
+) types.TemplateData {
+	return types.TemplateData{
+		Machine: selectors,
+		Assignment: types.AssignmentMetadata{
+			Name:      assignment.Name,
+			Namespace: assignment.Namespace,
+			Labels:    assignment.Labels,
+		},
+		Profile: types.ProfileMetadata{
+			Name:      p.Name,
+			Namespace: p.Namespace,
+		},
+		BaseURL: baseURL,
+	}
+}
+
+// recordMachine upserts the Machine resource of the booting machine. The phase is left untouched if unknown. Failing to
+// record a machine must not prevent it from booting, hence errors are only logged.
+func (i *ipxe) recordMachine(
+	ctx context.Context,
+	selectors types.IPXESelectors,
+	assignment types.Assignment,
+	phase types.MachinePhase,
+) {
+	if selectors.UUID == uuid.Nil {
+		return
+	}
+
+	if err := i.machine.Upsert(ctx, types.Machine{
+		Selectors:           selectors,
+		AssignmentName:      assignment.Name,
+		AssignmentNamespace: assignment.Namespace,
+		ProfileName:         assignment.ProfileName,
+		Phase:               phase,
+	}); err != nil {
+		slog.WarnContext(ctx, "failed to record machine",
+			"uuid", selectors.UUID,
+			"error", err.Error(),
+		)
+	}
+}
+
+// localBootScript returns an iPXE script booting the machine from its first local disk. EFI firmwares boot the next
+// entry of their boot order when iPXE exits.
+func localBootScript(platform string) []byte {
+	if platform == "efi" {
+		return []byte("#!ipxe\nexit\n")
+	}
+
+	return []byte("#!ipxe\nsanboot --no-describe --drive 0x80 || exit\n")
+}
+
+func templateIPXEProfile(ctx context.Context, ipxeTemplate string, data types.TemplateData) ([]byte, error) {
+	out, err := templateutil.Render(ctx, "ipxeTemplate", ipxeTemplate, data)
+	if err != nil {
 		return nil, errors.Join(err, errTemplatingIPXEProfile)
 	}
 
-	return buf.Bytes(), nil
+	return out, nil
 }
 
 // -------------------------------------------------------- Bootstrap ----------------------------------------------- //
@@ -177,12 +318,21 @@ func (i *ipxe) Boostrap() []byte {
 			params = fmt.Sprintf("%s&", params)
 		}
 
+		setting := param
+		if scope, ok := paramScopes[param]; ok {
+			setting = fmt.Sprintf("%s/%s", scope, param)
+		}
+
 		if paramType == none {
-			params = fmt.Sprintf("%s%s=${%s}", params, param, param)
+			params = fmt.Sprintf("%s%s=${%s}", params, param, setting)
 			continue
 		}
 
-		params = fmt.Sprintf("%s%s=${%s:%s}", params, param, param, paramType)
+		params = fmt.Sprintf("%s%s=${%s:%s}", params, param, setting, paramType)
+	}
+
+	if i.bootstrapToken != "" {
+		params = fmt.Sprintf("%s&token=%s", params, url.QueryEscape(i.bootstrapToken))
 	}
 
 	i.cachedBootstrap = []byte(fmt.Sprintf(ipxeBootstrapFormat, params))
@@ -190,16 +340,18 @@ func (i *ipxe) Boostrap() []byte {
 	return bytes.Clone(i.cachedBootstrap)
 }
 
-// TODO: mac should be `NETWORK_IFACE/mac`.
-
 const (
 	// #!ipxe
-	// chain ipxe?uuid=${uuid}&mac=${mac:hexhyp}&domain=${domain}&hostname=${hostname}&serial=${serial}&arch=${buildarch:uristring}
+	// chain ipxe?uuid=${uuid}&buildarch=${buildarch:uristring}&mac=${netX/mac:hexhyp}&serial=${serial:uristring}...
 	ipxeBootstrapFormat = `#!ipxe
 chain ipxe?%s
 `
 	none      ipxeParamType = ""
 	uriString ipxeParamType = "uristring"
+	hexHyp    ipxeParamType = "hexhyp"
+
+	// netX is the iPXE alias of the most recently opened network device, i.e. the one the machine is booting from.
+	netX = "netX"
 )
 
 type ipxeParamType string
@@ -209,10 +361,22 @@ var (
 	orderedAllowedParamKeys = []string{
 		types.Uuid,
 		types.Buildarch,
+		types.Mac,
+		types.Serial,
+		types.Hostname,
+		types.Asset,
+		types.Product,
+		types.Manufacturer,
+		types.Platform,
+	}
+
+	// paramScopes defines the settings block a parameter must be read from, if any.
+	paramScopes = map[string]string{
+		types.Mac: netX,
 	}
 
 	allowedParamsWithType = map[string]ipxeParamType{
-		// types.Mac,
+		types.Mac: hexHyp,
 		// types.BusType,
 		// types.BusLoc,
 		// types.BusID,
@@ -241,13 +405,13 @@ var (
 
 		// Host settings
 
-		// types.Hostname,
-		types.Uuid: none,
+		types.Hostname: uriString,
+		types.Uuid:     none,
 		// types.UserClass,
-		// types.Manufacturer,
-		// types.Product,
-		// types.Serial,
-		// types.Asset,
+		types.Manufacturer: uriString,
+		types.Product:      uriString,
+		types.Serial:       uriString,
+		types.Asset:        uriString,
 
 		// Authentication settings
 
@@ -271,7 +435,7 @@ var (
 		// types.DhcpServer,
 		// types.Keymap,
 		// types.Memsize,
-		// types.Platform,
+		types.Platform: uriString,
 		// types.Priority,
 		// types.Scriptlet,
 		// types.Syslog,
@@ -19,6 +19,7 @@ package controller_test
 import (
 	"context"
 	"fmt"
+	"net/netip"
 	"testing"
 
 	"k8s.io/utils/ptr"
@@ -42,6 +43,7 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 
 		assignment *mockadapter.MockAssignment
 		profile    *mockadapter.MockProfile
+		machine    *mockadapter.MockMachine
 		mux        *mockcontroller.MockResolveTransformerMux
 
 		ipxe controller.IPXE
@@ -55,23 +57,49 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 
 		assignment = mockadapter.NewMockAssignment(t)
 		profile = mockadapter.NewMockProfile(t)
+		machine = mockadapter.NewMockMachine(t)
 		mux = mockcontroller.NewMockResolveTransformerMux(t)
 
-		ipxe = controller.NewIPXE(assignment, profile, mux)
+		ipxe = controller.NewIPXE(assignment, profile, machine, mux, "https://shaper.example.com", "")
 
 		return func() {
 			t.Helper()
 
 			assignment.AssertExpectations(t)
 			profile.AssertExpectations(t)
+			machine.AssertExpectations(t)
 			mux.AssertExpectations(t)
 		}
 	}
 
+	unknownMachine := func(t *testing.T) {
+		t.Helper()
+
+		machine.EXPECT().
+			Get(ctx, inputSelectors.UUID).
+			Return(types.Machine{}, adapter.ErrMachineNotFound).
+			Once()
+	}
+
+	recordMachine := func(t *testing.T, assignment types.Assignment) {
+		t.Helper()
+
+		machine.EXPECT().
+			Upsert(ctx, types.Machine{
+				Selectors:           inputSelectors,
+				AssignmentName:      assignment.Name,
+				AssignmentNamespace: assignment.Namespace,
+				ProfileName:         assignment.ProfileName,
+			}).
+			Return(nil).
+			Once()
+	}
+
 	t.Run("Success", func(t *testing.T) {
 		t.Run("FindBySelectors", func(t *testing.T) {
 			t.Run("No additional content", func(t *testing.T) {
 				defer setup(t)()
+				unknownMachine(t)
 
 				expected := []byte("expected")
 				expectedProfileName := "expected-profile-name"
@@ -85,11 +113,11 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 
 				assignment.EXPECT().
 					FindBySelectors(ctx, inputSelectors).
-					Return(expectedAssignment, nil).
+					Return(expectedAssignment, "uuid", nil).
 					Once()
 
 				profile.EXPECT().
-					Get(ctx, expectedProfileName).
+					GetInNamespace(ctx, expectedProfileName, expectedAssignment.Namespace).
 					Return(expectedProfile, nil).
 					Once()
 
@@ -98,11 +126,14 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 						ctx,
 						expectedProfile.AdditionalContent,
 						inputSelectors,
+						mock.AnythingOfType("types.TemplateData"),
 						mock.AnythingOfType("controller.ResolveTransformBatchOption"), // -> controller.ReturnExposedContentURL
 					).
 					Return(expectedResolvedAndTransformedContent, nil).
 					Once()
 
+				recordMachine(t, expectedAssignment)
+
 				actual, err := ipxe.FindProfileAndRender(ctx, inputSelectors)
 				assert.NoError(t, err)
 				assert.Equal(t, expected, actual)
@@ -124,6 +155,7 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 				} {
 					t.Run(fmt.Sprintf("%s exposed config", tt.Name), func(t *testing.T) {
 						defer setup(t)()
+						unknownMachine(t)
 
 						expected := []byte("kernel")
 						expectedProfileName := "expected-profile-name"
@@ -160,7 +192,7 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 							}
 
 							expectedProfile.IPXETemplate = fmt.Sprintf(
-								"%s --additional-config-url {{ .%s }}",
+								"%s --additional-config-url {{ .AdditionalContent.%s }}",
 								expectedProfile.IPXETemplate,
 								name,
 							)
@@ -180,11 +212,11 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 
 						assignment.EXPECT().
 							FindBySelectors(ctx, inputSelectors).
-							Return(expectedAssignment, nil).
+							Return(expectedAssignment, "uuid", nil).
 							Once()
 
 						profile.EXPECT().
-							Get(ctx, expectedProfileName).
+							GetInNamespace(ctx, expectedProfileName, expectedAssignment.Namespace).
 							Return(expectedProfile, nil).
 							Once()
 
@@ -193,11 +225,14 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 								ctx,
 								expectedProfile.AdditionalContent,
 								inputSelectors,
+								mock.AnythingOfType("types.TemplateData"),
 								mock.AnythingOfType("controller.ResolveTransformBatchOption"), // -> controller.ReturnExposedContentURL
 							).
 							Return(expectedResolvedAndTransformedContent, nil).
 							Once()
 
+						recordMachine(t, expectedAssignment)
+
 						actual, err := ipxe.FindProfileAndRender(ctx, inputSelectors)
 						assert.NoError(t, err)
 						assert.Equal(t, expected, actual)
@@ -206,12 +241,340 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 			})
 		})
 
+		t.Run("Template data", func(t *testing.T) {
+			defer setup(t)()
+			unknownMachine(t)
+
+			expectedProfile := types.Profile{
+				Name:      "a-profile",
+				Namespace: "shaper",
+				IPXETemplate: "#!ipxe\n" +
+					"echo {{ .Machine.UUID }} {{ .Machine.Buildarch }}\n" +
+					"echo {{ .Assignment.Name }} {{ .Assignment.Labels.site }} {{ .Profile.Name }}\n" +
+					"chain {{ .BaseURL }}/{{ .AdditionalContent.config }}",
+				AdditionalContent: map[string]types.Content{"config": {Name: "config"}},
+			}
+
+			expectedAssignment := types.Assignment{
+				Name:        "an-assignment",
+				Namespace:   "shaper",
+				Labels:      map[string]string{"site": "dc1"},
+				ProfileName: expectedProfile.Name,
+			}
+
+			expectedData := types.TemplateData{
+				Machine: inputSelectors,
+				Assignment: types.AssignmentMetadata{
+					Name:      expectedAssignment.Name,
+					Namespace: expectedAssignment.Namespace,
+					Labels:    expectedAssignment.Labels,
+				},
+				Profile: types.ProfileMetadata{Name: expectedProfile.Name, Namespace: expectedProfile.Namespace},
+				BaseURL: "https://shaper.example.com",
+			}
+
+			assignment.EXPECT().
+				FindBySelectors(ctx, inputSelectors).
+				Return(expectedAssignment, "uuid", nil).
+				Once()
+
+			profile.EXPECT().
+				GetInNamespace(ctx, expectedProfile.Name, expectedAssignment.Namespace).
+				Return(expectedProfile, nil).
+				Once()
+
+			mux.EXPECT().
+				ResolveAndTransformBatch(
+					ctx,
+					expectedProfile.AdditionalContent,
+					inputSelectors,
+					expectedData,
+					mock.AnythingOfType("controller.ResolveTransformBatchOption"),
+				).
+				Return(map[string][]byte{"config": []byte("content/config")}, nil).
+				Once()
+
+			recordMachine(t, expectedAssignment)
+
+			expected := fmt.Sprintf("#!ipxe\n"+
+				"echo %s arm64\n"+
+				"echo an-assignment dc1 a-profile\n"+
+				"chain https://shaper.example.com/content/config", inputSelectors.UUID)
+
+			actual, err := ipxe.FindProfileAndRender(ctx, inputSelectors)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, string(actual))
+		})
+
+		t.Run("BaseURL", func(t *testing.T) {
+			for name, tt := range map[string]struct {
+				profileBaseURL string
+				expected       string
+			}{
+				"from the request": {expected: "https://boot.example.com"},
+				"from the profile": {profileBaseURL: "https://os.example.com/", expected: "https://os.example.com"},
+			} {
+				t.Run(name, func(t *testing.T) {
+					defer setup(t)()
+
+					ctx = controller.WithBaseURL(ctx, "https://boot.example.com")
+					unknownMachine(t)
+
+					expectedProfile := types.Profile{
+						Name:            "a-profile",
+						IPXETemplate:    "#!ipxe\nchain {{ .BaseURL }}",
+						ExternalBaseURL: tt.profileBaseURL,
+					}
+
+					expectedAssignment := types.Assignment{Name: "an-assignment", ProfileName: expectedProfile.Name}
+
+					assignment.EXPECT().FindBySelectors(ctx, inputSelectors).Return(expectedAssignment, "uuid", nil).Once()
+					profile.EXPECT().
+						GetInNamespace(ctx, expectedProfile.Name, expectedAssignment.Namespace).
+						Return(expectedProfile, nil).
+						Once()
+
+					// The exposed content URLs are rendered by the mux with the base URL of the template data.
+					mux.EXPECT().
+						ResolveAndTransformBatch(ctx, expectedProfile.AdditionalContent, inputSelectors,
+							mock.MatchedBy(func(data types.TemplateData) bool { return data.BaseURL == tt.expected }),
+							mock.AnythingOfType("controller.ResolveTransformBatchOption"),
+						).
+						Return(map[string][]byte{}, nil).
+						Once()
+
+					recordMachine(t, expectedAssignment)
+
+					actual, err := ipxe.FindProfileAndRender(ctx, inputSelectors)
+					assert.NoError(t, err)
+					assert.Equal(t, "#!ipxe\nchain "+tt.expected, string(actual))
+				})
+			}
+		})
+
+		t.Run("Params", func(t *testing.T) {
+			defer setup(t)()
+			unknownMachine(t)
+
+			expectedProfile := types.Profile{
+				Name:         "a-profile",
+				IPXETemplate: "#!ipxe\nchain http://example.com/{{ .Params.channel }}?ntp={{ .Params.ntp }}",
+				Parameters: map[string]types.Content{
+					"channel": {Name: "channel", Inline: "stable"},
+					"ntp":     {Name: "ntp", Inline: "pool.ntp.org"},
+				},
+			}
+
+			expectedAssignment := types.Assignment{
+				Name:        "an-assignment",
+				ProfileName: expectedProfile.Name,
+				Parameters:  map[string]types.Content{"channel": {Name: "channel", Inline: "beta"}},
+			}
+
+			assignment.EXPECT().
+				FindBySelectors(ctx, inputSelectors).
+				Return(expectedAssignment, "uuid", nil).
+				Once()
+
+			profile.EXPECT().
+				GetInNamespace(ctx, expectedProfile.Name, expectedAssignment.Namespace).
+				Return(expectedProfile, nil).
+				Once()
+
+			// The parameters of the assignment override the ones of the profile.
+			mux.EXPECT().
+				ResolveAndTransformBatch(
+					ctx,
+					map[string]types.Content{
+						"channel": {Name: "channel", Inline: "beta"},
+						"ntp":     {Name: "ntp", Inline: "pool.ntp.org"},
+					},
+					inputSelectors,
+					types.TemplateData{},
+					mock.AnythingOfType("controller.ResolveTransformBatchOption"),
+				).
+				Return(map[string][]byte{"channel": []byte("beta"), "ntp": []byte("pool.ntp.org")}, nil).
+				Once()
+
+			mux.EXPECT().
+				ResolveAndTransformBatch(
+					ctx,
+					expectedProfile.AdditionalContent,
+					inputSelectors,
+					mock.MatchedBy(func(data types.TemplateData) bool {
+						return data.Params["channel"] == "beta" && data.Params["ntp"] == "pool.ntp.org"
+					}),
+					mock.AnythingOfType("controller.ResolveTransformBatchOption"),
+				).
+				Return(map[string][]byte{}, nil).
+				Once()
+
+			recordMachine(t, expectedAssignment)
+
+			actual, err := ipxe.FindProfileAndRender(ctx, inputSelectors)
+			assert.NoError(t, err)
+			assert.Equal(t, "#!ipxe\nchain http://example.com/beta?ntp=pool.ntp.org", string(actual))
+		})
+
+		t.Run("Machine labels", func(t *testing.T) {
+			defer setup(t)()
+
+			expectedLabels := map[string]string{"rack": "a1"}
+			expectedSelectors := inputSelectors
+			expectedSelectors.Labels = expectedLabels
+
+			expectedProfile := types.Profile{IPXETemplate: "expected"}
+			expectedAssignment := types.Assignment{
+				Name:        "an-assignment",
+				ProfileName: "expected-profile-name",
+			}
+
+			machine.EXPECT().
+				Get(ctx, inputSelectors.UUID).
+				Return(types.Machine{Labels: expectedLabels}, nil).
+				Once()
+
+			assignment.EXPECT().
+				FindBySelectors(ctx, expectedSelectors).
+				Return(expectedAssignment, "uuid", nil).
+				Once()
+
+			profile.EXPECT().
+				GetInNamespace(ctx, expectedAssignment.ProfileName, expectedAssignment.Namespace).
+				Return(expectedProfile, nil).
+				Once()
+
+			mux.EXPECT().
+				ResolveAndTransformBatch(
+					ctx,
+					expectedProfile.AdditionalContent,
+					expectedSelectors,
+					mock.AnythingOfType("types.TemplateData"),
+					mock.AnythingOfType("controller.ResolveTransformBatchOption"),
+				).
+				Return(map[string][]byte{}, nil).
+				Once()
+
+			machine.EXPECT().
+				Upsert(ctx, types.Machine{
+					Selectors:           expectedSelectors,
+					AssignmentName:      expectedAssignment.Name,
+					AssignmentNamespace: expectedAssignment.Namespace,
+					ProfileName:         expectedAssignment.ProfileName,
+				}).
+				Return(assert.AnError). // failing to record a machine must not prevent it from booting.
+				Once()
+
+			actual, err := ipxe.FindProfileAndRender(ctx, inputSelectors)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("expected"), actual)
+		})
+
+		t.Run("ProvisionOnce", func(t *testing.T) {
+			expectedAssignment := types.Assignment{
+				Name:        "an-assignment",
+				ProfileName: "expected-profile-name",
+				BootMode:    types.ProvisionOnceBootMode,
+			}
+
+			for _, tt := range []struct {
+				Name          string
+				Machine       types.Machine
+				Platform      string
+				Expected      string
+				ExpectedPhase types.MachinePhase
+			}{
+				{
+					Name:          "First boot",
+					Expected:      "install",
+					ExpectedPhase: types.ProvisioningMachinePhase,
+				},
+				{
+					Name:          "Provisioning",
+					Machine:       types.Machine{Phase: types.ProvisioningMachinePhase},
+					Expected:      "install",
+					ExpectedPhase: types.ProvisioningMachinePhase,
+				},
+				{
+					Name:          "Provisioned",
+					Machine:       types.Machine{Phase: types.ProvisionedMachinePhase},
+					Expected:      "#!ipxe\nsanboot --no-describe --drive 0x80 || exit\n",
+					ExpectedPhase: types.UnknownMachinePhase,
+				},
+				{
+					Name:          "Provisioned EFI",
+					Machine:       types.Machine{Phase: types.ProvisionedMachinePhase},
+					Platform:      "efi",
+					Expected:      "#!ipxe\nexit\n",
+					ExpectedPhase: types.UnknownMachinePhase,
+				},
+				{
+					Name:          "Reprovision",
+					Machine:       types.Machine{Phase: types.ProvisionedMachinePhase, Reprovision: true},
+					Expected:      "install",
+					ExpectedPhase: types.ProvisioningMachinePhase,
+				},
+			} {
+				t.Run(tt.Name, func(t *testing.T) {
+					defer setup(t)()
+
+					inputSelectors.Platform = tt.Platform
+
+					machine.EXPECT().
+						Get(ctx, inputSelectors.UUID).
+						Return(tt.Machine, nil).
+						Once()
+
+					assignment.EXPECT().
+						FindBySelectors(ctx, inputSelectors).
+						Return(expectedAssignment, "uuid", nil).
+						Once()
+
+					if tt.ExpectedPhase == types.ProvisioningMachinePhase {
+						expectedProfile := types.Profile{IPXETemplate: "install"}
+
+						profile.EXPECT().
+							GetInNamespace(ctx, expectedAssignment.ProfileName, expectedAssignment.Namespace).
+							Return(expectedProfile, nil).
+							Once()
+
+						mux.EXPECT().
+							ResolveAndTransformBatch(
+								ctx,
+								expectedProfile.AdditionalContent,
+								inputSelectors,
+								mock.AnythingOfType("types.TemplateData"),
+								mock.AnythingOfType("controller.ResolveTransformBatchOption"),
+							).
+							Return(map[string][]byte{}, nil).
+							Once()
+					}
+
+					machine.EXPECT().
+						Upsert(ctx, types.Machine{
+							Selectors:           inputSelectors,
+							AssignmentName:      expectedAssignment.Name,
+							AssignmentNamespace: expectedAssignment.Namespace,
+							ProfileName:         expectedAssignment.ProfileName,
+							Phase:               tt.ExpectedPhase,
+						}).
+						Return(nil).
+						Once()
+
+					actual, err := ipxe.FindProfileAndRender(ctx, inputSelectors)
+					assert.NoError(t, err)
+					assert.Equal(t, tt.Expected, string(actual))
+				})
+			}
+		})
+
 		t.Run("FindDefaultByBuildarch", func(t *testing.T) {
 			defer setup(t)()
+			unknownMachine(t)
 
 			expectedDefaultProfileName := "default-profile-arm64"
 			expectedDefaultProfile := types.Profile{
-				IPXETemplate: "this is the default profile with {{ .mustBeReturned }}",
+				IPXETemplate: "this is the default profile with {{ .AdditionalContent.mustBeReturned }}",
 				AdditionalContent: map[string]types.Content{
 					mustBeReturned: {
 						Name: mustBeReturned,
@@ -236,7 +599,7 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 
 			assignment.EXPECT().
 				FindBySelectors(ctx, inputSelectors).
-				Return(types.Assignment{}, adapter.ErrAssignmentNotFound).
+				Return(types.Assignment{}, "", adapter.ErrAssignmentNotFound).
 				Once()
 
 			assignment.EXPECT().
@@ -245,7 +608,7 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 				Once()
 
 			profile.EXPECT().
-				Get(ctx, expectedDefaultProfileName).
+				GetInNamespace(ctx, expectedDefaultProfileName, expectedDefaultAssignment.Namespace).
 				Return(expectedDefaultProfile, nil).
 				Once()
 
@@ -254,11 +617,14 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 					ctx,
 					expectedDefaultProfile.AdditionalContent,
 					inputSelectors,
+					mock.AnythingOfType("types.TemplateData"),
 					mock.AnythingOfType("controller.ResolveTransformBatchOption"), // -> controller.ReturnExposedContentURL
 				).
 				Return(expectedResolvedAndTransformedAdditionalBatch, nil).
 				Once()
 
+			recordMachine(t, expectedDefaultAssignment)
+
 			actual, err := ipxe.FindProfileAndRender(ctx, inputSelectors)
 			assert.NoError(t, err)
 			assert.Equal(t, expected, actual)
@@ -268,12 +634,13 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 	t.Run("Failure", func(t *testing.T) {
 		t.Run("FindBySelectors fails and FindDefaultByBuildarch also fails", func(t *testing.T) {
 			defer setup(t)()
+			unknownMachine(t)
 
 			expectedError := assert.AnError
 
 			assignment.EXPECT().
 				FindBySelectors(ctx, inputSelectors).
-				Return(types.Assignment{}, adapter.ErrAssignmentNotFound).
+				Return(types.Assignment{}, "", adapter.ErrAssignmentNotFound).
 				Once()
 
 			assignment.EXPECT().
@@ -290,12 +657,50 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 
 		t.Run("FindBySelectors fails with non-ErrAssignmentNotFound error", func(t *testing.T) {
 			defer setup(t)()
+			unknownMachine(t)
 
 			expectedError := assert.AnError
 
 			assignment.EXPECT().
 				FindBySelectors(ctx, inputSelectors).
-				Return(types.Assignment{}, expectedError).
+				Return(types.Assignment{}, "", expectedError).
+				Once()
+
+			actual, err := ipxe.FindProfileAndRender(ctx, inputSelectors)
+			assert.Error(t, err)
+			assert.ErrorIs(t, err, expectedError)
+			assert.ErrorIs(t, err, controller.ErrIPXEFindProfileAndRender)
+			assert.Nil(t, actual)
+		})
+
+		t.Run("Client not allowed", func(t *testing.T) {
+			defer setup(t)()
+			unknownMachine(t)
+
+			inputSelectors.ClientIP = "10.0.2.42"
+
+			assignment.EXPECT().
+				FindBySelectors(ctx, inputSelectors).
+				Return(types.Assignment{
+					Name:         "an-assignment",
+					AllowedCIDRs: []netip.Prefix{netip.MustParsePrefix("10.0.1.0/24")},
+				}, "uuid", nil).
+				Once()
+
+			actual, err := ipxe.FindProfileAndRender(ctx, inputSelectors)
+			assert.ErrorIs(t, err, controller.ErrClientNotAllowed)
+			assert.ErrorIs(t, err, controller.ErrIPXEFindProfileAndRender)
+			assert.Nil(t, actual)
+		})
+
+		t.Run("Machine.Get fails", func(t *testing.T) {
+			defer setup(t)()
+
+			expectedError := assert.AnError
+
+			machine.EXPECT().
+				Get(ctx, inputSelectors.UUID).
+				Return(types.Machine{}, expectedError).
 				Once()
 
 			actual, err := ipxe.FindProfileAndRender(ctx, inputSelectors)
@@ -307,6 +712,7 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 
 		t.Run("Profile.Get fails", func(t *testing.T) {
 			defer setup(t)()
+			unknownMachine(t)
 
 			expectedProfileName := "test-profile"
 			expectedError := assert.AnError
@@ -318,11 +724,11 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 
 			assignment.EXPECT().
 				FindBySelectors(ctx, inputSelectors).
-				Return(expectedAssignment, nil).
+				Return(expectedAssignment, "uuid", nil).
 				Once()
 
 			profile.EXPECT().
-				Get(ctx, expectedProfileName).
+				GetInNamespace(ctx, expectedProfileName, expectedAssignment.Namespace).
 				Return(types.Profile{}, expectedError).
 				Once()
 
@@ -333,8 +739,49 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 			assert.Nil(t, actual)
 		})
 
+		t.Run("ResolveParameters fails", func(t *testing.T) {
+			defer setup(t)()
+			unknownMachine(t)
+
+			expectedError := assert.AnError
+
+			expectedAssignment := types.Assignment{
+				Name:        "test-assignment",
+				ProfileName: "test-profile",
+				Parameters:  map[string]types.Content{"token": {Name: "token", ResolverKind: types.ObjectRefResolverKind}},
+			}
+
+			assignment.EXPECT().
+				FindBySelectors(ctx, inputSelectors).
+				Return(expectedAssignment, "uuid", nil).
+				Once()
+
+			profile.EXPECT().
+				GetInNamespace(ctx, expectedAssignment.ProfileName, expectedAssignment.Namespace).
+				Return(types.Profile{IPXETemplate: "#!ipxe"}, nil).
+				Once()
+
+			mux.EXPECT().
+				ResolveAndTransformBatch(
+					ctx,
+					expectedAssignment.Parameters,
+					inputSelectors,
+					types.TemplateData{},
+					mock.AnythingOfType("controller.ResolveTransformBatchOption"),
+				).
+				Return(nil, expectedError).
+				Once()
+
+			actual, err := ipxe.FindProfileAndRender(ctx, inputSelectors)
+			assert.ErrorIs(t, err, expectedError)
+			assert.ErrorIs(t, err, controller.ErrResolveParameters)
+			assert.ErrorIs(t, err, controller.ErrIPXEFindProfileAndRender)
+			assert.Nil(t, actual)
+		})
+
 		t.Run("ResolveAndTransformBatch fails", func(t *testing.T) {
 			defer setup(t)()
+			unknownMachine(t)
 
 			expectedProfileName := "test-profile"
 			expectedError := assert.AnError
@@ -350,11 +797,11 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 
 			assignment.EXPECT().
 				FindBySelectors(ctx, inputSelectors).
-				Return(expectedAssignment, nil).
+				Return(expectedAssignment, "uuid", nil).
 				Once()
 
 			profile.EXPECT().
-				Get(ctx, expectedProfileName).
+				GetInNamespace(ctx, expectedProfileName, expectedAssignment.Namespace).
 				Return(expectedProfile, nil).
 				Once()
 
@@ -363,6 +810,7 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 					ctx,
 					expectedProfile.AdditionalContent,
 					inputSelectors,
+					mock.AnythingOfType("types.TemplateData"),
 					mock.AnythingOfType("controller.ResolveTransformBatchOption"),
 				).
 				Return(nil, expectedError).
@@ -377,6 +825,7 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 
 		t.Run("Template parsing fails", func(t *testing.T) {
 			defer setup(t)()
+			unknownMachine(t)
 
 			expectedProfileName := "test-profile"
 			expectedResolvedContent := make(map[string][]byte)
@@ -393,11 +842,11 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 
 			assignment.EXPECT().
 				FindBySelectors(ctx, inputSelectors).
-				Return(expectedAssignment, nil).
+				Return(expectedAssignment, "uuid", nil).
 				Once()
 
 			profile.EXPECT().
-				Get(ctx, expectedProfileName).
+				GetInNamespace(ctx, expectedProfileName, expectedAssignment.Namespace).
 				Return(expectedProfile, nil).
 				Once()
 
@@ -406,6 +855,7 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 					ctx,
 					expectedProfile.AdditionalContent,
 					inputSelectors,
+					mock.AnythingOfType("types.TemplateData"),
 					mock.AnythingOfType("controller.ResolveTransformBatchOption"),
 				).
 				Return(expectedResolvedContent, nil).
@@ -421,8 +871,20 @@ func TestIPXE_FindProfileAndRender(t *testing.T) {
 }
 
 func TestIpxe_Bootstrap(t *testing.T) {
-	expected := "#!ipxe\nchain ipxe?uuid=${uuid}&buildarch=${buildarch:uristring}\n"
-	actual := controller.NewIPXE(nil, nil, nil).Boostrap()
+	expected := "#!ipxe\nchain ipxe?uuid=${uuid}&buildarch=${buildarch:uristring}&mac=${netX/mac:hexhyp}" +
+		"&serial=${serial:uristring}&hostname=${hostname:uristring}&asset=${asset:uristring}" +
+		"&product=${product:uristring}&manufacturer=${manufacturer:uristring}&platform=${platform:uristring}\n"
+	actual := controller.NewIPXE(nil, nil, nil, nil, "", "").Boostrap()
+
+	assert.Equal(t, expected, string(actual))
+}
+
+func TestIpxe_Bootstrap_Token(t *testing.T) {
+	expected := "#!ipxe\nchain ipxe?uuid=${uuid}&buildarch=${buildarch:uristring}&mac=${netX/mac:hexhyp}" +
+		"&serial=${serial:uristring}&hostname=${hostname:uristring}&asset=${asset:uristring}" +
+		"&product=${product:uristring}&manufacturer=${manufacturer:uristring}&platform=${platform:uristring}" +
+		"&token=s3cr%24t%2Ftoken\n"
+	actual := controller.NewIPXE(nil, nil, nil, nil, "", "s3cr$t/token").Boostrap()
 
 	assert.Equal(t, expected, string(actual))
 }
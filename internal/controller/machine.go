@@ -0,0 +1,87 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/alexandremahdhaoui/shaper/internal/adapter"
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMachineNotFound    = errors.New("machine cannot be found")
+	ErrMachineRecordEvent = errors.New("recording machine event")
+)
+
+// ---------------------------------------------------- INTERFACE --------------------------------------------------- //
+
+// Machine is an interface for handling the events reported by machines.
+type Machine interface {
+	// RecordEvent records a provisioning event reported by a machine.
+	RecordEvent(ctx context.Context, id uuid.UUID, event types.MachineEvent) error
+}
+
+// --------------------------------------------------- CONSTRUCTORS ------------------------------------------------- //
+
+// NewMachine returns a new Machine.
+func NewMachine(m adapter.Machine) Machine {
+	return &machine{
+		machine: m,
+	}
+}
+
+// ---------------------------------------------------- MACHINE ----------------------------------------------------- //
+
+type machine struct {
+	machine adapter.Machine
+}
+
+func (m *machine) RecordEvent(ctx context.Context, id uuid.UUID, event types.MachineEvent) error {
+	if id == uuid.Nil {
+		return errors.Join(errUUIDCannotBeNil, ErrMachineRecordEvent)
+	}
+
+	if err := m.machine.RecordEvent(ctx, id, event); errors.Is(err, adapter.ErrMachineNotFound) {
+		return errors.Join(err, ErrMachineNotFound, ErrMachineRecordEvent)
+	} else if err != nil {
+		return errors.Join(err, ErrMachineRecordEvent)
+	}
+
+	// Log machine event
+	slog.InfoContext(ctx, "machine_event",
+		"uuid", id.String(),
+		"event_type", machineEventTypeString(event.Type),
+		"message", event.Message,
+	)
+
+	return nil
+}
+
+func machineEventTypeString(eventType types.MachineEventType) string {
+	switch eventType {
+	case types.InstallStartedMachineEvent:
+		return "install-started"
+	case types.InstallSucceededMachineEvent:
+		return "install-succeeded"
+	case types.InstallFailedMachineEvent:
+		return "install-failed"
+	default:
+		return "unknown"
+	}
+}
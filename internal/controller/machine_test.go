@@ -0,0 +1,110 @@
+//go:build unit
+
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alexandremahdhaoui/shaper/internal/adapter"
+	"github.com/alexandremahdhaoui/shaper/internal/controller"
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockadapter"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMachine(t *testing.T) {
+	var (
+		ctx context.Context
+
+		inputUUID  uuid.UUID
+		inputEvent types.MachineEvent
+
+		machineAdapter *mockadapter.MockMachine
+		machine        controller.Machine
+	)
+
+	setup := func(t *testing.T) func() {
+		t.Helper()
+
+		ctx = context.Background()
+
+		inputUUID = uuid.New()
+		inputEvent = types.MachineEvent{
+			Type:    types.InstallFailedMachineEvent,
+			Message: "disk not found",
+		}
+
+		machineAdapter = mockadapter.NewMockMachine(t)
+		machine = controller.NewMachine(machineAdapter)
+
+		return func() {
+			t.Helper()
+
+			machineAdapter.AssertExpectations(t)
+		}
+	}
+
+	t.Run("RecordEvent", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			defer setup(t)()
+
+			machineAdapter.EXPECT().
+				RecordEvent(ctx, inputUUID, inputEvent).
+				Return(nil).
+				Once()
+
+			assert.NoError(t, machine.RecordEvent(ctx, inputUUID, inputEvent))
+		})
+
+		t.Run("Failure", func(t *testing.T) {
+			t.Run("Nil UUID", func(t *testing.T) {
+				defer setup(t)()
+
+				err := machine.RecordEvent(ctx, uuid.Nil, inputEvent)
+				assert.ErrorIs(t, err, controller.ErrMachineRecordEvent)
+			})
+
+			t.Run("Machine not found", func(t *testing.T) {
+				defer setup(t)()
+
+				machineAdapter.EXPECT().
+					RecordEvent(ctx, inputUUID, inputEvent).
+					Return(adapter.ErrMachineNotFound).
+					Once()
+
+				err := machine.RecordEvent(ctx, inputUUID, inputEvent)
+				assert.ErrorIs(t, err, controller.ErrMachineNotFound)
+				assert.ErrorIs(t, err, controller.ErrMachineRecordEvent)
+			})
+
+			t.Run("Adapter error", func(t *testing.T) {
+				defer setup(t)()
+
+				machineAdapter.EXPECT().
+					RecordEvent(ctx, inputUUID, inputEvent).
+					Return(assert.AnError).
+					Once()
+
+				err := machine.RecordEvent(ctx, inputUUID, inputEvent)
+				assert.ErrorIs(t, err, assert.AnError)
+				assert.NotErrorIs(t, err, controller.ErrMachineNotFound)
+			})
+		})
+	})
+}
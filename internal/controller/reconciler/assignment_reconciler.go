@@ -15,31 +15,63 @@
 package reconciler
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/alexandremahdhaoui/shaper/internal/adapter"
+	"github.com/alexandremahdhaoui/shaper/internal/types"
 	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+var (
+	errAssignmentStatus             = errors.New("failed to reconcile assignment status")
+	errAssignmentStatusPatch        = errors.New("failed to update assignment status")
+	errAssignmentProfileConditions  = errors.New("failed to compute assignment profile conditions")
+	errAssignmentAmbiguousCondition = errors.New("failed to compute assignment ambiguous condition")
+	errAssignmentTiedAssignments    = errors.New("failed to find tied assignments")
+	errAssignmentServedMachines     = errors.New("failed to find last served machines")
+)
+
 // AssignmentReconciler reconciles Assignment objects
 type AssignmentReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	Log    logr.Logger
+
+	// Resolvers are used to verify the content of the referenced profile resolves. Content of kinds without a
+	// resolver is not verified.
+	Resolvers map[types.ResolverKind]adapter.Resolver
+
+	// MachineNamespace is the namespace the Machine resources are recorded in by shaper-api. Machines are listed in
+	// every namespace when empty.
+	MachineNamespace string
 }
 
 // Verify AssignmentReconciler implements reconcile.Reconciler
 var _ reconcile.Reconciler = &AssignmentReconciler{}
 
 // Reconcile implements the reconciliation loop for Assignment resources
-// It adds labels for UUID and buildarch from spec.subjectSelectors
+// It adds labels for buildarch and every subject selector (UUID, MAC, serial...) from spec.subjectSelectors, and marks
+// assignments specifying a machine selector
+// It then reports in the status whether the referenced profile exists, whether its content resolves, whether it ties
+// with other assignments and which machines were last served through the assignment
 func (r *AssignmentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("assignment", req.NamespacedName)
 
@@ -94,6 +126,26 @@ func (r *AssignmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
+	// Add other subject labels, e.g. MAC addresses or serial numbers
+	for prefix, values := range assignment.Spec.SubjectSelectors.ByPrefix() {
+		if prefix == v1alpha1.UUIDPrefix {
+			// UUIDs are handled above
+			continue
+		}
+
+		for _, value := range values {
+			labelKey := v1alpha1.NewSubjectLabelSelector(prefix, value)
+			if _, exists := assignment.Labels[labelKey]; !exists {
+				assignment.Labels[labelKey] = ""
+				needsUpdate = true
+				log.Info("Added subject label",
+					"prefix", prefix,
+					"value", value,
+					"labelKey", labelKey)
+			}
+		}
+	}
+
 	// Add default assignment label if isDefault is true
 	if assignment.Spec.IsDefault {
 		labelKey := v1alpha1.DefaultAssignmentLabel
@@ -105,6 +157,17 @@ func (r *AssignmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
+	// Add machine selector label if a machine selector is specified
+	if assignment.Spec.MachineSelector != nil {
+		labelKey := v1alpha1.MachineSelectorAssignmentLabel
+		if _, exists := assignment.Labels[labelKey]; !exists {
+			assignment.Labels[labelKey] = ""
+			needsUpdate = true
+			log.Info("Added machine selector label",
+				"labelKey", labelKey)
+		}
+	}
+
 	// Update if labels were added (idempotent)
 	if needsUpdate {
 		if err := r.Update(ctx, &assignment); err != nil {
@@ -116,5 +179,401 @@ func (r *AssignmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		log.V(1).Info("No label update needed")
 	}
 
+	if err := r.updateStatus(ctx, log, &assignment); err != nil {
+		return ctrl.Result{}, errors.Join(err, errAssignmentStatus)
+	}
+
 	return ctrl.Result{}, nil
 }
+
+// updateStatus sets the conditions and the last served machines of the assignment. The status is only patched when it
+// changes.
+func (r *AssignmentReconciler) updateStatus(
+	ctx context.Context,
+	log logr.Logger,
+	assignment *v1alpha1.Assignment,
+) error {
+	original := assignment.DeepCopy()
+
+	profileFound, contentResolvable, err := r.profileConditions(ctx, assignment)
+	if err != nil {
+		return errors.Join(err, errAssignmentProfileConditions)
+	}
+
+	ready := metav1.Condition{
+		Type:    v1alpha1.AssignmentConditionReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  v1alpha1.AssignmentReasonReady,
+		Message: "assignment can be served",
+	}
+
+	for _, condition := range []metav1.Condition{profileFound, contentResolvable} {
+		if condition.Type == "" {
+			continue
+		}
+
+		condition.ObservedGeneration = assignment.Generation
+		meta.SetStatusCondition(&assignment.Status.Conditions, condition)
+
+		if condition.Status != metav1.ConditionTrue && ready.Status == metav1.ConditionTrue {
+			ready.Status = metav1.ConditionFalse
+			ready.Reason = condition.Reason
+			ready.Message = condition.Message
+		}
+	}
+
+	if contentResolvable.Type == "" {
+		meta.RemoveStatusCondition(&assignment.Status.Conditions, v1alpha1.AssignmentConditionContentResolvable)
+	}
+
+	ambiguous, err := r.ambiguousCondition(ctx, assignment)
+	if err != nil {
+		return errors.Join(err, errAssignmentAmbiguousCondition)
+	}
+
+	ambiguous.ObservedGeneration = assignment.Generation
+	meta.SetStatusCondition(&assignment.Status.Conditions, ambiguous)
+
+	ready.ObservedGeneration = assignment.Generation
+	meta.SetStatusCondition(&assignment.Status.Conditions, ready)
+
+	lastServedMachines, err := r.lastServedMachines(ctx, assignment)
+	if err != nil {
+		return errors.Join(err, errAssignmentServedMachines)
+	}
+
+	assignment.Status.LastServedMachines = lastServedMachines
+
+	if equality.Semantic.DeepEqual(original.Status, assignment.Status) {
+		log.V(1).Info("No status update needed")
+		return nil
+	}
+
+	if err := r.Status().Patch(ctx, assignment, client.MergeFrom(original)); err != nil {
+		log.Error(err, "Failed to update Assignment status")
+		return errors.Join(err, errAssignmentStatusPatch)
+	}
+
+	log.Info("Successfully updated Assignment status")
+
+	return nil
+}
+
+// profileConditions returns the ProfileFound and ContentResolvable conditions. The ContentResolvable condition is
+// empty if the profile does not exist.
+func (r *AssignmentReconciler) profileConditions(
+	ctx context.Context,
+	assignment *v1alpha1.Assignment,
+) (metav1.Condition, metav1.Condition, error) {
+	var (
+		kind    = "profile"
+		profile types.Profile
+		err     error
+	)
+
+	if assignment.Spec.ProfileKind == v1alpha1.ProfileKindClusterProfile {
+		kind = "cluster profile"
+		profile, err = adapter.NewProfile(r.Client).GetClusterProfile(ctx, assignment.Spec.ProfileName)
+	} else {
+		profile, err = adapter.NewProfile(r.Client).GetInNamespace(ctx, assignment.Spec.ProfileName, assignment.Namespace)
+	}
+
+	if errors.Is(err, adapter.ErrProfileNotFound) {
+		return metav1.Condition{
+			Type:    v1alpha1.AssignmentConditionProfileFound,
+			Status:  metav1.ConditionFalse,
+			Reason:  v1alpha1.AssignmentReasonProfileNotFound,
+			Message: fmt.Sprintf("%s %q does not exist", kind, assignment.Spec.ProfileName),
+		}, metav1.Condition{}, nil
+	} else if err != nil {
+		return metav1.Condition{}, metav1.Condition{}, errors.Join(err, errors.New("failed to get profile"))
+	}
+
+	profileFound := metav1.Condition{
+		Type:    v1alpha1.AssignmentConditionProfileFound,
+		Status:  metav1.ConditionTrue,
+		Reason:  v1alpha1.AssignmentReasonProfileFound,
+		Message: fmt.Sprintf("%s %q exists", kind, assignment.Spec.ProfileName),
+	}
+
+	contentResolvable := metav1.Condition{
+		Type:    v1alpha1.AssignmentConditionContentResolvable,
+		Status:  metav1.ConditionTrue,
+		Reason:  v1alpha1.AssignmentReasonContentResolved,
+		Message: "every additional content resolves",
+	}
+
+	// Content is resolved without machine attributes except the buildarch.
+	attributes := types.IPXESelectors{}
+	if buildarchList := assignment.GetBuildarchList(); len(buildarchList) > 0 {
+		attributes.Buildarch = buildarchList[0].String()
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(profile.AdditionalContent)) {
+		content := profile.AdditionalContent[name]
+
+		resolver, ok := r.Resolvers[content.ResolverKind]
+		if !ok {
+			continue
+		}
+
+		if _, err := resolver.Resolve(ctx, content, attributes); err != nil {
+			contentResolvable.Status = metav1.ConditionFalse
+			contentResolvable.Reason = v1alpha1.AssignmentReasonContentUnresolvable
+			contentResolvable.Message = fmt.Sprintf("content %q cannot be resolved: %s", name, err.Error())
+
+			break
+		}
+	}
+
+	return profileFound, contentResolvable, nil
+}
+
+// ambiguousCondition returns the Ambiguous condition. It is true when other assignments of any namespace, with the
+// same priority, may match the same machines: the assignment selecting a machine is then chosen by name.
+func (r *AssignmentReconciler) ambiguousCondition(
+	ctx context.Context,
+	assignment *v1alpha1.Assignment,
+) (metav1.Condition, error) {
+	tied, err := r.tiedAssignments(ctx, assignment)
+	if err != nil {
+		return metav1.Condition{}, errors.Join(err, errAssignmentTiedAssignments)
+	}
+
+	if len(tied) == 0 {
+		return metav1.Condition{
+			Type:    v1alpha1.AssignmentConditionAmbiguous,
+			Status:  metav1.ConditionFalse,
+			Reason:  v1alpha1.AssignmentReasonNoPriorityTie,
+			Message: fmt.Sprintf("no other assignment with priority %d matches the same machines", assignment.Spec.Priority),
+		}, nil
+	}
+
+	names := make([]string, 0, len(tied))
+	for _, item := range tied {
+		if item.Namespace != assignment.Namespace {
+			names = append(names, client.ObjectKeyFromObject(&item).String())
+			continue
+		}
+
+		names = append(names, item.Name)
+	}
+
+	return metav1.Condition{
+		Type:   v1alpha1.AssignmentConditionAmbiguous,
+		Status: metav1.ConditionTrue,
+		Reason: v1alpha1.AssignmentReasonPriorityTie,
+		Message: fmt.Sprintf("assignments with priority %d may match the same machines, ties are broken by name: %s",
+			assignment.Spec.Priority, strings.Join(names, ", ")),
+	}, nil
+}
+
+// tiedAssignments returns the other assignments of every namespace that may tie with the assignment, sorted by
+// namespace and name.
+func (r *AssignmentReconciler) tiedAssignments(
+	ctx context.Context,
+	assignment *v1alpha1.Assignment,
+) ([]v1alpha1.Assignment, error) {
+	list := new(v1alpha1.AssignmentList)
+	if err := r.List(ctx, list); err != nil {
+		return nil, errors.Join(err, errors.New("failed to list assignments"))
+	}
+
+	out := make([]v1alpha1.Assignment, 0)
+	for _, item := range list.Items {
+		if item.Namespace == assignment.Namespace && item.Name == assignment.Name {
+			continue
+		}
+
+		if mayTie(assignment, &item) {
+			out = append(out, item)
+		}
+	}
+
+	slices.SortFunc(out, func(a, b v1alpha1.Assignment) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+
+	return out, nil
+}
+
+// mayTie returns true if both assignments have the same priority and may match the same machine with the same
+// specificity, i.e. they select a common subject, or an equal machine selector, for a common buildarch. The specificity
+// of a match also depends on the attributes of the machine, so two assignments sharing a subject may not tie for
+// every machine.
+func mayTie(a, b *v1alpha1.Assignment) bool {
+	if a.Spec.Priority != b.Spec.Priority || !shareBuildarch(a, b) {
+		return false
+	}
+
+	if a.Spec.MachineSelector != nil && b.Spec.MachineSelector != nil &&
+		equality.Semantic.DeepEqual(a.Spec.MachineSelector, b.Spec.MachineSelector) {
+		return true
+	}
+
+	subjects := subjectLabels(a)
+	for label := range subjectLabels(b) {
+		if _, ok := subjects[label]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shareBuildarch returns true if both assignments select a common buildarch. An assignment without buildarch selects
+// every buildarch.
+func shareBuildarch(a, b *v1alpha1.Assignment) bool {
+	if len(a.Spec.SubjectSelectors.BuildarchList) == 0 || len(b.Spec.SubjectSelectors.BuildarchList) == 0 {
+		return true
+	}
+
+	for _, buildarch := range a.Spec.SubjectSelectors.BuildarchList {
+		if slices.Contains(b.Spec.SubjectSelectors.BuildarchList, buildarch) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// subjectLabels returns the labels of the subjects selected by the assignment, which normalize their values.
+func subjectLabels(assignment *v1alpha1.Assignment) map[string]struct{} {
+	out := make(map[string]struct{})
+
+	for prefix, values := range assignment.Spec.SubjectSelectors.ByPrefix() {
+		for _, value := range values {
+			if prefix != v1alpha1.UUIDPrefix {
+				out[v1alpha1.NewSubjectLabelSelector(prefix, value)] = struct{}{}
+				continue
+			}
+
+			if id, err := uuid.Parse(value); err == nil {
+				out[v1alpha1.NewUUIDLabelSelector(id)] = struct{}{}
+			}
+		}
+	}
+
+	return out
+}
+
+// lastServedMachines returns the machines whose last boot was served through the assignment, most recent first.
+func (r *AssignmentReconciler) lastServedMachines(
+	ctx context.Context,
+	assignment *v1alpha1.Assignment,
+) ([]v1alpha1.ServedMachine, error) {
+	list := new(v1alpha1.MachineList)
+	if err := r.List(ctx, list, client.InNamespace(r.MachineNamespace)); err != nil {
+		return nil, errors.Join(err, errors.New("failed to list machines"))
+	}
+
+	out := make([]v1alpha1.ServedMachine, 0)
+	for _, m := range list.Items {
+		if servedBy(&m) != client.ObjectKeyFromObject(assignment) {
+			continue
+		}
+
+		out = append(out, v1alpha1.ServedMachine{
+			Name:         m.Name,
+			LastBootTime: m.Status.LastBootTime,
+		})
+	}
+
+	slices.SortFunc(out, func(a, b v1alpha1.ServedMachine) int {
+		return cmp.Or(
+			bootTime(b).Compare(bootTime(a)),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+
+	if len(out) > v1alpha1.MaxLastServedMachines {
+		out = out[:v1alpha1.MaxLastServedMachines]
+	}
+
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	return out, nil
+}
+
+// servedBy returns the key of the assignment that last served the machine. Machines recorded without the namespace of
+// their assignment were served by an assignment of their own namespace.
+func servedBy(m *v1alpha1.Machine) k8stypes.NamespacedName {
+	namespace := m.Status.AssignmentNamespace
+	if namespace == "" {
+		namespace = m.Namespace
+	}
+
+	return k8stypes.NamespacedName{Name: m.Status.AssignmentName, Namespace: namespace}
+}
+
+func bootTime(m v1alpha1.ServedMachine) time.Time {
+	if m.LastBootTime == nil {
+		return time.Time{}
+	}
+
+	return m.LastBootTime.Time
+}
+
+// MapProfileToAssignments returns a reconcile request for every assignment referencing the profile. A ClusterProfile,
+// i.e. an object without namespace, is mapped to the assignments of every namespace referencing it.
+func (r *AssignmentReconciler) MapProfileToAssignments(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := new(v1alpha1.AssignmentList)
+	if err := r.List(ctx, list, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list Assignments referencing Profile", "profile", obj.GetName())
+		return nil
+	}
+
+	isCluster := obj.GetNamespace() == ""
+
+	out := make([]reconcile.Request, 0)
+	for _, item := range list.Items {
+		if item.Spec.ProfileName != obj.GetName() ||
+			(item.Spec.ProfileKind == v1alpha1.ProfileKindClusterProfile) != isCluster {
+			continue
+		}
+
+		out = append(out, reconcile.Request{NamespacedName: k8stypes.NamespacedName{
+			Name:      item.Name,
+			Namespace: item.Namespace,
+		}})
+	}
+
+	return out
+}
+
+// MapAssignmentToTiedAssignments returns a reconcile request for every other assignment that may tie with the
+// assignment, so their Ambiguous condition is updated when the assignment is created, updated or deleted.
+func (r *AssignmentReconciler) MapAssignmentToTiedAssignments(
+	ctx context.Context,
+	obj client.Object,
+) []reconcile.Request {
+	assignment, ok := obj.(*v1alpha1.Assignment)
+	if !ok {
+		return nil
+	}
+
+	tied, err := r.tiedAssignments(ctx, assignment)
+	if err != nil {
+		r.Log.Error(err, "Failed to list Assignments tied with Assignment", "assignment", obj.GetName())
+		return nil
+	}
+
+	out := make([]reconcile.Request, 0, len(tied))
+	for _, item := range tied {
+		out = append(out, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&item)})
+	}
+
+	return out
+}
+
+// MapMachineToAssignment returns a reconcile request for the assignment that last served the machine.
+func (r *AssignmentReconciler) MapMachineToAssignment(_ context.Context, obj client.Object) []reconcile.Request {
+	m, ok := obj.(*v1alpha1.Machine)
+	if !ok || m.Status.AssignmentName == "" {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: servedBy(m)}}
+}
@@ -19,16 +19,26 @@ package reconciler
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/alexandremahdhaoui/shaper/internal/adapter"
+	shapertypes "github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockadapter"
 	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 func TestAssignmentReconciler_Reconcile(t *testing.T) {
@@ -113,6 +123,50 @@ func TestAssignmentReconciler_Reconcile(t *testing.T) {
 			},
 			expectedError: false,
 		},
+		{
+			name: "Assignment with MAC and serial selectors - should add subject labels",
+			assignment: &v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-assignment",
+					Namespace: "default",
+					Labels:    map[string]string{},
+				},
+				Spec: v1alpha1.AssignmentSpec{
+					ProfileName: "test-profile",
+					SubjectSelectors: v1alpha1.SubjectSelectors{
+						MACList:    []string{"52:54:00:12:34:56"},
+						SerialList: []string{"SN-0042"},
+					},
+				},
+			},
+			expectUpdate: true,
+			expectedLabels: map[string]string{
+				v1alpha1.NewSubjectLabelSelector(v1alpha1.MACPrefix, "52:54:00:12:34:56"): "",
+				v1alpha1.NewSubjectLabelSelector(v1alpha1.SerialPrefix, "SN-0042"):        "",
+			},
+			expectedError: false,
+		},
+		{
+			name: "Assignment with machine selector - should add machine-selector label",
+			assignment: &v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-assignment",
+					Namespace: "default",
+					Labels:    map[string]string{},
+				},
+				Spec: v1alpha1.AssignmentSpec{
+					ProfileName: "test-profile",
+					MachineSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"platform": "efi"},
+					},
+				},
+			},
+			expectUpdate: true,
+			expectedLabels: map[string]string{
+				v1alpha1.MachineSelectorAssignmentLabel: "",
+			},
+			expectedError: false,
+		},
 		{
 			name: "Assignment with buildarch selector but no label - should add buildarch label",
 			assignment: &v1alpha1.Assignment{
@@ -252,6 +306,7 @@ func TestAssignmentReconciler_Reconcile(t *testing.T) {
 			fakeClient := fake.NewClientBuilder().
 				WithScheme(scheme).
 				WithObjects(tt.assignment).
+				WithStatusSubresource(tt.assignment).
 				Build()
 
 			// Create reconciler
@@ -363,6 +418,7 @@ func TestAssignmentReconciler_Reconcile_Idempotency(t *testing.T) {
 	fakeClient := fake.NewClientBuilder().
 		WithScheme(scheme).
 		WithObjects(assignment).
+		WithStatusSubresource(assignment).
 		Build()
 
 	// Create reconciler
@@ -410,3 +466,515 @@ func TestAssignmentReconciler_Reconcile_Idempotency(t *testing.T) {
 	assert.Equal(t, firstLabels, assignment2.Labels,
 		"Labels should not change on subsequent reconciles")
 }
+
+func TestAssignmentReconciler_Reconcile_Status(t *testing.T) {
+	newAssignment := func() *v1alpha1.Assignment {
+		return &v1alpha1.Assignment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "test-assignment",
+				Namespace:  "default",
+				Generation: 2,
+			},
+			Spec: v1alpha1.AssignmentSpec{
+				ProfileName: "test-profile",
+			},
+		}
+	}
+
+	profile := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-profile",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.ProfileSpec{
+			IPXETemplate: "#!ipxe",
+			AdditionalContent: []v1alpha1.AdditionalContent{{
+				Name:   "config",
+				Inline: ptr.To("inline content"),
+			}},
+		},
+	}
+
+	newMachine := func(name, assignmentName string, lastBoot time.Time) *v1alpha1.Machine {
+		return &v1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status: v1alpha1.MachineStatus{
+				AssignmentName: assignmentName,
+				LastBootTime:   ptr.To(metav1.NewTime(lastBoot)),
+			},
+		}
+	}
+
+	// servedMachine returns a machine recorded in the machine namespace, served by the assignment of the namespace.
+	servedMachine := func(name, assignmentNamespace string, lastBoot time.Time) *v1alpha1.Machine {
+		return &v1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "machines"},
+			Status: v1alpha1.MachineStatus{
+				AssignmentName:      "test-assignment",
+				AssignmentNamespace: assignmentNamespace,
+				LastBootTime:        ptr.To(metav1.NewTime(lastBoot)),
+			},
+		}
+	}
+
+	clusterProfile := &v1alpha1.ClusterProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-profile"},
+		Spec:       profile.Spec,
+	}
+
+	otherNamespaceProfile := profile.DeepCopy()
+	otherNamespaceProfile.Namespace = "other"
+
+	now := time.Now().Truncate(time.Second)
+
+	tests := []struct {
+		name                       string
+		namespace                  string
+		machineNamespace           string
+		profileKind                v1alpha1.ProfileKind
+		objects                    []client.Object
+		resolverErr                error
+		expectedProfileFound       metav1.ConditionStatus
+		expectedContentResolvable  metav1.ConditionStatus
+		expectedReady              metav1.ConditionStatus
+		expectedReadyReason        string
+		expectedLastServedMachines []string
+	}{
+		{
+			name:                 "Profile not found",
+			expectedProfileFound: metav1.ConditionFalse,
+			expectedReady:        metav1.ConditionFalse,
+			expectedReadyReason:  v1alpha1.AssignmentReasonProfileNotFound,
+		},
+		{
+			name:                 "Profile of another namespace not found",
+			objects:              []client.Object{otherNamespaceProfile, clusterProfile},
+			expectedProfileFound: metav1.ConditionFalse,
+			expectedReady:        metav1.ConditionFalse,
+			expectedReadyReason:  v1alpha1.AssignmentReasonProfileNotFound,
+		},
+		{
+			name:                 "ClusterProfile not found",
+			profileKind:          v1alpha1.ProfileKindClusterProfile,
+			objects:              []client.Object{profile},
+			expectedProfileFound: metav1.ConditionFalse,
+			expectedReady:        metav1.ConditionFalse,
+			expectedReadyReason:  v1alpha1.AssignmentReasonProfileNotFound,
+		},
+		{
+			name:                      "Ready with ClusterProfile",
+			profileKind:               v1alpha1.ProfileKindClusterProfile,
+			objects:                   []client.Object{clusterProfile},
+			expectedProfileFound:      metav1.ConditionTrue,
+			expectedContentResolvable: metav1.ConditionTrue,
+			expectedReady:             metav1.ConditionTrue,
+			expectedReadyReason:       v1alpha1.AssignmentReasonReady,
+		},
+		{
+			name:                      "Content cannot be resolved",
+			objects:                   []client.Object{profile},
+			resolverErr:               assert.AnError,
+			expectedProfileFound:      metav1.ConditionTrue,
+			expectedContentResolvable: metav1.ConditionFalse,
+			expectedReady:             metav1.ConditionFalse,
+			expectedReadyReason:       v1alpha1.AssignmentReasonContentUnresolvable,
+		},
+		{
+			name: "Ready with last served machines",
+			objects: []client.Object{
+				profile,
+				newMachine("older", "test-assignment", now.Add(-time.Hour)),
+				newMachine("newer", "test-assignment", now),
+				newMachine("other", "other-assignment", now),
+			},
+			expectedProfileFound:       metav1.ConditionTrue,
+			expectedContentResolvable:  metav1.ConditionTrue,
+			expectedReady:              metav1.ConditionTrue,
+			expectedReadyReason:        v1alpha1.AssignmentReasonReady,
+			expectedLastServedMachines: []string{"newer", "older"},
+		},
+		{
+			name:             "Ready with last served machines of an assignment outside the machine namespace",
+			namespace:        "other",
+			machineNamespace: "machines",
+			objects: []client.Object{
+				otherNamespaceProfile,
+				servedMachine("served", "other", now),
+				servedMachine("served-by-same-name", "default", now),
+			},
+			expectedProfileFound:       metav1.ConditionTrue,
+			expectedContentResolvable:  metav1.ConditionTrue,
+			expectedReady:              metav1.ConditionTrue,
+			expectedReadyReason:        v1alpha1.AssignmentReasonReady,
+			expectedLastServedMachines: []string{"served"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create scheme and register types
+			scheme := runtime.NewScheme()
+			err := v1alpha1.AddToScheme(scheme)
+			assert.NoError(t, err)
+
+			assignment := newAssignment()
+			assignment.Spec.ProfileKind = tt.profileKind
+			if tt.namespace != "" {
+				assignment.Namespace = tt.namespace
+			}
+
+			// Create fake client
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(append(tt.objects, assignment)...).
+				WithStatusSubresource(assignment).
+				Build()
+
+			resolver := mockadapter.NewMockResolver(t)
+			if tt.expectedContentResolvable != "" {
+				resolver.EXPECT().
+					Resolve(mock.Anything, mock.Anything, mock.Anything).
+					Return([]byte("inline content"), tt.resolverErr).
+					Once()
+			}
+
+			// Create reconciler
+			reconciler := &AssignmentReconciler{
+				Client: fakeClient,
+				Scheme: scheme,
+				Log:    logr.Discard(),
+				Resolvers: map[shapertypes.ResolverKind]adapter.Resolver{
+					shapertypes.InlineResolverKind: resolver,
+				},
+				MachineNamespace: tt.machineNamespace,
+			}
+
+			req := ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      assignment.Name,
+					Namespace: assignment.Namespace,
+				},
+			}
+
+			_, err = reconciler.Reconcile(context.Background(), req)
+			assert.NoError(t, err)
+
+			var updated v1alpha1.Assignment
+			err = fakeClient.Get(context.Background(), req.NamespacedName, &updated)
+			assert.NoError(t, err)
+
+			// Verify conditions
+			for conditionType, expected := range map[string]metav1.ConditionStatus{
+				v1alpha1.AssignmentConditionProfileFound:      tt.expectedProfileFound,
+				v1alpha1.AssignmentConditionContentResolvable: tt.expectedContentResolvable,
+				v1alpha1.AssignmentConditionReady:             tt.expectedReady,
+			} {
+				condition := meta.FindStatusCondition(updated.Status.Conditions, conditionType)
+				if expected == "" {
+					assert.Nil(t, condition, "Condition %s should not exist", conditionType)
+					continue
+				}
+
+				if assert.NotNil(t, condition, "Condition %s should exist", conditionType) {
+					assert.Equal(t, expected, condition.Status, "Condition %s", conditionType)
+					assert.Equal(t, updated.Generation, condition.ObservedGeneration)
+				}
+			}
+
+			ready := meta.FindStatusCondition(updated.Status.Conditions, v1alpha1.AssignmentConditionReady)
+			assert.Equal(t, tt.expectedReadyReason, ready.Reason)
+
+			// Verify last served machines
+			names := make([]string, 0)
+			for _, m := range updated.Status.LastServedMachines {
+				names = append(names, m.Name)
+			}
+
+			if tt.expectedLastServedMachines == nil {
+				assert.Empty(t, names)
+			} else {
+				assert.Equal(t, tt.expectedLastServedMachines, names)
+			}
+		})
+	}
+}
+
+func TestAssignmentReconciler_Reconcile_StatusErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		funcs       interceptor.Funcs
+		expectedErr error
+	}{
+		{
+			name: "Get profile",
+			funcs: interceptor.Funcs{
+				Get: func(
+					ctx context.Context,
+					c client.WithWatch,
+					key client.ObjectKey,
+					obj client.Object,
+					opts ...client.GetOption,
+				) error {
+					if _, ok := obj.(*v1alpha1.Profile); ok {
+						return assert.AnError
+					}
+
+					return c.Get(ctx, key, obj, opts...)
+				},
+			},
+			expectedErr: errAssignmentProfileConditions,
+		},
+		{
+			name:        "List assignments",
+			funcs:       interceptor.Funcs{List: failListOf[*v1alpha1.AssignmentList]},
+			expectedErr: errAssignmentTiedAssignments,
+		},
+		{
+			name:        "List machines",
+			funcs:       interceptor.Funcs{List: failListOf[*v1alpha1.MachineList]},
+			expectedErr: errAssignmentServedMachines,
+		},
+		{
+			name: "Patch status",
+			funcs: interceptor.Funcs{
+				SubResourcePatch: func(
+					context.Context,
+					client.Client,
+					string,
+					client.Object,
+					client.Patch,
+					...client.SubResourcePatchOption,
+				) error {
+					return assert.AnError
+				},
+			},
+			expectedErr: errAssignmentStatusPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			assert.NoError(t, v1alpha1.AddToScheme(scheme))
+
+			assignment := &v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-assignment", Namespace: "default"},
+				Spec:       v1alpha1.AssignmentSpec{ProfileName: "test-profile"},
+			}
+
+			reconciler := &AssignmentReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(assignment).
+					WithStatusSubresource(assignment).
+					WithInterceptorFuncs(tt.funcs).
+					Build(),
+				Scheme: scheme,
+				Log:    logr.Discard(),
+			}
+
+			_, err := reconciler.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: client.ObjectKeyFromObject(assignment),
+			})
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.ErrorIs(t, err, errAssignmentStatus)
+		})
+	}
+}
+
+// failListOf fails listing objects of type T.
+func failListOf[T client.ObjectList](
+	ctx context.Context,
+	c client.WithWatch,
+	list client.ObjectList,
+	opts ...client.ListOption,
+) error {
+	if _, ok := list.(T); ok {
+		return assert.AnError
+	}
+
+	return c.List(ctx, list, opts...)
+}
+
+func TestAssignmentReconciler_Reconcile_Ambiguous(t *testing.T) {
+	machineUUID := uuid.NewString()
+
+	newAssignment := func(name, namespace string, priority int32, spec v1alpha1.AssignmentSpec) *v1alpha1.Assignment {
+		spec.ProfileName = "test-profile"
+		spec.Priority = priority
+
+		return &v1alpha1.Assignment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       spec,
+		}
+	}
+
+	byUUID := v1alpha1.AssignmentSpec{
+		SubjectSelectors: v1alpha1.SubjectSelectors{UUIDList: []string{machineUUID}},
+	}
+
+	byUUIDAndBuildarch := func(buildarch v1alpha1.Buildarch) v1alpha1.AssignmentSpec {
+		return v1alpha1.AssignmentSpec{SubjectSelectors: v1alpha1.SubjectSelectors{
+			BuildarchList: []v1alpha1.Buildarch{buildarch},
+			UUIDList:      []string{machineUUID},
+		}}
+	}
+
+	bySelector := v1alpha1.AssignmentSpec{
+		MachineSelector: &metav1.LabelSelector{MatchLabels: map[string]string{v1alpha1.PlatformKey: "efi"}},
+	}
+
+	tests := []struct {
+		name            string
+		assignment      *v1alpha1.Assignment
+		objects         []client.Object
+		expectedStatus  metav1.ConditionStatus
+		expectedMessage string
+	}{
+		{
+			name:           "No other assignment",
+			assignment:     newAssignment("a", "default", 10, byUUID),
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:       "Same uuid and priority",
+			assignment: newAssignment("a", "default", 10, byUUID),
+			objects: []client.Object{
+				newAssignment("b", "default", 10, byUUID),
+				newAssignment("c", "other", 10, byUUID),
+			},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedMessage: "b, other/c",
+		},
+		{
+			name:       "Same uuid and another priority",
+			assignment: newAssignment("a", "default", 10, byUUID),
+			objects: []client.Object{
+				newAssignment("b", "default", 20, byUUID),
+			},
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:           "Same uuid and another buildarch",
+			assignment:     newAssignment("a", "default", 10, byUUIDAndBuildarch(v1alpha1.Arm64)),
+			objects:        []client.Object{newAssignment("b", "default", 10, byUUIDAndBuildarch(v1alpha1.X8664))},
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:       "Same machine selector and priority",
+			assignment: newAssignment("a", "default", 10, bySelector),
+			objects: []client.Object{
+				newAssignment("b", "default", 10, bySelector),
+			},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedMessage: "b",
+		},
+		{
+			name: "Tie is gone",
+			assignment: func() *v1alpha1.Assignment {
+				a := newAssignment("a", "default", 10, byUUID)
+				a.Status.Conditions = []metav1.Condition{{
+					Type:               v1alpha1.AssignmentConditionAmbiguous,
+					Status:             metav1.ConditionTrue,
+					Reason:             v1alpha1.AssignmentReasonPriorityTie,
+					LastTransitionTime: metav1.Now(),
+				}}
+
+				return a
+			}(),
+			objects: []client.Object{
+				newAssignment("b", "default", 10, v1alpha1.AssignmentSpec{
+					SubjectSelectors: v1alpha1.SubjectSelectors{UUIDList: []string{uuid.NewString()}},
+				}),
+			},
+			expectedStatus: metav1.ConditionFalse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			err := v1alpha1.AddToScheme(scheme)
+			assert.NoError(t, err)
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(append(tt.objects, tt.assignment)...).
+				WithStatusSubresource(tt.assignment).
+				Build()
+
+			reconciler := &AssignmentReconciler{
+				Client: fakeClient,
+				Scheme: scheme,
+				Log:    logr.Discard(),
+			}
+
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(tt.assignment)}
+
+			_, err = reconciler.Reconcile(context.Background(), req)
+			assert.NoError(t, err)
+
+			var updated v1alpha1.Assignment
+			err = fakeClient.Get(context.Background(), req.NamespacedName, &updated)
+			assert.NoError(t, err)
+
+			condition := meta.FindStatusCondition(updated.Status.Conditions, v1alpha1.AssignmentConditionAmbiguous)
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, tt.expectedStatus, condition.Status)
+				assert.Contains(t, condition.Message, tt.expectedMessage)
+			}
+		})
+	}
+}
+
+func TestAssignmentReconciler_MapAssignmentToTiedAssignments(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	spec := v1alpha1.AssignmentSpec{
+		Priority:         10,
+		SubjectSelectors: v1alpha1.SubjectSelectors{MACList: []string{"52:54:00:12:34:56"}},
+	}
+
+	tied := &v1alpha1.Assignment{ObjectMeta: metav1.ObjectMeta{Name: "tied", Namespace: "default"}, Spec: spec}
+	notTied := &v1alpha1.Assignment{ObjectMeta: metav1.ObjectMeta{Name: "not-tied", Namespace: "default"}}
+
+	reconciler := &AssignmentReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(tied, notTied).Build(),
+		Log:    logr.Discard(),
+	}
+
+	// The assignment may be deleted: its tied assignments are found from its spec.
+	deleted := &v1alpha1.Assignment{ObjectMeta: metav1.ObjectMeta{Name: "deleted", Namespace: "default"}, Spec: spec}
+	deleted.Spec.SubjectSelectors.MACList = []string{"52-54-00-12-34-56"}
+
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{
+		Name:      "tied",
+		Namespace: "default",
+	}}}, reconciler.MapAssignmentToTiedAssignments(context.Background(), deleted))
+}
+
+func TestAssignmentReconciler_MapMachineToAssignment(t *testing.T) {
+	reconciler := &AssignmentReconciler{Log: logr.Discard()}
+
+	machine := &v1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: uuid.NewString(), Namespace: "default"},
+		Status:     v1alpha1.MachineStatus{AssignmentName: "test-assignment"},
+	}
+
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{
+		Name:      "test-assignment",
+		Namespace: "default",
+	}}}, reconciler.MapMachineToAssignment(context.Background(), machine))
+
+	// The assignment may live outside the namespace of the machine.
+	machine.Status.AssignmentNamespace = "other"
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{
+		Name:      "test-assignment",
+		Namespace: "other",
+	}}}, reconciler.MapMachineToAssignment(context.Background(), machine))
+
+	machine.Status.AssignmentName = ""
+	assert.Empty(t, reconciler.MapMachineToAssignment(context.Background(), machine))
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconciler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/alexandremahdhaoui/shaper/internal/controller"
+	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ClusterProfileReconciler reconciles ClusterProfile objects
+type ClusterProfileReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Log    logr.Logger
+
+	// Mux is used to dry-run the resolution and transformation of the additional content of cluster profiles. The
+	// content is not verified when Mux is nil.
+	Mux controller.ResolveTransformerMux
+}
+
+// Verify ClusterProfileReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ClusterProfileReconciler{}
+
+// Reconcile implements the reconciliation loop for ClusterProfile resources. A ClusterProfile is reconciled like a
+// Profile: the UUIDs of its exposed additional content are kept consistent between its labels and its status, and its
+// conditions report a dry-run rendering.
+func (r *ClusterProfileReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("clusterProfile", req.Name)
+
+	// Fetch the ClusterProfile
+	var clusterProfile v1alpha1.ClusterProfile
+	if err := r.Get(ctx, req.NamespacedName, &clusterProfile); err != nil {
+		if apierrors.IsNotFound(err) {
+			// ClusterProfile was deleted, nothing to do
+			log.V(1).Info("ClusterProfile not found, likely deleted")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Join(err, errors.New("failed to get cluster profile"))
+	}
+
+	profile := clusterProfile.AsProfile()
+
+	needsStatusUpdate, needsLabelUpdate, err := ensureExposedContentUUIDs(log, profile)
+	if err != nil {
+		return ctrl.Result{}, err // TODO: wrap err
+	}
+
+	// Update labels if needed (must happen before status update)
+	if needsLabelUpdate {
+		clusterProfile.Labels = profile.Labels
+
+		if err := r.Update(ctx, &clusterProfile); err != nil {
+			log.Error(err, "Failed to update ClusterProfile labels")
+			return ctrl.Result{}, errors.Join(err, errors.New("failed to update cluster profile labels"))
+		}
+		log.Info("Successfully updated ClusterProfile labels")
+
+		// Re-fetch the cluster profile after label update to get the new resourceVersion
+		if err := r.Get(ctx, req.NamespacedName, &clusterProfile); err != nil {
+			log.Error(err, "Failed to re-fetch ClusterProfile after label update")
+			return ctrl.Result{}, errors.Join(err, errors.New("failed to re-fetch cluster profile"))
+		}
+	}
+
+	// Dry-run the rendering of the cluster profile
+	if r.profileReconciler().setConditions(ctx, profile) {
+		needsStatusUpdate = true
+	}
+
+	// Update status if needed (idempotent)
+	if needsStatusUpdate {
+		clusterProfile.Status = profile.Status
+
+		if err := r.Status().Update(ctx, &clusterProfile); err != nil {
+			log.Error(err, "Failed to update ClusterProfile status")
+			return ctrl.Result{}, errors.Join(err, errors.New("failed to update cluster profile status"))
+		}
+		log.Info("Successfully updated ClusterProfile status")
+	}
+
+	if !needsStatusUpdate && !needsLabelUpdate {
+		log.V(1).Info("No update needed")
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// MapBaseClusterProfileToChildren returns a reconcile request for every cluster profile inheriting, directly or
+// transitively, from the cluster profile.
+func (r *ClusterProfileReconciler) MapBaseClusterProfileToChildren(
+	ctx context.Context,
+	obj client.Object,
+) []reconcile.Request {
+	list := new(v1alpha1.ClusterProfileList)
+	if err := r.List(ctx, list); err != nil {
+		r.Log.Error(err, "Failed to list ClusterProfiles inheriting from ClusterProfile", "clusterProfile", obj.GetName())
+		return nil
+	}
+
+	specs := make(map[string]v1alpha1.ProfileSpec, len(list.Items))
+	for _, item := range list.Items {
+		specs[item.Name] = item.Spec
+	}
+
+	return descendants(obj.GetName(), "", specs)
+}
+
+// profileReconciler returns a ProfileReconciler sharing the client and mux of the reconciler, used to dry-run the
+// rendering of cluster profiles.
+func (r *ClusterProfileReconciler) profileReconciler() *ProfileReconciler {
+	return &ProfileReconciler{
+		Client: r.Client,
+		Scheme: r.Scheme,
+		Log:    r.Log,
+		Mux:    r.Mux,
+	}
+}
@@ -0,0 +1,137 @@
+//go:build unit
+
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconciler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClusterProfileReconciler_Reconcile(t *testing.T) {
+	clusterProfile := &v1alpha1.ClusterProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-cluster-profile",
+		},
+		Spec: v1alpha1.ProfileSpec{
+			IPXETemplate: "test template",
+			AdditionalContent: []v1alpha1.AdditionalContent{
+				{Name: "ignition", Exposed: true},
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	err := v1alpha1.AddToScheme(scheme)
+	assert.NoError(t, err)
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(clusterProfile).
+		WithStatusSubresource(clusterProfile).
+		Build()
+
+	reconciler := &ClusterProfileReconciler{
+		Client: fakeClient,
+		Scheme: scheme,
+		Log:    logr.Discard(),
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: clusterProfile.Name}}
+
+	result, err := reconciler.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+
+	var updated v1alpha1.ClusterProfile
+	err = fakeClient.Get(context.Background(), req.NamespacedName, &updated)
+	assert.NoError(t, err)
+
+	// The UUID of the exposed content is consistent between the status and the labels.
+	id, ok := updated.Status.ExposedAdditionalContent["ignition"]
+	assert.True(t, ok)
+	assert.Equal(t, "ignition", updated.Labels[v1alpha1.NewUUIDLabelSelector(uuid.MustParse(id))])
+
+	// Reconciling again does not change the UUID.
+	_, err = reconciler.Reconcile(context.Background(), req)
+	assert.NoError(t, err)
+
+	err = fakeClient.Get(context.Background(), req.NamespacedName, &updated)
+	assert.NoError(t, err)
+	assert.Equal(t, id, updated.Status.ExposedAdditionalContent["ignition"])
+
+	t.Run("NotFound", func(t *testing.T) {
+		result, err := reconciler.Reconcile(context.Background(), ctrl.Request{
+			NamespacedName: types.NamespacedName{Name: "missing"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, ctrl.Result{}, result)
+	})
+}
+
+func TestClusterProfileReconciler_MapBaseClusterProfileToChildren(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := v1alpha1.AddToScheme(scheme)
+	assert.NoError(t, err)
+
+	newClusterProfile := func(name, base string) *v1alpha1.ClusterProfile {
+		clusterProfile := &v1alpha1.ClusterProfile{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if base != "" {
+			clusterProfile.Spec.BaseProfileRef = &v1alpha1.ProfileReference{Name: base}
+		}
+
+		return clusterProfile
+	}
+
+	base := newClusterProfile("base", "")
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			base,
+			newClusterProfile("child", "base"),
+			newClusterProfile("grandchild", "child"),
+			newClusterProfile("unrelated", ""),
+			// A namespaced Profile cannot inherit from a ClusterProfile.
+			&v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "namespaced", Namespace: "default"},
+				Spec:       v1alpha1.ProfileSpec{BaseProfileRef: &v1alpha1.ProfileReference{Name: "base"}},
+			},
+		).
+		Build()
+
+	reconciler := &ClusterProfileReconciler{
+		Client: fakeClient,
+		Scheme: scheme,
+		Log:    logr.Discard(),
+	}
+
+	actual := reconciler.MapBaseClusterProfileToChildren(context.Background(), base)
+	assert.ElementsMatch(t, []ctrl.Request{
+		{NamespacedName: types.NamespacedName{Name: "child"}},
+		{NamespacedName: types.NamespacedName{Name: "grandchild"}},
+	}, actual)
+}
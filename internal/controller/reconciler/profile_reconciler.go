@@ -17,12 +17,23 @@ package reconciler
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"slices"
 
+	"github.com/alexandremahdhaoui/shaper/internal/adapter"
+	"github.com/alexandremahdhaoui/shaper/internal/controller"
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/internal/util/templateutil"
 	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -33,8 +44,15 @@ type ProfileReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	Log    logr.Logger
+
+	// Mux is used to dry-run the resolution and transformation of the additional content of profiles. The content is
+	// not verified when Mux is nil.
+	Mux controller.ResolveTransformerMux
 }
 
+// dryRunSelectors are the synthetic machine attributes used to dry-run the resolution and transformation of content.
+var dryRunSelectors = types.IPXESelectors{Buildarch: v1alpha1.X8664.String()}
+
 // Verify ProfileReconciler implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ProfileReconciler{}
 
@@ -46,6 +64,9 @@ var _ reconcile.Reconciler = &ProfileReconciler{}
 // The reconciler coordinates with the Profile webhook:
 // - If webhook set labels: reconciler copies UUIDs from labels to status
 // - If webhook didn't run: reconciler generates UUIDs and sets both labels and status
+//
+// It then dry-runs the rendering of the profile and reports the TemplateValid, ContentResolvable and ButaneValid
+// conditions in the status.
 func (r *ProfileReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("profile", req.NamespacedName)
 
@@ -60,11 +81,62 @@ func (r *ProfileReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, errors.Join(err, errors.New("failed to get profile"))
 	}
 
+	needsStatusUpdate, needsLabelUpdate, err := ensureExposedContentUUIDs(log, &profile)
+	if err != nil {
+		return ctrl.Result{}, err // TODO: wrap err
+	}
+
+	// Update labels if needed (must happen before status update)
+	if needsLabelUpdate {
+		// Preserve the status updates before updating labels
+		statusSnapshot := profile.Status.ExposedAdditionalContent
+
+		if err := r.Update(ctx, &profile); err != nil {
+			log.Error(err, "Failed to update Profile labels")
+			return ctrl.Result{}, errors.Join(err, errors.New("failed to update profile labels"))
+		}
+		log.Info("Successfully updated Profile labels")
+
+		// Re-fetch profile after label update to get the new resourceVersion
+		// This is required before updating status, otherwise the status update will fail
+		if err := r.Get(ctx, req.NamespacedName, &profile); err != nil {
+			log.Error(err, "Failed to re-fetch Profile after label update")
+			return ctrl.Result{}, errors.Join(err, errors.New("failed to re-fetch profile"))
+		}
+
+		// Restore the status updates we calculated
+		profile.Status.ExposedAdditionalContent = statusSnapshot
+	}
+
+	// Dry-run the rendering of the profile
+	if r.setConditions(ctx, &profile) {
+		needsStatusUpdate = true
+	}
+
+	// Update status if needed (idempotent)
+	if needsStatusUpdate {
+		if err := r.Status().Update(ctx, &profile); err != nil {
+			log.Error(err, "Failed to update Profile status")
+			return ctrl.Result{}, errors.Join(err, errors.New("failed to update profile status"))
+		}
+		log.Info("Successfully updated Profile status")
+	}
+
+	if !needsStatusUpdate && !needsLabelUpdate {
+		log.V(1).Info("No update needed")
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// ensureExposedContentUUIDs ensures the UUIDs of the exposed additional content of the profile are consistent between
+// its labels and its status. It returns whether the status and the labels must be updated.
+func ensureExposedContentUUIDs(log logr.Logger, profile *v1alpha1.Profile) (bool, bool, error) {
 	// Parse existing UUID labels (may have been set by webhook)
 	_, nameToUUID, err := v1alpha1.UUIDLabelSelectors(profile.Labels)
 	if err != nil {
 		log.Error(err, "Failed to parse UUID labels")
-		return ctrl.Result{}, errors.Join(err, errors.New("failed to parse uuid labels"))
+		return false, false, errors.Join(err, errors.New("failed to parse uuid labels"))
 	}
 
 	// Initialize maps if needed
@@ -127,40 +199,210 @@ func (r *ProfileReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		// else: both exist and match, nothing to do
 	}
 
-	// Update labels if needed (must happen before status update)
-	if needsLabelUpdate {
-		// Preserve the status updates before updating labels
-		statusSnapshot := profile.Status.ExposedAdditionalContent
+	return needsStatusUpdate, needsLabelUpdate, nil
+}
 
-		if err := r.Update(ctx, &profile); err != nil {
-			log.Error(err, "Failed to update Profile labels")
-			return ctrl.Result{}, errors.Join(err, errors.New("failed to update profile labels"))
+// setConditions dry-runs the rendering of the profile merged with its base profiles and sets its conditions. It
+// returns true if the conditions changed.
+func (r *ProfileReconciler) setConditions(ctx context.Context, profile *v1alpha1.Profile) bool {
+	original := slices.Clone(profile.Status.Conditions)
+
+	var conditions []metav1.Condition
+
+	if merged, err := adapter.NewProfile(r.Client).Merge(ctx, profile); err != nil {
+		conditions = r.baseProfileConditions(err)
+	} else {
+		conditions = append(conditions, templateCondition(merged))
+
+		if r.Mux != nil {
+			conditions = append(conditions, r.contentConditions(ctx, merged)...)
 		}
-		log.Info("Successfully updated Profile labels")
+	}
 
-		// Re-fetch profile after label update to get the new resourceVersion
-		// This is required before updating status, otherwise the status update will fail
-		if err := r.Get(ctx, req.NamespacedName, &profile); err != nil {
-			log.Error(err, "Failed to re-fetch Profile after label update")
-			return ctrl.Result{}, errors.Join(err, errors.New("failed to re-fetch profile"))
+	if !slices.ContainsFunc(conditions, func(c metav1.Condition) bool {
+		return c.Type == v1alpha1.ProfileConditionButaneValid
+	}) {
+		meta.RemoveStatusCondition(&profile.Status.Conditions, v1alpha1.ProfileConditionButaneValid)
+	}
+
+	for _, condition := range conditions {
+		condition.ObservedGeneration = profile.Generation
+		meta.SetStatusCondition(&profile.Status.Conditions, condition)
+	}
+
+	return !equality.Semantic.DeepEqual(original, profile.Status.Conditions)
+}
+
+// baseProfileConditions returns the TemplateValid and ContentResolvable conditions of a profile that cannot be merged
+// with its base profiles.
+func (r *ProfileReconciler) baseProfileConditions(err error) []metav1.Condition {
+	message := fmt.Sprintf("profile cannot be merged with its base profiles: %s", err.Error())
+
+	conditions := []metav1.Condition{{
+		Type:    v1alpha1.ProfileConditionTemplateValid,
+		Status:  metav1.ConditionFalse,
+		Reason:  v1alpha1.ProfileReasonBaseProfileInvalid,
+		Message: message,
+	}}
+
+	if r.Mux != nil {
+		conditions = append(conditions, metav1.Condition{
+			Type:    v1alpha1.ProfileConditionContentResolvable,
+			Status:  metav1.ConditionFalse,
+			Reason:  v1alpha1.ProfileReasonBaseProfileInvalid,
+			Message: message,
+		})
+	}
+
+	return conditions
+}
+
+// templateCondition returns the TemplateValid condition of the profile.
+func templateCondition(profile *v1alpha1.Profile) metav1.Condition {
+	if _, err := templateutil.Parse("ipxeTemplate", profile.Spec.IPXETemplate); err != nil {
+		return metav1.Condition{
+			Type:    v1alpha1.ProfileConditionTemplateValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  v1alpha1.ProfileReasonTemplateInvalid,
+			Message: fmt.Sprintf("ipxe template cannot be parsed: %s", err.Error()),
 		}
+	}
 
-		// Restore the status updates we calculated
-		profile.Status.ExposedAdditionalContent = statusSnapshot
+	return metav1.Condition{
+		Type:    v1alpha1.ProfileConditionTemplateValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  v1alpha1.ProfileReasonTemplateParsed,
+		Message: "ipxe template parses",
 	}
+}
 
-	// Update status if needed (idempotent)
-	if needsStatusUpdate {
-		if err := r.Status().Update(ctx, &profile); err != nil {
-			log.Error(err, "Failed to update Profile status")
-			return ctrl.Result{}, errors.Join(err, errors.New("failed to update profile status"))
+// contentConditions resolves and transforms every additional content of the profile using synthetic selectors. It
+// returns the ContentResolvable condition, and the ButaneValid condition if a content is transformed from butane.
+func (r *ProfileReconciler) contentConditions(ctx context.Context, profile *v1alpha1.Profile) []metav1.Condition {
+	contentResolvable := metav1.Condition{
+		Type:    v1alpha1.ProfileConditionContentResolvable,
+		Status:  metav1.ConditionTrue,
+		Reason:  v1alpha1.ProfileReasonContentResolved,
+		Message: "every additional content resolves",
+	}
+
+	p, err := adapter.ConvertProfile(profile)
+	if err != nil {
+		contentResolvable.Status = metav1.ConditionFalse
+		contentResolvable.Reason = v1alpha1.ProfileReasonContentUnresolvable
+		contentResolvable.Message = fmt.Sprintf("profile cannot be converted: %s", err.Error())
+
+		return []metav1.Condition{contentResolvable}
+	}
+
+	butaneValid := metav1.Condition{
+		Type:    v1alpha1.ProfileConditionButaneValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  v1alpha1.ProfileReasonButaneTranslated,
+		Message: "every butane content translates to ignition",
+	}
+
+	params, err := controller.ResolveParameters(ctx, r.Mux, dryRunSelectors, p.Parameters)
+	if err != nil {
+		contentResolvable.Status = metav1.ConditionFalse
+		contentResolvable.Reason = v1alpha1.ProfileReasonContentUnresolvable
+		contentResolvable.Message = fmt.Sprintf("parameters cannot be resolved: %s", err.Error())
+
+		return []metav1.Condition{contentResolvable}
+	}
+
+	hasButane := false
+	data := types.TemplateData{
+		Machine: dryRunSelectors,
+		Profile: types.ProfileMetadata{Name: p.Name, Namespace: p.Namespace},
+		Params:  params,
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(p.AdditionalContent)) {
+		content := p.AdditionalContent[name]
+
+		isButane := slices.ContainsFunc(content.PostTransformers, func(cfg types.TransformerConfig) bool {
+			return cfg.Kind == types.ButaneTransformerKind
+		})
+		hasButane = hasButane || isButane
+
+		_, err := r.Mux.ResolveAndTransform(ctx, content, p.AdditionalContent, dryRunSelectors, data)
+
+		switch {
+		case err == nil:
+		case errors.Is(err, adapter.ErrButaneTranslate):
+			if butaneValid.Status != metav1.ConditionFalse {
+				butaneValid.Status = metav1.ConditionFalse
+				butaneValid.Reason = v1alpha1.ProfileReasonButaneInvalid
+				butaneValid.Message = fmt.Sprintf("content %q cannot be translated: %s", name, err.Error())
+			}
+		default:
+			if contentResolvable.Status == metav1.ConditionTrue {
+				contentResolvable.Status = metav1.ConditionFalse
+				contentResolvable.Reason = v1alpha1.ProfileReasonContentUnresolvable
+				contentResolvable.Message = fmt.Sprintf("content %q cannot be resolved: %s", name, err.Error())
+			}
+
+			// The butane of a content that cannot be resolved is not verified.
+			if isButane && butaneValid.Status == metav1.ConditionTrue {
+				butaneValid.Status = metav1.ConditionUnknown
+				butaneValid.Reason = v1alpha1.ProfileReasonContentUnresolvable
+				butaneValid.Message = fmt.Sprintf("content %q cannot be resolved", name)
+			}
 		}
-		log.Info("Successfully updated Profile status")
 	}
 
-	if !needsStatusUpdate && !needsLabelUpdate {
-		log.V(1).Info("No update needed")
+	if !hasButane {
+		return []metav1.Condition{contentResolvable}
 	}
 
-	return ctrl.Result{}, nil
+	return []metav1.Condition{contentResolvable, butaneValid}
+}
+
+// MapBaseProfileToChildren returns a reconcile request for every profile inheriting, directly or transitively, from
+// the profile, so their conditions reflect the changes of their base profiles.
+func (r *ProfileReconciler) MapBaseProfileToChildren(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := new(v1alpha1.ProfileList)
+	if err := r.List(ctx, list, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list Profiles inheriting from Profile", "profile", obj.GetName())
+		return nil
+	}
+
+	specs := make(map[string]v1alpha1.ProfileSpec, len(list.Items))
+	for _, item := range list.Items {
+		specs[item.Name] = item.Spec
+	}
+
+	return descendants(obj.GetName(), obj.GetNamespace(), specs)
+}
+
+// descendants returns a reconcile request for every profile inheriting, directly or transitively, from the named
+// profile. The specs map the name of each profile of the namespace to its spec.
+func descendants(name, namespace string, specs map[string]v1alpha1.ProfileSpec) []reconcile.Request {
+	children := make(map[string][]string)
+	for _, child := range slices.Sorted(maps.Keys(specs)) {
+		if ref := specs[child].BaseProfileRef; ref != nil {
+			children[ref.Name] = append(children[ref.Name], child)
+		}
+	}
+
+	out := make([]reconcile.Request, 0)
+	visited := map[string]struct{}{name: {}}
+
+	for queue := []string{name}; len(queue) > 0; queue = queue[1:] {
+		for _, child := range children[queue[0]] {
+			if _, ok := visited[child]; ok {
+				continue
+			}
+
+			visited[child] = struct{}{}
+			queue = append(queue, child)
+			out = append(out, reconcile.Request{NamespacedName: k8stypes.NamespacedName{
+				Name:      child,
+				Namespace: namespace,
+			}})
+		}
+	}
+
+	return out
 }
@@ -18,16 +18,25 @@ package reconciler
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
+	"github.com/alexandremahdhaoui/shaper/internal/adapter"
+	"github.com/alexandremahdhaoui/shaper/internal/controller"
+	shapertypes "github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockadapter"
 	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -298,3 +307,305 @@ func TestProfileReconciler_Reconcile_Idempotency(t *testing.T) {
 	secondUUID := profile2.Status.ExposedAdditionalContent["ignition"]
 	assert.Equal(t, firstUUID, secondUUID, "UUID should not change on subsequent reconciles")
 }
+
+func TestProfileReconciler_Reconcile_Conditions(t *testing.T) {
+	butane := "variant: fcos\nversion: 1.5.0\n"
+
+	tests := []struct {
+		name                      string
+		spec                      v1alpha1.ProfileSpec
+		bases                     []v1alpha1.ProfileSpec
+		expectedTemplateValid     metav1.ConditionStatus
+		expectedContentResolvable metav1.ConditionStatus
+		expectedButaneValid       metav1.ConditionStatus
+		expectedMessage           string
+	}{
+		{
+			name: "Valid profile",
+			spec: v1alpha1.ProfileSpec{
+				IPXETemplate: "#!ipxe\nchain {{ .config }}",
+				AdditionalContent: []v1alpha1.AdditionalContent{
+					{Name: "config", Inline: ptr.To("inline content")},
+				},
+			},
+			expectedTemplateValid:     metav1.ConditionTrue,
+			expectedContentResolvable: metav1.ConditionTrue,
+		},
+		{
+			name:                      "Invalid template",
+			spec:                      v1alpha1.ProfileSpec{IPXETemplate: "#!ipxe\nchain {{ .config"},
+			expectedTemplateValid:     metav1.ConditionFalse,
+			expectedContentResolvable: metav1.ConditionTrue,
+			expectedMessage:           "ipxe template cannot be parsed",
+		},
+		{
+			name: "Unresolvable content",
+			spec: v1alpha1.ProfileSpec{
+				IPXETemplate: "#!ipxe",
+				AdditionalContent: []v1alpha1.AdditionalContent{{
+					Name: "config",
+					ObjectRef: &v1alpha1.ObjectRef{
+						ResourceRef: v1alpha1.ResourceRef{
+							Group:     "",
+							Version:   "v1",
+							Resource:  "configmaps",
+							Namespace: "default",
+							Name:      "missing",
+						},
+						JSONPath: ".data.config",
+					},
+					PostTransformations: []v1alpha1.Transformer{{ButaneToIgnition: true}},
+				}},
+			},
+			expectedTemplateValid:     metav1.ConditionTrue,
+			expectedContentResolvable: metav1.ConditionFalse,
+			expectedButaneValid:       metav1.ConditionUnknown,
+			expectedMessage:           `content "config" cannot be resolved`,
+		},
+		{
+			name: "Valid butane",
+			spec: v1alpha1.ProfileSpec{
+				IPXETemplate: "#!ipxe",
+				AdditionalContent: []v1alpha1.AdditionalContent{{
+					Name:                "ignition",
+					Inline:              ptr.To(butane),
+					PostTransformations: []v1alpha1.Transformer{{ButaneToIgnition: true}},
+				}},
+			},
+			expectedTemplateValid:     metav1.ConditionTrue,
+			expectedContentResolvable: metav1.ConditionTrue,
+			expectedButaneValid:       metav1.ConditionTrue,
+		},
+		{
+			name: "Invalid butane",
+			spec: v1alpha1.ProfileSpec{
+				IPXETemplate: "#!ipxe",
+				AdditionalContent: []v1alpha1.AdditionalContent{{
+					Name:                "ignition",
+					Inline:              ptr.To("variant: fcos\nversion: 0.0.0\n"),
+					PostTransformations: []v1alpha1.Transformer{{ButaneToIgnition: true}},
+				}},
+			},
+			expectedTemplateValid:     metav1.ConditionTrue,
+			expectedContentResolvable: metav1.ConditionTrue,
+			expectedButaneValid:       metav1.ConditionFalse,
+			expectedMessage:           `content "ignition" cannot be translated`,
+		},
+		{
+			name: "Valid butane with parameters",
+			spec: v1alpha1.ProfileSpec{
+				IPXETemplate: "#!ipxe",
+				AdditionalContent: []v1alpha1.AdditionalContent{{
+					Name:                "ignition",
+					Inline:              ptr.To("variant: fcos\nversion: {{ .Params.version }}\n"),
+					PostTransformations: []v1alpha1.Transformer{{ButaneToIgnition: true}},
+				}},
+				Parameters: []v1alpha1.Parameter{{Name: "version", Value: ptr.To("1.5.0")}},
+			},
+			expectedTemplateValid:     metav1.ConditionTrue,
+			expectedContentResolvable: metav1.ConditionTrue,
+			expectedButaneValid:       metav1.ConditionTrue,
+		},
+		{
+			name: "Unresolvable parameters",
+			spec: v1alpha1.ProfileSpec{
+				IPXETemplate: "#!ipxe",
+				Parameters: []v1alpha1.Parameter{{
+					Name: "token",
+					ObjectRef: &v1alpha1.ObjectRef{
+						ResourceRef: v1alpha1.ResourceRef{
+							Version:   "v1",
+							Resource:  "secrets",
+							Namespace: "default",
+							Name:      "missing",
+						},
+						JSONPath: ".data.token",
+					},
+				}},
+			},
+			expectedTemplateValid:     metav1.ConditionTrue,
+			expectedContentResolvable: metav1.ConditionFalse,
+			expectedMessage:           "parameters cannot be resolved",
+		},
+		{
+			name: "Inherited template and content",
+			spec: v1alpha1.ProfileSpec{
+				BaseProfileRef: &v1alpha1.ProfileReference{Name: "base-0"},
+				Parameters:     []v1alpha1.Parameter{{Name: "version", Value: ptr.To("1.5.0")}},
+			},
+			bases: []v1alpha1.ProfileSpec{{
+				IPXETemplate: "#!ipxe\nchain {{ .ignition }}",
+				AdditionalContent: []v1alpha1.AdditionalContent{{
+					Name:                "ignition",
+					Inline:              ptr.To("variant: fcos\nversion: {{ .Params.version }}\n"),
+					PostTransformations: []v1alpha1.Transformer{{ButaneToIgnition: true}},
+				}},
+			}},
+			expectedTemplateValid:     metav1.ConditionTrue,
+			expectedContentResolvable: metav1.ConditionTrue,
+			expectedButaneValid:       metav1.ConditionTrue,
+		},
+		{
+			name: "Base profile not found",
+			spec: v1alpha1.ProfileSpec{
+				BaseProfileRef: &v1alpha1.ProfileReference{Name: "missing"},
+			},
+			expectedTemplateValid:     metav1.ConditionFalse,
+			expectedContentResolvable: metav1.ConditionFalse,
+			expectedMessage:           `base profile not found: "missing"`,
+		},
+		{
+			name: "Inheritance cycle",
+			spec: v1alpha1.ProfileSpec{
+				BaseProfileRef: &v1alpha1.ProfileReference{Name: "base-0"},
+			},
+			bases: []v1alpha1.ProfileSpec{{
+				IPXETemplate:   "#!ipxe",
+				BaseProfileRef: &v1alpha1.ProfileReference{Name: "test-profile"},
+			}},
+			expectedTemplateValid:     metav1.ConditionFalse,
+			expectedContentResolvable: metav1.ConditionFalse,
+			expectedMessage:           "test-profile -> base-0 -> test-profile",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create scheme and register types
+			scheme := runtime.NewScheme()
+			err := v1alpha1.AddToScheme(scheme)
+			assert.NoError(t, err)
+
+			profile := &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test-profile",
+					Namespace:  "default",
+					Generation: 3,
+				},
+				Spec: tt.spec,
+			}
+
+			objects := []client.Object{profile}
+			for i, spec := range tt.bases {
+				objects = append(objects, &v1alpha1.Profile{
+					ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("base-%d", i), Namespace: "default"},
+					Spec:       spec,
+				})
+			}
+
+			// Create fake client with status subresource
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(objects...).
+				WithStatusSubresource(profile).
+				Build()
+
+			objectRefResolver := mockadapter.NewMockResolver(t)
+			objectRefResolver.EXPECT().
+				Resolve(mock.Anything, mock.Anything, mock.Anything).
+				Return(nil, assert.AnError).
+				Maybe()
+
+			// Create reconciler
+			reconciler := &ProfileReconciler{
+				Client: fakeClient,
+				Scheme: scheme,
+				Log:    logr.Discard(),
+				Mux: controller.NewResolveTransformerMux("", map[shapertypes.ResolverKind]adapter.Resolver{
+					shapertypes.InlineResolverKind:    adapter.NewInlineResolver(),
+					shapertypes.ObjectRefResolverKind: objectRefResolver,
+				}, map[shapertypes.TransformerKind]adapter.Transformer{
+					shapertypes.ButaneTransformerKind: adapter.NewButaneTransformer(),
+				}, nil, nil),
+			}
+
+			req := ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      profile.Name,
+					Namespace: profile.Namespace,
+				},
+			}
+
+			_, err = reconciler.Reconcile(context.Background(), req)
+			assert.NoError(t, err)
+
+			var updated v1alpha1.Profile
+			err = fakeClient.Get(context.Background(), req.NamespacedName, &updated)
+			assert.NoError(t, err)
+
+			// Verify conditions
+			messages := ""
+			for conditionType, expected := range map[string]metav1.ConditionStatus{
+				v1alpha1.ProfileConditionTemplateValid:     tt.expectedTemplateValid,
+				v1alpha1.ProfileConditionContentResolvable: tt.expectedContentResolvable,
+				v1alpha1.ProfileConditionButaneValid:       tt.expectedButaneValid,
+			} {
+				condition := meta.FindStatusCondition(updated.Status.Conditions, conditionType)
+				if expected == "" {
+					assert.Nil(t, condition, "Condition %s should not exist", conditionType)
+					continue
+				}
+
+				if assert.NotNil(t, condition, "Condition %s should exist", conditionType) {
+					assert.Equal(t, expected, condition.Status, "Condition %s", conditionType)
+					assert.Equal(t, updated.Generation, condition.ObservedGeneration)
+					messages += condition.Message + "\n"
+				}
+			}
+
+			assert.Contains(t, messages, tt.expectedMessage)
+		})
+	}
+}
+
+func TestProfileReconciler_MapBaseProfileToChildren(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := v1alpha1.AddToScheme(scheme)
+	assert.NoError(t, err)
+
+	newProfile := func(name, namespace, base string) *v1alpha1.Profile {
+		profile := &v1alpha1.Profile{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		if base != "" {
+			profile.Spec.BaseProfileRef = &v1alpha1.ProfileReference{Name: base}
+		}
+
+		return profile
+	}
+
+	base := newProfile("base", "default", "")
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			base,
+			newProfile("child", "default", "base"),
+			newProfile("grandchild", "default", "child"),
+			newProfile("unrelated", "default", ""),
+			newProfile("other-namespace", "other", "base"),
+			// A cycle must not loop forever.
+			newProfile("cycle-a", "default", "cycle-b"),
+			newProfile("cycle-b", "default", "cycle-a"),
+		).
+		Build()
+
+	reconciler := &ProfileReconciler{
+		Client: fakeClient,
+		Scheme: scheme,
+		Log:    logr.Discard(),
+	}
+
+	t.Run("Descendants", func(t *testing.T) {
+		actual := reconciler.MapBaseProfileToChildren(context.Background(), base)
+		assert.ElementsMatch(t, []ctrl.Request{
+			{NamespacedName: types.NamespacedName{Name: "child", Namespace: "default"}},
+			{NamespacedName: types.NamespacedName{Name: "grandchild", Namespace: "default"}},
+		}, actual)
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		actual := reconciler.MapBaseProfileToChildren(context.Background(), newProfile("cycle-a", "default", "cycle-b"))
+		assert.Equal(t, []ctrl.Request{
+			{NamespacedName: types.NamespacedName{Name: "cycle-b", Namespace: "default"}},
+		}, actual)
+	})
+}
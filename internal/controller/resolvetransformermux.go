@@ -18,51 +18,98 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/alexandremahdhaoui/shaper/internal/adapter"
 	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/internal/util/templateutil"
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
 	shaperAPIContentPath = "content"
+
+	// additionalContentField is the field of the template data holding the additional content.
+	additionalContentField = "AdditionalContent"
+
+	// DefaultBatchConcurrency is the default maximum number of content of a batch resolved and transformed
+	// concurrently.
+	DefaultBatchConcurrency = 4
+	// DefaultContentTimeout is the default maximum duration for resolving and transforming one content of a batch.
+	DefaultContentTimeout = 30 * time.Second
 )
 
 var (
 	ErrResolveAndTransform      = errors.New("resolve and transform content")
 	ErrResolveAndTransformBatch = errors.New("resolve and transform batch")
+	ErrResolveParameters        = errors.New("resolving parameters")
 
 	ErrResolverUnknown    = errors.New("unknown resolver")
 	ErrTransformerUnknown = errors.New("unknown transformer")
+
+	errTemplatingContent       = errors.New("templating content")
+	errContentFailed           = errors.New("content failed")
+	errUnknownContentReference = errors.New("reference to unknown content")
 )
 
 // ---------------------------------------------------- INTERFACES -------------------------------------------------- //
 
 // ResolveTransformerMux is an interface for resolving and transforming content.
 type ResolveTransformerMux interface {
-	// ResolveAndTransform resolves and transforms content.
-	ResolveAndTransform(ctx context.Context, content types.Content, selectors types.IPXESelectors) ([]byte, error)
+	// ResolveAndTransform resolves and transforms content. Inline and objectRef content is rendered as a template with
+	// the given data before being transformed. The additional content it references, i.e. the other content of its
+	// profile, are resolved first: their URL if they are exposed, else their value.
+	ResolveAndTransform(
+		ctx context.Context,
+		content types.Content,
+		additionalContent map[string]types.Content,
+		selectors types.IPXESelectors,
+		data types.TemplateData,
+	) ([]byte, error)
 
-	// ResolveAndTransformBatch resolves and transforms a batch of content.
+	// ResolveAndTransformBatch resolves and transforms a batch of content. Content referencing other content of the
+	// batch is rendered once they are resolved and transformed.
 	ResolveAndTransformBatch(
 		ctx context.Context,
 		batch map[string]types.Content,
 		selectors types.IPXESelectors,
+		data types.TemplateData,
 		options ...ResolveTransformBatchOption,
 	) (map[string][]byte, error)
 }
 
 // --------------------------------------------------- CONSTRUCTORS ------------------------------------------------- //
 
-// NewResolveTransformerMux returns a new ResolveTransformerMux.
+// NewResolveTransformerMux returns a new ResolveTransformerMux. The URLs of the exposed content are signed when the
+// signer is not nil, and the output of the resolvers and transformers is cached when the cache is not nil.
 func NewResolveTransformerMux(
 	shaperBaseURL string,
 	resolvers map[types.ResolverKind]adapter.Resolver,
 	transformers map[types.TransformerKind]adapter.Transformer,
+	signer ContentURLSigner,
+	cache ContentCache,
+	options ...ResolveTransformerMuxOption,
 ) ResolveTransformerMux {
+	opts := (&ResolveTransformerMuxOptions{
+		batchConcurrency: DefaultBatchConcurrency,
+		contentTimeout:   DefaultContentTimeout,
+	}).apply(options...)
+
 	return &resolveTransformerMux{
-		shaperBaseURL: shaperBaseURL,
-		resolvers:     resolvers,
-		transformers:  transformers,
+		shaperBaseURL:    shaperBaseURL,
+		resolvers:        resolvers,
+		transformers:     transformers,
+		signer:           signer,
+		cache:            cache,
+		batchConcurrency: opts.batchConcurrency,
+		contentTimeout:   opts.contentTimeout,
 	}
 }
 
@@ -73,38 +120,105 @@ type resolveTransformerMux struct {
 	transformers map[types.TransformerKind]adapter.Transformer
 
 	shaperBaseURL string
+	signer        ContentURLSigner
+	cache         ContentCache
+
+	batchConcurrency int
+	contentTimeout   time.Duration
 }
 
 func (r *resolveTransformerMux) ResolveAndTransform(
 	ctx context.Context,
 	content types.Content,
+	additionalContent map[string]types.Content,
 	selectors types.IPXESelectors,
+	data types.TemplateData,
 ) ([]byte, error) {
-	resolver, ok := r.resolvers[content.ResolverKind]
-	if !ok {
-		return nil, errors.Join(ErrResolverUnknown, ErrResolveAndTransform)
+	batch := maps.Clone(additionalContent)
+	if batch == nil {
+		batch = make(map[string]types.Content, 1)
 	}
 
-	out, err := resolver.Resolve(ctx, content, selectors)
+	batch[content.Name] = content
+
+	// The content is rendered even if it is exposed, while the content it references are returned as URLs.
+	opts := &ResolveTransformBatchOptions{returnURLInsteadOfResolveAndTransform: true}
+
+	out, err := r.resolveAndTransformBatch(ctx, batch, content.Name, selectors, data, opts)
 	if err != nil {
 		return nil, errors.Join(err, ErrResolveAndTransform)
 	}
 
+	return out[content.Name], nil
+}
+
+// resolve resolves the content with its resolver.
+func (r *resolveTransformerMux) resolve(
+	ctx context.Context,
+	content types.Content,
+	selectors types.IPXESelectors,
+	data types.TemplateData,
+) ([]byte, error) {
+	resolver, ok := r.resolvers[content.ResolverKind]
+	if !ok {
+		return nil, ErrResolverUnknown
+	}
+
+	return r.cached(ctx, resolveCacheStage, content, selectors, data, nil, func(ctx context.Context) ([]byte, error) {
+		return resolver.Resolve(ctx, content, selectors)
+	})
+}
+
+func (r *resolveTransformerMux) transform(
+	ctx context.Context,
+	content types.Content,
+	out []byte,
+	selectors types.IPXESelectors,
+) ([]byte, error) {
 	for _, transformerConfig := range content.PostTransformers {
 		transformer, ok := r.transformers[transformerConfig.Kind]
 		if !ok {
-			return nil, errors.Join(ErrTransformerUnknown, ErrResolveAndTransform)
+			return nil, ErrTransformerUnknown
 		}
 
+		var err error
+
 		out, err = transformer.Transform(ctx, transformerConfig, out, selectors)
 		if err != nil {
-			return nil, errors.Join(err, ErrResolveAndTransform)
+			return nil, err // TODO: wrap err
 		}
 	}
 
 	return out, nil
 }
 
+// cached returns the output of a stage of the content from the cache, or computes it with fn. The output is not cached
+// if the mux has no cache or the content has no spec hash.
+//
+// A cached computation is shared by the concurrent callers of the same key, hence it runs detached from the
+// cancellation of the caller starting it, within the content timeout: a machine closing its connection must not fail
+// the machines waiting on the same content.
+func (r *resolveTransformerMux) cached(
+	ctx context.Context,
+	stage string,
+	content types.Content,
+	selectors types.IPXESelectors,
+	data types.TemplateData,
+	input []byte,
+	fn func(ctx context.Context) ([]byte, error),
+) ([]byte, error) {
+	if r.cache == nil || content.SpecHash == "" {
+		return fn(ctx)
+	}
+
+	return r.cache.GetOrAdd(newContentCacheKey(stage, content, selectors, data.Profile, input), func() ([]byte, error) {
+		sharedCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.contentTimeout)
+		defer cancel()
+
+		return fn(sharedCtx)
+	})
+}
+
 // -------------------------------------------------- ResolveAndTransformBatch -------------------------------------- //
 
 // TODO: ResolveAndTransformBatch should return the URL corresponding to the ConfigID of the content if the content has
@@ -113,38 +227,356 @@ func (r *resolveTransformerMux) ResolveAndTransform(
 //          types.Content as an argument and fully compute the Resolve/Transformation.
 //      !!! Then ResolveAndTransformBatch will only resolve and transform if types.Content.ExposedConfigID != true.
 
+// ResolveAndTransformBatch resolves and transforms the content of the batch concurrently, each within the content
+// timeout of the mux. The first content failing cancels the others, and the returned error names it.
 func (r *resolveTransformerMux) ResolveAndTransformBatch(
 	ctx context.Context,
 	batch map[string]types.Content,
 	selectors types.IPXESelectors,
+	data types.TemplateData,
 	options ...ResolveTransformBatchOption,
 ) (map[string][]byte, error) {
-	opts := new(ResolveTransformBatchOptions).apply(options...)
+	out, err := r.resolveAndTransformBatch(ctx, batch, "", selectors, data,
+		new(ResolveTransformBatchOptions).apply(options...))
+	if err != nil {
+		return nil, errors.Join(err, ErrResolveAndTransformBatch)
+	}
 
-	output := make(map[string][]byte)
+	return out, nil
+}
 
-	for name, cont := range batch {
-		if opts.returnURLInsteadOfResolveAndTransform && cont.Exposed {
-			output[name] = []byte(fmt.Sprintf(
-				"%s/%s/%s", r.shaperBaseURL, shaperAPIContentPath, cont.ExposedUUID.String()))
-			continue
-		}
+// resolveAndTransformBatch resolves the content of the batch, then the content they reference, and renders and
+// transforms them in the order of their references: a content is rendered with the output of the content it
+// references. It fails if content reference each other in a cycle. When target is not empty, only the target and the
+// content it references are resolved, and the target is rendered even if it is exposed.
+func (r *resolveTransformerMux) resolveAndTransformBatch(
+	ctx context.Context,
+	batch map[string]types.Content,
+	target string,
+	selectors types.IPXESelectors,
+	data types.TemplateData,
+	opts *ResolveTransformBatchOptions,
+) (map[string][]byte, error) {
+	// The base URL resolved for the rendered profile takes precedence over the one of the mux.
+	baseURL := data.BaseURL
+	if baseURL == "" {
+		baseURL = r.shaperBaseURL
+	}
+
+	var (
+		mu           sync.Mutex
+		output       = make(map[string][]byte, len(batch))
+		templates    = make(map[string]*template.Template)
+		dependencies = make(map[string][]string)
+	)
+
+	pending := slices.Sorted(maps.Keys(batch))
+	if target != "" {
+		pending = []string{target}
+	}
+
+	seen := make(map[string]struct{}, len(batch))
+	for _, name := range pending {
+		seen[name] = struct{}{}
+	}
+
+	// 1. Resolve the content, then the content they reference until every referenced content is resolved. Exposed
+	//    content is returned as a URL and references nothing.
+	for len(pending) > 0 {
+		err := r.forEach(ctx, pending, func(ctx context.Context, name string) error {
+			cont := batch[name]
+
+			if opts.returnURLInsteadOfResolveAndTransform && cont.Exposed && name != target {
+				u, err := r.exposedContentURL(ctx, baseURL, cont.ExposedUUID, selectors)
+				if err != nil {
+					return err // TODO: wrap err
+				}
 
-		result, err := r.ResolveAndTransform(ctx, cont, selectors)
+				mu.Lock()
+				defer mu.Unlock()
+
+				output[name] = []byte(u)
+
+				return nil
+			}
+
+			out, err := r.resolve(ctx, cont, selectors, data)
+			if err != nil {
+				return err // TODO: wrap err
+			}
+
+			var (
+				tpl  *template.Template
+				refs []string
+			)
+
+			if !opts.skipTemplating && isTemplatedContent(cont) {
+				if tpl, err = templateutil.Parse(name, string(out)); err != nil {
+					return errors.Join(err, errTemplatingContent)
+				}
+
+				keys, _ := templateutil.References(tpl, additionalContentField)
+
+				// AdditionalContent only holds the referenced content: a reference to unknown content fails rather
+				// than rendering "<no value>". missingkey=error would also fail on optional parameters and labels.
+				for _, key := range slices.Sorted(maps.Keys(keys)) {
+					if _, ok := batch[key]; !ok && key != templateutil.AllReferences {
+						return errors.Join(fmt.Errorf("%w: %q", errUnknownContentReference, key), errTemplatingContent)
+					}
+				}
+
+				refs = templateutil.ResolveReferences(keys, batch, name)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			output[name] = out
+			templates[name] = tpl
+			dependencies[name] = refs
+
+			return nil
+		})
 		if err != nil {
-			return nil, errors.Join(err, ErrResolveAndTransformBatch)
+			return nil, err // TODO: wrap err
+		}
+
+		var next []string
+
+		for _, name := range pending {
+			for _, ref := range dependencies[name] {
+				if _, ok := seen[ref]; !ok {
+					seen[ref] = struct{}{}
+					next = append(next, ref)
+				}
+			}
 		}
 
-		output[name] = result
+		pending = next
+	}
+
+	// 2. Render and transform the resolved content once the content they reference are.
+	levels, err := templateutil.SortDependencies(dependencies)
+	if err != nil {
+		return nil, err // TODO: wrap err
+	}
+
+	for _, level := range levels {
+		err := r.forEach(ctx, level, func(ctx context.Context, name string) error {
+			cont := batch[name]
+
+			mu.Lock()
+			out := output[name]
+			additionalContent := make(map[string]string, len(dependencies[name]))
+			for _, ref := range dependencies[name] {
+				additionalContent[ref] = string(output[ref])
+			}
+			mu.Unlock()
+
+			var err error
+
+			if tpl := templates[name]; tpl != nil {
+				if out, err = templateContent(ctx, tpl, data, additionalContent); err != nil {
+					return err // TODO: wrap err
+				}
+			}
+
+			if len(cont.PostTransformers) > 0 {
+				// The transformed content is cached by its input, so machines rendering the same content share it.
+				out, err = r.cached(ctx, transformCacheStage, cont, selectors, data, out,
+					func(ctx context.Context) ([]byte, error) {
+						return r.transform(ctx, cont, out, selectors)
+					})
+				if err != nil {
+					return err // TODO: wrap err
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			output[name] = out
+
+			return nil
+		})
+		if err != nil {
+			return nil, err // TODO: wrap err
+		}
 	}
 
 	return output, nil
 }
 
+// forEach calls fn for each name concurrently, with at most batchConcurrency calls at once, each within the content
+// timeout. The first call failing cancels the others, and the returned error names its content.
+func (r *resolveTransformerMux) forEach(
+	ctx context.Context,
+	names []string,
+	fn func(ctx context.Context, name string) error,
+) error {
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(r.batchConcurrency)
+
+	for _, name := range names {
+		g.Go(func() error {
+			// Content waiting for a worker is not resolved once another content failed.
+			if err := gctx.Err(); err != nil {
+				return err // TODO: wrap err
+			}
+
+			contentCtx, cancel := context.WithTimeout(gctx, r.contentTimeout)
+			defer cancel()
+
+			if err := fn(contentCtx, name); err != nil {
+				return errors.Join(err, fmt.Errorf("%w: %q", errContentFailed, name))
+			}
+
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+// exposedContentURL returns the URL of an exposed content. The URL carries the buildarch and the UUID of the machine,
+// so the content is resolved and transformed for the machine that was served the iPXE script. When a signer is
+// configured, the URL also carries its expiry and signature, which bind it to the machine. The URL carries the token
+// that authenticated the request, if any.
+func (r *resolveTransformerMux) exposedContentURL(
+	ctx context.Context,
+	baseURL string,
+	contentID uuid.UUID,
+	selectors types.IPXESelectors,
+) (string, error) {
+	query := url.Values{}
+	query.Set("buildarch", selectors.Buildarch)
+
+	if selectors.UUID != uuid.Nil {
+		query.Set("uuid", selectors.UUID.String())
+	}
+
+	if r.signer != nil {
+		signature, err := r.signer.Sign(ctx, contentID, selectors)
+		if err != nil {
+			return "", err // TODO: wrap err
+		}
+
+		query.Set("expires", strconv.FormatInt(signature.Expires.Unix(), 10))
+		query.Set("kid", signature.KeyID)
+		query.Set("signature", signature.Signature)
+	}
+
+	if token := AuthTokenFromContext(ctx); token != "" {
+		query.Set("token", token)
+	}
+
+	return fmt.Sprintf("%s/%s/%s?%s", baseURL, shaperAPIContentPath, contentID.String(), query.Encode()), nil
+}
+
+// -------------------------------------------------- PARAMETERS ---------------------------------------------------- //
+
+// ResolveParameters resolves the values of the parameters available to templates as `.Params`. Parameters are merged in
+// order: a parameter overrides the parameters of the same name given before it, e.g. the parameters of an assignment
+// override the ones of its profile. Parameters are not rendered as templates.
+func ResolveParameters(
+	ctx context.Context,
+	mux ResolveTransformerMux,
+	selectors types.IPXESelectors,
+	parameters ...map[string]types.Content,
+) (map[string]string, error) {
+	merged := make(map[string]types.Content)
+	for _, params := range parameters {
+		maps.Copy(merged, params)
+	}
+
+	if len(merged) == 0 {
+		return nil, nil
+	}
+
+	resolved, err := mux.ResolveAndTransformBatch(ctx, merged, selectors, types.TemplateData{}, SkipTemplating)
+	if err != nil {
+		return nil, errors.Join(err, ErrResolveParameters)
+	}
+
+	out := make(map[string]string, len(resolved))
+	for name, value := range resolved {
+		out[name] = string(value)
+	}
+
+	return out, nil
+}
+
+// -------------------------------------------------- TEMPLATING ---------------------------------------------------- //
+
+// isTemplatedContent returns true if the content is rendered as a template. Content returned by webhooks is not
+// templated: the webhook already receives the attributes of the machine.
+func isTemplatedContent(content types.Content) bool {
+	return content.ResolverKind == types.InlineResolverKind || content.ResolverKind == types.ObjectRefResolverKind
+}
+
+// templateContent renders the content as a template. AdditionalContent only holds the content it references.
+func templateContent(
+	ctx context.Context,
+	tpl *template.Template,
+	data types.TemplateData,
+	additionalContent map[string]string,
+) ([]byte, error) {
+	data.AdditionalContent = additionalContent
+
+	out, err := templateutil.Execute(ctx, tpl, data)
+	if err != nil {
+		return nil, errors.Join(err, errTemplatingContent)
+	}
+
+	return out, nil
+}
+
+// -------------------------------------------------- OPTIONS ------------------------------------------------------- //
+
+type (
+	// ResolveTransformerMuxOptions contains options for the ResolveTransformerMux.
+	ResolveTransformerMuxOptions struct {
+		batchConcurrency int
+		contentTimeout   time.Duration
+	}
+
+	// ResolveTransformerMuxOption is a function that sets an option of the ResolveTransformerMux.
+	ResolveTransformerMuxOption func(options *ResolveTransformerMuxOptions)
+)
+
+func (o *ResolveTransformerMuxOptions) apply(options ...ResolveTransformerMuxOption) *ResolveTransformerMuxOptions {
+	for _, f := range options {
+		f(o)
+	}
+
+	return o
+}
+
+// WithBatchConcurrency sets the maximum number of content of a batch resolved and transformed concurrently. It
+// defaults to DefaultBatchConcurrency; values lower than 1 are ignored.
+func WithBatchConcurrency(n int) ResolveTransformerMuxOption {
+	return func(options *ResolveTransformerMuxOptions) {
+		if n > 0 {
+			options.batchConcurrency = n
+		}
+	}
+}
+
+// WithContentTimeout sets the maximum duration for resolving and transforming one content of a batch. It defaults to
+// DefaultContentTimeout; non-positive values are ignored.
+func WithContentTimeout(d time.Duration) ResolveTransformerMuxOption {
+	return func(options *ResolveTransformerMuxOptions) {
+		if d > 0 {
+			options.contentTimeout = d
+		}
+	}
+}
+
 type (
 	// ResolveTransformBatchOptions contains options for resolving and transforming a batch of content.
 	ResolveTransformBatchOptions struct {
 		returnURLInsteadOfResolveAndTransform bool
+		skipTemplating                        bool
 	}
 
 	// ResolveTransformBatchOption is a function that sets an option for resolving and transforming a batch of content.
@@ -164,3 +596,9 @@ func (o *ResolveTransformBatchOptions) apply(options ...ResolveTransformBatchOpt
 func ReturnExposedContentURL(options *ResolveTransformBatchOptions) { //nolint:revive
 	options.returnURLInsteadOfResolveAndTransform = true
 }
+
+// SkipTemplating will ensure resolvetransformermux.ResolveAndTransformBatch does not render the content as a template,
+// e.g. when resolving the parameters of a profile.
+func SkipTemplating(options *ResolveTransformBatchOptions) { //nolint:revive
+	options.skipTemplating = true
+}
@@ -19,7 +19,9 @@ package controller_test
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"k8s.io/utils/ptr"
 
@@ -27,6 +29,8 @@ import (
 	"github.com/alexandremahdhaoui/shaper/internal/controller"
 	"github.com/alexandremahdhaoui/shaper/internal/types"
 	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockadapter"
+	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockcontroller"
+	"github.com/alexandremahdhaoui/shaper/internal/util/templateutil"
 	"github.com/alexandremahdhaoui/shaper/internal/util/testutil"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -37,6 +41,7 @@ func TestResolveTransformerMux(t *testing.T) {
 	var (
 		ctx            context.Context
 		inputSelectors types.IPXESelectors
+		inputData      types.TemplateData
 		inputBatch     map[string]types.Content
 
 		inlineResolver    *mockadapter.MockResolver
@@ -63,6 +68,13 @@ func TestResolveTransformerMux(t *testing.T) {
 			Buildarch: "arm64",
 		}
 
+		inputData = types.TemplateData{
+			Machine:    inputSelectors,
+			Assignment: types.AssignmentMetadata{Name: "an-assignment", Labels: map[string]string{"site": "dc1"}},
+			Profile:    types.ProfileMetadata{Name: "a-profile"},
+			BaseURL:    baseURL,
+		}
+
 		inputBatch = make(map[string]types.Content)
 
 		inlineResolver = mockadapter.NewMockResolver(t)
@@ -83,7 +95,7 @@ func TestResolveTransformerMux(t *testing.T) {
 			types.WebhookTransformerKind: webhookTransformer,
 		}
 
-		mux = controller.NewResolveTransformerMux(baseURL, resolvers, transformers)
+		mux = controller.NewResolveTransformerMux(baseURL, resolvers, transformers, nil, nil)
 
 		return func() {
 			t.Helper()
@@ -131,28 +143,107 @@ func TestResolveTransformerMux(t *testing.T) {
 						expected[inputContent.Name] = expectedTransformationResult1
 
 						resolvers[kind].(*mockadapter.MockResolver).EXPECT().
-							Resolve(ctx, inputContent, inputSelectors).
+							Resolve(mock.Anything, inputContent, inputSelectors).
 							Return(expectedResolverResult, nil).
 							Once()
 
 						butaneTransformer.EXPECT().
-							Transform(ctx, inputContent.PostTransformers[0], expectedResolverResult, inputSelectors).
+							Transform(mock.Anything, inputContent.PostTransformers[0], expectedResolverResult, inputSelectors).
 							Return(expectedTransformationResult0, nil).
 							Once()
 
 						webhookTransformer.EXPECT().
-							Transform(ctx, inputContent.PostTransformers[1], expectedTransformationResult0, inputSelectors).
+							Transform(mock.Anything, inputContent.PostTransformers[1], expectedTransformationResult0, inputSelectors).
 							Return(expectedTransformationResult1, nil).
 							Once()
 					}
 
-					actual, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors)
+					actual, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData)
 					assert.NoError(t, err)
 					assert.Equal(t, expected, actual)
 				})
 			})
 		}
 
+		t.Run("ReturnExposedContentURL", func(t *testing.T) {
+			defer setup(t)()
+
+			id := uuid.New()
+			inputBatch["config"] = types.Content{Name: "config", Exposed: true, ExposedUUID: id}
+
+			t.Run("Base URL of the data", func(t *testing.T) {
+				data := inputData
+				data.BaseURL = "https://boot.example.com/shaper"
+
+				actual, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, data,
+					controller.ReturnExposedContentURL)
+				assert.NoError(t, err)
+				assert.Equal(t, fmt.Sprintf("https://boot.example.com/shaper/content/%s?buildarch=arm64&uuid=%s",
+					id, inputSelectors.UUID), string(actual["config"]))
+			})
+
+			t.Run("Base URL of the mux", func(t *testing.T) {
+				actual, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, types.TemplateData{},
+					controller.ReturnExposedContentURL)
+				assert.NoError(t, err)
+				assert.Equal(t, fmt.Sprintf("%s/content/%s?buildarch=arm64&uuid=%s", baseURL, id, inputSelectors.UUID),
+					string(actual["config"]))
+			})
+
+			t.Run("Without machine UUID", func(t *testing.T) {
+				actual, err := mux.ResolveAndTransformBatch(ctx, inputBatch, types.IPXESelectors{Buildarch: "x86_64"},
+					inputData, controller.ReturnExposedContentURL)
+				assert.NoError(t, err)
+				assert.Equal(t, fmt.Sprintf("%s/content/%s?buildarch=x86_64", baseURL, id), string(actual["config"]))
+			})
+
+			t.Run("Signed", func(t *testing.T) {
+				signer := mockcontroller.NewMockContentURLSigner(t)
+				signer.EXPECT().
+					Sign(mock.Anything, id, inputSelectors).
+					Return(types.ContentURLSignature{
+						Expires:   time.Unix(1700000000, 0),
+						KeyID:     "2024-01",
+						Signature: "c2lnbmF0dXJl",
+					}, nil).
+					Once()
+
+				mux := controller.NewResolveTransformerMux(baseURL, resolvers, transformers, signer, nil)
+
+				actual, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData,
+					controller.ReturnExposedContentURL)
+				assert.NoError(t, err)
+				assert.Equal(t, fmt.Sprintf(
+					"%s/content/%s?buildarch=arm64&expires=1700000000&kid=2024-01&signature=c2lnbmF0dXJl&uuid=%s",
+					baseURL, id, inputSelectors.UUID), string(actual["config"]))
+			})
+
+			t.Run("Token", func(t *testing.T) {
+				mux := controller.NewResolveTransformerMux(baseURL, resolvers, transformers, nil, nil)
+
+				actual, err := mux.ResolveAndTransformBatch(controller.WithAuthToken(ctx, "a token"), inputBatch,
+					inputSelectors, inputData, controller.ReturnExposedContentURL)
+				assert.NoError(t, err)
+				assert.Equal(t, fmt.Sprintf("%s/content/%s?buildarch=arm64&token=a+token&uuid=%s",
+					baseURL, id, inputSelectors.UUID), string(actual["config"]))
+			})
+
+			t.Run("Signing error", func(t *testing.T) {
+				signer := mockcontroller.NewMockContentURLSigner(t)
+				signer.EXPECT().
+					Sign(mock.Anything, id, inputSelectors).
+					Return(types.ContentURLSignature{}, assert.AnError).
+					Once()
+
+				mux := controller.NewResolveTransformerMux(baseURL, resolvers, transformers, signer, nil)
+
+				_, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData,
+					controller.ReturnExposedContentURL)
+				assert.ErrorIs(t, err, assert.AnError)
+				assert.ErrorIs(t, err, controller.ErrResolveAndTransformBatch)
+			})
+		})
+
 		t.Run("Failure", func(t *testing.T) {
 			t.Run("unknown resolver", func(t *testing.T) {
 				defer setup(t)()
@@ -162,7 +253,7 @@ func TestResolveTransformerMux(t *testing.T) {
 					ResolverKind: -1,
 				}
 
-				_, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors)
+				_, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData)
 				assert.ErrorIs(t, err, controller.ErrResolverUnknown)
 			})
 
@@ -182,7 +273,7 @@ func TestResolveTransformerMux(t *testing.T) {
 					Return([]byte("something"), nil).
 					Once()
 
-				_, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors)
+				_, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData)
 				assert.ErrorIs(t, err, controller.ErrTransformerUnknown)
 			})
 
@@ -199,7 +290,7 @@ func TestResolveTransformerMux(t *testing.T) {
 					Return(nil, assert.AnError).
 					Once()
 
-				_, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors)
+				_, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData)
 				assert.ErrorIs(t, err, assert.AnError)
 			})
 
@@ -224,9 +315,456 @@ func TestResolveTransformerMux(t *testing.T) {
 					Return(nil, assert.AnError).
 					Once()
 
-				_, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors)
+				_, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData)
+				assert.ErrorIs(t, err, assert.AnError)
+			})
+
+			t.Run("error names the content and cancels the others", func(t *testing.T) {
+				defer setup(t)()
+
+				inputBatch["failing"] = types.Content{Name: "failing", ResolverKind: types.InlineResolverKind}
+				inputBatch["slow"] = types.Content{Name: "slow", ResolverKind: types.WebhookResolverKind}
+
+				// The failing content fails only once the slow one started, which then waits to be canceled.
+				started := make(chan struct{})
+
+				inlineResolver.EXPECT().
+					Resolve(mock.Anything, inputBatch["failing"], inputSelectors).
+					RunAndReturn(func(context.Context, types.Content, types.IPXESelectors) ([]byte, error) {
+						<-started
+						return nil, assert.AnError
+					}).
+					Once()
+
+				webhookResolver.EXPECT().
+					Resolve(mock.Anything, inputBatch["slow"], inputSelectors).
+					RunAndReturn(func(ctx context.Context, _ types.Content, _ types.IPXESelectors) ([]byte, error) {
+						close(started)
+						<-ctx.Done()
+
+						return nil, ctx.Err()
+					}).
+					Once()
+
+				_, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData)
 				assert.ErrorIs(t, err, assert.AnError)
+				assert.ErrorIs(t, err, controller.ErrResolveAndTransformBatch)
+				assert.Contains(t, err.Error(), `"failing"`)
+			})
+
+			t.Run("content timeout", func(t *testing.T) {
+				defer setup(t)()
+
+				mux = controller.NewResolveTransformerMux(baseURL, resolvers, transformers, nil, nil,
+					controller.WithContentTimeout(time.Millisecond))
+
+				inputBatch["slow"] = types.Content{Name: "slow", ResolverKind: types.WebhookResolverKind}
+
+				webhookResolver.EXPECT().
+					Resolve(mock.Anything, inputBatch["slow"], inputSelectors).
+					RunAndReturn(func(ctx context.Context, _ types.Content, _ types.IPXESelectors) ([]byte, error) {
+						<-ctx.Done()
+
+						return nil, ctx.Err()
+					}).
+					Once()
+
+				_, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData)
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+				assert.Contains(t, err.Error(), `"slow"`)
+			})
+		})
+
+		t.Run("Concurrency", func(t *testing.T) {
+			defer setup(t)()
+
+			const n = 3
+
+			mux = controller.NewResolveTransformerMux(baseURL, resolvers, transformers, nil, nil,
+				controller.WithBatchConcurrency(n))
+
+			// Each resolution waits for all of them to start, so they only complete if they run concurrently.
+			var started sync.WaitGroup
+			started.Add(n)
+
+			expected := make(map[string][]byte, n)
+			for i := range n {
+				name := fmt.Sprintf("content-%d", i)
+				inputBatch[name] = types.Content{Name: name, ResolverKind: types.WebhookResolverKind}
+				expected[name] = []byte(name)
+			}
+
+			webhookResolver.EXPECT().
+				Resolve(mock.Anything, mock.Anything, inputSelectors).
+				RunAndReturn(func(_ context.Context, content types.Content, _ types.IPXESelectors) ([]byte, error) {
+					started.Done()
+					started.Wait()
+
+					return []byte(content.Name), nil
+				}).
+				Times(n)
+
+			actual, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+
+		t.Run("References", func(t *testing.T) {
+			t.Run("Success", func(t *testing.T) {
+				defer setup(t)()
+
+				id := uuid.New()
+				inputBatch["script"] = types.Content{Name: "script", Exposed: true, ExposedUUID: id}
+				inputBatch["hostname"] = types.Content{Name: "hostname", ResolverKind: types.InlineResolverKind}
+				inputBatch["cloudinit"] = types.Content{Name: "cloudinit", ResolverKind: types.InlineResolverKind}
+
+				inlineResolver.EXPECT().
+					Resolve(mock.Anything, inputBatch["hostname"], inputSelectors).
+					Return([]byte("{{ .Profile.Name }}-host"), nil).
+					Once()
+
+				inlineResolver.EXPECT().
+					Resolve(mock.Anything, inputBatch["cloudinit"], inputSelectors).
+					Return([]byte(`{{ .AdditionalContent.hostname }} {{ index .AdditionalContent "script" }}`), nil).
+					Once()
+
+				scriptURL := fmt.Sprintf("%s/content/%s?buildarch=arm64&uuid=%s", baseURL, id, inputSelectors.UUID)
+
+				actual, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData,
+					controller.ReturnExposedContentURL)
+				assert.NoError(t, err)
+				assert.Equal(t, map[string][]byte{
+					"script":    []byte(scriptURL),
+					"hostname":  []byte("a-profile-host"),
+					"cloudinit": []byte("a-profile-host " + scriptURL),
+				}, actual)
 			})
+
+			t.Run("Root variable and whole map", func(t *testing.T) {
+				defer setup(t)()
+
+				inputBatch["hostname"] = types.Content{Name: "hostname", ResolverKind: types.InlineResolverKind}
+				inputBatch["domain"] = types.Content{Name: "domain", ResolverKind: types.InlineResolverKind}
+				inputBatch["fqdn"] = types.Content{Name: "fqdn", ResolverKind: types.InlineResolverKind}
+				inputBatch["motd"] = types.Content{Name: "motd", ResolverKind: types.InlineResolverKind}
+
+				inlineResolver.EXPECT().
+					Resolve(mock.Anything, inputBatch["hostname"], inputSelectors).
+					Return([]byte("{{ .Profile.Name }}-host"), nil).
+					Once()
+
+				inlineResolver.EXPECT().
+					Resolve(mock.Anything, inputBatch["domain"], inputSelectors).
+					Return([]byte("example.com"), nil).
+					Once()
+
+				// The content referencing `$.AdditionalContent.KEY` depends on KEY; the one using the map as a whole
+				// depends on every other content.
+				inlineResolver.EXPECT().
+					Resolve(mock.Anything, inputBatch["fqdn"], inputSelectors).
+					Return([]byte(`{{ with .Profile }}{{ $.AdditionalContent.hostname }}.{{ end }}`+
+						`{{- index $.AdditionalContent "domain" }}`), nil).
+					Once()
+
+				inlineResolver.EXPECT().
+					Resolve(mock.Anything, inputBatch["motd"], inputSelectors).
+					Return([]byte(`{{ with .AdditionalContent }}welcome to {{ .fqdn }}{{ end }}`), nil).
+					Once()
+
+				actual, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData)
+				assert.NoError(t, err)
+				assert.Equal(t, map[string][]byte{
+					"hostname": []byte("a-profile-host"),
+					"domain":   []byte("example.com"),
+					"fqdn":     []byte("a-profile-host.example.com"),
+					"motd":     []byte("welcome to a-profile-host.example.com"),
+				}, actual)
+			})
+
+			t.Run("Unknown content", func(t *testing.T) {
+				defer setup(t)()
+
+				inputBatch["a"] = types.Content{Name: "a", ResolverKind: types.ObjectRefResolverKind}
+
+				objectRefResolver.EXPECT().
+					Resolve(mock.Anything, inputBatch["a"], inputSelectors).
+					Return([]byte("{{ .AdditionalContent.unknown }}"), nil).
+					Once()
+
+				_, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData)
+				assert.ErrorIs(t, err, controller.ErrResolveAndTransformBatch)
+				assert.ErrorContains(t, err, `"unknown"`)
+			})
+
+			t.Run("Cycle", func(t *testing.T) {
+				defer setup(t)()
+
+				inputBatch["a"] = types.Content{Name: "a", ResolverKind: types.InlineResolverKind}
+				inputBatch["b"] = types.Content{Name: "b", ResolverKind: types.ObjectRefResolverKind}
+
+				inlineResolver.EXPECT().
+					Resolve(mock.Anything, inputBatch["a"], inputSelectors).
+					Return([]byte("{{ .AdditionalContent.b }}"), nil).
+					Once()
+
+				objectRefResolver.EXPECT().
+					Resolve(mock.Anything, inputBatch["b"], inputSelectors).
+					Return([]byte("{{ .AdditionalContent.a }}"), nil).
+					Once()
+
+				_, err := mux.ResolveAndTransformBatch(ctx, inputBatch, inputSelectors, inputData)
+				assert.ErrorIs(t, err, templateutil.ErrReferenceCycle)
+				assert.ErrorIs(t, err, controller.ErrResolveAndTransformBatch)
+				assert.ErrorContains(t, err, "a -> b -> a")
+			})
+		})
+	})
+
+	t.Run("ResolveAndTransform", func(t *testing.T) {
+		t.Run("References", func(t *testing.T) {
+			defer setup(t)()
+
+			id := uuid.New()
+			additionalContent := map[string]types.Content{
+				"script":    {Name: "script", Exposed: true, ExposedUUID: id},
+				"cloudinit": {Name: "cloudinit", Exposed: true, ExposedUUID: uuid.New(), ResolverKind: types.InlineResolverKind},
+				"unused":    {Name: "unused", ResolverKind: types.WebhookResolverKind},
+			}
+
+			// The exposed content is rendered, while the exposed content it references is returned as a URL. Content
+			// it does not reference is not resolved.
+			inlineResolver.EXPECT().
+				Resolve(mock.Anything, additionalContent["cloudinit"], inputSelectors).
+				Return([]byte("runcmd: [curl {{ .AdditionalContent.script }}]"), nil).
+				Once()
+
+			actual, err := mux.ResolveAndTransform(ctx, additionalContent["cloudinit"], additionalContent, inputSelectors,
+				inputData)
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("runcmd: [curl %s/content/%s?buildarch=arm64&uuid=%s]",
+				baseURL, id, inputSelectors.UUID), string(actual))
+		})
+
+		const tpl = "{{ .Machine.Buildarch }} {{ .Assignment.Name }} {{ .Assignment.Labels.site }} " +
+			"{{ .Profile.Name }} {{ .BaseURL }}"
+
+		for _, tt := range []struct {
+			Name     string
+			Kind     types.ResolverKind
+			Resolved string
+			Expected string
+			Err      bool
+		}{
+			{
+				Name:     "inline content is templated",
+				Kind:     types.InlineResolverKind,
+				Resolved: tpl,
+				Expected: "arm64 an-assignment dc1 a-profile " + baseURL,
+			},
+			{
+				Name:     "objectRef content is templated",
+				Kind:     types.ObjectRefResolverKind,
+				Resolved: tpl,
+				Expected: "arm64 an-assignment dc1 a-profile " + baseURL,
+			},
+			{
+				Name:     "webhook content is not templated",
+				Kind:     types.WebhookResolverKind,
+				Resolved: tpl,
+				Expected: tpl,
+			},
+			{
+				Name:     "invalid template",
+				Kind:     types.InlineResolverKind,
+				Resolved: "{{ .Machine.UUID",
+				Err:      true,
+			},
+		} {
+			t.Run(tt.Name, func(t *testing.T) {
+				defer setup(t)()
+
+				inputContent := types.Content{Name: "content", ResolverKind: tt.Kind}
+
+				resolvers[tt.Kind].(*mockadapter.MockResolver).EXPECT().
+					Resolve(mock.Anything, inputContent, inputSelectors).
+					Return([]byte(tt.Resolved), nil).
+					Once()
+
+				actual, err := mux.ResolveAndTransform(ctx, inputContent, nil, inputSelectors, inputData)
+				if tt.Err {
+					assert.ErrorIs(t, err, controller.ErrResolveAndTransform)
+					return
+				}
+
+				assert.NoError(t, err)
+				assert.Equal(t, tt.Expected, string(actual))
+			})
+		}
+	})
+
+	t.Run("ResolveParameters", func(t *testing.T) {
+		defer setup(t)()
+
+		profileParams := map[string]types.Content{
+			"channel": {Name: "channel", Inline: "stable"},
+			"ntp":     {Name: "ntp", ResolverKind: types.ObjectRefResolverKind},
+		}
+		assignmentParams := map[string]types.Content{
+			"channel": {Name: "channel", Inline: "{{ .Machine.Buildarch }}"},
+		}
+
+		// Parameters are not templated.
+		inlineResolver.EXPECT().
+			Resolve(mock.Anything, assignmentParams["channel"], inputSelectors).
+			Return([]byte("{{ .Machine.Buildarch }}"), nil).
+			Once()
+
+		objectRefResolver.EXPECT().
+			Resolve(mock.Anything, profileParams["ntp"], inputSelectors).
+			Return([]byte("pool.ntp.org"), nil).
+			Once()
+
+		actual, err := controller.ResolveParameters(ctx, mux, inputSelectors, profileParams, assignmentParams)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"channel": "{{ .Machine.Buildarch }}", "ntp": "pool.ntp.org"}, actual)
+
+		t.Run("No parameters", func(t *testing.T) {
+			actual, err := controller.ResolveParameters(ctx, mux, inputSelectors, nil, nil)
+			assert.NoError(t, err)
+			assert.Nil(t, actual)
+		})
+	})
+
+	t.Run("Params are available to content templates", func(t *testing.T) {
+		defer setup(t)()
+
+		inputContent := types.Content{Name: "content", ResolverKind: types.InlineResolverKind}
+		inputData.Params = map[string]string{"channel": "beta"}
+
+		inlineResolver.EXPECT().
+			Resolve(mock.Anything, inputContent, inputSelectors).
+			Return([]byte(`{{ .Params.channel }} {{ .Params.missing | default "none" }}`), nil).
+			Once()
+
+		actual, err := mux.ResolveAndTransform(ctx, inputContent, nil, inputSelectors, inputData)
+		assert.NoError(t, err)
+		assert.Equal(t, "beta none", string(actual))
+	})
+
+	t.Run("Cache", func(t *testing.T) {
+		var cache controller.ContentCache
+
+		setupCache := func(t *testing.T) func() {
+			t.Helper()
+
+			teardown := setup(t)
+			cache = controller.NewContentCache(10, time.Hour)
+			mux = controller.NewResolveTransformerMux(baseURL, resolvers, transformers, nil, cache)
+
+			return teardown
+		}
+
+		otherSelectors := types.IPXESelectors{UUID: uuid.New(), Buildarch: "arm64"}
+
+		t.Run("Shared by machines", func(t *testing.T) {
+			defer setupCache(t)()
+
+			content := types.Content{
+				Name:             "config",
+				SpecHash:         "a-hash",
+				ResolverKind:     types.ObjectRefResolverKind,
+				ObjectRef:        &types.ObjectRef{Version: "v1", Resource: "configmaps", Namespace: "shaper", Name: "a-cm"},
+				PostTransformers: []types.TransformerConfig{{Kind: types.ButaneTransformerKind}},
+			}
+
+			objectRefResolver.EXPECT().
+				Resolve(mock.Anything, content, mock.Anything).
+				Return([]byte("variant: flatcar"), nil).
+				Twice()
+
+			butaneTransformer.EXPECT().
+				Transform(mock.Anything, content.PostTransformers[0], []byte("variant: flatcar"), mock.Anything).
+				Return([]byte("ignition"), nil).
+				Once()
+
+			for _, selectors := range []types.IPXESelectors{inputSelectors, otherSelectors, inputSelectors} {
+				actual, err := mux.ResolveAndTransform(ctx, content, nil, selectors, types.TemplateData{Machine: selectors})
+				assert.NoError(t, err)
+				assert.Equal(t, []byte("ignition"), actual)
+			}
+
+			// The ConfigMap is resolved again once changed; its transformation is cached as long as it is unchanged.
+			cache.InvalidateObject("", "configmaps", "shaper", "a-cm")
+
+			actual, err := mux.ResolveAndTransform(ctx, content, nil, inputSelectors, types.TemplateData{Machine: inputSelectors})
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("ignition"), actual)
+		})
+
+		t.Run("Webhooks are cached per machine", func(t *testing.T) {
+			defer setupCache(t)()
+
+			content := types.Content{
+				Name:          "config",
+				SpecHash:      "a-hash",
+				ResolverKind:  types.WebhookResolverKind,
+				WebhookConfig: ptr.To(testutil.NewTypesWebhookConfig()),
+			}
+
+			webhookResolver.EXPECT().Resolve(mock.Anything, content, inputSelectors).Return([]byte("a"), nil).Once()
+			webhookResolver.EXPECT().Resolve(mock.Anything, content, otherSelectors).Return([]byte("b"), nil).Once()
+
+			for range 2 {
+				actual, err := mux.ResolveAndTransform(ctx, content, nil, inputSelectors, inputData)
+				assert.NoError(t, err)
+				assert.Equal(t, []byte("a"), actual)
+
+				actual, err = mux.ResolveAndTransform(ctx, content, nil, otherSelectors, inputData)
+				assert.NoError(t, err)
+				assert.Equal(t, []byte("b"), actual)
+			}
+		})
+
+		t.Run("Shared computation outlives its first caller", func(t *testing.T) {
+			defer setupCache(t)()
+
+			content := types.Content{
+				Name:         "config",
+				SpecHash:     "a-hash",
+				ResolverKind: types.ObjectRefResolverKind,
+				ObjectRef:    &types.ObjectRef{Version: "v1", Resource: "configmaps", Namespace: "shaper", Name: "a-cm"},
+			}
+
+			callerCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			// The first caller goes away while the content is resolved: the waiters of the computation must not fail.
+			objectRefResolver.EXPECT().
+				Resolve(mock.Anything, content, inputSelectors).
+				RunAndReturn(func(ctx context.Context, _ types.Content, _ types.IPXESelectors) ([]byte, error) {
+					cancel()
+					return []byte("qwe"), ctx.Err()
+				}).
+				Once()
+
+			_, _ = mux.ResolveAndTransform(callerCtx, content, nil, inputSelectors, inputData)
+
+			actual, err := mux.ResolveAndTransform(ctx, content, nil, inputSelectors, inputData)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("qwe"), actual)
+		})
+
+		t.Run("Content without spec hash", func(t *testing.T) {
+			defer setupCache(t)()
+
+			content := types.Content{Name: "config", ResolverKind: types.InlineResolverKind, Inline: "qwe"}
+
+			inlineResolver.EXPECT().Resolve(mock.Anything, content, inputSelectors).Return([]byte("qwe"), nil).Twice()
+
+			for range 2 {
+				_, err := mux.ResolveAndTransform(ctx, content, nil, inputSelectors, inputData)
+				assert.NoError(t, err)
+			}
 		})
 	})
 }
@@ -0,0 +1,167 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/alexandremahdhaoui/shaper/internal/controller"
+)
+
+var (
+	// ErrUnauthorized is returned by an Authenticator when the request does not carry valid credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is returned by an Authenticator when the client is not allowed to make the request.
+	ErrForbidden = errors.New("forbidden")
+
+	errClientIPNotAllowed = errors.New("client ip is not allowed")
+	errMissingToken       = errors.New("missing bearer token")
+	errInvalidToken       = errors.New("invalid bearer token")
+)
+
+// bootstrapPath is the path of the bootstrap script, which distributes the token used to authenticate the other
+// requests.
+const bootstrapPath = "/boot.ipxe"
+
+// ---------------------------------------------------- INTERFACE --------------------------------------------------- //
+
+// Authenticator authenticates the requests to the shaper API.
+type Authenticator interface {
+	// Authenticate authenticates the request and returns its context, possibly carrying the credentials of the
+	// request. It returns an error wrapping ErrUnauthorized or ErrForbidden if the request must be rejected.
+	Authenticate(r *http.Request) (context.Context, error)
+}
+
+// ---------------------------------------------------- MIDDLEWARE -------------------------------------------------- //
+
+// AuthMiddleware authenticates the requests with each authenticator in order. A request is rejected with 401 if an
+// authenticator returns ErrUnauthorized, or with 403 otherwise. It must be wrapped by ClientIPMiddleware, so the
+// authenticators can read the IP of the client.
+func AuthMiddleware(authenticators ...Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, authenticator := range authenticators {
+				ctx, err := authenticator.Authenticate(r)
+				if err == nil {
+					r = r.WithContext(ctx)
+					continue
+				}
+
+				slog.WarnContext(r.Context(), "request_rejected",
+					"path", r.URL.Path,
+					"client_ip", GetClientIP(r.Context()),
+					"error", err.Error(),
+				)
+
+				code := http.StatusForbidden
+				if errors.Is(err, ErrUnauthorized) {
+					code = http.StatusUnauthorized
+					w.Header().Set("WWW-Authenticate", "Bearer")
+				}
+
+				writeJSONError(w, code, err.Error())
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ---------------------------------------------------- CIDR -------------------------------------------------------- //
+
+// NewCIDRAuthenticator returns an Authenticator allowing the clients whose IP is in one of the CIDRs. The IP of the
+// client is the one extracted by ClientIPMiddleware: the address of the peer, or the forwarded address of the client
+// when the peer is a trusted proxy.
+func NewCIDRAuthenticator(allowedCIDRs []netip.Prefix) Authenticator {
+	return &cidrAuthenticator{
+		allowedCIDRs: allowedCIDRs,
+	}
+}
+
+type cidrAuthenticator struct {
+	allowedCIDRs []netip.Prefix
+}
+
+func (a *cidrAuthenticator) Authenticate(r *http.Request) (context.Context, error) {
+	ip, err := netip.ParseAddr(GetClientIP(r.Context()))
+	if err != nil {
+		return nil, errors.Join(err, errClientIPNotAllowed, ErrForbidden)
+	}
+
+	ip = ip.Unmap()
+	for _, prefix := range a.allowedCIDRs {
+		if prefix.Contains(ip) {
+			return r.Context(), nil
+		}
+	}
+
+	return nil, errors.Join(errClientIPNotAllowed, ErrForbidden)
+}
+
+// ---------------------------------------------------- BEARER TOKEN ------------------------------------------------ //
+
+// NewBearerTokenAuthenticator returns an Authenticator requiring one of the tokens. The token is read from the
+// Authorization header or, as iPXE and most provisioning agents cannot set headers, from the `token` query parameter.
+// The bootstrap script is not authenticated as it distributes the token. The token is carried by the context of the
+// request, so the URLs of the exposed content carry it too.
+func NewBearerTokenAuthenticator(tokens []string) Authenticator {
+	return &bearerTokenAuthenticator{
+		tokens: tokens,
+	}
+}
+
+type bearerTokenAuthenticator struct {
+	tokens []string
+}
+
+func (a *bearerTokenAuthenticator) Authenticate(r *http.Request) (context.Context, error) {
+	if r.URL.Path == bootstrapPath {
+		return r.Context(), nil
+	}
+
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, errors.Join(errMissingToken, ErrUnauthorized)
+	}
+
+	for _, expected := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+			return controller.WithAuthToken(r.Context(), token), nil
+		}
+	}
+
+	return nil, errors.Join(errInvalidToken, ErrUnauthorized)
+}
+
+// bearerToken returns the token of the Authorization header, else the one of the `token` query parameter.
+func bearerToken(r *http.Request) (string, bool) {
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token), true
+	}
+
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token, true
+	}
+
+	return "", false
+}
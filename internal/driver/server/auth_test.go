@@ -0,0 +1,174 @@
+//go:build unit
+
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/alexandremahdhaoui/shaper/internal/controller"
+	"github.com/alexandremahdhaoui/shaper/internal/driver/server"
+	"github.com/alexandremahdhaoui/shaper/pkg/generated/shaperserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	allowedCIDRs := []netip.Prefix{netip.MustParsePrefix("10.0.1.0/24")}
+	trustedProxies := []netip.Prefix{netip.MustParsePrefix("192.168.0.0/24")}
+	tokens := []string{"current-token", "previous-token"}
+
+	tests := []struct {
+		name           string
+		authenticators []server.Authenticator
+		target         string
+		trustedProxies []netip.Prefix
+		remoteAddr     string
+		forwardedFor   string
+		authorization  string
+		expectedCode   int
+		expectedToken  string
+	}{
+		{
+			name:           "client ip allowed",
+			authenticators: []server.Authenticator{server.NewCIDRAuthenticator(allowedCIDRs)},
+			target:         "/ipxe?buildarch=x86_64",
+			remoteAddr:     "10.0.1.42:12345",
+			expectedCode:   http.StatusOK,
+		},
+		{
+			name:           "client ip not allowed",
+			authenticators: []server.Authenticator{server.NewCIDRAuthenticator(allowedCIDRs)},
+			target:         "/ipxe?buildarch=x86_64",
+			remoteAddr:     "10.0.2.42:12345",
+			expectedCode:   http.StatusForbidden,
+		},
+		{
+			name:           "spoofed X-Forwarded-For is ignored",
+			authenticators: []server.Authenticator{server.NewCIDRAuthenticator(allowedCIDRs)},
+			target:         "/ipxe?buildarch=x86_64",
+			remoteAddr:     "10.0.2.42:12345",
+			forwardedFor:   "10.0.1.42",
+			expectedCode:   http.StatusForbidden,
+		},
+		{
+			name:           "X-Forwarded-For set by a trusted proxy",
+			authenticators: []server.Authenticator{server.NewCIDRAuthenticator(allowedCIDRs)},
+			target:         "/ipxe?buildarch=x86_64",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "192.168.0.2:12345",
+			forwardedFor:   "10.0.2.42, 10.0.1.42, 192.168.0.1",
+			expectedCode:   http.StatusOK,
+		},
+		{
+			name:           "spoofed X-Forwarded-For through a trusted proxy",
+			authenticators: []server.Authenticator{server.NewCIDRAuthenticator(allowedCIDRs)},
+			target:         "/ipxe?buildarch=x86_64",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "192.168.0.2:12345",
+			forwardedFor:   "10.0.1.42, 10.0.2.42",
+			expectedCode:   http.StatusForbidden,
+		},
+		{
+			name:           "token in authorization header",
+			authenticators: []server.Authenticator{server.NewBearerTokenAuthenticator(tokens)},
+			target:         "/ipxe?buildarch=x86_64",
+			authorization:  "Bearer current-token",
+			expectedCode:   http.StatusOK,
+			expectedToken:  "current-token",
+		},
+		{
+			name:           "token in query",
+			authenticators: []server.Authenticator{server.NewBearerTokenAuthenticator(tokens)},
+			target:         "/ipxe?buildarch=x86_64&token=previous-token",
+			expectedCode:   http.StatusOK,
+			expectedToken:  "previous-token",
+		},
+		{
+			name:           "missing token",
+			authenticators: []server.Authenticator{server.NewBearerTokenAuthenticator(tokens)},
+			target:         "/ipxe?buildarch=x86_64",
+			expectedCode:   http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid token",
+			authenticators: []server.Authenticator{server.NewBearerTokenAuthenticator(tokens)},
+			target:         "/ipxe?buildarch=x86_64&token=invalid",
+			expectedCode:   http.StatusUnauthorized,
+		},
+		{
+			name:           "bootstrap script without token",
+			authenticators: []server.Authenticator{server.NewBearerTokenAuthenticator(tokens)},
+			target:         "/boot.ipxe",
+			expectedCode:   http.StatusOK,
+		},
+		{
+			name: "client ip checked before token",
+			authenticators: []server.Authenticator{
+				server.NewCIDRAuthenticator(allowedCIDRs),
+				server.NewBearerTokenAuthenticator(tokens),
+			},
+			target:       "/ipxe?buildarch=x86_64",
+			remoteAddr:   "10.0.2.42:12345",
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token string
+
+			handler := server.ClientIPMiddleware(tt.trustedProxies)(server.AuthMiddleware(tt.authenticators...)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					token = controller.AuthTokenFromContext(r.Context())
+					w.WriteHeader(http.StatusOK)
+				}),
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "http://shaper.example.com"+tt.target, nil)
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedToken, token)
+
+			if tt.expectedCode != http.StatusOK {
+				var body shaperserver.Error
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.Equal(t, int32(tt.expectedCode), body.Code)
+			}
+
+			if tt.expectedCode == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
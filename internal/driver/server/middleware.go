@@ -16,10 +16,17 @@ package server
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/json"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
 	"strings"
+
+	"github.com/alexandremahdhaoui/shaper/internal/controller"
+	"github.com/alexandremahdhaoui/shaper/pkg/generated/shaperserver"
+	"github.com/google/uuid"
 )
 
 // contextKey is a custom type for context keys to avoid collisions.
@@ -30,34 +37,51 @@ const (
 	ClientIPContextKey contextKey = "client_ip"
 )
 
-// ClientIPMiddleware extracts the client IP from the request and adds it to the context.
-// It checks X-Forwarded-For header first (for proxied requests), then falls back to RemoteAddr.
-func ClientIPMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		clientIP := extractClientIP(r)
-		ctx := context.WithValue(r.Context(), ClientIPContextKey, clientIP)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// ClientIPMiddleware extracts the client IP from the request and adds it to the context. The IP is the address of the
+// peer, unless the peer is one of the trusted proxies: the IP is then read from the X-Forwarded-For header, else from the
+// X-Real-IP header.
+func ClientIPMiddleware(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientIP := extractClientIP(r, trustedProxies)
+			ctx := context.WithValue(r.Context(), ClientIPContextKey, clientIP)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
-// extractClientIP extracts the client IP address from the request.
-// It first checks the X-Forwarded-For header, then X-Real-IP, then RemoteAddr.
-func extractClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header (comma-separated list, first is original client)
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		ips := strings.Split(xff, ",")
-		if len(ips) > 0 {
-			// Return the first IP (original client), trimmed
-			return strings.TrimSpace(ips[0])
+// extractClientIP extracts the client IP address from the request. The forwarded headers are only honored when the peer
+// is a trusted proxy, as any client can set them. The X-Forwarded-For header is read from right to left, as each proxy
+// appends the address of its peer: the client IP is the rightmost address that is not a trusted proxy.
+func extractClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	peer := remoteIP(r)
+	if !isTrustedProxy(peer, trustedProxies) {
+		return peer
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+
+		clientIP := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			clientIP = strings.TrimSpace(hops[i])
+			if !isTrustedProxy(clientIP, trustedProxies) {
+				break
+			}
 		}
+
+		return clientIP
 	}
 
-	// Check X-Real-IP header
 	if xri := r.Header.Get("X-Real-IP"); xri != "" {
 		return strings.TrimSpace(xri)
 	}
 
-	// Fall back to RemoteAddr
+	return peer
+}
+
+// remoteIP returns the IP of the peer of the connection.
+func remoteIP(r *http.Request) string {
 	// RemoteAddr is in the format "IP:port" or "[IPv6]:port"
 	ip, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
@@ -68,6 +92,23 @@ func extractClientIP(r *http.Request) string {
 	return ip
 }
 
+// isTrustedProxy returns true if the IP is in one of the CIDRs of the trusted proxies.
+func isTrustedProxy(ip string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // GetClientIP retrieves the client IP from the context.
 // Returns empty string if not found.
 func GetClientIP(ctx context.Context) string {
@@ -77,18 +118,161 @@ func GetClientIP(ctx context.Context) string {
 	return ""
 }
 
+// BaseURLMiddleware adds the base URL of the shaper API to the context of the request, used to render absolute URLs
+// to the exposed content. The external base URL is used if set. Otherwise, the base URL is derived from the
+// X-Forwarded-Proto, X-Forwarded-Host and X-Forwarded-Prefix headers when the peer is a trusted proxy, as any client can
+// set them, then from the Host header. The scheme defaults to https when TLS is enabled.
+func BaseURLMiddleware(
+	externalBaseURL string,
+	tlsEnabled bool,
+	trustedProxies []netip.Prefix,
+) func(http.Handler) http.Handler {
+	externalBaseURL = strings.TrimSuffix(externalBaseURL, "/")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			baseURL := externalBaseURL
+			if baseURL == "" {
+				baseURL = extractBaseURL(r, tlsEnabled, isTrustedProxy(remoteIP(r), trustedProxies))
+			}
+
+			next.ServeHTTP(w, r.WithContext(controller.WithBaseURL(r.Context(), baseURL)))
+		})
+	}
+}
+
+// extractBaseURL derives the base URL of the shaper API from the request. The forwarded headers are only honored when
+// the request comes from a trusted proxy.
+func extractBaseURL(r *http.Request, tlsEnabled, proxied bool) string {
+	scheme := "http"
+	if tlsEnabled || r.TLS != nil {
+		scheme = "https"
+	}
+
+	if !proxied {
+		return scheme + "://" + r.Host
+	}
+
+	if proto := firstHeaderValue(r, "X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	host := r.Host
+	if xfh := firstHeaderValue(r, "X-Forwarded-Host"); xfh != "" {
+		host = xfh
+	}
+
+	prefix := strings.Trim(firstHeaderValue(r, "X-Forwarded-Prefix"), "/")
+	if prefix != "" {
+		prefix = "/" + prefix
+	}
+
+	return scheme + "://" + host + prefix
+}
+
+// firstHeaderValue returns the first value of a comma-separated header, i.e. the one set by the first proxy.
+func firstHeaderValue(r *http.Request, key string) string {
+	value, _, _ := strings.Cut(r.Header.Get(key), ",")
+	return strings.TrimSpace(value)
+}
+
 // TLSLoggingMiddleware logs successful mTLS connections.
 // When a client presents a valid certificate, it logs the client's CN, issuer, and serial number.
 func TLSLoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
 			clientCert := r.TLS.PeerCertificates[0]
+			machineUUID, _ := machineUUIDFromCertificate(clientCert)
 			slog.Info("tls_client_connected",
 				"client_cn", clientCert.Subject.CommonName,
 				"client_issuer", clientCert.Issuer.CommonName,
 				"client_serial", clientCert.SerialNumber.String(),
+				"client_uuid", machineUUID,
 			)
 		}
 		next.ServeHTTP(w, r)
 	})
 }
+
+// MachineIdentityMiddleware binds the client certificate to the identity of a machine. The iPXE and content requests
+// of a client whose certificate certifies a machine UUID are rejected with 403 unless their `uuid` query parameter is
+// that UUID, and so are its `/machines/{uuid}/...` requests unless the path UUID is that UUID, so the certificate
+// embedded in one machine cannot be used to fetch the content of, or report events for, another machine.
+// Certificates that do not certify a machine UUID, e.g. a certificate shared by a fleet, are not bound. The context of
+// a bound request carries the certified UUID as the verified machine.
+func MachineIdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedUUID, ok := requestedMachineUUID(r)
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 || !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		certified, ok := machineUUIDFromCertificate(r.TLS.PeerCertificates[0])
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requested, err := uuid.Parse(requestedUUID)
+		if err != nil || requested != certified {
+			slog.WarnContext(r.Context(), "machine_identity_mismatch",
+				"path", r.URL.Path,
+				"certified_uuid", certified,
+				"requested_uuid", requestedUUID,
+			)
+
+			writeJSONError(w, http.StatusForbidden, "uuid does not match the machine identity of the client certificate")
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(controller.WithVerifiedMachine(r.Context(), certified)))
+	})
+}
+
+// requestedMachineUUID returns the machine UUID a request is served for, and false if the request is not served for a
+// machine. It is the `uuid` query parameter of iPXE and content requests, and the path UUID of `/machines/{uuid}/...`
+// requests.
+func requestedMachineUUID(r *http.Request) (string, bool) {
+	if r.URL.Path == "/ipxe" || strings.HasPrefix(r.URL.Path, "/content/") {
+		return r.URL.Query().Get("uuid"), true
+	}
+
+	if rest, ok := strings.CutPrefix(r.URL.Path, "/machines/"); ok {
+		requested, _, _ := strings.Cut(rest, "/")
+		return requested, true
+	}
+
+	return "", false
+}
+
+// machineUUIDFromCertificate returns the machine UUID certified by the certificate. It is read, by order of precedence,
+// from a `urn:uuid:` URI SAN, a DNS SAN or the common name of the certificate.
+func machineUUIDFromCertificate(cert *x509.Certificate) (uuid.UUID, bool) {
+	for _, u := range cert.URIs {
+		if !strings.EqualFold(u.Scheme, "urn") || !strings.HasPrefix(strings.ToLower(u.Opaque), "uuid:") {
+			continue
+		}
+
+		if id, err := uuid.Parse(u.String()); err == nil {
+			return id, true
+		}
+	}
+
+	for _, name := range append(cert.DNSNames, cert.Subject.CommonName) {
+		if id, err := uuid.Parse(name); err == nil {
+			return id, true
+		}
+	}
+
+	return uuid.Nil, false
+}
+
+// writeJSONError writes an error response in the format of the shaper API.
+func writeJSONError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	_ = json.NewEncoder(w).Encode(shaperserver.Error{Code: int32(code), Message: message})
+}
@@ -0,0 +1,221 @@
+//go:build unit
+
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/alexandremahdhaoui/shaper/internal/controller"
+	"github.com/alexandremahdhaoui/shaper/internal/driver/server"
+	"github.com/alexandremahdhaoui/shaper/pkg/generated/shaperserver"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseURLMiddleware(t *testing.T) {
+	tests := []struct {
+		name            string
+		externalBaseURL string
+		tlsEnabled      bool
+		trustedProxies  []netip.Prefix
+		headers         map[string]string
+		expected        string
+	}{
+		{
+			name:     "Host header",
+			expected: "http://shaper.example.com:30443",
+		},
+		{
+			name:       "TLS enabled",
+			tlsEnabled: true,
+			expected:   "https://shaper.example.com:30443",
+		},
+		{
+			name:           "Forwarded headers",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+			headers: map[string]string{
+				"X-Forwarded-Proto":  "https, http",
+				"X-Forwarded-Host":   "boot.example.com, proxy.internal",
+				"X-Forwarded-Prefix": "/shaper/",
+			},
+			expected: "https://boot.example.com/shaper",
+		},
+		{
+			name:           "Forwarded headers from an untrusted peer",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			headers: map[string]string{
+				"X-Forwarded-Proto":  "http",
+				"X-Forwarded-Host":   "attacker.example.com",
+				"X-Forwarded-Prefix": "/evil",
+			},
+			expected: "http://shaper.example.com:30443",
+		},
+		{
+			name:            "External base URL",
+			externalBaseURL: "https://boot.example.com/",
+			headers:         map[string]string{"X-Forwarded-Host": "ignored.example.com"},
+			expected:        "https://boot.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actual string
+			handler := server.BaseURLMiddleware(tt.externalBaseURL, tt.tlsEnabled, tt.trustedProxies)(http.HandlerFunc(
+				func(_ http.ResponseWriter, r *http.Request) {
+					actual = controller.BaseURLFromContext(r.Context())
+				}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://shaper.example.com:30443/ipxe", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestMachineIdentityMiddleware(t *testing.T) {
+	machineUUID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	otherUUID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+
+	tests := []struct {
+		name             string
+		cert             *x509.Certificate
+		target           string
+		expectedCode     int
+		expectedVerified uuid.UUID
+	}{
+		{
+			name:             "URI SAN matches",
+			cert:             &x509.Certificate{URIs: []*url.URL{{Scheme: "urn", Opaque: "uuid:" + machineUUID.String()}}},
+			target:           "/ipxe?buildarch=x86_64&uuid=" + machineUUID.String(),
+			expectedCode:     http.StatusOK,
+			expectedVerified: machineUUID,
+		},
+		{
+			name:         "URI SAN takes precedence over common name",
+			cert:         newIdentityCertificate(otherUUID.String(), "urn:uuid:"+machineUUID.String()),
+			target:       "/ipxe?buildarch=x86_64&uuid=" + otherUUID.String(),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:             "DNS SAN matches",
+			cert:             &x509.Certificate{DNSNames: []string{"node.example.com", machineUUID.String()}},
+			target:           "/content/" + otherUUID.String() + "?buildarch=x86_64&uuid=" + machineUUID.String(),
+			expectedCode:     http.StatusOK,
+			expectedVerified: machineUUID,
+		},
+		{
+			name:             "common name matches",
+			cert:             newIdentityCertificate(machineUUID.String(), ""),
+			target:           "/ipxe?buildarch=x86_64&uuid=" + machineUUID.String(),
+			expectedCode:     http.StatusOK,
+			expectedVerified: machineUUID,
+		},
+		{
+			name:         "uuid of another machine",
+			cert:         newIdentityCertificate(machineUUID.String(), ""),
+			target:       "/content/" + otherUUID.String() + "?buildarch=x86_64&uuid=" + otherUUID.String(),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "missing uuid",
+			cert:         newIdentityCertificate(machineUUID.String(), ""),
+			target:       "/ipxe?buildarch=x86_64&mac=52-54-00-12-34-56",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:             "events of the machine",
+			cert:             newIdentityCertificate(machineUUID.String(), ""),
+			target:           "/machines/" + machineUUID.String() + "/events",
+			expectedCode:     http.StatusOK,
+			expectedVerified: machineUUID,
+		},
+		{
+			name:         "events of another machine",
+			cert:         newIdentityCertificate(machineUUID.String(), ""),
+			target:       "/machines/" + otherUUID.String() + "/events?uuid=" + machineUUID.String(),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "certificate without machine identity",
+			cert:         newIdentityCertificate("ipxe-client", ""),
+			target:       "/ipxe?buildarch=x86_64&uuid=" + otherUUID.String(),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "other endpoint",
+			cert:         newIdentityCertificate(machineUUID.String(), ""),
+			target:       "/boot.ipxe",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "without client certificate",
+			target:       "/ipxe?buildarch=x86_64&uuid=" + otherUUID.String(),
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verified uuid.UUID
+
+			handler := server.MachineIdentityMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				verified = controller.VerifiedMachineFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "https://shaper.example.com"+tt.target, nil)
+			if tt.cert != nil {
+				req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{tt.cert}}
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedVerified, verified)
+
+			if tt.expectedCode == http.StatusForbidden {
+				var body shaperserver.Error
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.Equal(t, int32(http.StatusForbidden), body.Code)
+			}
+		})
+	}
+}
+
+func newIdentityCertificate(commonName, uri string) *x509.Certificate {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+	if uri != "" {
+		u, _ := url.Parse(uri)
+		cert.URIs = []*url.URL{u}
+	}
+
+	return cert
+}
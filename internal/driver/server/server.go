@@ -18,28 +18,43 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/alexandremahdhaoui/shaper/internal/controller"
 	"github.com/alexandremahdhaoui/shaper/internal/types"
 	"github.com/alexandremahdhaoui/shaper/pkg/generated/shaperserver"
+	"k8s.io/utils/ptr"
 )
 
 var (
 	ErrGetConfigByID      = errors.New("getting config by id")
 	ErrGetIPXEBySelectors = errors.New("getting ipxe by labels")
+	ErrPostMachineEvent   = errors.New("posting machine event")
+
+	errContentURLNotSigned         = errors.New("content url is not signed")
+	errUnsupportedMachineEventType = errors.New("unsupported machine event type")
 )
 
-// New returns a new server.
-func New(ipxe controller.IPXE, config controller.Content) shaperserver.StrictServerInterface {
+// New returns a new server. The signature of the content URLs is verified when the signer is not nil.
+func New(
+	ipxe controller.IPXE,
+	config controller.Content,
+	machine controller.Machine,
+	signer controller.ContentURLSigner,
+) shaperserver.StrictServerInterface {
 	return &server{
-		ipxe:   ipxe,
-		config: config,
+		ipxe:    ipxe,
+		config:  config,
+		machine: machine,
+		signer:  signer,
 	}
 }
 
 type server struct {
-	ipxe   controller.IPXE
-	config controller.Content
+	ipxe    controller.IPXE
+	config  controller.Content
+	machine controller.Machine
+	signer  controller.ContentURLSigner
 }
 
 func (s *server) GetIPXEBootstrap(
@@ -65,9 +80,28 @@ func (s *server) GetContentByID(
 		attributes.UUID = *request.Params.Uuid
 	}
 
+	if resp := s.verifyContentURL(ctx, request, attributes); resp != nil {
+		return resp, nil
+	}
+
+	if s.signer != nil {
+		// The signature of the content URL covers the uuid of the machine.
+		ctx = controller.WithVerifiedMachine(ctx, attributes.UUID)
+	}
+
+	// Get client IP from context (set by ClientIPMiddleware)
+	attributes.ClientIP = GetClientIP(ctx)
+
 	// call controller
 	b, err := s.config.GetByID(ctx, request.ContentID, attributes)
-	if err != nil {
+	if errors.Is(err, controller.ErrClientNotAllowed) {
+		return shaperserver.GetContentByID403JSONResponse{
+			N403JSONResponse: shaperserver.N403JSONResponse{
+				Code:    403,
+				Message: errors.Join(err, ErrGetConfigByID).Error(),
+			},
+		}, nil
+	} else if err != nil {
 		return shaperserver.GetContentByID500JSONResponse{
 			N500JSONResponse: shaperserver.N500JSONResponse{
 				Code:    500,
@@ -79,6 +113,60 @@ func (s *server) GetContentByID(
 	return shaperserver.GetContentByID200TextResponse(b), nil
 }
 
+// verifyContentURL verifies the signature of the content URL when a signer is configured. It returns the response to
+// send if the URL is not signed, has an invalid signature or is expired, and nil otherwise.
+func (s *server) verifyContentURL(
+	ctx context.Context,
+	request shaperserver.GetContentByIDRequestObject,
+	attributes types.IPXESelectors,
+) shaperserver.GetContentByIDResponseObject {
+	if s.signer == nil {
+		return nil
+	}
+
+	if request.Params.Expires == nil || request.Params.Kid == nil || request.Params.Signature == nil {
+		return shaperserver.GetContentByID403JSONResponse{
+			N403JSONResponse: shaperserver.N403JSONResponse{
+				Code:    403,
+				Message: errors.Join(errContentURLNotSigned, ErrGetConfigByID).Error(),
+			},
+		}
+	}
+
+	signature := types.ContentURLSignature{
+		Expires:   time.Unix(*request.Params.Expires, 0),
+		KeyID:     *request.Params.Kid,
+		Signature: *request.Params.Signature,
+	}
+
+	err := s.signer.Verify(ctx, request.ContentID, attributes, signature)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, controller.ErrContentURLSignatureInvalid):
+		return shaperserver.GetContentByID403JSONResponse{
+			N403JSONResponse: shaperserver.N403JSONResponse{
+				Code:    403,
+				Message: errors.Join(err, ErrGetConfigByID).Error(),
+			},
+		}
+	case errors.Is(err, controller.ErrContentURLExpired):
+		return shaperserver.GetContentByID410JSONResponse{
+			N410JSONResponse: shaperserver.N410JSONResponse{
+				Code:    410,
+				Message: errors.Join(err, ErrGetConfigByID).Error(),
+			},
+		}
+	default:
+		return shaperserver.GetContentByID500JSONResponse{
+			N500JSONResponse: shaperserver.N500JSONResponse{
+				Code:    500,
+				Message: errors.Join(err, ErrGetConfigByID).Error(),
+			},
+		}
+	}
+}
+
 func (s *server) GetIPXEBySelectors(
 	ctx context.Context,
 	request shaperserver.GetIPXEBySelectorsRequestObject,
@@ -94,19 +182,39 @@ func (s *server) GetIPXEBySelectors(
 		selectors.UUID = *request.Params.Uuid
 	}
 
+	selectors.MAC = ptr.Deref(request.Params.Mac, "")
+	selectors.Serial = ptr.Deref(request.Params.Serial, "")
+	selectors.Hostname = ptr.Deref(request.Params.Hostname, "")
+	selectors.Asset = ptr.Deref(request.Params.Asset, "")
+	selectors.Product = ptr.Deref(request.Params.Product, "")
+	selectors.Manufacturer = ptr.Deref(request.Params.Manufacturer, "")
+	selectors.Platform = ptr.Deref(request.Params.Platform, "")
+
 	// Get client IP from context (set by ClientIPMiddleware)
 	clientIP := GetClientIP(ctx)
+	selectors.ClientIP = clientIP
 
 	// Log iPXE boot request with client IP for E2E test verification
 	slog.InfoContext(ctx, "ipxe_boot_request",
 		"client_ip", clientIP,
 		"uuid", selectors.UUID,
 		"buildarch", selectors.Buildarch,
+		"mac", selectors.MAC,
+		"serial", selectors.Serial,
+		"hostname", selectors.Hostname,
+		"platform", selectors.Platform,
 	)
 
 	// call controller
 	b, err := s.ipxe.FindProfileAndRender(ctx, selectors)
-	if err != nil {
+	if errors.Is(err, controller.ErrClientNotAllowed) {
+		return shaperserver.GetIPXEBySelectors403JSONResponse{
+			N403JSONResponse: shaperserver.N403JSONResponse{
+				Code:    403,
+				Message: errors.Join(err, ErrGetIPXEBySelectors).Error(),
+			},
+		}, nil
+	} else if err != nil {
 		return shaperserver.GetIPXEBySelectors500JSONResponse{
 			N500JSONResponse: shaperserver.N500JSONResponse{
 				Code:    0,
@@ -117,3 +225,56 @@ func (s *server) GetIPXEBySelectors(
 
 	return shaperserver.GetIPXEBySelectors200TextResponse(b), nil
 }
+
+func (s *server) PostMachineEvent(
+	ctx context.Context,
+	request shaperserver.PostMachineEventRequestObject,
+) (shaperserver.PostMachineEventResponseObject, error) {
+	if request.Body == nil {
+		return shaperserver.PostMachineEvent400JSONResponse{
+			N400JSONResponse: shaperserver.N400JSONResponse{
+				Code:    400,
+				Message: ErrPostMachineEvent.Error(),
+			},
+		}, nil
+	}
+
+	event := types.MachineEvent{
+		Message: ptr.Deref(request.Body.Message, ""),
+	}
+
+	switch request.Body.Type {
+	case shaperserver.InstallStarted:
+		event.Type = types.InstallStartedMachineEvent
+	case shaperserver.InstallSucceeded:
+		event.Type = types.InstallSucceededMachineEvent
+	case shaperserver.InstallFailed:
+		event.Type = types.InstallFailedMachineEvent
+	default:
+		return shaperserver.PostMachineEvent400JSONResponse{
+			N400JSONResponse: shaperserver.N400JSONResponse{
+				Code:    400,
+				Message: errors.Join(errUnsupportedMachineEventType, ErrPostMachineEvent).Error(),
+			},
+		}, nil
+	}
+
+	// call controller
+	if err := s.machine.RecordEvent(ctx, request.Uuid, event); errors.Is(err, controller.ErrMachineNotFound) {
+		return shaperserver.PostMachineEvent404JSONResponse{
+			N404JSONResponse: shaperserver.N404JSONResponse{
+				Code:    404,
+				Message: errors.Join(err, ErrPostMachineEvent).Error(),
+			},
+		}, nil
+	} else if err != nil {
+		return shaperserver.PostMachineEvent500JSONResponse{
+			N500JSONResponse: shaperserver.N500JSONResponse{
+				Code:    500,
+				Message: errors.Join(err, ErrPostMachineEvent).Error(),
+			},
+		}, nil
+	}
+
+	return shaperserver.PostMachineEvent204Response{}, nil
+}
@@ -18,8 +18,11 @@ package server_test
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/alexandremahdhaoui/shaper/internal/controller"
 	"github.com/alexandremahdhaoui/shaper/internal/driver/server"
 	"github.com/alexandremahdhaoui/shaper/internal/types"
 	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockcontroller"
@@ -27,6 +30,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"k8s.io/utils/ptr"
 )
 
 func TestGetIPXEBootstrap(t *testing.T) {
@@ -70,7 +74,7 @@ boot`),
 			mockIPXE.EXPECT().Boostrap().Return(tt.mockBootstrap)
 
 			// Create server
-			srv := server.New(mockIPXE, mockContent)
+			srv := server.New(mockIPXE, mockContent, mockcontroller.NewMockMachine(t), nil)
 
 			// Create request
 			ctx := context.Background()
@@ -168,7 +172,7 @@ hostname: test-host`)
 			).Return(tt.mockReturnContent, nil)
 
 			// Create server
-			srv := server.New(mockIPXE, mockContent)
+			srv := server.New(mockIPXE, mockContent, mockcontroller.NewMockMachine(t), nil)
 
 			// Create request
 			ctx := context.Background()
@@ -252,7 +256,7 @@ func TestGetContentByID_Error(t *testing.T) {
 			).Return(tt.mockReturnContent, tt.mockReturnError)
 
 			// Create server
-			srv := server.New(mockIPXE, mockContent)
+			srv := server.New(mockIPXE, mockContent, mockcontroller.NewMockMachine(t), nil)
 
 			// Create request
 			ctx := context.Background()
@@ -283,6 +287,123 @@ func TestGetContentByID_Error(t *testing.T) {
 	}
 }
 
+func TestGetContentByID_Signature(t *testing.T) {
+	testUUID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	contentUUID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	expires := time.Unix(1700000000, 0)
+
+	signed := shaperserver.GetContentByIDParams{
+		Buildarch: shaperserver.GetContentByIDParamsBuildarchX8664,
+		Uuid:      &testUUID,
+		Expires:   ptr.To(expires.Unix()),
+		Kid:       ptr.To("2024-01"),
+		Signature: ptr.To("c2lnbmF0dXJl"),
+	}
+
+	expectedSignature := types.ContentURLSignature{
+		Expires:   expires,
+		KeyID:     "2024-01",
+		Signature: "c2lnbmF0dXJl",
+	}
+
+	tests := []struct {
+		name         string
+		params       shaperserver.GetContentByIDParams
+		verifyError  error
+		expectVerify bool
+		expectedCode int
+	}{
+		{
+			name:         "valid signature",
+			params:       signed,
+			expectVerify: true,
+			expectedCode: 200,
+		},
+		{
+			name: "unsigned url",
+			params: shaperserver.GetContentByIDParams{
+				Buildarch: shaperserver.GetContentByIDParamsBuildarchX8664,
+				Uuid:      &testUUID,
+			},
+			expectedCode: 403,
+		},
+		{
+			name:         "invalid signature",
+			params:       signed,
+			verifyError:  controller.ErrContentURLSignatureInvalid,
+			expectVerify: true,
+			expectedCode: 403,
+		},
+		{
+			name:         "expired url",
+			params:       signed,
+			verifyError:  controller.ErrContentURLExpired,
+			expectVerify: true,
+			expectedCode: 410,
+		},
+		{
+			name:         "signing keys unavailable",
+			params:       signed,
+			verifyError:  assert.AnError,
+			expectVerify: true,
+			expectedCode: 500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockContent := mockcontroller.NewMockContent(t)
+			mockSigner := mockcontroller.NewMockContentURLSigner(t)
+
+			expectedAttributes := types.IPXESelectors{UUID: testUUID, Buildarch: "x86_64"}
+
+			if tt.expectVerify {
+				mockSigner.EXPECT().
+					Verify(mock.Anything, contentUUID, expectedAttributes, expectedSignature).
+					Return(tt.verifyError).
+					Once()
+			}
+
+			if tt.expectedCode == 200 {
+				// The machine is verified by the signature, which covers its uuid.
+				verified := mock.MatchedBy(func(ctx context.Context) bool {
+					return controller.VerifiedMachineFromContext(ctx) == testUUID
+				})
+
+				mockContent.EXPECT().
+					GetByID(verified, contentUUID, expectedAttributes).
+					Return([]byte("content"), nil).
+					Once()
+			}
+
+			srv := server.New(mockcontroller.NewMockIPXE(t), mockContent, mockcontroller.NewMockMachine(t), mockSigner)
+
+			resp, err := srv.GetContentByID(context.Background(), shaperserver.GetContentByIDRequestObject{
+				ContentID: contentUUID,
+				Params:    tt.params,
+			})
+			assert.NoError(t, err)
+
+			switch tt.expectedCode {
+			case 200:
+				assert.Equal(t, shaperserver.GetContentByID200TextResponse("content"), resp)
+			case 403:
+				resp403, ok := resp.(shaperserver.GetContentByID403JSONResponse)
+				assert.True(t, ok, "expected GetContentByID403JSONResponse")
+				assert.Equal(t, int32(403), resp403.Code)
+			case 410:
+				resp410, ok := resp.(shaperserver.GetContentByID410JSONResponse)
+				assert.True(t, ok, "expected GetContentByID410JSONResponse")
+				assert.Equal(t, int32(410), resp410.Code)
+			case 500:
+				resp500, ok := resp.(shaperserver.GetContentByID500JSONResponse)
+				assert.True(t, ok, "expected GetContentByID500JSONResponse")
+				assert.Contains(t, resp500.Message, assert.AnError.Error())
+			}
+		})
+	}
+}
+
 func TestGetIPXEBySelectors_Success(t *testing.T) {
 	testUUID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
 
@@ -350,7 +471,7 @@ boot`),
 			).Return(tt.mockReturnScript, nil)
 
 			// Create server
-			srv := server.New(mockIPXE, mockContent)
+			srv := server.New(mockIPXE, mockContent, mockcontroller.NewMockMachine(t), nil)
 
 			// Create request
 			ctx := context.Background()
@@ -428,7 +549,7 @@ func TestGetIPXEBySelectors_Error(t *testing.T) {
 			).Return(tt.mockReturnScript, tt.mockReturnError)
 
 			// Create server
-			srv := server.New(mockIPXE, mockContent)
+			srv := server.New(mockIPXE, mockContent, mockcontroller.NewMockMachine(t), nil)
 
 			// Create request
 			ctx := context.Background()
@@ -464,7 +585,7 @@ func TestNew(t *testing.T) {
 	mockContent := mockcontroller.NewMockContent(t)
 
 	// Call constructor
-	srv := server.New(mockIPXE, mockContent)
+	srv := server.New(mockIPXE, mockContent, mockcontroller.NewMockMachine(t), nil)
 
 	// Assert non-nil
 	assert.NotNil(t, srv)
@@ -472,3 +593,140 @@ func TestNew(t *testing.T) {
 	// Assert implements interface (compile-time check)
 	var _ shaperserver.StrictServerInterface = srv
 }
+
+func TestPostMachineEvent(t *testing.T) {
+	testUUID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+
+	tests := []struct {
+		name            string
+		body            *shaperserver.PostMachineEventJSONRequestBody
+		expectedEvent   *types.MachineEvent
+		mockReturnError error
+		expectedStatus  int
+	}{
+		{
+			name:           "install-started",
+			body:           &shaperserver.MachineEvent{Type: shaperserver.InstallStarted},
+			expectedEvent:  &types.MachineEvent{Type: types.InstallStartedMachineEvent},
+			expectedStatus: 204,
+		},
+		{
+			name:           "install-succeeded",
+			body:           &shaperserver.MachineEvent{Type: shaperserver.InstallSucceeded},
+			expectedEvent:  &types.MachineEvent{Type: types.InstallSucceededMachineEvent},
+			expectedStatus: 204,
+		},
+		{
+			name: "install-failed with message",
+			body: &shaperserver.MachineEvent{
+				Type:    shaperserver.InstallFailed,
+				Message: ptr.To("disk not found"),
+			},
+			expectedEvent:  &types.MachineEvent{Type: types.InstallFailedMachineEvent, Message: "disk not found"},
+			expectedStatus: 204,
+		},
+		{
+			name:           "missing body",
+			expectedStatus: 400,
+		},
+		{
+			name:           "unsupported event type",
+			body:           &shaperserver.MachineEvent{Type: "rebooted"},
+			expectedStatus: 400,
+		},
+		{
+			name:            "machine not found",
+			body:            &shaperserver.MachineEvent{Type: shaperserver.InstallStarted},
+			expectedEvent:   &types.MachineEvent{Type: types.InstallStartedMachineEvent},
+			mockReturnError: controller.ErrMachineNotFound,
+			expectedStatus:  404,
+		},
+		{
+			name:            "controller returns generic error",
+			body:            &shaperserver.MachineEvent{Type: shaperserver.InstallStarted},
+			expectedEvent:   &types.MachineEvent{Type: types.InstallStartedMachineEvent},
+			mockReturnError: assert.AnError,
+			expectedStatus:  500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create mocks
+			mockMachine := mockcontroller.NewMockMachine(t)
+
+			// Setup mock expectations
+			if tt.expectedEvent != nil {
+				mockMachine.EXPECT().
+					RecordEvent(mock.Anything, testUUID, *tt.expectedEvent).
+					Return(tt.mockReturnError)
+			}
+
+			// Create server
+			srv := server.New(mockcontroller.NewMockIPXE(t), mockcontroller.NewMockContent(t), mockMachine, nil)
+
+			// Execute
+			resp, err := srv.PostMachineEvent(context.Background(), shaperserver.PostMachineEventRequestObject{
+				Uuid: testUUID,
+				Body: tt.body,
+			})
+
+			// Assert no error from handler (error is in response body)
+			assert.NoError(t, err)
+
+			// Assert response type
+			switch tt.expectedStatus {
+			case 204:
+				assert.IsType(t, shaperserver.PostMachineEvent204Response{}, resp)
+			case 400:
+				assert.IsType(t, shaperserver.PostMachineEvent400JSONResponse{}, resp)
+			case 404:
+				assert.IsType(t, shaperserver.PostMachineEvent404JSONResponse{}, resp)
+			case 500:
+				assert.IsType(t, shaperserver.PostMachineEvent500JSONResponse{}, resp)
+			}
+		})
+	}
+}
+
+func TestClientNotAllowed(t *testing.T) {
+	testUUID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	contentUUID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	notAllowed := errors.Join(controller.ErrClientNotAllowed, assert.AnError)
+
+	t.Run("GetIPXEBySelectors", func(t *testing.T) {
+		mockIPXE := mockcontroller.NewMockIPXE(t)
+		mockIPXE.EXPECT().FindProfileAndRender(mock.Anything, mock.Anything).Return(nil, notAllowed).Once()
+
+		srv := server.New(mockIPXE, mockcontroller.NewMockContent(t), mockcontroller.NewMockMachine(t), nil)
+
+		resp, err := srv.GetIPXEBySelectors(context.Background(), shaperserver.GetIPXEBySelectorsRequestObject{
+			Params: shaperserver.GetIPXEBySelectorsParams{Buildarch: shaperserver.X8664, Uuid: &testUUID},
+		})
+		assert.NoError(t, err)
+
+		resp403, ok := resp.(shaperserver.GetIPXEBySelectors403JSONResponse)
+		assert.True(t, ok, "expected GetIPXEBySelectors403JSONResponse")
+		assert.Equal(t, int32(403), resp403.Code)
+	})
+
+	t.Run("GetContentByID", func(t *testing.T) {
+		mockContent := mockcontroller.NewMockContent(t)
+		mockContent.EXPECT().GetByID(mock.Anything, contentUUID, mock.Anything).Return(nil, notAllowed).Once()
+
+		srv := server.New(mockcontroller.NewMockIPXE(t), mockContent, mockcontroller.NewMockMachine(t), nil)
+
+		resp, err := srv.GetContentByID(context.Background(), shaperserver.GetContentByIDRequestObject{
+			ContentID: contentUUID,
+			Params: shaperserver.GetContentByIDParams{
+				Buildarch: shaperserver.GetContentByIDParamsBuildarchX8664,
+				Uuid:      &testUUID,
+			},
+		})
+		assert.NoError(t, err)
+
+		resp403, ok := resp.(shaperserver.GetContentByID403JSONResponse)
+		assert.True(t, ok, "expected GetContentByID403JSONResponse")
+		assert.Equal(t, int32(403), resp403.Code)
+	})
+}
@@ -18,13 +18,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
+	"net/netip"
 	"slices"
+	"strings"
 
 	"github.com/alexandremahdhaoui/shaper/internal/adapter"
 	"github.com/alexandremahdhaoui/shaper/internal/types"
 	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
 	"github.com/google/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
@@ -34,17 +40,38 @@ var (
 	_ webhook.CustomDefaulter = &Assignment{}
 )
 
-// NewAssignment returns a new Assignment webhook.
-func NewAssignment(assignment adapter.Assignment, profile adapter.Profile) *Assignment {
+// AssignmentConflictPolicy defines how an assignment overlapping with another assignment of the same priority is
+// handled.
+type AssignmentConflictPolicy string
+
+const (
+	// RejectAssignmentConflictPolicy rejects the overlapping assignment. It is the default.
+	RejectAssignmentConflictPolicy AssignmentConflictPolicy = "Reject"
+	// WarnAssignmentConflictPolicy admits the overlapping assignment and warns about the other assignments.
+	WarnAssignmentConflictPolicy AssignmentConflictPolicy = "Warn"
+)
+
+// NewAssignment returns a new Assignment webhook. The objects referenced by the parameters of an assignment must be
+// allowed by the objectRefPolicy for the namespace of the assignment.
+func NewAssignment(
+	assignment adapter.Assignment,
+	profile adapter.Profile,
+	objectRefPolicy types.ObjectRefPolicy,
+	conflictPolicy AssignmentConflictPolicy,
+) *Assignment {
 	return &Assignment{
-		assignment: assignment,
-		profile:    profile,
+		assignment:      assignment,
+		profile:         profile,
+		objectRefPolicy: objectRefPolicy,
+		conflictPolicy:  conflictPolicy,
 	}
 }
 
 type Assignment struct {
-	assignment adapter.Assignment
-	profile    adapter.Profile
+	assignment      adapter.Assignment
+	profile         adapter.Profile
+	objectRefPolicy types.ObjectRefPolicy
+	conflictPolicy  AssignmentConflictPolicy
 }
 
 func (a *Assignment) Default(ctx context.Context, obj runtime.Object) error {
@@ -63,8 +90,9 @@ func (a *Assignment) Default(ctx context.Context, obj runtime.Object) error {
 	}
 
 	// 1. Remove all "internal" labels. (remove ones created by users && clean up old ones)
+	// Other labels belong to users, e.g. they are exposed to templates as `.Assignment.Labels`.
 	for k := range assignment.Labels {
-		if !v1alpha1.IsInternalLabel(k) {
+		if v1alpha1.IsInternalLabel(k) {
 			delete(assignment.Labels, k)
 		}
 	}
@@ -79,17 +107,27 @@ func (a *Assignment) Default(ctx context.Context, obj runtime.Object) error {
 		v1alpha1.SetUUIDLabelSelector(assignment, id, "")
 	}
 
-	// 3. Add buildarch labels etc...
-	buildarchList := assignment.Spec.SubjectSelectors.BuildarchList
-	if len(buildarchList) == 0 {
-		// unspecified implies any buildarch.
-		buildarchList = slices.Clone(v1alpha1.AllowedBuildarchList)
+	// 3. Add other subject selectors, e.g. MAC addresses or serial numbers
+	for prefix, values := range assignment.Spec.SubjectSelectors.ByPrefix() {
+		if prefix == v1alpha1.UUIDPrefix {
+			continue
+		}
+
+		for _, value := range values {
+			assignment.Labels[v1alpha1.NewSubjectLabelSelector(prefix, value)] = ""
+		}
 	}
 
-	for _, b := range buildarchList {
+	// 4. Add buildarch labels etc...
+	for _, b := range buildarchListOrAll(assignment.Spec.SubjectSelectors.BuildarchList) {
 		assignment.SetBuildarch(b)
 	}
 
+	// 5. Mark assignments specifying a machine selector
+	if assignment.Spec.MachineSelector != nil {
+		assignment.Labels[v1alpha1.MachineSelectorAssignmentLabel] = ""
+	}
+
 	return nil
 }
 
@@ -107,7 +145,7 @@ func (a *Assignment) ValidateCreate(
 		return nil, err // TODO: log + wrap err
 	}
 
-	return nil, nil
+	return a.validateConflicts(ctx, obj)
 }
 
 func (a *Assignment) ValidateUpdate(
@@ -124,7 +162,7 @@ func (a *Assignment) ValidateUpdate(
 		return nil, err // TODO: log + wrap err
 	}
 
-	return nil, nil
+	return a.validateConflicts(ctx, newObj)
 }
 
 func (a *Assignment) ValidateDelete(
@@ -137,8 +175,13 @@ func (a *Assignment) ValidateDelete(
 func (a *Assignment) validateAssignmentStatic(ctx context.Context, obj runtime.Object) error {
 	for _, f := range []validatingFunc{
 		validateUUIDList,
+		validateMACList,
+		validateMachineSelector,
 		validateBuildarchList,
 		validateIsDefault,
+		validateAssignmentParameters,
+		a.validateObjectRefPolicy,
+		validateAllowedCIDRs,
 	} {
 		if err := f(ctx, obj); err != nil {
 			return err // TODO: wrap err
@@ -156,8 +199,6 @@ func (a *Assignment) validateAssignmentDynamic(ctx context.Context, obj runtime.
 
 	for _, f := range []validatingFunc{
 		a.validateProfileName,
-		a.validateDefaultAssignmentForBuildarchIsUnique,
-		a.validateUUIDAssignmentIsUnique,
 	} {
 		if err := f(ctx, obj); err != nil {
 			return err // TODO: wrap err
@@ -195,6 +236,32 @@ func validateUUIDList(_ context.Context, obj runtime.Object) error {
 	return nil
 }
 
+func validateMACList(_ context.Context, obj runtime.Object) error {
+	assignment := obj.(*v1alpha1.Assignment)
+
+	for _, mac := range assignment.Spec.SubjectSelectors.MACList {
+		if _, err := net.ParseMAC(mac); err != nil {
+			return err // TODO: wrap err
+		}
+	}
+
+	return nil
+}
+
+func validateMachineSelector(_ context.Context, obj runtime.Object) error {
+	assignment := obj.(*v1alpha1.Assignment)
+
+	if assignment.Spec.MachineSelector == nil {
+		return nil
+	}
+
+	if _, err := metav1.LabelSelectorAsSelector(assignment.Spec.MachineSelector); err != nil {
+		return errors.Join(errors.New("invalid machineSelector"), err) // TODO: err + wrap err
+	}
+
+	return nil
+}
+
 func validateIsDefault(_ context.Context, obj runtime.Object) error {
 	assignment := obj.(*v1alpha1.Assignment)
 
@@ -202,8 +269,96 @@ func validateIsDefault(_ context.Context, obj runtime.Object) error {
 		return nil
 	}
 
-	if len(assignment.Spec.SubjectSelectors.UUIDList) > 0 {
-		return errors.New("a default assignment must not specify subject selectors of type UUID") // TODO: err + wrap err
+	if assignment.Spec.MachineSelector != nil {
+		return errors.New("a default assignment must not specify a machineSelector") // TODO: err + wrap err
+	}
+
+	byPrefix := assignment.Spec.SubjectSelectors.ByPrefix()
+	for _, prefix := range v1alpha1.SubjectPrefixes {
+		if len(byPrefix[prefix]) == 0 {
+			continue
+		}
+
+		return fmt.Errorf(
+			"a default assignment must not specify subject selectors of type %s", strings.ToUpper(prefix),
+		) // TODO: err + wrap err
+	}
+
+	return nil
+}
+
+// validateAssignmentParameters ensures the parameters of the assignment are valid. The objects they reference are
+// resolved when machines boot.
+func validateAssignmentParameters(_ context.Context, obj runtime.Object) error {
+	assignment := obj.(*v1alpha1.Assignment)
+
+	fldPath := field.NewPath("spec", "parameters")
+	errs := validateParameters(fldPath, assignment.Spec.Parameters)
+
+	for i, param := range assignment.Spec.Parameters {
+		if param.ObjectRef != nil {
+			errs = append(errs, validateRefNamespace(
+				fldPath.Index(i).Child("objectRef"), assignment.Namespace, param.ObjectRef.ResourceRef,
+			)...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind("Assignment").GroupKind(), assignment.Name, errs)
+	}
+
+	return nil
+}
+
+// validateObjectRefPolicy ensures the objects referenced by the parameters of the assignment are allowed by the
+// ObjectRefPolicy for the namespace of the assignment.
+func (a *Assignment) validateObjectRefPolicy(_ context.Context, obj runtime.Object) error {
+	assignment := obj.(*v1alpha1.Assignment)
+
+	fldPath := field.NewPath("spec", "parameters")
+	errs := make(field.ErrorList, 0)
+
+	for i, param := range assignment.Spec.Parameters {
+		if param.ObjectRef == nil {
+			continue
+		}
+
+		ref := param.ObjectRef.ResourceRef
+		err := adapter.CheckObjectRefPolicy(a.objectRefPolicy, types.ObjectRef{
+			Group:             ref.Group,
+			Version:           ref.Version,
+			Resource:          ref.Resource,
+			Namespace:         ref.Namespace,
+			Name:              ref.Name,
+			ReferrerNamespace: assignment.Namespace,
+		})
+		if err != nil {
+			errs = append(errs, field.Forbidden(fldPath.Index(i).Child("objectRef"), err.Error()))
+		}
+	}
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind("Assignment").GroupKind(), assignment.Name, errs)
+	}
+
+	return nil
+}
+
+// validateAllowedCIDRs ensures the allowed CIDRs of the assignment are valid CIDRs.
+func validateAllowedCIDRs(_ context.Context, obj runtime.Object) error {
+	assignment := obj.(*v1alpha1.Assignment)
+
+	fldPath := field.NewPath("spec", "allowedCIDRs")
+	errs := make(field.ErrorList, 0)
+
+	for i, cidr := range assignment.Spec.AllowedCIDRs {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			errs = append(errs, field.Invalid(fldPath.Index(i), cidr, "must be a CIDR, e.g. 10.0.1.0/24"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind("Assignment").GroupKind(), assignment.Name, errs)
 	}
 
 	return nil
@@ -212,8 +367,15 @@ func validateIsDefault(_ context.Context, obj runtime.Object) error {
 func (a *Assignment) validateProfileName(ctx context.Context, obj runtime.Object) error {
 	assignment := obj.(*v1alpha1.Assignment)
 
-	// Use the assignment's namespace to look up the profile
-	_, err := a.profile.GetInNamespace(ctx, assignment.Spec.ProfileName, assignment.Namespace)
+	// Use the assignment's namespace to look up the profile: an assignment cannot reference the profiles of another
+	// namespace.
+	var err error
+	if assignment.Spec.ProfileKind == v1alpha1.ProfileKindClusterProfile {
+		_, err = a.profile.GetClusterProfile(ctx, assignment.Spec.ProfileName)
+	} else {
+		_, err = a.profile.GetInNamespace(ctx, assignment.Spec.ProfileName, assignment.Namespace)
+	}
+
 	if errors.Is(err, adapter.ErrProfileNotFound) {
 		// Return an error if the referred profile does not exist.
 		return errors.New("assignment must specify an existing profileName") // TODO: err + wrap err
@@ -224,73 +386,126 @@ func (a *Assignment) validateProfileName(ctx context.Context, obj runtime.Object
 	return nil
 }
 
-func (a *Assignment) validateDefaultAssignmentForBuildarchIsUnique(ctx context.Context, obj runtime.Object) error {
-	//  A default assignment should be unique for a given list of buildarch.
+// validateConflicts ensures the assignment does not overlap with another assignment of the same priority: such
+// assignments could only be told apart by name. Depending on the conflict policy, overlaps are rejected or admitted
+// with a warning naming the other assignments.
+func (a *Assignment) validateConflicts(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
 	assignment := obj.(*v1alpha1.Assignment)
-	if !assignment.Spec.IsDefault {
-		return nil
+
+	errs, err := a.findConflicts(ctx, assignment)
+	if err != nil {
+		return nil, err // TODO: wrap err
 	}
 
-	for _, b := range assignment.GetBuildarchList() {
-		assign, err := a.assignment.FindDefaultByBuildarch(ctx, b.String())
-		if errors.Is(err, adapter.ErrAssignmentNotFound) {
-			// this is the good scenario
-			continue
-		} else if err != nil {
-			return err // TODO: wrap err
-		}
+	if len(errs) == 0 {
+		return nil, nil
+	}
 
-		if assign.Name == assignment.Name {
-			// update scenario should pass
-			continue
+	if a.conflictPolicy == WarnAssignmentConflictPolicy {
+		warnings := make(admission.Warnings, 0, len(errs))
+		for _, e := range errs {
+			warnings = append(warnings, e.Error())
 		}
 
-		return errors.New("TODO") // TODO: err + wrap err
+		return warnings, nil
 	}
 
-	return nil
+	return nil, apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind("Assignment").GroupKind(), assignment.Name, errs)
 }
 
-func (a *Assignment) validateUUIDAssignmentIsUnique(ctx context.Context, obj runtime.Object) error {
-	assignment := obj.(*v1alpha1.Assignment)
+// findConflicts looks up the assignments indexed by the same default+buildarch labels or by the same UUID labels.
+func (a *Assignment) findConflicts(ctx context.Context, assignment *v1alpha1.Assignment) (field.ErrorList, error) {
+	specPath := field.NewPath("spec")
+	errs := make(field.ErrorList, 0)
 
 	if assignment.Spec.IsDefault {
-		return nil
+		for _, b := range buildarchListOrAll(assignment.Spec.SubjectSelectors.BuildarchList) {
+			others, err := a.assignment.ListDefaultByBuildarch(ctx, b.String())
+			if err != nil {
+				return nil, err // TODO: wrap err
+			}
+
+			if names := conflictingAssignments(assignment, others); len(names) > 0 {
+				errs = append(errs, field.Invalid(specPath.Child("isDefault"), true, fmt.Sprintf(
+					"buildarch %s is already covered by default assignment %s with the same priority",
+					b, strings.Join(names, ", "),
+				)))
+			}
+		}
+
+		return errs, nil
 	}
 
-	// 1. Assignment should be mutually exclusive.
-	// 1.a. Build list of selectors
-	selectorsList := make([]types.IPXESelectors, 0)
-	// this is extremely inefficient
-	for _, id := range assignment.Spec.SubjectSelectors.UUIDList {
+	for i, id := range assignment.Spec.SubjectSelectors.UUIDList {
 		parsed, _ := uuid.Parse(id) // safely ignoring err because it has already been validated.
 
-		selectorsList = append(selectorsList, types.IPXESelectors{
-			UUID: parsed,
-		})
+		others, err := a.assignment.ListByUUID(ctx, parsed)
+		if err != nil {
+			return nil, err // TODO: wrap err
+		}
+
+		if names := conflictingAssignments(assignment, others); len(names) > 0 {
+			errs = append(errs, field.Invalid(specPath.Child("subjectSelectors", "uuidList").Index(i), id, fmt.Sprintf(
+				"uuid is already selected by assignment %s with the same priority",
+				strings.Join(names, ", "),
+			)))
+		}
 	}
 
-	// 1.b. check if we find any match.
-	// TODO: looping over selectors is a very poor operation; use a better solution, such as listing all then filtering.
-	for _, selectors := range selectorsList {
-		// Verify no other Assignment exist for the specified selector.
-		matchedAssignment, err := a.assignment.FindBySelectors(ctx, selectors)
-		if errors.Is(err, adapter.ErrAssignmentNotFound) {
-			// ignore if assignment is not found.
+	return errs, nil
+}
+
+// conflictingAssignments returns the "namespace/name" of the other assignments having the same priority and at least
+// one buildarch in common with the assignment.
+func conflictingAssignments(assignment *v1alpha1.Assignment, others []types.Assignment) []string {
+	out := make([]string, 0)
+
+	for _, other := range others {
+		if other.Name == assignment.Name && other.Namespace == assignment.Namespace {
+			// UPDATE CASE: the assignment being modified can safely be ignored.
+			continue
+		}
+
+		if other.Priority != assignment.Spec.Priority {
+			// the assignment with the highest priority is selected deterministically.
 			continue
-		} else if err != nil {
-			return err // TODO: wrap error
 		}
 
-		if matchedAssignment.Name == assignment.GetName() {
-			// UPDATE CASE: matchedAssignment matches the assignment being modified: we can safely ignore.
+		if !buildarchListsOverlap(
+			assignment.Spec.SubjectSelectors.BuildarchList,
+			other.SubjectSelectors[v1alpha1.BuildarchPrefix],
+		) {
 			continue
 		}
 
-		return errors.Join(
-			errors.New("assignment cannot reference a subject selector referenced "),
-		) // TODO: wrap err
+		out = append(out, fmt.Sprintf("%s/%s", other.Namespace, other.Name))
 	}
 
-	return nil
+	return out
+}
+
+// buildarchListsOverlap returns true if the two lists have at least one buildarch in common. An empty list implies any
+// buildarch.
+func buildarchListsOverlap(a []v1alpha1.Buildarch, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+
+	for _, buildarch := range a {
+		if slices.Contains(b, buildarch.String()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// buildarchListOrAll returns the list, or all the allowed buildarchs if it is empty.
+func buildarchListOrAll(list []v1alpha1.Buildarch) []v1alpha1.Buildarch {
+	if len(list) == 0 {
+		// unspecified implies any buildarch.
+		return slices.Clone(v1alpha1.AllowedBuildarchList)
+	}
+
+	return list
 }
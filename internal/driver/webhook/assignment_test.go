@@ -28,6 +28,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -36,7 +37,12 @@ func TestNewAssignment(t *testing.T) {
 	mockAssignment := mockadapter.NewMockAssignment(t)
 	mockProfile := mockadapter.NewMockProfile(t)
 
-	webhook := webhook.NewAssignment(mockAssignment, mockProfile)
+	webhook := webhook.NewAssignment(
+		mockAssignment,
+		mockProfile,
+		types.ObjectRefPolicy{},
+		webhook.RejectAssignmentConflictPolicy,
+	)
 
 	assert.NotNil(t, webhook)
 }
@@ -74,6 +80,43 @@ func TestAssignment_Default_Success(t *testing.T) {
 				assert.True(t, foundUUID, "should have UUID label")
 			},
 		},
+		{
+			name: "assignment with MAC list creates labels",
+			inputAssignment: &v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test-assignment-mac",
+					Labels: make(map[string]string),
+				},
+				Spec: v1alpha1.AssignmentSpec{
+					SubjectSelectors: v1alpha1.SubjectSelectors{
+						MACList: []string{"52:54:00:12:34:56"},
+					},
+					ProfileName: "test-profile",
+				},
+			},
+			verifyLabels: func(t *testing.T, assignment *v1alpha1.Assignment) {
+				// Should have normalized MAC label
+				assert.Contains(t, assignment.Labels, "mac.shaper.amahdha.com/52-54-00-12-34-56")
+			},
+		},
+		{
+			name: "assignment with machine selector creates label",
+			inputAssignment: &v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test-assignment-machine-selector",
+					Labels: make(map[string]string),
+				},
+				Spec: v1alpha1.AssignmentSpec{
+					MachineSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"platform": "efi"},
+					},
+					ProfileName: "test-profile",
+				},
+			},
+			verifyLabels: func(t *testing.T, assignment *v1alpha1.Assignment) {
+				assert.Contains(t, assignment.Labels, v1alpha1.MachineSelectorAssignmentLabel)
+			},
+		},
 		{
 			name: "assignment with buildarch list creates labels",
 			inputAssignment: &v1alpha1.Assignment{
@@ -114,6 +157,29 @@ func TestAssignment_Default_Success(t *testing.T) {
 				assert.Equal(t, 4, len(buildarchs), "should have all 4 allowed buildarchs")
 			},
 		},
+		{
+			name: "user labels are kept and stale internal labels are removed",
+			inputAssignment: &v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-assignment",
+					Labels: map[string]string{
+						"site": "dc1",
+						"mac.shaper.amahdha.com/52-54-00-00-00-00": "",
+					},
+				},
+				Spec: v1alpha1.AssignmentSpec{
+					SubjectSelectors: v1alpha1.SubjectSelectors{
+						BuildarchList: []v1alpha1.Buildarch{v1alpha1.X8664},
+					},
+					ProfileName: "test-profile",
+				},
+			},
+			verifyLabels: func(t *testing.T, assignment *v1alpha1.Assignment) {
+				assert.Equal(t, "dc1", assignment.Labels["site"])
+				assert.NotContains(t, assignment.Labels, "mac.shaper.amahdha.com/52-54-00-00-00-00")
+				assert.Contains(t, assignment.Labels, v1alpha1.X8664BuildarchLabelSelector)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -121,7 +187,12 @@ func TestAssignment_Default_Success(t *testing.T) {
 			mockAssignment := mockadapter.NewMockAssignment(t)
 			mockProfile := mockadapter.NewMockProfile(t)
 
-			w := webhook.NewAssignment(mockAssignment, mockProfile)
+			w := webhook.NewAssignment(
+				mockAssignment,
+				mockProfile,
+				types.ObjectRefPolicy{},
+				webhook.RejectAssignmentConflictPolicy,
+			)
 
 			ctx := context.Background()
 			err := w.Default(ctx, tt.inputAssignment)
@@ -166,7 +237,12 @@ func TestAssignment_Default_Error(t *testing.T) {
 			mockAssignment := mockadapter.NewMockAssignment(t)
 			mockProfile := mockadapter.NewMockProfile(t)
 
-			w := webhook.NewAssignment(mockAssignment, mockProfile)
+			w := webhook.NewAssignment(
+				mockAssignment,
+				mockProfile,
+				types.ObjectRefPolicy{},
+				webhook.RejectAssignmentConflictPolicy,
+			)
 
 			ctx := context.Background()
 			err := w.Default(ctx, tt.inputObj)
@@ -205,7 +281,7 @@ func TestAssignment_ValidateCreate_Success(t *testing.T) {
 			},
 			setupMocks: func(ma *mockadapter.MockAssignment, mp *mockadapter.MockProfile) {
 				mp.EXPECT().GetInNamespace(mock.Anything, "test-profile", mock.Anything).Return(types.Profile{}, nil)
-				ma.EXPECT().FindBySelectors(mock.Anything, mock.Anything).Return(types.Assignment{}, adapter.ErrAssignmentNotFound)
+				ma.EXPECT().ListByUUID(mock.Anything, testUUID).Return(nil, nil)
 			},
 		},
 		{
@@ -225,7 +301,34 @@ func TestAssignment_ValidateCreate_Success(t *testing.T) {
 			},
 			setupMocks: func(ma *mockadapter.MockAssignment, mp *mockadapter.MockProfile) {
 				mp.EXPECT().GetInNamespace(mock.Anything, "test-profile", mock.Anything).Return(types.Profile{}, nil)
-				ma.EXPECT().FindDefaultByBuildarch(mock.Anything, "x86_64").Return(types.Assignment{}, adapter.ErrAssignmentNotFound)
+				ma.EXPECT().ListDefaultByBuildarch(mock.Anything, "x86_64").Return(nil, nil)
+			},
+		},
+		{
+			name: "valid assignment with parameters",
+			inputAssignment: &v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test-assignment",
+					Labels: make(map[string]string),
+				},
+				Spec: v1alpha1.AssignmentSpec{
+					SubjectSelectors: v1alpha1.SubjectSelectors{
+						UUIDList:      []string{testUUID.String()},
+						BuildarchList: []v1alpha1.Buildarch{v1alpha1.X8664},
+					},
+					ProfileName: "test-profile",
+					Parameters: []v1alpha1.Parameter{
+						{Name: "channel", Value: strPtr("beta")},
+						{Name: "join_token", ObjectRef: &v1alpha1.ObjectRef{
+							ResourceRef: v1alpha1.ResourceRef{Version: "v1", Resource: "secrets", Name: "rack-1"},
+							JSONPath:    ".data.token",
+						}},
+					},
+				},
+			},
+			setupMocks: func(ma *mockadapter.MockAssignment, mp *mockadapter.MockProfile) {
+				mp.EXPECT().GetInNamespace(mock.Anything, "test-profile", mock.Anything).Return(types.Profile{}, nil)
+				ma.EXPECT().ListByUUID(mock.Anything, testUUID).Return(nil, nil)
 			},
 		},
 	}
@@ -237,7 +340,12 @@ func TestAssignment_ValidateCreate_Success(t *testing.T) {
 
 			tt.setupMocks(mockAssignment, mockProfile)
 
-			w := webhook.NewAssignment(mockAssignment, mockProfile)
+			w := webhook.NewAssignment(
+				mockAssignment,
+				mockProfile,
+				types.ObjectRefPolicy{},
+				webhook.RejectAssignmentConflictPolicy,
+			)
 
 			ctx := context.Background()
 			// Call Default() first to set labels (mimics admission webhook flow)
@@ -291,6 +399,106 @@ func TestAssignment_ValidateCreate_StaticError(t *testing.T) {
 			},
 			errorContains: "default assignment must not specify",
 		},
+		{
+			name: "invalid MAC in list",
+			inputObj: &v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "invalid-mac",
+					Labels: make(map[string]string),
+				},
+				Spec: v1alpha1.AssignmentSpec{
+					SubjectSelectors: v1alpha1.SubjectSelectors{
+						MACList: []string{"not-a-mac"},
+					},
+					ProfileName: "test-profile",
+				},
+			},
+			errorContains: "invalid MAC address",
+		},
+		{
+			name: "default assignment with serial selectors",
+			inputObj: &v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "invalid-default-serial",
+					Labels: make(map[string]string),
+				},
+				Spec: v1alpha1.AssignmentSpec{
+					SubjectSelectors: v1alpha1.SubjectSelectors{
+						SerialList: []string{"SN-0042"},
+					},
+					ProfileName: "test-profile",
+					IsDefault:   true,
+				},
+			},
+			errorContains: "subject selectors of type SERIAL",
+		},
+		{
+			name: "invalid machine selector",
+			inputObj: &v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "invalid-machine-selector",
+					Labels: make(map[string]string),
+				},
+				Spec: v1alpha1.AssignmentSpec{
+					MachineSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{
+							Key:      "product",
+							Operator: "Unknown",
+						}},
+					},
+					ProfileName: "test-profile",
+				},
+			},
+			errorContains: "invalid machineSelector",
+		},
+		{
+			name: "default assignment with machine selector",
+			inputObj: &v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "invalid-default-machine-selector",
+					Labels: make(map[string]string),
+				},
+				Spec: v1alpha1.AssignmentSpec{
+					MachineSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"platform": "efi"},
+					},
+					ProfileName: "test-profile",
+					IsDefault:   true,
+				},
+			},
+			errorContains: "must not specify a machineSelector",
+		},
+		{
+			name: "invalid parameters",
+			inputObj: &v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "invalid-parameters",
+					Labels: make(map[string]string),
+				},
+				Spec: v1alpha1.AssignmentSpec{
+					ProfileName: "test-profile",
+					Parameters: []v1alpha1.Parameter{
+						{Name: "channel", Value: strPtr("stable")},
+						{Name: "channel", Value: strPtr("beta")},
+					},
+				},
+			},
+			errorContains: `spec.parameters[1].name: Duplicate value: "channel"`,
+		},
+		{
+			name: "invalid allowed CIDR",
+			inputObj: &v1alpha1.Assignment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "invalid-allowed-cidr",
+					Labels: make(map[string]string),
+				},
+				Spec: v1alpha1.AssignmentSpec{
+					ProfileName:  "test-profile",
+					AllowedCIDRs: []string{"10.0.1.0/24", "10.0.1.42"},
+				},
+			},
+			errorContains: "spec.allowedCIDRs[1]",
+		},
 	}
 
 	for _, tt := range tests {
@@ -298,7 +506,12 @@ func TestAssignment_ValidateCreate_StaticError(t *testing.T) {
 			mockAssignment := mockadapter.NewMockAssignment(t)
 			mockProfile := mockadapter.NewMockProfile(t)
 
-			w := webhook.NewAssignment(mockAssignment, mockProfile)
+			w := webhook.NewAssignment(
+				mockAssignment,
+				mockProfile,
+				types.ObjectRefPolicy{},
+				webhook.RejectAssignmentConflictPolicy,
+			)
 
 			ctx := context.Background()
 			warnings, err := w.ValidateCreate(ctx, tt.inputObj)
@@ -310,6 +523,72 @@ func TestAssignment_ValidateCreate_StaticError(t *testing.T) {
 	}
 }
 
+func TestAssignment_ValidateCreate_ObjectRefPolicy(t *testing.T) {
+	newPolicyAssignment := func(namespace, resource string) *v1alpha1.Assignment {
+		return &v1alpha1.Assignment{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-assignment", Namespace: namespace},
+			Spec: v1alpha1.AssignmentSpec{
+				SubjectSelectors: v1alpha1.SubjectSelectors{BuildarchList: []v1alpha1.Buildarch{v1alpha1.X8664}},
+				ProfileName:      "test-profile",
+				IsDefault:        true,
+				Parameters: []v1alpha1.Parameter{{
+					Name: "token",
+					ObjectRef: &v1alpha1.ObjectRef{
+						ResourceRef: v1alpha1.ResourceRef{
+							Version:   "v1",
+							Resource:  resource,
+							Namespace: namespace,
+							Name:      "token",
+						},
+						JSONPath: ".data.token",
+					},
+				}},
+			},
+		}
+	}
+
+	policy := types.ObjectRefPolicy{Rules: []types.ObjectRefPolicyRule{{
+		ProfileNamespaces: []string{"tenant-a"},
+		Allowed: []types.ObjectRefRule{
+			{Version: "v1", Resource: "secrets", Namespaces: []string{"tenant-a"}},
+		},
+	}}}
+
+	t.Run("Success", func(t *testing.T) {
+		mockAssignment := mockadapter.NewMockAssignment(t)
+		mockProfile := mockadapter.NewMockProfile(t)
+
+		mockProfile.EXPECT().GetInNamespace(mock.Anything, "test-profile", "tenant-a").Return(types.Profile{}, nil)
+		mockAssignment.EXPECT().ListDefaultByBuildarch(mock.Anything, "x86_64").Return(nil, nil)
+
+		w := webhook.NewAssignment(mockAssignment, mockProfile, policy, webhook.RejectAssignmentConflictPolicy)
+
+		_, err := w.ValidateCreate(context.Background(), newPolicyAssignment("tenant-a", "secrets"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		for name, assignment := range map[string]*v1alpha1.Assignment{
+			"assignment namespace not allowed": newPolicyAssignment("tenant-b", "secrets"),
+			"resource not allowed":             newPolicyAssignment("tenant-a", "configmaps"),
+		} {
+			t.Run(name, func(t *testing.T) {
+				w := webhook.NewAssignment(
+					mockadapter.NewMockAssignment(t),
+					mockadapter.NewMockProfile(t),
+					policy,
+					webhook.RejectAssignmentConflictPolicy,
+				)
+
+				_, err := w.ValidateCreate(context.Background(), assignment)
+				assert.True(t, apierrors.IsInvalid(err))
+				assert.ErrorContains(t, err, "spec.parameters[0].objectRef: Forbidden")
+				assert.ErrorContains(t, err, "object ref not allowed")
+			})
+		}
+	})
+}
+
 func TestAssignment_ValidateCreate_DynamicError(t *testing.T) {
 	testUUID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
 
@@ -356,9 +635,12 @@ func TestAssignment_ValidateCreate_DynamicError(t *testing.T) {
 			},
 			setupMocks: func(ma *mockadapter.MockAssignment, mp *mockadapter.MockProfile) {
 				mp.EXPECT().GetInNamespace(mock.Anything, "test-profile", mock.Anything).Return(types.Profile{}, nil)
-				ma.EXPECT().FindBySelectors(mock.Anything, mock.Anything).Return(types.Assignment{}, nil)
+				ma.EXPECT().ListByUUID(mock.Anything, testUUID).Return([]types.Assignment{{
+					Name:      "other-assignment",
+					Namespace: "default",
+				}}, nil)
 			},
-			errorContains: "assignment cannot reference a subject selector",
+			errorContains: "uuid is already selected by assignment default/other-assignment",
 		},
 	}
 
@@ -369,7 +651,12 @@ func TestAssignment_ValidateCreate_DynamicError(t *testing.T) {
 
 			tt.setupMocks(mockAssignment, mockProfile)
 
-			w := webhook.NewAssignment(mockAssignment, mockProfile)
+			w := webhook.NewAssignment(
+				mockAssignment,
+				mockProfile,
+				types.ObjectRefPolicy{},
+				webhook.RejectAssignmentConflictPolicy,
+			)
 
 			ctx := context.Background()
 			warnings, err := w.ValidateCreate(ctx, tt.inputAssignment)
@@ -381,6 +668,131 @@ func TestAssignment_ValidateCreate_DynamicError(t *testing.T) {
 	}
 }
 
+func TestAssignment_ValidateCreate_Conflicts(t *testing.T) {
+	testUUID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+
+	uuidAssignment := &v1alpha1.Assignment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-assignment", Namespace: "default"},
+		Spec: v1alpha1.AssignmentSpec{
+			SubjectSelectors: v1alpha1.SubjectSelectors{
+				UUIDList:      []string{testUUID.String()},
+				BuildarchList: []v1alpha1.Buildarch{v1alpha1.X8664},
+			},
+			ProfileName: "test-profile",
+		},
+	}
+
+	defaultAssignment := &v1alpha1.Assignment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-default", Namespace: "default"},
+		Spec: v1alpha1.AssignmentSpec{
+			SubjectSelectors: v1alpha1.SubjectSelectors{
+				BuildarchList: []v1alpha1.Buildarch{v1alpha1.Arm64},
+			},
+			ProfileName: "test-profile",
+			IsDefault:   true,
+		},
+	}
+
+	tests := []struct {
+		name             string
+		policy           webhook.AssignmentConflictPolicy
+		inputAssignment  *v1alpha1.Assignment
+		setupMocks       func(*mockadapter.MockAssignment)
+		errorContains    string
+		warningsContains string
+	}{
+		{
+			name:            "default assignment conflicts for the same buildarch",
+			policy:          webhook.RejectAssignmentConflictPolicy,
+			inputAssignment: defaultAssignment,
+			setupMocks: func(ma *mockadapter.MockAssignment) {
+				ma.EXPECT().ListDefaultByBuildarch(mock.Anything, "arm64").Return([]types.Assignment{{
+					Name:      "other-default",
+					Namespace: "default",
+				}}, nil)
+			},
+			errorContains: "buildarch arm64 is already covered by default assignment default/other-default",
+		},
+		{
+			name:            "default assignment with a different priority does not conflict",
+			policy:          webhook.RejectAssignmentConflictPolicy,
+			inputAssignment: defaultAssignment,
+			setupMocks: func(ma *mockadapter.MockAssignment) {
+				ma.EXPECT().ListDefaultByBuildarch(mock.Anything, "arm64").Return([]types.Assignment{{
+					Name:      "other-default",
+					Namespace: "default",
+					Priority:  10,
+				}}, nil)
+			},
+		},
+		{
+			name:            "uuid assignment for another buildarch does not conflict",
+			policy:          webhook.RejectAssignmentConflictPolicy,
+			inputAssignment: uuidAssignment,
+			setupMocks: func(ma *mockadapter.MockAssignment) {
+				ma.EXPECT().ListByUUID(mock.Anything, testUUID).Return([]types.Assignment{{
+					Name:             "other-assignment",
+					Namespace:        "default",
+					SubjectSelectors: map[string][]string{v1alpha1.BuildarchPrefix: {"arm64"}},
+				}}, nil)
+			},
+		},
+		{
+			name:            "uuid assignment conflicts are reported as warnings",
+			policy:          webhook.WarnAssignmentConflictPolicy,
+			inputAssignment: uuidAssignment,
+			setupMocks: func(ma *mockadapter.MockAssignment) {
+				ma.EXPECT().ListByUUID(mock.Anything, testUUID).Return([]types.Assignment{
+					{Name: "a", Namespace: "default"},
+					{Name: "b", Namespace: "default"},
+				}, nil)
+			},
+			warningsContains: "uuid is already selected by assignment default/a, default/b",
+		},
+		{
+			name:            "list error",
+			policy:          webhook.WarnAssignmentConflictPolicy,
+			inputAssignment: uuidAssignment,
+			setupMocks: func(ma *mockadapter.MockAssignment) {
+				ma.EXPECT().ListByUUID(mock.Anything, testUUID).Return(nil, assert.AnError)
+			},
+			errorContains: assert.AnError.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAssignment := mockadapter.NewMockAssignment(t)
+			mockProfile := mockadapter.NewMockProfile(t)
+
+			mockProfile.EXPECT().GetInNamespace(mock.Anything, "test-profile", "default").Return(types.Profile{}, nil)
+			tt.setupMocks(mockAssignment)
+
+			w := webhook.NewAssignment(
+				mockAssignment,
+				mockProfile,
+				types.ObjectRefPolicy{},
+				tt.policy,
+			)
+
+			warnings, err := w.ValidateCreate(context.Background(), tt.inputAssignment)
+
+			switch {
+			case tt.errorContains != "":
+				assert.ErrorContains(t, err, tt.errorContains)
+				assert.Nil(t, warnings)
+			case tt.warningsContains != "":
+				assert.NoError(t, err)
+				assert.Len(t, warnings, 1)
+				assert.Contains(t, warnings[0], tt.warningsContains)
+			default:
+				assert.NoError(t, err)
+				assert.Nil(t, warnings)
+			}
+		})
+	}
+}
+
 func TestAssignment_ValidateUpdate(t *testing.T) {
 	testUUID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
 
@@ -421,7 +833,7 @@ func TestAssignment_ValidateUpdate(t *testing.T) {
 			},
 			setupMocks: func(ma *mockadapter.MockAssignment, mp *mockadapter.MockProfile) {
 				mp.EXPECT().GetInNamespace(mock.Anything, "test-profile-updated", mock.Anything).Return(types.Profile{}, nil)
-				ma.EXPECT().FindBySelectors(mock.Anything, mock.Anything).Return(types.Assignment{Name: "test-assignment"}, nil)
+				ma.EXPECT().ListByUUID(mock.Anything, testUUID).Return([]types.Assignment{{Name: "test-assignment"}}, nil)
 			},
 			expectError: false,
 		},
@@ -434,7 +846,12 @@ func TestAssignment_ValidateUpdate(t *testing.T) {
 
 			tt.setupMocks(mockAssignment, mockProfile)
 
-			w := webhook.NewAssignment(mockAssignment, mockProfile)
+			w := webhook.NewAssignment(
+				mockAssignment,
+				mockProfile,
+				types.ObjectRefPolicy{},
+				webhook.RejectAssignmentConflictPolicy,
+			)
 
 			ctx := context.Background()
 			warnings, err := w.ValidateUpdate(ctx, tt.oldAssignment, tt.newAssignment)
@@ -463,7 +880,12 @@ func TestAssignment_ValidateDelete(t *testing.T) {
 	mockAssignment := mockadapter.NewMockAssignment(t)
 	mockProfile := mockadapter.NewMockProfile(t)
 
-	w := webhook.NewAssignment(mockAssignment, mockProfile)
+	w := webhook.NewAssignment(
+		mockAssignment,
+		mockProfile,
+		types.ObjectRefPolicy{},
+		webhook.RejectAssignmentConflictPolicy,
+	)
 
 	ctx := context.Background()
 	warnings, err := w.ValidateDelete(ctx, assignment)
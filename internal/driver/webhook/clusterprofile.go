@@ -0,0 +1,95 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+
+	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var (
+	_ webhook.CustomDefaulter = &ClusterProfile{}
+	_ webhook.CustomValidator = &ClusterProfile{}
+)
+
+// NewClusterProfile returns a new ClusterProfile webhook. A ClusterProfile is defaulted and validated by the Profile
+// webhook as a Profile without namespace.
+func NewClusterProfile(profile *Profile) *ClusterProfile {
+	return &ClusterProfile{
+		profile: profile,
+	}
+}
+
+type ClusterProfile struct {
+	profile *Profile
+}
+
+func (c *ClusterProfile) Default(ctx context.Context, obj runtime.Object) error {
+	clusterProfile, ok := obj.(*v1alpha1.ClusterProfile)
+	if !ok {
+		return NewUnsupportedResource(obj) // TODO: wrap err
+	}
+
+	profile := clusterProfile.AsProfile()
+	if err := c.profile.Default(ctx, profile); err != nil {
+		return err // TODO: wrap err
+	}
+
+	clusterProfile.Labels = profile.Labels
+
+	return nil
+}
+
+func (c *ClusterProfile) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	clusterProfile, ok := obj.(*v1alpha1.ClusterProfile)
+	if !ok {
+		return nil, NewUnsupportedResource(obj) // TODO: wrap err
+	}
+
+	return c.profile.ValidateCreate(ctx, clusterProfile.AsProfile())
+}
+
+func (c *ClusterProfile) ValidateUpdate(
+	ctx context.Context,
+	oldObj, newObj runtime.Object,
+) (admission.Warnings, error) {
+	oldClusterProfile, ok := oldObj.(*v1alpha1.ClusterProfile)
+	if !ok {
+		return nil, NewUnsupportedResource(oldObj) // TODO: wrap err
+	}
+
+	newClusterProfile, ok := newObj.(*v1alpha1.ClusterProfile)
+	if !ok {
+		return nil, NewUnsupportedResource(newObj) // TODO: wrap err
+	}
+
+	return c.profile.ValidateUpdate(ctx, oldClusterProfile.AsProfile(), newClusterProfile.AsProfile())
+}
+
+// ValidateDelete warns about or, depending on the deletion policy, rejects the deletion of a cluster profile still
+// referenced by the assignments of any namespace.
+func (c *ClusterProfile) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	clusterProfile, ok := obj.(*v1alpha1.ClusterProfile)
+	if !ok {
+		return nil, NewUnsupportedResource(obj) // TODO: wrap err
+	}
+
+	return c.profile.ValidateDelete(ctx, clusterProfile.AsProfile())
+}
@@ -0,0 +1,106 @@
+//go:build unit
+
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alexandremahdhaoui/shaper/internal/driver/webhook"
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockadapter"
+	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newClusterProfile() *v1alpha1.ClusterProfile {
+	return &v1alpha1.ClusterProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-cluster-profile",
+		},
+		Spec: v1alpha1.ProfileSpec{
+			IPXETemplate: "#!ipxe\nchain {{ .AdditionalContent.config }}",
+			AdditionalContent: []v1alpha1.AdditionalContent{
+				{Name: "config", Exposed: true, Inline: strPtr("config")},
+			},
+		},
+	}
+}
+
+func TestClusterProfile_Default(t *testing.T) {
+	clusterProfile := newClusterProfile()
+
+	err := webhook.NewClusterProfile(newProfileWebhook(t)).Default(context.Background(), clusterProfile)
+	assert.NoError(t, err)
+
+	// The UUID of the exposed content is recorded in the labels of the cluster profile.
+	assert.Len(t, clusterProfile.Labels, 1)
+	for key, value := range clusterProfile.Labels {
+		assert.Contains(t, key, v1alpha1.UUIDPrefix)
+		assert.Equal(t, "config", value)
+	}
+}
+
+func TestClusterProfile_ValidateCreate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		clusterProfile := newClusterProfile()
+		p := webhook.NewClusterProfile(newProfileWebhook(t))
+
+		assert.NoError(t, p.Default(context.Background(), clusterProfile))
+
+		_, err := p.ValidateCreate(context.Background(), clusterProfile)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		clusterProfile := newClusterProfile()
+		clusterProfile.Spec.IPXETemplate = ""
+
+		_, err := webhook.NewClusterProfile(newProfileWebhook(t)).ValidateCreate(context.Background(), clusterProfile)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.ErrorContains(t, err, "ClusterProfile")
+	})
+
+	t.Run("Unsupported resource", func(t *testing.T) {
+		_, err := webhook.NewClusterProfile(newProfileWebhook(t)).ValidateCreate(context.Background(), &v1alpha1.Profile{})
+		assert.Error(t, err)
+	})
+}
+
+func TestClusterProfile_ValidateDelete(t *testing.T) {
+	assignment := mockadapter.NewMockAssignment(t)
+	assignment.EXPECT().
+		ListByProfileName(mock.Anything, "test-cluster-profile", "").
+		Return([]types.Assignment{{Name: "worker", Namespace: "tenant-a"}}, nil).
+		Once()
+
+	p := webhook.NewProfile(
+		assignment,
+		mockadapter.NewMockProfile(t),
+		mockadapter.NewMockObjectRefResolver(t),
+		types.ObjectRefPolicy{},
+		webhook.BlockProfileDeletionPolicy,
+	)
+
+	_, err := webhook.NewClusterProfile(p).ValidateDelete(context.Background(), newClusterProfile())
+	assert.True(t, apierrors.IsForbidden(err))
+	assert.ErrorContains(t, err, "clusterprofiles")
+	assert.ErrorContains(t, err, "worker")
+}
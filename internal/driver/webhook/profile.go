@@ -17,11 +17,21 @@ package webhook
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"net/url"
 	"regexp"
+	"slices"
+	"strings"
 
+	"github.com/alexandremahdhaoui/shaper/internal/adapter"
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/internal/util/templateutil"
 	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
 	"github.com/google/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/util/jsonpath"
 
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -35,14 +45,86 @@ var (
 	// Regexes
 
 	contentNameRegex = regexp.MustCompile("")
+
+	// templateActionRegex matches the actions of a template, e.g. `{{ .AdditionalContent.config }}`.
+	templateActionRegex = regexp.MustCompile(`(?s){{.*?}}`)
+)
+
+const (
+	// ipxeShebang is the first line of any iPXE script.
+	ipxeShebang = "#!ipxe"
+
+	// additionalContentField is the field of the template data holding the additional content.
+	additionalContentField = "AdditionalContent"
+)
+
+// templateDataFields are the top-level fields of the data available to iPXE templates.
+var templateDataFields = []string{additionalContentField, "Assignment", "BaseURL", "Machine", "Params", "Profile"}
+
+var (
+	// ipxeImageCommands are the iPXE commands requiring an image URI.
+	ipxeImageCommands = map[string]struct{}{
+		"chain":     {},
+		"imgexec":   {},
+		"imgfetch":  {},
+		"imgload":   {},
+		"imgselect": {},
+		"initrd":    {},
+		"kernel":    {},
+		"module":    {},
+	}
+
+	// ipxeSelectingCommands are the iPXE commands selecting the image that `boot` executes.
+	ipxeSelectingCommands = map[string]struct{}{
+		"imgload":   {},
+		"imgselect": {},
+		"kernel":    {},
+	}
+
+	// ipxeOptionsWithValue are the options of the iPXE image commands expecting a value.
+	ipxeOptionsWithValue = map[string]struct{}{
+		"--name":    {},
+		"-n":        {},
+		"--timeout": {},
+		"-t":        {},
+	}
+)
+
+// ProfileDeletionPolicy defines how the deletion of a profile still referenced by assignments is handled.
+type ProfileDeletionPolicy string
+
+const (
+	// WarnProfileDeletionPolicy admits the deletion and warns about the referencing assignments. It is the default.
+	WarnProfileDeletionPolicy ProfileDeletionPolicy = "Warn"
+	// BlockProfileDeletionPolicy rejects the deletion.
+	BlockProfileDeletionPolicy ProfileDeletionPolicy = "Block"
 )
 
-// NewProfile returns a new Profile webhook.
-func NewProfile() *Profile {
-	return &Profile{}
+// NewProfile returns a new Profile webhook. The objects referenced by a profile must be allowed by the objectRefPolicy
+// for the namespace of the profile.
+func NewProfile(
+	assignment adapter.Assignment,
+	profile adapter.Profile,
+	objectRefResolver adapter.ObjectRefResolver,
+	objectRefPolicy types.ObjectRefPolicy,
+	deletionPolicy ProfileDeletionPolicy,
+) *Profile {
+	return &Profile{
+		assignment:        assignment,
+		profile:           profile,
+		objectRefResolver: objectRefResolver,
+		objectRefPolicy:   objectRefPolicy,
+		deletionPolicy:    deletionPolicy,
+	}
 }
 
-type Profile struct{}
+type Profile struct {
+	assignment        adapter.Assignment
+	profile           adapter.Profile
+	objectRefResolver adapter.ObjectRefResolver
+	objectRefPolicy   types.ObjectRefPolicy
+	deletionPolicy    ProfileDeletionPolicy
+}
 
 func (p *Profile) Default(ctx context.Context, obj runtime.Object) error {
 	profile, ok := obj.(*v1alpha1.Profile)
@@ -50,7 +132,8 @@ func (p *Profile) Default(ctx context.Context, obj runtime.Object) error {
 		return NewUnsupportedResource(obj) // TODO: wrap err
 	}
 
-	if err := p.validateProfileStatic(ctx, obj); err != nil {
+	// The iPXE template is only validated by the validating webhook.
+	if err := validateAdditionalContent(ctx, obj); err != nil {
 		return err // TODO: wrap err
 	}
 
@@ -118,14 +201,57 @@ func (p *Profile) ValidateUpdate(
 	return nil, nil
 }
 
-func (p *Profile) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
-	return nil, nil
+// ValidateDelete warns about or, depending on the deletion policy, rejects the deletion of a profile still referenced
+// by assignments.
+func (p *Profile) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	profile, ok := obj.(*v1alpha1.Profile)
+	if !ok {
+		return nil, NewUnsupportedResource(obj) // TODO: wrap err
+	}
+
+	// Defensive nil checks for adapters
+	if p.assignment == nil {
+		return nil, errors.New("webhook adapters not properly initialized")
+	}
+
+	assignments, err := p.assignment.ListByProfileName(ctx, profile.Name, profile.Namespace)
+	if err != nil {
+		return nil, err // TODO: wrap err
+	}
+
+	if len(assignments) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(assignments))
+	for _, a := range assignments {
+		names = append(names, a.Name)
+	}
+
+	slices.Sort(names)
+
+	msg := fmt.Sprintf("profile %q is still referenced by assignments: %s", profile.Name, strings.Join(names, ", "))
+
+	if p.deletionPolicy == BlockProfileDeletionPolicy {
+		return nil, apierrors.NewForbidden(
+			v1alpha1.GroupVersion.WithResource(strings.ToLower(profileKind(profile))+"s").GroupResource(),
+			profile.Name,
+			errors.New(msg),
+		)
+	}
+
+	return admission.Warnings{msg}, nil
 }
 
 func (p *Profile) validateProfileStatic(ctx context.Context, obj runtime.Object) error {
 	for _, f := range []validatingFunc{
-		validateIPXETemplate,
 		validateAdditionalContent,
+		validateProfileParameters,
+		validateProfileRefNamespaces,
+		p.validateObjectRefPolicy,
+		validateBaseProfileRef,
+		validateExternalBaseURL,
+		validateOwnIPXETemplate,
 	} {
 		if err := f(ctx, obj); err != nil {
 			return err // TODO: wrap err
@@ -136,8 +262,14 @@ func (p *Profile) validateProfileStatic(ctx context.Context, obj runtime.Object)
 }
 
 func (p *Profile) validateProfileDynamic(ctx context.Context, obj runtime.Object) error {
+	// Defensive nil checks for adapters
+	if p.profile == nil || p.objectRefResolver == nil {
+		return errors.New("webhook adapters not properly initialized")
+	}
+
 	for _, f := range []validatingFunc{
-		// TODO
+		p.validateInheritance,
+		p.validateObjectRefs,
 	} {
 		if err := f(ctx, obj); err != nil {
 			return err // TODO: wrap err
@@ -147,10 +279,538 @@ func (p *Profile) validateProfileDynamic(ctx context.Context, obj runtime.Object
 	return nil
 }
 
-func validateIPXETemplate(_ context.Context, _ runtime.Object) error {
+// validateBaseProfileRef ensures the profile does not inherit from itself.
+func validateBaseProfileRef(_ context.Context, obj runtime.Object) error {
+	profile := obj.(*v1alpha1.Profile)
+
+	if ref := profile.Spec.BaseProfileRef; ref != nil && ref.Name == profile.Name {
+		return newInvalidProfile(profile, field.ErrorList{
+			field.Invalid(field.NewPath("spec", "baseProfileRef", "name"), ref.Name, "a profile cannot inherit from itself"),
+		})
+	}
+
+	return nil
+}
+
+// validateExternalBaseURL ensures the base URL overriding the one of the shaper API is an absolute http(s) URL.
+func validateExternalBaseURL(_ context.Context, obj runtime.Object) error {
+	profile := obj.(*v1alpha1.Profile)
+
+	if profile.Spec.ExternalBaseURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(profile.Spec.ExternalBaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return newInvalidProfile(profile, field.ErrorList{
+			field.Invalid(field.NewPath("spec", "externalBaseURL"), profile.Spec.ExternalBaseURL,
+				"must be an absolute http or https URL"),
+		})
+	}
+
 	return nil
 }
 
+// validateOwnIPXETemplate validates the iPXE template of a profile without base profile. The iPXE template of a profile
+// inheriting from a base profile is validated once merged by validateInheritance.
+func validateOwnIPXETemplate(ctx context.Context, obj runtime.Object) error {
+	if obj.(*v1alpha1.Profile).Spec.BaseProfileRef != nil {
+		return nil
+	}
+
+	return validateIPXETemplate(ctx, obj)
+}
+
+// validateInheritance ensures the base profiles of the profile exist and do not inherit from the profile, then
+// validates the iPXE template of the merged profile.
+func (p *Profile) validateInheritance(ctx context.Context, obj runtime.Object) error {
+	profile := obj.(*v1alpha1.Profile)
+	if profile.Spec.BaseProfileRef == nil {
+		return nil
+	}
+
+	fldPath := field.NewPath("spec", "baseProfileRef", "name")
+
+	merged, err := p.profile.Merge(ctx, profile)
+	switch {
+	case errors.Is(err, adapter.ErrProfileInheritanceCycle):
+		return newInvalidProfile(profile, field.ErrorList{
+			field.Invalid(fldPath, profile.Spec.BaseProfileRef.Name, inheritanceCycle(err)),
+		})
+	case errors.Is(err, adapter.ErrBaseProfileNotFound):
+		return newInvalidProfile(profile, field.ErrorList{
+			field.NotFound(fldPath, profile.Spec.BaseProfileRef.Name),
+		})
+	case err != nil:
+		return err // TODO: wrap err
+	}
+
+	return validateIPXETemplate(ctx, merged)
+}
+
+// inheritanceCycle returns the message describing the inheritance cycle reported by err.
+func inheritanceCycle(err error) string {
+	for _, line := range strings.Split(err.Error(), "\n") {
+		if strings.HasPrefix(line, adapter.ErrProfileInheritanceCycle.Error()) {
+			return line
+		}
+	}
+
+	return adapter.ErrProfileInheritanceCycle.Error()
+}
+
+// validateIPXETemplate ensures the iPXE template parses, starts with the iPXE shebang, only references declared
+// additional content and uses the iPXE image commands correctly. It also ensures the inline additional content only
+// references declared content without cycles, and that every exposed content is referenced.
+func validateIPXETemplate(_ context.Context, obj runtime.Object) error {
+	profile := obj.(*v1alpha1.Profile)
+	fldPath := field.NewPath("spec", "ipxeTemplate")
+
+	if !strings.HasPrefix(strings.TrimSpace(profile.Spec.IPXETemplate), ipxeShebang) {
+		return newInvalidProfile(profile, field.ErrorList{
+			field.Invalid(fldPath, firstLine(profile.Spec.IPXETemplate), fmt.Sprintf("must start with %q", ipxeShebang)),
+		})
+	}
+
+	tpl, err := templateutil.Parse("ipxeTemplate", profile.Spec.IPXETemplate)
+	if err != nil {
+		return newInvalidProfile(profile, field.ErrorList{
+			field.Invalid(fldPath, profile.Spec.IPXETemplate, err.Error()),
+		})
+	}
+
+	var errs field.ErrorList
+
+	// 1. Every top-level field must exist and every referenced content must be declared.
+	references, fields := templateutil.References(tpl, additionalContentField)
+
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if !slices.Contains(templateDataFields, name) {
+			errs = append(errs, field.Invalid(fldPath, "."+name, fmt.Sprintf(
+				"unknown template field; expected one of %s", strings.Join(templateDataFields, ", "),
+			)))
+		}
+	}
+
+	declared := make(map[string]struct{}, len(profile.Spec.AdditionalContent))
+	for _, content := range profile.Spec.AdditionalContent {
+		declared[content.Name] = struct{}{}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(references)) {
+		if _, ok := declared[name]; !ok && name != templateutil.AllReferences {
+			errs = append(errs, field.NotFound(
+				fldPath,
+				fmt.Sprintf("%s.%s", additionalContentField, name),
+			))
+		}
+	}
+
+	// 2. The inline content may only reference declared content, and must not reference each other in a cycle.
+	contentReferences, contentErrs := validateContentReferences(profile, declared)
+	errs = append(errs, contentErrs...)
+
+	// 3. Every exposed content must be referenced by the iPXE template or by another content.
+	_, referencesAll := references[templateutil.AllReferences]
+
+	for i, content := range profile.Spec.AdditionalContent {
+		_, ok := references[content.Name]
+		ok = ok || referencesAll
+		if _, byContent := contentReferences[content.Name]; content.Exposed && !ok && !byContent {
+			errs = append(errs, field.Invalid(
+				field.NewPath("spec", "additionalContent").Index(i).Child("exposed"),
+				content.Exposed,
+				fmt.Sprintf("exposed content %q is not referenced by spec.ipxeTemplate or by another additional content",
+					content.Name),
+			))
+		}
+	}
+
+	// 4. Lint the iPXE commands.
+	errs = append(errs, lintIPXECommands(fldPath, profile.Spec.IPXETemplate)...)
+
+	if len(errs) > 0 {
+		return newInvalidProfile(profile, errs)
+	}
+
+	return nil
+}
+
+// validateContentReferences ensures the templates of the inline additional content parse, only reference declared
+// content, and do not reference each other in a cycle. It returns the content referenced by another content. The
+// references of objectRef content are only known once resolved, hence they are checked by shaper-api.
+func validateContentReferences(
+	profile *v1alpha1.Profile,
+	declared map[string]struct{},
+) (map[string]struct{}, field.ErrorList) {
+	var errs field.ErrorList
+
+	referenced := make(map[string]struct{})
+	dependencies := make(map[string][]string)
+
+	for i, content := range profile.Spec.AdditionalContent {
+		if content.Inline == nil {
+			continue
+		}
+
+		fldPath := field.NewPath("spec", "additionalContent").Index(i).Child("inline")
+
+		tpl, err := templateutil.Parse(content.Name, *content.Inline)
+		if err != nil {
+			errs = append(errs, field.Invalid(fldPath, *content.Inline, err.Error()))
+			continue
+		}
+
+		keys, _ := templateutil.References(tpl, additionalContentField)
+
+		for _, name := range slices.Sorted(maps.Keys(keys)) {
+			if _, ok := declared[name]; !ok && name != templateutil.AllReferences {
+				errs = append(errs, field.NotFound(fldPath, fmt.Sprintf("%s.%s", additionalContentField, name)))
+			}
+		}
+
+		// The content referencing AdditionalContent as a whole depends on every other content.
+		for _, name := range templateutil.ResolveReferences(keys, declared, content.Name) {
+			if name != content.Name {
+				referenced[name] = struct{}{}
+			}
+
+			dependencies[content.Name] = append(dependencies[content.Name], name)
+		}
+	}
+
+	if _, err := templateutil.SortDependencies(dependencies); err != nil {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "additionalContent"), profile.Name, err.Error()))
+	}
+
+	return referenced, errs
+}
+
+// lintIPXECommands ensures the iPXE image commands, e.g. `kernel` or `chain`, specify an image, and that `boot` is
+// only called without an image after one was selected. Template actions are considered as a single argument.
+func lintIPXECommands(fldPath *field.Path, ipxeTemplate string) field.ErrorList {
+	var errs field.ErrorList
+
+	script := templateActionRegex.ReplaceAllStringFunc(ipxeTemplate, func(action string) string {
+		// Preserve line numbers.
+		return "{{}}" + strings.Repeat("\n", strings.Count(action, "\n"))
+	})
+
+	selected := false
+	lines := strings.Split(script, "\n")
+
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimSpace(lines[i])
+
+		// Join continued lines.
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(lines[i])
+		}
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		for _, statement := range splitIPXEStatement(line) {
+			fields := strings.Fields(statement)
+			if len(fields) == 0 || strings.HasPrefix(fields[0], "{{") {
+				continue
+			}
+
+			command, args := fields[0], ipxeArguments(fields[1:])
+
+			if _, ok := ipxeImageCommands[command]; ok && len(args) == 0 {
+				errs = append(errs, field.Invalid(
+					fldPath,
+					statement,
+					fmt.Sprintf("line %d: %q requires an image URI", lineNumber, command),
+				))
+			}
+
+			if _, ok := ipxeSelectingCommands[command]; ok {
+				selected = true
+			}
+
+			if command == "boot" && len(args) == 0 && !selected {
+				errs = append(errs, field.Invalid(
+					fldPath,
+					statement,
+					fmt.Sprintf("line %d: \"boot\" requires an image URI or a preceding \"kernel\"", lineNumber),
+				))
+			}
+		}
+	}
+
+	return errs
+}
+
+// splitIPXEStatement splits a line on the `||` and `&&` iPXE operators.
+func splitIPXEStatement(line string) []string {
+	var out []string
+
+	for _, s := range strings.Split(line, "||") {
+		out = append(out, strings.Split(s, "&&")...)
+	}
+
+	return out
+}
+
+// ipxeArguments returns the arguments of an iPXE command without its options.
+func ipxeArguments(fields []string) []string {
+	var out []string
+
+	for i := 0; i < len(fields); i++ {
+		if !strings.HasPrefix(fields[i], "-") {
+			out = append(out, fields[i])
+			continue
+		}
+
+		if _, ok := ipxeOptionsWithValue[fields[i]]; ok {
+			i++
+		}
+	}
+
+	return out
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// validateProfileParameters ensures the parameters of the profile are valid.
+func validateProfileParameters(_ context.Context, obj runtime.Object) error {
+	profile := obj.(*v1alpha1.Profile)
+
+	if errs := validateParameters(field.NewPath("spec", "parameters"), profile.Spec.Parameters); len(errs) > 0 {
+		return newInvalidProfile(profile, errs)
+	}
+
+	return nil
+}
+
+// validateProfileRefNamespaces ensures a profile only references objects of its namespace. A ClusterProfile may
+// reference objects of any namespace.
+func validateProfileRefNamespaces(_ context.Context, obj runtime.Object) error {
+	profile := obj.(*v1alpha1.Profile)
+
+	var errs field.ErrorList
+
+	forEachResourceRef(profile, func(fldPath *field.Path, ref v1alpha1.ResourceRef) {
+		errs = append(errs, validateRefNamespace(fldPath, profile.Namespace, ref)...)
+	})
+
+	if len(errs) > 0 {
+		return newInvalidProfile(profile, errs)
+	}
+
+	return nil
+}
+
+// validateObjectRefPolicy ensures the objects referenced by the profile are allowed by the ObjectRefPolicy for the
+// namespace of the profile.
+func (p *Profile) validateObjectRefPolicy(_ context.Context, obj runtime.Object) error {
+	profile := obj.(*v1alpha1.Profile)
+
+	var errs field.ErrorList
+
+	forEachResourceRef(profile, func(fldPath *field.Path, ref v1alpha1.ResourceRef) {
+		err := adapter.CheckObjectRefPolicy(p.objectRefPolicy, types.ObjectRef{
+			Group:             ref.Group,
+			Version:           ref.Version,
+			Resource:          ref.Resource,
+			Namespace:         ref.Namespace,
+			Name:              ref.Name,
+			ReferrerNamespace: profile.Namespace,
+		})
+		if err != nil {
+			errs = append(errs, field.Forbidden(fldPath, err.Error()))
+		}
+	})
+
+	if len(errs) > 0 {
+		return newInvalidProfile(profile, errs)
+	}
+
+	return nil
+}
+
+// forEachResourceRef calls fn with the path and value of each object reference of the profile: the objectRef content
+// sources and parameters, and the mTLS and basic auth references of webhooks.
+func forEachResourceRef(profile *v1alpha1.Profile, fn func(fldPath *field.Path, ref v1alpha1.ResourceRef)) {
+	webhookRefs := func(fldPath *field.Path, cfg *v1alpha1.WebhookConfig) {
+		if cfg.MTLSObjectRef != nil {
+			fn(fldPath.Child("mTLSRef"), cfg.MTLSObjectRef.ResourceRef)
+		}
+
+		if cfg.BasicAuthObjectRef != nil {
+			fn(fldPath.Child("basicAuthRef"), cfg.BasicAuthObjectRef.ResourceRef)
+		}
+	}
+
+	for i, content := range profile.Spec.AdditionalContent {
+		fldPath := field.NewPath("spec", "additionalContent").Index(i)
+
+		if content.ObjectRef != nil {
+			fn(fldPath.Child("objectRef"), content.ObjectRef.ResourceRef)
+		}
+
+		if content.Webhook != nil {
+			webhookRefs(fldPath.Child("webhook"), content.Webhook)
+		}
+
+		for j, transformer := range content.PostTransformations {
+			if transformer.Webhook != nil {
+				webhookRefs(fldPath.Child("postTransformations").Index(j).Child("webhook"), transformer.Webhook)
+			}
+		}
+	}
+
+	for i, param := range profile.Spec.Parameters {
+		if param.ObjectRef != nil {
+			fn(field.NewPath("spec", "parameters").Index(i).Child("objectRef"), param.ObjectRef.ResourceRef)
+		}
+	}
+}
+
+// newInvalidProfile returns the error of an invalid profile. A profile without namespace is a ClusterProfile.
+func newInvalidProfile(profile *v1alpha1.Profile, errs field.ErrorList) error {
+	return apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind(profileKind(profile)).GroupKind(), profile.Name, errs)
+}
+
+// profileKind returns the kind of the profile: a profile without namespace is a ClusterProfile.
+func profileKind(profile *v1alpha1.Profile) string {
+	if profile.Namespace == "" {
+		return string(v1alpha1.ProfileKindClusterProfile)
+	}
+
+	return string(v1alpha1.ProfileKindProfile)
+}
+
+// validateObjectRefs ensures the objects referenced by the profile exist and that their JSONPaths yield a value. It
+// covers the objectRef content sources and parameters, and the mTLS and basic auth references of webhooks.
+func (p *Profile) validateObjectRefs(ctx context.Context, obj runtime.Object) error {
+	profile := obj.(*v1alpha1.Profile)
+
+	var errs field.ErrorList
+
+	for i, content := range profile.Spec.AdditionalContent {
+		fldPath := field.NewPath("spec", "additionalContent").Index(i)
+
+		if content.ObjectRef != nil {
+			errs = append(errs, p.validateObjectRef(ctx, fldPath.Child("objectRef"), content.ObjectRef.ResourceRef,
+				namedJSONPath{name: "jsonpath", path: content.ObjectRef.JSONPath},
+			)...)
+		}
+
+		if content.Webhook != nil {
+			errs = append(errs, p.validateWebhookObjectRefs(ctx, fldPath.Child("webhook"), content.Webhook)...)
+		}
+
+		for j, transformer := range content.PostTransformations {
+			if transformer.Webhook != nil {
+				errs = append(errs, p.validateWebhookObjectRefs(ctx,
+					fldPath.Child("postTransformations").Index(j).Child("webhook"), transformer.Webhook)...)
+			}
+		}
+	}
+
+	for i, param := range profile.Spec.Parameters {
+		if param.ObjectRef != nil {
+			errs = append(errs, p.validateObjectRef(ctx,
+				field.NewPath("spec", "parameters").Index(i).Child("objectRef"), param.ObjectRef.ResourceRef,
+				namedJSONPath{name: "jsonpath", path: param.ObjectRef.JSONPath},
+			)...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return newInvalidProfile(profile, errs)
+	}
+
+	return nil
+}
+
+func (p *Profile) validateWebhookObjectRefs(
+	ctx context.Context,
+	fldPath *field.Path,
+	cfg *v1alpha1.WebhookConfig,
+) field.ErrorList {
+	var errs field.ErrorList
+
+	if ref := cfg.MTLSObjectRef; ref != nil {
+		paths := []namedJSONPath{
+			{name: "clientKeyJSONPath", path: ref.ClientKeyJSONPath},
+			{name: "clientCertJSONPath", path: ref.ClientCertJSONPath},
+		}
+
+		// The CA bundle is optional.
+		if ref.CaBundleJSONPath != "" {
+			paths = append(paths, namedJSONPath{name: "caBundleJSONPath", path: ref.CaBundleJSONPath})
+		}
+
+		errs = append(errs, p.validateObjectRef(ctx, fldPath.Child("mTLSRef"), ref.ResourceRef, paths...)...)
+	}
+
+	if ref := cfg.BasicAuthObjectRef; ref != nil {
+		errs = append(errs, p.validateObjectRef(ctx, fldPath.Child("basicAuthRef"), ref.ResourceRef,
+			namedJSONPath{name: "usernameJSONPath", path: ref.UsernameJSONPath},
+			namedJSONPath{name: "passwordJSONPath", path: ref.PasswordJSONPath},
+		)...)
+	}
+
+	return errs
+}
+
+// namedJSONPath is a JSONPath and the name of the field holding it.
+type namedJSONPath struct {
+	name string
+	path string
+}
+
+// validateObjectRef ensures the referenced object exists and that each JSONPath yields a value.
+func (p *Profile) validateObjectRef(
+	ctx context.Context,
+	fldPath *field.Path,
+	ref v1alpha1.ResourceRef,
+	paths ...namedJSONPath,
+) field.ErrorList {
+	objectRef := types.ObjectRef{
+		Group:     ref.Group,
+		Version:   ref.Version,
+		Resource:  ref.Resource,
+		Namespace: ref.Namespace,
+		Name:      ref.Name,
+	}
+
+	var errs field.ErrorList
+
+	for _, path := range paths {
+		jp, err := adapter.ParseJSONPath(path.path)
+		if err != nil {
+			errs = append(errs, field.Invalid(fldPath.Child(path.name), path.path, err.Error()))
+			continue
+		}
+
+		out, err := p.objectRefResolver.ResolvePaths(ctx, []*jsonpath.JSONPath{jp}, objectRef)
+		if apierrors.IsNotFound(err) {
+			// The object does not exist: other paths would report the same error.
+			return append(errs, field.NotFound(fldPath.Child("name"), fmt.Sprintf("%s/%s", ref.Namespace, ref.Name)))
+		} else if err != nil {
+			errs = append(errs, field.Invalid(fldPath.Child(path.name), path.path, err.Error()))
+			continue
+		}
+
+		if len(out) == 0 || len(out[0]) == 0 {
+			errs = append(errs, field.Invalid(fldPath.Child(path.name), path.path, "jsonpath yields no value"))
+		}
+	}
+
+	return errs
+}
+
 func validateAdditionalContent(ctx context.Context, obj runtime.Object) error {
 	profile := obj.(*v1alpha1.Profile)
 
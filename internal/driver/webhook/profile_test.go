@@ -18,22 +18,61 @@ package webhook_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
+	"github.com/alexandremahdhaoui/shaper/internal/adapter"
 	"github.com/alexandremahdhaoui/shaper/internal/driver/webhook"
+	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/internal/util/mocks/mockadapter"
 	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func strPtr(s string) *string {
 	return &s
 }
 
+// newProfileWebhook returns a Profile webhook whose referenced objects always resolve and whose profiles are never
+// referenced by an assignment.
+func newProfileWebhook(t *testing.T) *webhook.Profile {
+	t.Helper()
+
+	assignment := mockadapter.NewMockAssignment(t)
+	assignment.EXPECT().
+		ListByProfileName(mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, nil).
+		Maybe()
+
+	objectRefResolver := mockadapter.NewMockObjectRefResolver(t)
+	objectRefResolver.EXPECT().
+		ResolvePaths(mock.Anything, mock.Anything, mock.Anything).
+		Return([][]byte{[]byte("value")}, nil).
+		Maybe()
+
+	return webhook.NewProfile(
+		assignment,
+		mockadapter.NewMockProfile(t),
+		objectRefResolver,
+		types.ObjectRefPolicy{},
+		webhook.WarnProfileDeletionPolicy,
+	)
+}
+
 func TestNewProfile(t *testing.T) {
-	p := webhook.NewProfile()
+	p := webhook.NewProfile(
+		mockadapter.NewMockAssignment(t),
+		mockadapter.NewMockProfile(t),
+		mockadapter.NewMockObjectRefResolver(t),
+		types.ObjectRefPolicy{},
+		webhook.WarnProfileDeletionPolicy,
+	)
 	assert.NotNil(t, p)
 }
 
@@ -131,7 +170,7 @@ func TestProfile_Default_Success(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := webhook.NewProfile()
+			p := newProfileWebhook(t)
 			ctx := context.Background()
 			err := p.Default(ctx, tt.inputProfile)
 
@@ -142,7 +181,7 @@ func TestProfile_Default_Success(t *testing.T) {
 }
 
 func TestProfile_Default_Error(t *testing.T) {
-	p := webhook.NewProfile()
+	p := newProfileWebhook(t)
 	ctx := context.Background()
 
 	err := p.Default(ctx, &v1alpha1.Assignment{})
@@ -162,7 +201,7 @@ func TestProfile_ValidateCreate_Success(t *testing.T) {
 					Name: "valid-inline",
 				},
 				Spec: v1alpha1.ProfileSpec{
-					IPXETemplate: "#!ipxe\nboot",
+					IPXETemplate: "#!ipxe\nchain {{ .AdditionalContent.config }}",
 					AdditionalContent: []v1alpha1.AdditionalContent{
 						{
 							Name:    "config",
@@ -180,7 +219,7 @@ func TestProfile_ValidateCreate_Success(t *testing.T) {
 					Name: "valid-objectref",
 				},
 				Spec: v1alpha1.ProfileSpec{
-					IPXETemplate: "#!ipxe\nboot",
+					IPXETemplate: "#!ipxe\nchain {{ .AdditionalContent.config }}",
 					AdditionalContent: []v1alpha1.AdditionalContent{
 						{
 							Name:    "config",
@@ -203,7 +242,7 @@ func TestProfile_ValidateCreate_Success(t *testing.T) {
 					Name: "valid-webhook",
 				},
 				Spec: v1alpha1.ProfileSpec{
-					IPXETemplate: "#!ipxe\nboot",
+					IPXETemplate: "#!ipxe\nchain {{ .AdditionalContent.config }}",
 					AdditionalContent: []v1alpha1.AdditionalContent{
 						{
 							Name:    "config",
@@ -223,7 +262,7 @@ func TestProfile_ValidateCreate_Success(t *testing.T) {
 					Name: "valid-butane",
 				},
 				Spec: v1alpha1.ProfileSpec{
-					IPXETemplate: "#!ipxe\nboot",
+					IPXETemplate: "#!ipxe\nchain {{ .AdditionalContent.ignition }}",
 					AdditionalContent: []v1alpha1.AdditionalContent{
 						{
 							Name:    "ignition",
@@ -246,7 +285,7 @@ func TestProfile_ValidateCreate_Success(t *testing.T) {
 					Name: "valid-webhook-transformer",
 				},
 				Spec: v1alpha1.ProfileSpec{
-					IPXETemplate: "#!ipxe\nboot",
+					IPXETemplate: "#!ipxe\nchain {{ .AdditionalContent.config }}",
 					AdditionalContent: []v1alpha1.AdditionalContent{
 						{
 							Name:    "config",
@@ -268,7 +307,7 @@ func TestProfile_ValidateCreate_Success(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := webhook.NewProfile()
+			p := newProfileWebhook(t)
 			ctx := context.Background()
 			warnings, err := p.ValidateCreate(ctx, tt.inputProfile)
 
@@ -420,11 +459,21 @@ func TestProfile_ValidateCreate_Error(t *testing.T) {
 			},
 			errorContains: "exactly one configuration",
 		},
+		{
+			name: "relative external base URL",
+			inputObj: &v1alpha1.Profile{
+				Spec: v1alpha1.ProfileSpec{
+					IPXETemplate:    "#!ipxe\nexit",
+					ExternalBaseURL: "/shaper",
+				},
+			},
+			errorContains: "spec.externalBaseURL",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := webhook.NewProfile()
+			p := newProfileWebhook(t)
 			ctx := context.Background()
 			warnings, err := p.ValidateCreate(ctx, tt.inputObj)
 
@@ -435,13 +484,194 @@ func TestProfile_ValidateCreate_Error(t *testing.T) {
 	}
 }
 
+func TestProfile_ValidateCreate_IPXETemplate(t *testing.T) {
+	newProfile := func(ipxeTemplate string, content ...v1alpha1.AdditionalContent) *v1alpha1.Profile {
+		return &v1alpha1.Profile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test-profile",
+			},
+			Spec: v1alpha1.ProfileSpec{
+				IPXETemplate:      ipxeTemplate,
+				AdditionalContent: content,
+			},
+		}
+	}
+
+	exposed := v1alpha1.AdditionalContent{Name: "ignition", Exposed: true, Inline: strPtr("data")}
+	inline := v1alpha1.AdditionalContent{Name: "cmdline", Inline: strPtr("console=ttyS0")}
+
+	t.Run("Success", func(t *testing.T) {
+		for _, tt := range []struct {
+			name         string
+			inputProfile *v1alpha1.Profile
+		}{
+			{
+				name: "kernel, initrd and boot",
+				inputProfile: newProfile(`#!ipxe
+# Boot flatcar
+kernel --name vmlinuz http://example.com/vmlinuz ignition.config.url={{ .AdditionalContent.ignition }} \
+  {{ .AdditionalContent.cmdline }}
+initrd http://example.com/initrd.img
+boot || goto failed
+:failed
+shell
+`, exposed, inline),
+			},
+			{
+				name:         "index reference",
+				inputProfile: newProfile(`#!ipxe`+"\n"+`chain {{ index .AdditionalContent "ignition" }}`, exposed),
+			},
+			{
+				name: "conditional reference",
+				inputProfile: newProfile(
+					"#!ipxe\n{{ if .AdditionalContent.cmdline }}\nchain {{ .AdditionalContent.ignition }}\n{{ end }}",
+					exposed, inline),
+			},
+			{
+				name: "machine, assignment and profile fields",
+				inputProfile: newProfile(`#!ipxe
+echo {{ .Profile.Name }} {{ .Assignment.Name }} {{ .Machine.UUID }}
+{{ range $k, $v := .Assignment.Labels }}echo {{ $k }}={{ $v }}{{ end }}
+{{ with .Machine }}echo {{ .MAC }}{{ end }}
+chain {{ .BaseURL }}/boot?buildarch={{ .Machine.Buildarch }} ignition={{ .AdditionalContent.ignition }}`, exposed),
+			},
+			{
+				name: "template functions",
+				inputProfile: newProfile(`#!ipxe
+echo {{ .Machine.Hostname | default "unknown" | upper }}
+chain {{ .AdditionalContent.ignition }}?hostname={{ .Machine.Hostname | urlquery }}&{{ ipxeEscape .Profile.Name }}`,
+					exposed),
+			},
+			{
+				name: "exposed content referenced by another content",
+				inputProfile: newProfile("#!ipxe\nkernel http://example.com/vmlinuz {{ .AdditionalContent.cmdline }}\nboot",
+					exposed, v1alpha1.AdditionalContent{
+						Name:   "cmdline",
+						Inline: strPtr("ignition.config.url={{ .AdditionalContent.ignition }}"),
+					}),
+			},
+			{
+				name: "root variable reference",
+				inputProfile: newProfile(
+					"#!ipxe\n{{ range .Assignment.Labels }}chain {{ $.AdditionalContent.ignition }}{{ end }}", exposed),
+			},
+			{
+				name:         "exposed content referenced through the whole map",
+				inputProfile: newProfile("#!ipxe\n{{ with .AdditionalContent }}chain {{ .ignition }}{{ end }}", exposed),
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				warnings, err := newProfileWebhook(t).ValidateCreate(context.Background(), tt.inputProfile)
+				assert.NoError(t, err)
+				assert.Nil(t, warnings)
+			})
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		for _, tt := range []struct {
+			name          string
+			inputProfile  *v1alpha1.Profile
+			errorContains []string
+		}{
+			{
+				name:          "missing shebang",
+				inputProfile:  newProfile("chain http://example.com/boot"),
+				errorContains: []string{`spec.ipxeTemplate: Invalid value: "chain http://example.com/boot"`, "#!ipxe"},
+			},
+			{
+				name:          "parse error",
+				inputProfile:  newProfile("#!ipxe\nchain {{ .AdditionalContent.ignition"),
+				errorContains: []string{"spec.ipxeTemplate", "unclosed action"},
+			},
+			{
+				name:          "undeclared content",
+				inputProfile:  newProfile("#!ipxe\nchain {{ .AdditionalContent.missing }}"),
+				errorContains: []string{`spec.ipxeTemplate: Not found: "AdditionalContent.missing"`},
+			},
+			{
+				name:          "unknown function",
+				inputProfile:  newProfile("#!ipxe\nchain {{ .AdditionalContent.ignition | shell }}", exposed),
+				errorContains: []string{"spec.ipxeTemplate", `function "shell" not defined`},
+			},
+			{
+				name:          "unknown top-level field",
+				inputProfile:  newProfile("#!ipxe\nchain http://example.com/{{ .Hostname }}"),
+				errorContains: []string{`spec.ipxeTemplate: Invalid value: ".Hostname"`, "unknown template field"},
+			},
+			{
+				name:          "unreferenced exposed content",
+				inputProfile:  newProfile("#!ipxe\nchain http://example.com/boot", inline, exposed),
+				errorContains: []string{"spec.additionalContent[1].exposed", `"ignition" is not referenced`},
+			},
+			{
+				name: "content references undeclared content",
+				inputProfile: newProfile("#!ipxe\nchain {{ .AdditionalContent.ignition }}",
+					exposed, v1alpha1.AdditionalContent{Name: "cmdline", Inline: strPtr("{{ .AdditionalContent.missing }}")}),
+				errorContains: []string{`spec.additionalContent[1].inline: Not found: "AdditionalContent.missing"`},
+			},
+			{
+				name: "content parse error",
+				inputProfile: newProfile("#!ipxe\nchain {{ .AdditionalContent.ignition }}",
+					exposed, v1alpha1.AdditionalContent{Name: "cmdline", Inline: strPtr("{{ .AdditionalContent")}),
+				errorContains: []string{"spec.additionalContent[1].inline"},
+			},
+			{
+				name: "content reference cycle",
+				inputProfile: newProfile("#!ipxe\nchain {{ .AdditionalContent.a }}",
+					v1alpha1.AdditionalContent{Name: "a", Exposed: true, Inline: strPtr("{{ .AdditionalContent.b }}")},
+					v1alpha1.AdditionalContent{Name: "b", Inline: strPtr("{{ .AdditionalContent.a }}")}),
+				errorContains: []string{"spec.additionalContent", "reference cycle: a -> b -> a"},
+			},
+			{
+				name: "content references itself",
+				inputProfile: newProfile("#!ipxe\nchain {{ .AdditionalContent.ignition }}", v1alpha1.AdditionalContent{
+					Name: "ignition", Exposed: true, Inline: strPtr("{{ .AdditionalContent.ignition }}"),
+				}),
+				errorContains: []string{"reference cycle: ignition -> ignition"},
+			},
+			{
+				name: "contents referencing each other through the whole map",
+				inputProfile: newProfile("#!ipxe\nchain {{ .AdditionalContent.a }}",
+					v1alpha1.AdditionalContent{Name: "a", Exposed: true, Inline: strPtr("{{ range .AdditionalContent }}{{ end }}")},
+					v1alpha1.AdditionalContent{Name: "b", Inline: strPtr("{{ $.AdditionalContent.a }}")}),
+				errorContains: []string{"spec.additionalContent", "reference cycle: a -> b -> a"},
+			},
+			{
+				name:          "kernel without image",
+				inputProfile:  newProfile("#!ipxe\nkernel --name vmlinuz\nboot"),
+				errorContains: []string{`line 2: "kernel" requires an image URI`},
+			},
+			{
+				name:          "initrd without image",
+				inputProfile:  newProfile("#!ipxe\nkernel http://example.com/vmlinuz\ninitrd\nboot"),
+				errorContains: []string{`line 3: "initrd" requires an image URI`},
+			},
+			{
+				name:          "boot without selected image",
+				inputProfile:  newProfile("#!ipxe\ninitrd http://example.com/initrd.img\nboot"),
+				errorContains: []string{`line 3: "boot" requires an image URI or a preceding "kernel"`},
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				warnings, err := newProfileWebhook(t).ValidateCreate(context.Background(), tt.inputProfile)
+				assert.True(t, apierrors.IsInvalid(err))
+				for _, s := range tt.errorContains {
+					assert.ErrorContains(t, err, s)
+				}
+				assert.Nil(t, warnings)
+			})
+		}
+	})
+}
+
 func TestProfile_ValidateUpdate(t *testing.T) {
 	oldProfile := &v1alpha1.Profile{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "test-profile",
 		},
 		Spec: v1alpha1.ProfileSpec{
-			IPXETemplate: "#!ipxe\nboot old",
+			IPXETemplate: "#!ipxe\necho old\nchain {{ .AdditionalContent.config }}",
 			AdditionalContent: []v1alpha1.AdditionalContent{
 				{
 					Name:    "config",
@@ -457,7 +687,7 @@ func TestProfile_ValidateUpdate(t *testing.T) {
 			Name: "test-profile",
 		},
 		Spec: v1alpha1.ProfileSpec{
-			IPXETemplate: "#!ipxe\nboot new",
+			IPXETemplate: "#!ipxe\necho new\nchain {{ .AdditionalContent.config }}",
 			AdditionalContent: []v1alpha1.AdditionalContent{
 				{
 					Name:    "config",
@@ -468,7 +698,7 @@ func TestProfile_ValidateUpdate(t *testing.T) {
 		},
 	}
 
-	p := webhook.NewProfile()
+	p := newProfileWebhook(t)
 	ctx := context.Background()
 	warnings, err := p.ValidateUpdate(ctx, oldProfile, newProfile)
 
@@ -476,20 +706,465 @@ func TestProfile_ValidateUpdate(t *testing.T) {
 	assert.Nil(t, warnings)
 }
 
+func TestProfile_ValidateCreate_ObjectRefs(t *testing.T) {
+	newObjectRefProfile := func() *v1alpha1.Profile {
+		return &v1alpha1.Profile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test-profile",
+			},
+			Spec: v1alpha1.ProfileSpec{
+				IPXETemplate: "#!ipxe\nchain {{ .AdditionalContent.config }}",
+				AdditionalContent: []v1alpha1.AdditionalContent{
+					{
+						Name:    "config",
+						Exposed: true,
+						ObjectRef: &v1alpha1.ObjectRef{
+							ResourceRef: v1alpha1.ResourceRef{
+								Group:     "",
+								Version:   "v1",
+								Resource:  "configmaps",
+								Namespace: "default",
+								Name:      "config",
+							},
+							JSONPath: ".data.config",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		resolved    [][]byte
+		resolvedErr error
+		expectedErr string
+	}{
+		{
+			name:     "referenced object resolves",
+			resolved: [][]byte{[]byte("value")},
+		},
+		{
+			name:        "referenced object not found",
+			resolvedErr: apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "config"),
+			expectedErr: "spec.additionalContent[0].objectRef.name: Not found: \"default/config\"",
+		},
+		{
+			name:        "jsonpath yields no value",
+			resolved:    [][]byte{},
+			expectedErr: "spec.additionalContent[0].objectRef.jsonpath",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objectRefResolver := mockadapter.NewMockObjectRefResolver(t)
+			objectRefResolver.EXPECT().
+				ResolvePaths(mock.Anything, mock.Anything, types.ObjectRef{
+					Version:   "v1",
+					Resource:  "configmaps",
+					Namespace: "default",
+					Name:      "config",
+				}).
+				Return(tt.resolved, tt.resolvedErr).
+				Once()
+
+			p := webhook.NewProfile(
+				mockadapter.NewMockAssignment(t),
+				mockadapter.NewMockProfile(t),
+				objectRefResolver,
+				types.ObjectRefPolicy{},
+				webhook.WarnProfileDeletionPolicy,
+			)
+
+			_, err := p.ValidateCreate(context.Background(), newObjectRefProfile())
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.True(t, apierrors.IsInvalid(err))
+			assert.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestProfile_ValidateCreate_Parameters(t *testing.T) {
+	secretRef := &v1alpha1.ObjectRef{
+		ResourceRef: v1alpha1.ResourceRef{Version: "v1", Resource: "secrets", Namespace: "default", Name: "rack-1"},
+		JSONPath:    ".data.token",
+	}
+
+	newParamsProfile := func(params ...v1alpha1.Parameter) *v1alpha1.Profile {
+		return &v1alpha1.Profile{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-profile"},
+			Spec: v1alpha1.ProfileSpec{
+				IPXETemplate: "#!ipxe\nchain http://example.com/{{ .Params.channel }}/boot.ipxe",
+				Parameters:   params,
+			},
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		warnings, err := newProfileWebhook(t).ValidateCreate(context.Background(), newParamsProfile(
+			v1alpha1.Parameter{Name: "channel", Value: strPtr("stable")},
+			v1alpha1.Parameter{Name: "join_token", ObjectRef: secretRef},
+		))
+		assert.NoError(t, err)
+		assert.Nil(t, warnings)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		for _, tt := range []struct {
+			name          string
+			params        []v1alpha1.Parameter
+			errorContains string
+		}{
+			{
+				name:          "invalid name",
+				params:        []v1alpha1.Parameter{{Name: "ntp-server", Value: strPtr("pool.ntp.org")}},
+				errorContains: `spec.parameters[0].name: Invalid value: "ntp-server"`,
+			},
+			{
+				name: "duplicate name",
+				params: []v1alpha1.Parameter{
+					{Name: "channel", Value: strPtr("stable")},
+					{Name: "channel", Value: strPtr("beta")},
+				},
+				errorContains: `spec.parameters[1].name: Duplicate value: "channel"`,
+			},
+			{
+				name:          "no source",
+				params:        []v1alpha1.Parameter{{Name: "channel"}},
+				errorContains: "spec.parameters[0]: Required value",
+			},
+			{
+				name:          "both sources",
+				params:        []v1alpha1.Parameter{{Name: "channel", Value: strPtr("stable"), ObjectRef: secretRef}},
+				errorContains: "spec.parameters[0]: Forbidden",
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				warnings, err := newProfileWebhook(t).ValidateCreate(context.Background(), newParamsProfile(tt.params...))
+				assert.True(t, apierrors.IsInvalid(err))
+				assert.ErrorContains(t, err, tt.errorContains)
+				assert.Nil(t, warnings)
+			})
+		}
+
+		t.Run("referenced object not found", func(t *testing.T) {
+			objectRefResolver := mockadapter.NewMockObjectRefResolver(t)
+			objectRefResolver.EXPECT().
+				ResolvePaths(mock.Anything, mock.Anything, mock.Anything).
+				Return(nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "rack-1")).
+				Once()
+
+			p := webhook.NewProfile(
+				mockadapter.NewMockAssignment(t),
+				mockadapter.NewMockProfile(t),
+				objectRefResolver,
+				types.ObjectRefPolicy{},
+				webhook.WarnProfileDeletionPolicy,
+			)
+
+			_, err := p.ValidateCreate(context.Background(), newParamsProfile(
+				v1alpha1.Parameter{Name: "join_token", ObjectRef: secretRef},
+			))
+			assert.True(t, apierrors.IsInvalid(err))
+			assert.ErrorContains(t, err, `spec.parameters[0].objectRef.name: Not found: "default/rack-1"`)
+		})
+	})
+}
+
+func TestProfile_ValidateCreate_Inheritance(t *testing.T) {
+	newChild := func(content ...v1alpha1.AdditionalContent) *v1alpha1.Profile {
+		return &v1alpha1.Profile{
+			ObjectMeta: metav1.ObjectMeta{Name: "child", Namespace: "default"},
+			Spec: v1alpha1.ProfileSpec{
+				BaseProfileRef:    &v1alpha1.ProfileReference{Name: "base"},
+				AdditionalContent: content,
+			},
+		}
+	}
+
+	// merge returns the child with the iPXE template of its base.
+	merge := func(ipxeTemplate string) func(context.Context, *v1alpha1.Profile) (*v1alpha1.Profile, error) {
+		return func(_ context.Context, profile *v1alpha1.Profile) (*v1alpha1.Profile, error) {
+			merged := profile.DeepCopy()
+			merged.Spec.IPXETemplate = ipxeTemplate
+
+			return merged, nil
+		}
+	}
+
+	for _, tt := range []struct {
+		name          string
+		profile       *v1alpha1.Profile
+		merge         func(context.Context, *v1alpha1.Profile) (*v1alpha1.Profile, error)
+		errorContains string
+	}{
+		{
+			name:    "inherited template",
+			profile: newChild(v1alpha1.AdditionalContent{Name: "config", Exposed: true, Inline: strPtr("qwe")}),
+			merge:   merge("#!ipxe\nchain {{ .AdditionalContent.config }}"),
+		},
+		{
+			name:          "merged template does not reference exposed content",
+			profile:       newChild(v1alpha1.AdditionalContent{Name: "config", Exposed: true, Inline: strPtr("qwe")}),
+			merge:         merge("#!ipxe\nchain http://example.com/boot.ipxe"),
+			errorContains: `exposed content "config" is not referenced by spec.ipxeTemplate`,
+		},
+		{
+			name: "self reference",
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "base", Namespace: "default"},
+				Spec:       v1alpha1.ProfileSpec{BaseProfileRef: &v1alpha1.ProfileReference{Name: "base"}},
+			},
+			errorContains: "a profile cannot inherit from itself",
+		},
+		{
+			name:    "inheritance cycle",
+			profile: newChild(),
+			merge: func(context.Context, *v1alpha1.Profile) (*v1alpha1.Profile, error) {
+				return nil, fmt.Errorf("%w: child -> base -> child", adapter.ErrProfileInheritanceCycle)
+			},
+			errorContains: `spec.baseProfileRef.name: Invalid value: "base": profile inheritance cycle: child -> base -> child`,
+		},
+		{
+			name:    "base profile not found",
+			profile: newChild(),
+			merge: func(context.Context, *v1alpha1.Profile) (*v1alpha1.Profile, error) {
+				return nil, adapter.ErrBaseProfileNotFound
+			},
+			errorContains: `spec.baseProfileRef.name: Not found: "base"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := mockadapter.NewMockProfile(t)
+			if tt.merge != nil {
+				profile.EXPECT().Merge(mock.Anything, tt.profile).RunAndReturn(tt.merge).Once()
+			}
+
+			p := webhook.NewProfile(
+				mockadapter.NewMockAssignment(t),
+				profile,
+				mockadapter.NewMockObjectRefResolver(t),
+				types.ObjectRefPolicy{},
+				webhook.WarnProfileDeletionPolicy,
+			)
+
+			_, err := p.ValidateCreate(context.Background(), tt.profile)
+			if tt.errorContains == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.True(t, apierrors.IsInvalid(err))
+			assert.ErrorContains(t, err, tt.errorContains)
+		})
+	}
+}
+
 func TestProfile_ValidateDelete(t *testing.T) {
 	profile := &v1alpha1.Profile{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "test-profile",
+			Name:      "test-profile",
+			Namespace: "default",
 		},
 		Spec: v1alpha1.ProfileSpec{
 			IPXETemplate: "#!ipxe\nboot",
 		},
 	}
 
-	p := webhook.NewProfile()
-	ctx := context.Background()
-	warnings, err := p.ValidateDelete(ctx, profile)
+	referencing := []types.Assignment{
+		{Name: "worker", Namespace: "default"},
+		{Name: "control-plane", Namespace: "default"},
+	}
 
-	assert.NoError(t, err)
-	assert.Nil(t, warnings)
+	tests := []struct {
+		name             string
+		policy           webhook.ProfileDeletionPolicy
+		assignments      []types.Assignment
+		expectedWarnings bool
+		expectForbidden  bool
+	}{
+		{
+			name:   "unreferenced profile",
+			policy: webhook.BlockProfileDeletionPolicy,
+		},
+		{
+			name:             "referenced profile with Warn policy",
+			policy:           webhook.WarnProfileDeletionPolicy,
+			assignments:      referencing,
+			expectedWarnings: true,
+		},
+		{
+			name:             "referenced profile with default policy",
+			policy:           "",
+			assignments:      referencing,
+			expectedWarnings: true,
+		},
+		{
+			name:            "referenced profile with Block policy",
+			policy:          webhook.BlockProfileDeletionPolicy,
+			assignments:     referencing,
+			expectForbidden: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignment := mockadapter.NewMockAssignment(t)
+			assignment.EXPECT().
+				ListByProfileName(mock.Anything, "test-profile", "default").
+				Return(tt.assignments, nil).
+				Once()
+
+			p := webhook.NewProfile(
+				assignment,
+				mockadapter.NewMockProfile(t),
+				mockadapter.NewMockObjectRefResolver(t),
+				types.ObjectRefPolicy{},
+				tt.policy,
+			)
+			warnings, err := p.ValidateDelete(context.Background(), profile)
+
+			switch {
+			case tt.expectForbidden:
+				assert.True(t, apierrors.IsForbidden(err))
+				assert.ErrorContains(t, err, "control-plane, worker")
+				assert.Nil(t, warnings)
+			case tt.expectedWarnings:
+				assert.NoError(t, err)
+				assert.Len(t, warnings, 1)
+				assert.Contains(t, warnings[0], "control-plane, worker")
+			default:
+				assert.NoError(t, err)
+				assert.Nil(t, warnings)
+			}
+		})
+	}
+}
+
+func TestProfile_ValidateCreate_RefNamespaces(t *testing.T) {
+	newRefProfile := func(namespace, refNamespace string) *v1alpha1.Profile {
+		return &v1alpha1.Profile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-profile",
+				Namespace: namespace,
+			},
+			Spec: v1alpha1.ProfileSpec{
+				IPXETemplate: "#!ipxe\nchain {{ .AdditionalContent.config }}",
+				AdditionalContent: []v1alpha1.AdditionalContent{{
+					Name: "config",
+					ObjectRef: &v1alpha1.ObjectRef{
+						ResourceRef: v1alpha1.ResourceRef{
+							Version:   "v1",
+							Resource:  "configmaps",
+							Namespace: refNamespace,
+							Name:      "config",
+						},
+						JSONPath: ".data.config",
+					},
+				}},
+			},
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		for name, profile := range map[string]*v1alpha1.Profile{
+			"same namespace":             newRefProfile("tenant-a", "tenant-a"),
+			"cluster profile references": newRefProfile("", "tenant-b"),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := newProfileWebhook(t).ValidateCreate(context.Background(), profile)
+				assert.NoError(t, err)
+			})
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		_, err := newProfileWebhook(t).ValidateCreate(context.Background(), newRefProfile("tenant-a", "tenant-b"))
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.ErrorContains(t, err, "spec.additionalContent[0].objectRef.namespace: Forbidden")
+	})
+}
+
+func TestProfile_ValidateCreate_ObjectRefPolicy(t *testing.T) {
+	newPolicyProfile := func(namespace, refNamespace string) *v1alpha1.Profile {
+		return &v1alpha1.Profile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-profile",
+				Namespace: namespace,
+			},
+			Spec: v1alpha1.ProfileSpec{
+				IPXETemplate: "#!ipxe\nchain {{ .AdditionalContent.config }}",
+				AdditionalContent: []v1alpha1.AdditionalContent{{
+					Name:   "config",
+					Inline: strPtr("qwe"),
+				}},
+				Parameters: []v1alpha1.Parameter{{
+					Name: "token",
+					ObjectRef: &v1alpha1.ObjectRef{
+						ResourceRef: v1alpha1.ResourceRef{
+							Version:   "v1",
+							Resource:  "secrets",
+							Namespace: refNamespace,
+							Name:      "token",
+						},
+						JSONPath: ".data.token",
+					},
+				}},
+			},
+		}
+	}
+
+	newPolicyProfileWebhook := func(t *testing.T) *webhook.Profile {
+		t.Helper()
+
+		objectRefResolver := mockadapter.NewMockObjectRefResolver(t)
+		objectRefResolver.EXPECT().
+			ResolvePaths(mock.Anything, mock.Anything, mock.Anything).
+			Return([][]byte{[]byte("value")}, nil).
+			Maybe()
+
+		return webhook.NewProfile(
+			mockadapter.NewMockAssignment(t),
+			mockadapter.NewMockProfile(t),
+			objectRefResolver,
+			types.ObjectRefPolicy{Rules: []types.ObjectRefPolicyRule{{
+				ProfileNamespaces: []string{"tenant-a", ""},
+				Allowed: []types.ObjectRefRule{
+					{Version: "v1", Resource: "secrets", Namespaces: []string{"tenant-a"}},
+				},
+			}}},
+			webhook.WarnProfileDeletionPolicy,
+		)
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		for name, profile := range map[string]*v1alpha1.Profile{
+			"allowed namespace":       newPolicyProfile("tenant-a", "tenant-a"),
+			"cluster profile allowed": newPolicyProfile("", "tenant-a"),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := newPolicyProfileWebhook(t).ValidateCreate(context.Background(), profile)
+				assert.NoError(t, err)
+			})
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		for name, profile := range map[string]*v1alpha1.Profile{
+			"profile namespace not allowed": newPolicyProfile("tenant-b", "tenant-b"),
+			"object namespace not allowed":  newPolicyProfile("", "kube-system"),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := newPolicyProfileWebhook(t).ValidateCreate(context.Background(), profile)
+				assert.True(t, apierrors.IsInvalid(err))
+				assert.ErrorContains(t, err, "spec.parameters[0].objectRef: Forbidden")
+				assert.ErrorContains(t, err, "object ref not allowed")
+			})
+		}
+	})
 }
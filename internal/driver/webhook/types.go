@@ -18,12 +18,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 
+	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 type validatingFunc = func(ctx context.Context, obj runtime.Object) error
 
+// parameterNameRegex matches the names of parameters that can be used as `.Params.NAME` in templates.
+var parameterNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // NewUnsupportedResource returns a new error for an unsupported resource.
 func NewUnsupportedResource(obj runtime.Object, errs ...error) error {
 	return errors.Join(
@@ -32,3 +38,50 @@ func NewUnsupportedResource(obj runtime.Object, errs ...error) error {
 			obj.GetObjectKind().GroupVersionKind()),
 	)
 }
+
+// validateRefNamespace ensures an object of a namespace only references objects of its own namespace, which isolates the
+// tenants of a cluster from each other. Objects without namespace, i.e. ClusterProfiles, may reference objects of any
+// namespace.
+func validateRefNamespace(fldPath *field.Path, namespace string, ref v1alpha1.ResourceRef) field.ErrorList {
+	if namespace == "" || ref.Namespace == namespace {
+		return nil
+	}
+
+	return field.ErrorList{field.Forbidden(
+		fldPath.Child("namespace"),
+		fmt.Sprintf("must be %q: objects of other namespaces cannot be referenced", namespace),
+	)}
+}
+
+// validateParameters ensures the parameters have unique template identifiers as names and exactly one source, i.e. a
+// value or an objectRef.
+func validateParameters(fldPath *field.Path, params []v1alpha1.Parameter) field.ErrorList {
+	var errs field.ErrorList
+
+	names := make(map[string]struct{}, len(params))
+	for i, param := range params {
+		idxPath := fldPath.Index(i)
+
+		if !parameterNameRegex.MatchString(param.Name) {
+			errs = append(errs, field.Invalid(idxPath.Child("name"), param.Name,
+				fmt.Sprintf("must match %q to be used as .Params.NAME", parameterNameRegex.String())))
+		} else if _, ok := names[param.Name]; ok {
+			errs = append(errs, field.Duplicate(idxPath.Child("name"), param.Name))
+		}
+
+		names[param.Name] = struct{}{}
+
+		switch {
+		case param.Value == nil && param.ObjectRef == nil:
+			errs = append(errs, field.Required(idxPath, "must specify exactly one of value or objectRef"))
+		case param.Value != nil && param.ObjectRef != nil:
+			errs = append(errs, field.Forbidden(idxPath, "must specify exactly one of value or objectRef"))
+		case param.ObjectRef != nil:
+			if err := validateObjectRef(param.ObjectRef); err != nil {
+				errs = append(errs, field.Invalid(idxPath.Child("objectRef"), param.ObjectRef.ResourceRef, err.Error()))
+			}
+		}
+	}
+
+	return errs
+}
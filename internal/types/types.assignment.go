@@ -14,14 +14,51 @@
 
 package types
 
+import "net/netip"
+
 // Assignment is a struct that holds the name of an assignment and the name of the profile it assigns.
 type Assignment struct {
 	// Name is the name given to the Assignment resource itself.
 	Name string
 	// Namespace is the namespace of the Assignment resource.
 	Namespace string
+	// Labels are the labels of the Assignment resource, excluding the labels managed by shaper.
+	Labels map[string]string
 	// ProfileName is the name of the assigned profile.
 	ProfileName string
+	// ProfileKind is the kind of the assigned profile, i.e. a Profile in the namespace of the assignment or a
+	// ClusterProfile.
+	ProfileKind ProfileKind
 	// SubjectSelectors contains the selectors used to match machines.
 	SubjectSelectors map[string][]string
+	// BootMode defines whether the profile is served on every boot or only until the machine is provisioned.
+	BootMode BootMode
+	// Priority is used to select an assignment when many of them match a machine.
+	Priority int32
+	// Parameters maps the name of each parameter to its inline or objectRef content. They override the parameters of
+	// the profile.
+	Parameters map[string]Content
+	// AllowedCIDRs are the source CIDRs of the clients allowed to be served through the assignment. All clients are
+	// allowed when empty.
+	AllowedCIDRs []netip.Prefix
 }
+
+// ProfileKind is a type for the kinds of profile an assignment can reference.
+type ProfileKind int
+
+const (
+	// NamespacedProfileKind references a Profile in the namespace of the assignment.
+	NamespacedProfileKind ProfileKind = iota
+	// ClusterProfileKind references a cluster-scoped ClusterProfile.
+	ClusterProfileKind
+)
+
+// BootMode is a type for boot modes.
+type BootMode int
+
+const (
+	// AlwaysBootMode serves the assigned profile on every boot.
+	AlwaysBootMode BootMode = iota
+	// ProvisionOnceBootMode serves the assigned profile until the machine is provisioned.
+	ProvisionOnceBootMode
+)
@@ -248,4 +248,24 @@ type IPXESelectors struct {
 	Buildarch string
 	// UUID is the UUID of the machine.
 	UUID uuid.UUID
+
+	// MAC is the MAC address of the booting network interface.
+	MAC string
+	// Serial is the serial number of the machine.
+	Serial string
+	// Hostname is the hostname of the machine.
+	Hostname string
+	// Asset is the asset tag of the machine.
+	Asset string
+	// Product is the product name of the machine.
+	Product string
+	// Manufacturer is the manufacturer of the machine.
+	Manufacturer string
+	// Platform is the firmware platform of the machine, e.g. `pcbios` or `efi`.
+	Platform string
+
+	// ClientIP is the IP address of the machine as seen by the API server. It is recorded but never matched.
+	ClientIP string
+	// Labels are the labels of the Machine resource. They can be matched by assignments' machine selectors.
+	Labels map[string]string
 }
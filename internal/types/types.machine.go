@@ -0,0 +1,78 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "time"
+
+// Machine is a host that booted through shaper.
+type Machine struct {
+	// Name is the name given to the Machine resource itself, i.e. the UUID of the machine.
+	Name string
+	// Namespace is the namespace of the Machine resource.
+	Namespace string
+	// Labels are the labels of the Machine resource.
+	Labels map[string]string
+
+	// Selectors are the iPXE attributes reported by the machine during its last boot.
+	Selectors IPXESelectors
+	// AssignmentName is the name of the assignment served during the last boot.
+	AssignmentName string
+	// AssignmentNamespace is the namespace of the assignment served during the last boot.
+	AssignmentNamespace string
+	// ProfileName is the name of the profile served during the last boot.
+	ProfileName string
+
+	// FirstBootTime is the time the machine booted through shaper for the first time.
+	FirstBootTime time.Time
+	// LastBootTime is the time the machine booted through shaper for the last time.
+	LastBootTime time.Time
+
+	// Phase is the provisioning phase of the machine.
+	Phase MachinePhase
+	// Reprovision is true if the machine was requested to be provisioned again.
+	Reprovision bool
+}
+
+// MachinePhase is a type for machine provisioning phases.
+type MachinePhase int
+
+const (
+	// UnknownMachinePhase is the phase of machines that were never served a provision-once profile.
+	UnknownMachinePhase MachinePhase = iota
+	// ProvisioningMachinePhase is the phase of machines that were served a provision-once profile.
+	ProvisioningMachinePhase
+	// ProvisionedMachinePhase is the phase of machines that completed their provisioning.
+	ProvisionedMachinePhase
+)
+
+// MachineEvent is a provisioning event reported by a machine.
+type MachineEvent struct {
+	// Type is the type of the event.
+	Type MachineEventType
+	// Message is an optional human-readable message, e.g. the reason of a failure.
+	Message string
+}
+
+// MachineEventType is a type for machine event types.
+type MachineEventType int
+
+const (
+	// InstallStartedMachineEvent is reported when the machine starts installing its operating system.
+	InstallStartedMachineEvent MachineEventType = iota
+	// InstallSucceededMachineEvent is reported when the machine successfully installed its operating system.
+	InstallSucceededMachineEvent
+	// InstallFailedMachineEvent is reported when the machine failed to install its operating system.
+	InstallFailedMachineEvent
+)
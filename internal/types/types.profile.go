@@ -25,15 +25,20 @@ import (
 type Profile struct {
 	// Name is the name of the Profile resource.
 	Name string
-	// Namespace is the namespace of the Profile resource.
+	// Namespace is the namespace of the Profile resource. It is empty for a ClusterProfile.
 	Namespace string
 	// IPXETemplate is the iPXE template.
 	IPXETemplate string
+	// ExternalBaseURL overrides the base URL of the shaper API when rendering the profile. It is empty unless set.
+	ExternalBaseURL string
 
 	// AdditionalContent is a map of additional content.
 	AdditionalContent map[string]Content
 	// ContentIDToNameMap is a map of content IDs to content names.
 	ContentIDToNameMap map[uuid.UUID]string
+	// Parameters maps the name of each parameter to its inline or objectRef content. They are the default values of
+	// the template variables available as `.Params`.
+	Parameters map[string]Content
 }
 
 // ---------------------------------------------------- CONTENT ----------------------------------------------------- //
@@ -46,6 +51,9 @@ type Content struct {
 	Exposed bool
 	// ExposedUUID is the UUID of the exposed content.
 	ExposedUUID uuid.UUID
+	// SpecHash is the hash of the spec the content was converted from. It identifies the content in the content cache;
+	// content without SpecHash is never cached.
+	SpecHash string
 
 	// PostTransformers is a list of post transformers.
 	PostTransformers []TransformerConfig
@@ -72,11 +80,43 @@ type ObjectRef struct {
 	Namespace string
 	// Name is the name of the object.
 	Name string
+	// ReferrerNamespace is the namespace of the profile or assignment referencing the object. It is empty for a
+	// ClusterProfile. The ObjectRefPolicy is enforced against it.
+	ReferrerNamespace string
 
 	// JSONPath is optional for types that extends this struct.
 	JSONPath *jsonpath.JSONPath
 }
 
+// ObjectRefRule allows the objects of a resource to be resolved.
+type ObjectRefRule struct {
+	// Group is the group of the resource.
+	Group string
+	// Version is the version of the resource.
+	Version string
+	// Resource is the resource, e.g. "configmaps".
+	Resource string
+	// Namespaces are the namespaces of the allowed objects. Objects of every namespace are allowed when empty.
+	Namespaces []string
+}
+
+// ObjectRefPolicy restricts the objects that profiles and assignments may reference. Every object may be referenced
+// when it has no rules.
+type ObjectRefPolicy struct {
+	// Rules are the rules of the policy. An object may be referenced if one of the rules applying to the namespace of
+	// the referrer allows it.
+	Rules []ObjectRefPolicyRule
+}
+
+// ObjectRefPolicyRule allows the profiles and assignments of some namespaces to reference objects.
+type ObjectRefPolicyRule struct {
+	// ProfileNamespaces are the namespaces of the profiles and assignments the rule applies to. ClusterProfiles are
+	// matched by the empty namespace. The rule applies to every referrer when empty.
+	ProfileNamespaces []string
+	// Allowed are the objects the referrers may reference.
+	Allowed []ObjectRefRule
+}
+
 // WebhookConfig is a struct that holds the configuration for a webhook.
 type WebhookConfig struct {
 	// URL is the URL of the webhook.
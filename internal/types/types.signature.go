@@ -0,0 +1,35 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "time"
+
+// SigningKeys are the keys signing the URLs of the exposed content.
+type SigningKeys struct {
+	// CurrentID is the ID of the key signing new URLs.
+	CurrentID string
+	// Keys are the keys by ID. Every key verifies URLs, so URLs signed before a rotation remain valid.
+	Keys map[string][]byte
+}
+
+// ContentURLSignature binds the URL of an exposed content to a machine until it expires.
+type ContentURLSignature struct {
+	// Expires is the time the URL expires.
+	Expires time.Time
+	// KeyID is the ID of the key that signed the URL.
+	KeyID string
+	// Signature is the base64url-encoded HMAC-SHA256 signature of the URL.
+	Signature string
+}
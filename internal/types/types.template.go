@@ -0,0 +1,52 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// TemplateData is the data available to iPXE templates and to templated content, i.e. inline and objectRef content.
+type TemplateData struct {
+	// Machine holds the attributes of the machine, e.g. `.Machine.UUID`, `.Machine.Buildarch` or `.Machine.MAC`.
+	Machine IPXESelectors
+	// Assignment holds the metadata of the assignment selected for the machine.
+	Assignment AssignmentMetadata
+	// Profile holds the metadata of the rendered profile.
+	Profile ProfileMetadata
+	// BaseURL is the base URL of the shaper API.
+	BaseURL string
+	// Params maps the name of each parameter of the profile and of the assignment to its value. The parameters of the
+	// assignment override the ones of the profile.
+	Params map[string]string
+
+	// AdditionalContent maps the name of each additional content to its value, or to its URL if the content is
+	// exposed. Templated content only receives the content it references.
+	AdditionalContent map[string]string
+}
+
+// AssignmentMetadata holds the metadata of an assignment.
+type AssignmentMetadata struct {
+	// Name is the name of the Assignment resource.
+	Name string
+	// Namespace is the namespace of the Assignment resource.
+	Namespace string
+	// Labels are the labels of the Assignment resource, excluding the labels managed by shaper.
+	Labels map[string]string
+}
+
+// ProfileMetadata holds the metadata of a profile.
+type ProfileMetadata struct {
+	// Name is the name of the Profile resource.
+	Name string
+	// Namespace is the namespace of the Profile resource.
+	Namespace string
+}
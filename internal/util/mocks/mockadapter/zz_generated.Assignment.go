@@ -8,6 +8,7 @@ import (
 	"context"
 
 	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/google/uuid"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -39,7 +40,7 @@ func (_m *MockAssignment) EXPECT() *MockAssignment_Expecter {
 }
 
 // FindBySelectors provides a mock function for the type MockAssignment
-func (_mock *MockAssignment) FindBySelectors(ctx context.Context, selectors types.IPXESelectors) (types.Assignment, error) {
+func (_mock *MockAssignment) FindBySelectors(ctx context.Context, selectors types.IPXESelectors) (types.Assignment, string, error) {
 	ret := _mock.Called(ctx, selectors)
 
 	if len(ret) == 0 {
@@ -47,8 +48,9 @@ func (_mock *MockAssignment) FindBySelectors(ctx context.Context, selectors type
 	}
 
 	var r0 types.Assignment
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, types.IPXESelectors) (types.Assignment, error)); ok {
+	var r1 string
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, types.IPXESelectors) (types.Assignment, string, error)); ok {
 		return returnFunc(ctx, selectors)
 	}
 	if returnFunc, ok := ret.Get(0).(func(context.Context, types.IPXESelectors) types.Assignment); ok {
@@ -56,12 +58,17 @@ func (_mock *MockAssignment) FindBySelectors(ctx context.Context, selectors type
 	} else {
 		r0 = ret.Get(0).(types.Assignment)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, types.IPXESelectors) error); ok {
+	if returnFunc, ok := ret.Get(1).(func(context.Context, types.IPXESelectors) string); ok {
 		r1 = returnFunc(ctx, selectors)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(string)
 	}
-	return r0, r1
+	if returnFunc, ok := ret.Get(2).(func(context.Context, types.IPXESelectors) error); ok {
+		r2 = returnFunc(ctx, selectors)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
 }
 
 // MockAssignment_FindBySelectors_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindBySelectors'
@@ -94,12 +101,12 @@ func (_c *MockAssignment_FindBySelectors_Call) Run(run func(ctx context.Context,
 	return _c
 }
 
-func (_c *MockAssignment_FindBySelectors_Call) Return(assignment types.Assignment, err error) *MockAssignment_FindBySelectors_Call {
-	_c.Call.Return(assignment, err)
+func (_c *MockAssignment_FindBySelectors_Call) Return(assignment types.Assignment, s string, err error) *MockAssignment_FindBySelectors_Call {
+	_c.Call.Return(assignment, s, err)
 	return _c
 }
 
-func (_c *MockAssignment_FindBySelectors_Call) RunAndReturn(run func(ctx context.Context, selectors types.IPXESelectors) (types.Assignment, error)) *MockAssignment_FindBySelectors_Call {
+func (_c *MockAssignment_FindBySelectors_Call) RunAndReturn(run func(ctx context.Context, selectors types.IPXESelectors) (types.Assignment, string, error)) *MockAssignment_FindBySelectors_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -169,3 +176,213 @@ func (_c *MockAssignment_FindDefaultByBuildarch_Call) RunAndReturn(run func(ctx
 	_c.Call.Return(run)
 	return _c
 }
+
+// ListByProfileName provides a mock function for the type MockAssignment
+func (_mock *MockAssignment) ListByProfileName(ctx context.Context, profileName string, namespace string) ([]types.Assignment, error) {
+	ret := _mock.Called(ctx, profileName, namespace)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListByProfileName")
+	}
+
+	var r0 []types.Assignment
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) ([]types.Assignment, error)); ok {
+		return returnFunc(ctx, profileName, namespace)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) []types.Assignment); ok {
+		r0 = returnFunc(ctx, profileName, namespace)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.Assignment)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, profileName, namespace)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockAssignment_ListByProfileName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListByProfileName'
+type MockAssignment_ListByProfileName_Call struct {
+	*mock.Call
+}
+
+// ListByProfileName is a helper method to define mock.On call
+//   - ctx context.Context
+//   - profileName string
+//   - namespace string
+func (_e *MockAssignment_Expecter) ListByProfileName(ctx interface{}, profileName interface{}, namespace interface{}) *MockAssignment_ListByProfileName_Call {
+	return &MockAssignment_ListByProfileName_Call{Call: _e.mock.On("ListByProfileName", ctx, profileName, namespace)}
+}
+
+func (_c *MockAssignment_ListByProfileName_Call) Run(run func(ctx context.Context, profileName string, namespace string)) *MockAssignment_ListByProfileName_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockAssignment_ListByProfileName_Call) Return(assignments []types.Assignment, err error) *MockAssignment_ListByProfileName_Call {
+	_c.Call.Return(assignments, err)
+	return _c
+}
+
+func (_c *MockAssignment_ListByProfileName_Call) RunAndReturn(run func(ctx context.Context, profileName string, namespace string) ([]types.Assignment, error)) *MockAssignment_ListByProfileName_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListByUUID provides a mock function for the type MockAssignment
+func (_mock *MockAssignment) ListByUUID(ctx context.Context, id uuid.UUID) ([]types.Assignment, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListByUUID")
+	}
+
+	var r0 []types.Assignment
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]types.Assignment, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) []types.Assignment); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.Assignment)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockAssignment_ListByUUID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListByUUID'
+type MockAssignment_ListByUUID_Call struct {
+	*mock.Call
+}
+
+// ListByUUID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *MockAssignment_Expecter) ListByUUID(ctx interface{}, id interface{}) *MockAssignment_ListByUUID_Call {
+	return &MockAssignment_ListByUUID_Call{Call: _e.mock.On("ListByUUID", ctx, id)}
+}
+
+func (_c *MockAssignment_ListByUUID_Call) Run(run func(ctx context.Context, id uuid.UUID)) *MockAssignment_ListByUUID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockAssignment_ListByUUID_Call) Return(assignments []types.Assignment, err error) *MockAssignment_ListByUUID_Call {
+	_c.Call.Return(assignments, err)
+	return _c
+}
+
+func (_c *MockAssignment_ListByUUID_Call) RunAndReturn(run func(ctx context.Context, id uuid.UUID) ([]types.Assignment, error)) *MockAssignment_ListByUUID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListDefaultByBuildarch provides a mock function for the type MockAssignment
+func (_mock *MockAssignment) ListDefaultByBuildarch(ctx context.Context, buildarch string) ([]types.Assignment, error) {
+	ret := _mock.Called(ctx, buildarch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDefaultByBuildarch")
+	}
+
+	var r0 []types.Assignment
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]types.Assignment, error)); ok {
+		return returnFunc(ctx, buildarch)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []types.Assignment); ok {
+		r0 = returnFunc(ctx, buildarch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.Assignment)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, buildarch)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockAssignment_ListDefaultByBuildarch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListDefaultByBuildarch'
+type MockAssignment_ListDefaultByBuildarch_Call struct {
+	*mock.Call
+}
+
+// ListDefaultByBuildarch is a helper method to define mock.On call
+//   - ctx context.Context
+//   - buildarch string
+func (_e *MockAssignment_Expecter) ListDefaultByBuildarch(ctx interface{}, buildarch interface{}) *MockAssignment_ListDefaultByBuildarch_Call {
+	return &MockAssignment_ListDefaultByBuildarch_Call{Call: _e.mock.On("ListDefaultByBuildarch", ctx, buildarch)}
+}
+
+func (_c *MockAssignment_ListDefaultByBuildarch_Call) Run(run func(ctx context.Context, buildarch string)) *MockAssignment_ListDefaultByBuildarch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockAssignment_ListDefaultByBuildarch_Call) Return(assignments []types.Assignment, err error) *MockAssignment_ListDefaultByBuildarch_Call {
+	_c.Call.Return(assignments, err)
+	return _c
+}
+
+func (_c *MockAssignment_ListDefaultByBuildarch_Call) RunAndReturn(run func(ctx context.Context, buildarch string) ([]types.Assignment, error)) *MockAssignment_ListDefaultByBuildarch_Call {
+	_c.Call.Return(run)
+	return _c
+}
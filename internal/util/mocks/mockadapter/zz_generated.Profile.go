@@ -8,6 +8,7 @@ import (
 	"context"
 
 	"github.com/alexandremahdhaoui/shaper/internal/types"
+	"github.com/alexandremahdhaoui/shaper/pkg/v1alpha1"
 	"github.com/google/uuid"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -39,12 +40,12 @@ func (_m *MockProfile) EXPECT() *MockProfile_Expecter {
 	return &MockProfile_Expecter{mock: &_m.Mock}
 }
 
-// Get provides a mock function for the type MockProfile
-func (_mock *MockProfile) Get(ctx context.Context, name string) (types.Profile, error) {
+// GetClusterProfile provides a mock function for the type MockProfile
+func (_mock *MockProfile) GetClusterProfile(ctx context.Context, name string) (types.Profile, error) {
 	ret := _mock.Called(ctx, name)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Get")
+		panic("no return value specified for GetClusterProfile")
 	}
 
 	var r0 types.Profile
@@ -65,19 +66,19 @@ func (_mock *MockProfile) Get(ctx context.Context, name string) (types.Profile,
 	return r0, r1
 }
 
-// MockProfile_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
-type MockProfile_Get_Call struct {
+// MockProfile_GetClusterProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetClusterProfile'
+type MockProfile_GetClusterProfile_Call struct {
 	*mock.Call
 }
 
-// Get is a helper method to define mock.On call
+// GetClusterProfile is a helper method to define mock.On call
 //   - ctx context.Context
 //   - name string
-func (_e *MockProfile_Expecter) Get(ctx interface{}, name interface{}) *MockProfile_Get_Call {
-	return &MockProfile_Get_Call{Call: _e.mock.On("Get", ctx, name)}
+func (_e *MockProfile_Expecter) GetClusterProfile(ctx interface{}, name interface{}) *MockProfile_GetClusterProfile_Call {
+	return &MockProfile_GetClusterProfile_Call{Call: _e.mock.On("GetClusterProfile", ctx, name)}
 }
 
-func (_c *MockProfile_Get_Call) Run(run func(ctx context.Context, name string)) *MockProfile_Get_Call {
+func (_c *MockProfile_GetClusterProfile_Call) Run(run func(ctx context.Context, name string)) *MockProfile_GetClusterProfile_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -95,12 +96,12 @@ func (_c *MockProfile_Get_Call) Run(run func(ctx context.Context, name string))
 	return _c
 }
 
-func (_c *MockProfile_Get_Call) Return(profile types.Profile, err error) *MockProfile_Get_Call {
+func (_c *MockProfile_GetClusterProfile_Call) Return(profile types.Profile, err error) *MockProfile_GetClusterProfile_Call {
 	_c.Call.Return(profile, err)
 	return _c
 }
 
-func (_c *MockProfile_Get_Call) RunAndReturn(run func(ctx context.Context, name string) (types.Profile, error)) *MockProfile_Get_Call {
+func (_c *MockProfile_GetClusterProfile_Call) RunAndReturn(run func(ctx context.Context, name string) (types.Profile, error)) *MockProfile_GetClusterProfile_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -244,3 +245,71 @@ func (_c *MockProfile_ListByContentID_Call) RunAndReturn(run func(ctx context.Co
 	_c.Call.Return(run)
 	return _c
 }
+
+// Merge provides a mock function for the type MockProfile
+func (_mock *MockProfile) Merge(ctx context.Context, profile *v1alpha1.Profile) (*v1alpha1.Profile, error) {
+	ret := _mock.Called(ctx, profile)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Merge")
+	}
+
+	var r0 *v1alpha1.Profile
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1alpha1.Profile) (*v1alpha1.Profile, error)); ok {
+		return returnFunc(ctx, profile)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1alpha1.Profile) *v1alpha1.Profile); ok {
+		r0 = returnFunc(ctx, profile)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1alpha1.Profile)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v1alpha1.Profile) error); ok {
+		r1 = returnFunc(ctx, profile)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockProfile_Merge_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Merge'
+type MockProfile_Merge_Call struct {
+	*mock.Call
+}
+
+// Merge is a helper method to define mock.On call
+//   - ctx context.Context
+//   - profile *v1alpha1.Profile
+func (_e *MockProfile_Expecter) Merge(ctx interface{}, profile interface{}) *MockProfile_Merge_Call {
+	return &MockProfile_Merge_Call{Call: _e.mock.On("Merge", ctx, profile)}
+}
+
+func (_c *MockProfile_Merge_Call) Run(run func(ctx context.Context, profile *v1alpha1.Profile)) *MockProfile_Merge_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v1alpha1.Profile
+		if args[1] != nil {
+			arg1 = args[1].(*v1alpha1.Profile)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockProfile_Merge_Call) Return(merged *v1alpha1.Profile, err error) *MockProfile_Merge_Call {
+	_c.Call.Return(merged, err)
+	return _c
+}
+
+func (_c *MockProfile_Merge_Call) RunAndReturn(run func(ctx context.Context, profile *v1alpha1.Profile) (*v1alpha1.Profile, error)) *MockProfile_Merge_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -40,8 +40,8 @@ func (_m *MockResolveTransformerMux) EXPECT() *MockResolveTransformerMux_Expecte
 }
 
 // ResolveAndTransform provides a mock function for the type MockResolveTransformerMux
-func (_mock *MockResolveTransformerMux) ResolveAndTransform(ctx context.Context, content types.Content, selectors types.IPXESelectors) ([]byte, error) {
-	ret := _mock.Called(ctx, content, selectors)
+func (_mock *MockResolveTransformerMux) ResolveAndTransform(ctx context.Context, content types.Content, additionalContent map[string]types.Content, selectors types.IPXESelectors, data types.TemplateData) ([]byte, error) {
+	ret := _mock.Called(ctx, content, additionalContent, selectors, data)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ResolveAndTransform")
@@ -49,18 +49,18 @@ func (_mock *MockResolveTransformerMux) ResolveAndTransform(ctx context.Context,
 
 	var r0 []byte
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, types.Content, types.IPXESelectors) ([]byte, error)); ok {
-		return returnFunc(ctx, content, selectors)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, types.Content, map[string]types.Content, types.IPXESelectors, types.TemplateData) ([]byte, error)); ok {
+		return returnFunc(ctx, content, additionalContent, selectors, data)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, types.Content, types.IPXESelectors) []byte); ok {
-		r0 = returnFunc(ctx, content, selectors)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, types.Content, map[string]types.Content, types.IPXESelectors, types.TemplateData) []byte); ok {
+		r0 = returnFunc(ctx, content, additionalContent, selectors, data)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]byte)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, types.Content, types.IPXESelectors) error); ok {
-		r1 = returnFunc(ctx, content, selectors)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, types.Content, map[string]types.Content, types.IPXESelectors, types.TemplateData) error); ok {
+		r1 = returnFunc(ctx, content, additionalContent, selectors, data)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -75,12 +75,14 @@ type MockResolveTransformerMux_ResolveAndTransform_Call struct {
 // ResolveAndTransform is a helper method to define mock.On call
 //   - ctx context.Context
 //   - content types.Content
+//   - additionalContent map[string]types.Content
 //   - selectors types.IPXESelectors
-func (_e *MockResolveTransformerMux_Expecter) ResolveAndTransform(ctx interface{}, content interface{}, selectors interface{}) *MockResolveTransformerMux_ResolveAndTransform_Call {
-	return &MockResolveTransformerMux_ResolveAndTransform_Call{Call: _e.mock.On("ResolveAndTransform", ctx, content, selectors)}
+//   - data types.TemplateData
+func (_e *MockResolveTransformerMux_Expecter) ResolveAndTransform(ctx interface{}, content interface{}, additionalContent interface{}, selectors interface{}, data interface{}) *MockResolveTransformerMux_ResolveAndTransform_Call {
+	return &MockResolveTransformerMux_ResolveAndTransform_Call{Call: _e.mock.On("ResolveAndTransform", ctx, content, additionalContent, selectors, data)}
 }
 
-func (_c *MockResolveTransformerMux_ResolveAndTransform_Call) Run(run func(ctx context.Context, content types.Content, selectors types.IPXESelectors)) *MockResolveTransformerMux_ResolveAndTransform_Call {
+func (_c *MockResolveTransformerMux_ResolveAndTransform_Call) Run(run func(ctx context.Context, content types.Content, additionalContent map[string]types.Content, selectors types.IPXESelectors, data types.TemplateData)) *MockResolveTransformerMux_ResolveAndTransform_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -90,14 +92,24 @@ func (_c *MockResolveTransformerMux_ResolveAndTransform_Call) Run(run func(ctx c
 		if args[1] != nil {
 			arg1 = args[1].(types.Content)
 		}
-		var arg2 types.IPXESelectors
+		var arg2 map[string]types.Content
 		if args[2] != nil {
-			arg2 = args[2].(types.IPXESelectors)
+			arg2 = args[2].(map[string]types.Content)
+		}
+		var arg3 types.IPXESelectors
+		if args[3] != nil {
+			arg3 = args[3].(types.IPXESelectors)
+		}
+		var arg4 types.TemplateData
+		if args[4] != nil {
+			arg4 = args[4].(types.TemplateData)
 		}
 		run(
 			arg0,
 			arg1,
 			arg2,
+			arg3,
+			arg4,
 		)
 	})
 	return _c
@@ -108,20 +120,20 @@ func (_c *MockResolveTransformerMux_ResolveAndTransform_Call) Return(bytes []byt
 	return _c
 }
 
-func (_c *MockResolveTransformerMux_ResolveAndTransform_Call) RunAndReturn(run func(ctx context.Context, content types.Content, selectors types.IPXESelectors) ([]byte, error)) *MockResolveTransformerMux_ResolveAndTransform_Call {
+func (_c *MockResolveTransformerMux_ResolveAndTransform_Call) RunAndReturn(run func(ctx context.Context, content types.Content, additionalContent map[string]types.Content, selectors types.IPXESelectors, data types.TemplateData) ([]byte, error)) *MockResolveTransformerMux_ResolveAndTransform_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
 // ResolveAndTransformBatch provides a mock function for the type MockResolveTransformerMux
-func (_mock *MockResolveTransformerMux) ResolveAndTransformBatch(ctx context.Context, batch map[string]types.Content, selectors types.IPXESelectors, options ...controller.ResolveTransformBatchOption) (map[string][]byte, error) {
+func (_mock *MockResolveTransformerMux) ResolveAndTransformBatch(ctx context.Context, batch map[string]types.Content, selectors types.IPXESelectors, data types.TemplateData, options ...controller.ResolveTransformBatchOption) (map[string][]byte, error) {
 	// controller.ResolveTransformBatchOption
 	_va := make([]interface{}, len(options))
 	for _i := range options {
 		_va[_i] = options[_i]
 	}
 	var _ca []interface{}
-	_ca = append(_ca, ctx, batch, selectors)
+	_ca = append(_ca, ctx, batch, selectors, data)
 	_ca = append(_ca, _va...)
 	ret := _mock.Called(_ca...)
 
@@ -131,18 +143,18 @@ func (_mock *MockResolveTransformerMux) ResolveAndTransformBatch(ctx context.Con
 
 	var r0 map[string][]byte
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, map[string]types.Content, types.IPXESelectors, ...controller.ResolveTransformBatchOption) (map[string][]byte, error)); ok {
-		return returnFunc(ctx, batch, selectors, options...)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, map[string]types.Content, types.IPXESelectors, types.TemplateData, ...controller.ResolveTransformBatchOption) (map[string][]byte, error)); ok {
+		return returnFunc(ctx, batch, selectors, data, options...)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, map[string]types.Content, types.IPXESelectors, ...controller.ResolveTransformBatchOption) map[string][]byte); ok {
-		r0 = returnFunc(ctx, batch, selectors, options...)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, map[string]types.Content, types.IPXESelectors, types.TemplateData, ...controller.ResolveTransformBatchOption) map[string][]byte); ok {
+		r0 = returnFunc(ctx, batch, selectors, data, options...)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(map[string][]byte)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, map[string]types.Content, types.IPXESelectors, ...controller.ResolveTransformBatchOption) error); ok {
-		r1 = returnFunc(ctx, batch, selectors, options...)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, map[string]types.Content, types.IPXESelectors, types.TemplateData, ...controller.ResolveTransformBatchOption) error); ok {
+		r1 = returnFunc(ctx, batch, selectors, data, options...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -158,13 +170,14 @@ type MockResolveTransformerMux_ResolveAndTransformBatch_Call struct {
 //   - ctx context.Context
 //   - batch map[string]types.Content
 //   - selectors types.IPXESelectors
+//   - data types.TemplateData
 //   - options ...controller.ResolveTransformBatchOption
-func (_e *MockResolveTransformerMux_Expecter) ResolveAndTransformBatch(ctx interface{}, batch interface{}, selectors interface{}, options ...interface{}) *MockResolveTransformerMux_ResolveAndTransformBatch_Call {
+func (_e *MockResolveTransformerMux_Expecter) ResolveAndTransformBatch(ctx interface{}, batch interface{}, selectors interface{}, data interface{}, options ...interface{}) *MockResolveTransformerMux_ResolveAndTransformBatch_Call {
 	return &MockResolveTransformerMux_ResolveAndTransformBatch_Call{Call: _e.mock.On("ResolveAndTransformBatch",
-		append([]interface{}{ctx, batch, selectors}, options...)...)}
+		append([]interface{}{ctx, batch, selectors, data}, options...)...)}
 }
 
-func (_c *MockResolveTransformerMux_ResolveAndTransformBatch_Call) Run(run func(ctx context.Context, batch map[string]types.Content, selectors types.IPXESelectors, options ...controller.ResolveTransformBatchOption)) *MockResolveTransformerMux_ResolveAndTransformBatch_Call {
+func (_c *MockResolveTransformerMux_ResolveAndTransformBatch_Call) Run(run func(ctx context.Context, batch map[string]types.Content, selectors types.IPXESelectors, data types.TemplateData, options ...controller.ResolveTransformBatchOption)) *MockResolveTransformerMux_ResolveAndTransformBatch_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -178,19 +191,24 @@ func (_c *MockResolveTransformerMux_ResolveAndTransformBatch_Call) Run(run func(
 		if args[2] != nil {
 			arg2 = args[2].(types.IPXESelectors)
 		}
-		var arg3 []controller.ResolveTransformBatchOption
-		variadicArgs := make([]controller.ResolveTransformBatchOption, len(args)-3)
-		for i, a := range args[3:] {
+		var arg3 types.TemplateData
+		if args[3] != nil {
+			arg3 = args[3].(types.TemplateData)
+		}
+		var arg4 []controller.ResolveTransformBatchOption
+		variadicArgs := make([]controller.ResolveTransformBatchOption, len(args)-4)
+		for i, a := range args[4:] {
 			if a != nil {
 				variadicArgs[i] = a.(controller.ResolveTransformBatchOption)
 			}
 		}
-		arg3 = variadicArgs
+		arg4 = variadicArgs
 		run(
 			arg0,
 			arg1,
 			arg2,
-			arg3...,
+			arg3,
+			arg4...,
 		)
 	})
 	return _c
@@ -201,7 +219,7 @@ func (_c *MockResolveTransformerMux_ResolveAndTransformBatch_Call) Return(string
 	return _c
 }
 
-func (_c *MockResolveTransformerMux_ResolveAndTransformBatch_Call) RunAndReturn(run func(ctx context.Context, batch map[string]types.Content, selectors types.IPXESelectors, options ...controller.ResolveTransformBatchOption) (map[string][]byte, error)) *MockResolveTransformerMux_ResolveAndTransformBatch_Call {
+func (_c *MockResolveTransformerMux_ResolveAndTransformBatch_Call) RunAndReturn(run func(ctx context.Context, batch map[string]types.Content, selectors types.IPXESelectors, data types.TemplateData, options ...controller.ResolveTransformBatchOption) (map[string][]byte, error)) *MockResolveTransformerMux_ResolveAndTransformBatch_Call {
 	_c.Call.Return(run)
 	return _c
 }
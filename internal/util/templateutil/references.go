@@ -0,0 +1,258 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templateutil
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// ErrReferenceCycle is returned when templates reference each other.
+var ErrReferenceCycle = errors.New("reference cycle")
+
+// ---------------------------------------------------- REFERENCES -------------------------------------------------- //
+
+// AllReferences is the key returned by References when the template uses the map field as a whole, e.g.
+// `{{ with .FIELD }}{{ .KEY }}{{ end }}` or `{{ range .FIELD }}`: the template may then reference any of its keys.
+const AllReferences = "*"
+
+// References returns the keys of the map field referenced by the template, i.e. `.FIELD.KEY`, `$.FIELD.KEY` or
+// `index .FIELD "KEY"`, and the names of the top-level fields it uses. Keys contain AllReferences if the template uses
+// the map field as a whole. Fields used inside the body of `range` and `with` actions are relative to another value,
+// hence they are not returned as top-level fields, unless they are accessed through `$`.
+func References(tpl *template.Template, mapField string) (keys, fields map[string]struct{}) {
+	w := &referenceWalker{
+		mapField: mapField,
+		keys:     make(map[string]struct{}),
+		fields:   make(map[string]struct{}),
+	}
+
+	for _, t := range tpl.Templates() {
+		if t.Tree != nil {
+			w.walk(t.Tree.Root, false)
+		}
+	}
+
+	return w.keys, w.fields
+}
+
+// ResolveReferences returns the sorted keys referenced by a template among the available ones. AllReferences resolves
+// to every available key but self, the key of the template itself; a template referencing self explicitly depends on
+// itself.
+func ResolveReferences[V any](keys map[string]struct{}, available map[string]V, self string) []string {
+	out := make([]string, 0, len(keys))
+
+	for key := range available {
+		_, referenced := keys[key]
+		_, all := keys[AllReferences]
+
+		if referenced || (all && key != self) {
+			out = append(out, key)
+		}
+	}
+
+	slices.Sort(out)
+
+	return out
+}
+
+type referenceWalker struct {
+	mapField string
+	keys     map[string]struct{}
+	fields   map[string]struct{}
+}
+
+// walk collects the references of the node. relative is true while walking the bodies of `range` and `with` actions,
+// where `.` is not the top-level value.
+func (w *referenceWalker) walk(node parse.Node, relative bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+
+		for _, child := range n.Nodes {
+			w.walk(child, relative)
+		}
+	case *parse.ActionNode:
+		w.walk(n.Pipe, relative)
+	case *parse.IfNode:
+		w.walk(n.Pipe, relative)
+		w.walk(n.List, relative)
+		w.walk(n.ElseList, relative)
+	case *parse.RangeNode:
+		w.walk(n.Pipe, relative)
+		w.walk(n.List, true)
+		w.walk(n.ElseList, relative)
+	case *parse.WithNode:
+		w.walk(n.Pipe, relative)
+		w.walk(n.List, true)
+		w.walk(n.ElseList, relative)
+	case *parse.TemplateNode:
+		w.walk(n.Pipe, relative)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+
+		for _, cmd := range n.Cmds {
+			w.walk(cmd, relative)
+		}
+	case *parse.CommandNode:
+		args := n.Args
+		if key, ok := w.indexReference(n, relative); ok {
+			w.keys[key] = struct{}{}
+			args = args[2:] // the map field is not used as a whole.
+		}
+
+		for _, arg := range args {
+			w.walk(arg, relative)
+		}
+	case *parse.ChainNode:
+		w.walk(n.Node, relative)
+	case *parse.FieldNode:
+		if !relative {
+			w.fields[n.Ident[0]] = struct{}{}
+		}
+
+		w.reference(n.Ident, relative)
+	case *parse.VariableNode:
+		// `$` is the top-level value, even within the bodies of `range` and `with` actions.
+		if n.Ident[0] == "$" && len(n.Ident) >= 2 {
+			w.fields[n.Ident[1]] = struct{}{}
+			w.reference(n.Ident[1:], false)
+		}
+	}
+}
+
+// reference collects the key of the map field referenced by the identifiers of a field, e.g. `FIELD.KEY`. A field
+// referencing the map field as a whole may reference any key. Relative fields only reference keys.
+func (w *referenceWalker) reference(ident []string, relative bool) {
+	if ident[0] != w.mapField {
+		return
+	}
+
+	switch {
+	case len(ident) >= 2:
+		w.keys[ident[1]] = struct{}{}
+	case !relative:
+		w.keys[AllReferences] = struct{}{}
+	}
+}
+
+// indexReference returns the key referenced by a command of the form `index .FIELD "KEY"` or `index $.FIELD "KEY"`.
+func (w *referenceWalker) indexReference(cmd *parse.CommandNode, relative bool) (string, bool) {
+	if len(cmd.Args) != 3 {
+		return "", false
+	}
+
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); !ok || ident.Ident != "index" {
+		return "", false
+	}
+
+	s, ok := cmd.Args[2].(*parse.StringNode)
+	if !ok {
+		return "", false
+	}
+
+	switch arg := cmd.Args[1].(type) {
+	case *parse.FieldNode:
+		if !slices.Equal(arg.Ident, []string{w.mapField}) {
+			return "", false
+		}
+
+		if !relative {
+			w.fields[w.mapField] = struct{}{}
+		}
+	case *parse.VariableNode:
+		if !slices.Equal(arg.Ident, []string{"$", w.mapField}) {
+			return "", false
+		}
+
+		w.fields[w.mapField] = struct{}{}
+	default:
+		return "", false
+	}
+
+	return s.Text, true
+}
+
+// ---------------------------------------------------- DEPENDENCIES ------------------------------------------------ //
+
+// SortDependencies sorts the nodes of a dependency graph into levels: the nodes of a level only depend on the nodes of
+// the previous levels, so the nodes of a level can be rendered concurrently once the previous levels are. Dependencies
+// on nodes absent from the graph are ignored. Names are sorted within a level. It fails with ErrReferenceCycle,
+// describing the cycle, if nodes depend on each other.
+func SortDependencies(dependencies map[string][]string) ([][]string, error) {
+	level := make(map[string]int, len(dependencies))
+	visiting := make(map[string]bool, len(dependencies))
+
+	var visit func(name string, path []string) error
+
+	visit = func(name string, path []string) error {
+		if _, ok := level[name]; ok {
+			return nil
+		}
+
+		path = append(path, name)
+		if visiting[name] {
+			start := slices.Index(path, name)
+			return fmt.Errorf("%w: %s", ErrReferenceCycle, strings.Join(path[start:], " -> "))
+		}
+
+		visiting[name] = true
+
+		l := 0
+
+		for _, dep := range slices.Sorted(slices.Values(dependencies[name])) {
+			if _, ok := dependencies[dep]; !ok {
+				continue
+			}
+
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+
+			l = max(l, level[dep]+1)
+		}
+
+		level[name] = l
+
+		return nil
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(dependencies)) {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	var out [][]string
+
+	for _, name := range slices.Sorted(maps.Keys(level)) {
+		for len(out) <= level[name] {
+			out = append(out, nil)
+		}
+
+		out[level[name]] = append(out[level[name]], name)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,109 @@
+//go:build unit
+
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templateutil_test
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/alexandremahdhaoui/shaper/internal/util/templateutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferences(t *testing.T) {
+	tpl, err := templateutil.Parse("", `{{ .AdditionalContent.a }} {{ index .AdditionalContent "b" }}
+{{ if .Machine.UUID }}{{ .AdditionalContent.c }}{{ end }}
+{{ range .Assignment.Labels }}{{ .Name }}{{ end }}`)
+	require.NoError(t, err)
+
+	keys, fields := templateutil.References(tpl, "AdditionalContent")
+	assert.Equal(t, map[string]struct{}{"a": {}, "b": {}, "c": {}}, keys)
+	assert.Equal(t, map[string]struct{}{"AdditionalContent": {}, "Machine": {}, "Assignment": {}}, fields)
+
+	for name, tc := range map[string]struct {
+		text           string
+		expectedKeys   []string
+		expectedFields []string
+	}{
+		"root variable": {
+			text: `{{ range .Assignment.Labels }}{{ $.AdditionalContent.a }}` +
+				`{{ index $.AdditionalContent "b" }}{{ end }}`,
+			expectedKeys:   []string{"a", "b"},
+			expectedFields: []string{"AdditionalContent", "Assignment"},
+		},
+		"with the map field": {
+			text:           `{{ with .AdditionalContent }}{{ .a }}{{ end }}`,
+			expectedKeys:   []string{templateutil.AllReferences},
+			expectedFields: []string{"AdditionalContent"},
+		},
+		"range over the map field": {
+			text:           `{{ range $name, $content := $.AdditionalContent }}{{ $content }}{{ end }}`,
+			expectedKeys:   []string{templateutil.AllReferences},
+			expectedFields: []string{"AdditionalContent"},
+		},
+		"map field passed to a function": {
+			text:           `{{ toJson .AdditionalContent }}`,
+			expectedKeys:   []string{templateutil.AllReferences},
+			expectedFields: []string{"AdditionalContent"},
+		},
+		"relative map field": {
+			text:           `{{ with .Machine }}{{ .AdditionalContent }}{{ end }}`,
+			expectedKeys:   []string{},
+			expectedFields: []string{"Machine"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tpl, err := templateutil.Parse(name, tc.text)
+			require.NoError(t, err)
+
+			keys, fields := templateutil.References(tpl, "AdditionalContent")
+			assert.ElementsMatch(t, tc.expectedKeys, slices.Collect(maps.Keys(keys)))
+			assert.ElementsMatch(t, tc.expectedFields, slices.Collect(maps.Keys(fields)))
+		})
+	}
+}
+
+func TestSortDependencies(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		levels, err := templateutil.SortDependencies(map[string][]string{
+			"ipxe":      {"cloudinit", "cmdline"},
+			"cloudinit": {"script", "absent"},
+			"cmdline":   nil,
+			"script":    nil,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"cmdline", "script"}, {"cloudinit"}, {"ipxe"}}, levels)
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		_, err := templateutil.SortDependencies(map[string][]string{
+			"a": {"b"},
+			"b": {"c"},
+			"c": {"a"},
+		})
+		assert.ErrorIs(t, err, templateutil.ErrReferenceCycle)
+		assert.ErrorContains(t, err, "a -> b -> c -> a")
+	})
+
+	t.Run("Self reference", func(t *testing.T) {
+		_, err := templateutil.SortDependencies(map[string][]string{"a": {"a"}})
+		assert.ErrorIs(t, err, templateutil.ErrReferenceCycle)
+		assert.ErrorContains(t, err, "a -> a")
+	})
+}
@@ -0,0 +1,486 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package templateutil parses and executes the templates of profiles with a curated, deterministic function library.
+// Execution is bounded in time, in output size and in the number of range iterations.
+package templateutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// MaxOutputSize is the maximum size in bytes of the output of a template.
+	MaxOutputSize = 4 << 20
+	// Timeout is the maximum duration of the execution of a template.
+	Timeout = 2 * time.Second
+	// MaxIterations is the maximum number of range iterations and template calls of an execution of a template.
+	MaxIterations = 1 << 16
+)
+
+var (
+	// ErrTemplateParse is returned when a template cannot be parsed.
+	ErrTemplateParse = errors.New("parsing template")
+	// ErrTemplateExecute is returned when a template cannot be executed.
+	ErrTemplateExecute = errors.New("executing template")
+	// ErrTemplateTimeout is returned when the execution of a template exceeds Timeout.
+	ErrTemplateTimeout = errors.New("template execution timed out")
+	// ErrTemplateOutputTooLarge is returned when the output of a template exceeds MaxOutputSize.
+	ErrTemplateOutputTooLarge = errors.New("template output too large")
+	// ErrTemplateTooManyIterations is returned when an execution of a template exceeds MaxIterations.
+	ErrTemplateTooManyIterations = errors.New("template exceeds the maximum number of iterations")
+	// ErrTemplateRangeOverInteger is returned when a template ranges over an integer.
+	ErrTemplateRangeOverInteger = errors.New("range over integer is not allowed")
+)
+
+// ---------------------------------------------------- TEMPLATE ---------------------------------------------------- //
+
+// Parse parses the text as a template with the functions returned by FuncMap. The pipelines of its range actions and
+// template calls are guarded, so Execute can count their iterations.
+func Parse(name, text string) (*template.Template, error) {
+	tpl, err := template.New(name).
+		Funcs(FuncMap()).
+		Funcs(newGuardFuncMap(func(int) error { return nil })).
+		Parse(text)
+	if err != nil {
+		return nil, errors.Join(err, ErrTemplateParse)
+	}
+
+	for _, t := range tpl.Templates() {
+		if t.Tree != nil {
+			guardNode(t.Tree, t.Root)
+		}
+	}
+
+	return tpl, nil
+}
+
+// Render parses and executes the text as a template.
+func Render(ctx context.Context, name, text string, data any) ([]byte, error) {
+	tpl, err := Parse(name, text)
+	if err != nil {
+		return nil, err // TODO: wrap err
+	}
+
+	return Execute(ctx, tpl, data)
+}
+
+// Execute executes the template with the data. It fails if the execution exceeds Timeout, if the output exceeds
+// MaxOutputSize or if the execution exceeds MaxIterations.
+//
+// text/template cannot be interrupted: a template timing out keeps running in the background until it writes output,
+// enters a range action or calls a template; its output is discarded.
+func Execute(ctx context.Context, tpl *template.Template, data any) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	// The guards count the iterations of this execution only.
+	tpl, err := tpl.Clone()
+	if err != nil {
+		return nil, errors.Join(err, ErrTemplateExecute)
+	}
+
+	tpl.Funcs(newGuardFuncMap(newIterationCounter(ctx)))
+
+	w := &boundedWriter{ctx: ctx, limit: MaxOutputSize}
+	done := make(chan error, 1)
+
+	go func() {
+		done <- tpl.Execute(w, data)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, errors.Join(err, ErrTemplateExecute)
+		}
+
+		return w.buf.Bytes(), nil
+	case <-ctx.Done():
+		return nil, errors.Join(ctx.Err(), ErrTemplateTimeout, ErrTemplateExecute)
+	}
+}
+
+// boundedWriter fails once its limit is exceeded or its context is done, which stops the execution of the template.
+type boundedWriter struct {
+	ctx   context.Context
+	buf   bytes.Buffer
+	limit int
+}
+
+func (w *boundedWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, errors.Join(err, ErrTemplateTimeout)
+	}
+
+	if w.buf.Len()+len(p) > w.limit {
+		return 0, fmt.Errorf("%w: exceeds %d bytes", ErrTemplateOutputTooLarge, w.limit)
+	}
+
+	return w.buf.Write(p)
+}
+
+// ----------------------------------------------------- GUARDS ----------------------------------------------------- //
+
+const (
+	rangeGuardFunc    = "_shaperRangeGuard"
+	templateGuardFunc = "_shaperTemplateGuard"
+)
+
+// guardNode appends the guard functions to the pipelines of the range actions and template calls below the node.
+func guardNode(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+
+		for _, child := range n.Nodes {
+			guardNode(tree, child)
+		}
+	case *parse.IfNode:
+		guardNode(tree, n.List)
+		guardNode(tree, n.ElseList)
+	case *parse.WithNode:
+		guardNode(tree, n.List)
+		guardNode(tree, n.ElseList)
+	case *parse.RangeNode:
+		n.Pipe.Cmds = append(n.Pipe.Cmds, guardCommand(tree, n.Pipe.Pos, rangeGuardFunc))
+		guardNode(tree, n.List)
+		guardNode(tree, n.ElseList)
+	case *parse.TemplateNode:
+		if n.Pipe == nil {
+			n.Pipe = &parse.PipeNode{NodeType: parse.NodePipe, Pos: n.Pos, Line: n.Line}
+		}
+
+		n.Pipe.Cmds = append(n.Pipe.Cmds, guardCommand(tree, n.Pipe.Pos, templateGuardFunc))
+	}
+}
+
+func guardCommand(tree *parse.Tree, pos parse.Pos, name string) *parse.CommandNode {
+	return &parse.CommandNode{
+		NodeType: parse.NodeCommand,
+		Pos:      pos,
+		Args:     []parse.Node{parse.NewIdentifier(name).SetTree(tree).SetPos(pos)},
+	}
+}
+
+// newIterationCounter returns a function counting the iterations of an execution. It fails once the context is done
+// or once the execution exceeds MaxIterations, as text/template cannot be interrupted otherwise.
+func newIterationCounter(ctx context.Context) func(n int) error {
+	iterations := 0
+
+	return func(n int) error {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(err, ErrTemplateTimeout)
+		}
+
+		iterations += n
+		if iterations > MaxIterations {
+			return fmt.Errorf("%w: exceeds %d iterations", ErrTemplateTooManyIterations, MaxIterations)
+		}
+
+		return nil
+	}
+}
+
+// newGuardFuncMap returns the guard functions, which count the iterations of range actions and template calls. The
+// range guard rejects integers, as ranging over them does not depend on the size of the data.
+func newGuardFuncMap(count func(n int) error) template.FuncMap {
+	return template.FuncMap{
+		rangeGuardFunc: func(v any) (any, error) {
+			n := 0
+
+			rv := reflect.ValueOf(v)
+			switch rv.Kind() { //nolint:exhaustive
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				return nil, ErrTemplateRangeOverInteger
+			case reflect.Array, reflect.Map, reflect.Slice, reflect.String, reflect.Chan:
+				n = rv.Len()
+			}
+
+			if err := count(n + 1); err != nil {
+				return nil, err // TODO: wrap err
+			}
+
+			return v, nil
+		},
+		// The template guard is variadic, as a template call without a pipeline passes no data.
+		templateGuardFunc: func(v ...any) (any, error) {
+			if err := count(1); err != nil {
+				return nil, err // TODO: wrap err
+			}
+
+			if len(v) == 0 {
+				return nil, nil
+			}
+
+			return v[0], nil
+		},
+	}
+}
+
+// ---------------------------------------------------- FUNCTIONS --------------------------------------------------- //
+
+// FuncMap returns the functions available to templates. Their names and the order of their arguments follow Sprig,
+// so they can be chained, e.g. `{{ .Machine.Hostname | default "unknown" | upper }}`. None of them depends on the
+// environment, the time or randomness: rendering a template twice with the same data yields the same output.
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		// Defaults
+		"default":  defaultFunc,
+		"empty":    empty,
+		"coalesce": coalesce,
+		"ternary":  ternary,
+
+		// Strings
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    replace,
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":       join,
+		"quote":      func(s any) string { return fmt.Sprintf("%q", toString(s)) },
+		"squote":     func(s any) string { return fmt.Sprintf("'%s'", toString(s)) },
+		"indent":     indent,
+		"nindent":    nindent,
+
+		// Encoding
+		"b64enc":       func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec":       b64dec,
+		"sha256sum":    sha256sum,
+		"toJson":       toJSON,
+		"toPrettyJson": toPrettyJSON,
+		"toYaml":       toYAML,
+		"ipxeEscape":   ipxeEscape,
+	}
+}
+
+// defaultFunc returns the given value, or def if the given value is empty.
+func defaultFunc(def any, given ...any) any {
+	if len(given) == 0 || empty(given[0]) {
+		return def
+	}
+
+	return given[0]
+}
+
+// empty returns true if the value is nil or the zero value of its type. Empty collections are empty.
+func empty(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() { //nolint:exhaustive
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+// coalesce returns the first non-empty value.
+func coalesce(values ...any) any {
+	for _, v := range values {
+		if !empty(v) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// ternary returns vt if cond is true, vf otherwise.
+func ternary(vt, vf any, cond bool) any {
+	if cond {
+		return vt
+	}
+
+	return vf
+}
+
+// checkOutputSize fails if a function would return a string of the given size, which would exceed MaxOutputSize.
+// Functions check the size of their output before building it, so a template cannot allocate beyond the limit.
+func checkOutputSize(size int) error {
+	if size < 0 || size > MaxOutputSize {
+		return fmt.Errorf("%w: exceeds %d bytes", ErrTemplateOutputTooLarge, MaxOutputSize)
+	}
+
+	return nil
+}
+
+// replace replaces all occurrences of old by new in the string.
+func replace(old, new, s string) (string, error) {
+	// strings.Count returns the number of runes plus one if old is empty, which is the number of insertions.
+	if err := checkOutputSize(len(s) + strings.Count(s, old)*(len(new)-len(old))); err != nil {
+		return "", err // TODO: wrap err
+	}
+
+	return strings.ReplaceAll(s, old, new), nil
+}
+
+// join joins the elements of a list, converted to strings, with the separator.
+func join(sep string, list any) (string, error) {
+	rv := reflect.ValueOf(list)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return toString(list), nil
+	}
+
+	size := 0
+	out := make([]string, 0, rv.Len())
+
+	for i := range rv.Len() {
+		s := toString(rv.Index(i).Interface())
+		size += len(s) + len(sep)
+		if err := checkOutputSize(size); err != nil {
+			return "", err // TODO: wrap err
+		}
+
+		out = append(out, s)
+	}
+
+	return strings.Join(out, sep), nil
+}
+
+// indent prefixes every line of the string with the given number of spaces.
+func indent(spaces int, s string) (string, error) {
+	if spaces < 0 {
+		return "", fmt.Errorf("indent: negative number of spaces %d", spaces) // TODO: wrap err
+	}
+
+	if err := checkOutputSize(spaces); err != nil {
+		return "", err // TODO: wrap err
+	}
+
+	if err := checkOutputSize(len(s) + spaces*(strings.Count(s, "\n")+1)); err != nil {
+		return "", err // TODO: wrap err
+	}
+
+	pad := strings.Repeat(" ", spaces)
+
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad), nil
+}
+
+// nindent prefixes every line of the string with the given number of spaces, and the string with a newline.
+func nindent(spaces int, s string) (string, error) {
+	out, err := indent(spaces, s)
+	if err != nil {
+		return "", err // TODO: wrap err
+	}
+
+	if err := checkOutputSize(len(out) + 1); err != nil {
+		return "", err // TODO: wrap err
+	}
+
+	return "\n" + out, nil
+}
+
+func b64dec(s string) (string, error) {
+	out, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err // TODO: wrap err
+	}
+
+	return string(out), nil
+}
+
+func sha256sum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func toJSON(v any) (string, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", err // TODO: wrap err
+	}
+
+	return string(out), nil
+}
+
+func toPrettyJSON(v any) (string, error) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err // TODO: wrap err
+	}
+
+	return string(out), nil
+}
+
+func toYAML(v any) (string, error) {
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err // TODO: wrap err
+	}
+
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// ipxeEscaper percent-encodes the characters iPXE interprets in a command line: whitespace splits arguments, `${`
+// expands settings, and `||` and `&&` chain commands. `%` is encoded too, so the result can be decoded.
+var ipxeEscaper = strings.NewReplacer(
+	"%", "%25",
+	" ", "%20",
+	"\t", "%09",
+	"\n", "%0A",
+	"\r", "%0D",
+	"$", "%24",
+	"{", "%7B",
+	"}", "%7D",
+	"&", "%26",
+	"|", "%7C",
+)
+
+// ipxeEscape makes the string safe to use as a single literal argument of an iPXE command, e.g. within a URI.
+func ipxeEscape(s any) string {
+	return ipxeEscaper.Replace(toString(s))
+}
+
+func toString(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case fmt.Stringer:
+		return s.String()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,171 @@
+//go:build unit
+
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templateutil_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexandremahdhaoui/shaper/internal/util/templateutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type slowData struct{}
+
+func (slowData) Slow() string {
+	time.Sleep(200 * time.Millisecond)
+	return "slow"
+}
+
+func TestRender(t *testing.T) {
+	data := map[string]any{
+		"Hostname": "node-0",
+		"Empty":    "",
+		"List":     []string{"a", "b"},
+		"Map":      map[string]string{"key": "value"},
+	}
+
+	for name, tc := range map[string]struct {
+		text     string
+		expected string
+	}{
+		"default":    {text: `{{ .Empty | default "x" }}`, expected: "x"},
+		"not empty":  {text: `{{ .Hostname | default "x" | upper }}`, expected: "NODE-0"},
+		"coalesce":   {text: `{{ coalesce .Empty .Hostname }}`, expected: "node-0"},
+		"ternary":    {text: `{{ ternary "yes" "no" (empty .Empty) }}`, expected: "yes"},
+		"replace":    {text: `{{ .Hostname | replace "-" "_" }}`, expected: "node_0"},
+		"trimPrefix": {text: `{{ .Hostname | trimPrefix "node-" }}`, expected: "0"},
+		"join":       {text: `{{ .List | join "," }}`, expected: "a,b"},
+		"splitList":  {text: `{{ splitList "," "a,b" | join ";" }}`, expected: "a;b"},
+		"quote":      {text: `{{ quote .Hostname }}`, expected: `"node-0"`},
+		"indent":     {text: `{{ "a\nb" | indent 2 }}`, expected: "  a\n  b"},
+		"nindent":    {text: `{{ "a" | nindent 2 }}`, expected: "\n  a"},
+		"b64enc":     {text: `{{ b64enc "hello" }}`, expected: "aGVsbG8="},
+		"b64dec":     {text: `{{ b64dec "aGVsbG8=" }}`, expected: "hello"},
+		"sha256sum": {
+			text:     `{{ sha256sum "hello" }}`,
+			expected: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		},
+		"toJson":     {text: `{{ toJson .Map }}`, expected: `{"key":"value"}`},
+		"toYaml":     {text: `{{ toYaml .Map }}`, expected: "key: value"},
+		"ipxeEscape": {text: `{{ ipxeEscape "a b${c}||d&&e%" }}`, expected: "a%20b%24%7Bc%7D%7C%7Cd%26%26e%25"},
+		"urlquery":   {text: `{{ urlquery "a b&c" }}`, expected: "a+b%26c"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, err := templateutil.Render(context.Background(), name, tc.text, data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(out))
+		})
+	}
+
+	t.Run("Parse error", func(t *testing.T) {
+		_, err := templateutil.Render(context.Background(), "", `{{ unknownFunc }}`, data)
+		assert.ErrorIs(t, err, templateutil.ErrTemplateParse)
+	})
+
+	t.Run("Execute error", func(t *testing.T) {
+		_, err := templateutil.Render(context.Background(), "", `{{ b64dec "%%%" }}`, data)
+		assert.ErrorIs(t, err, templateutil.ErrTemplateExecute)
+	})
+
+	t.Run("Output too large", func(t *testing.T) {
+		data := map[string]any{"Chunk": strings.Repeat("x", 1<<20), "List": make([]int, 8)}
+		text := `{{ range .List }}{{ $.Chunk }}{{ end }}`
+
+		_, err := templateutil.Render(context.Background(), "", text, data)
+		assert.ErrorIs(t, err, templateutil.ErrTemplateOutputTooLarge)
+		assert.ErrorIs(t, err, templateutil.ErrTemplateExecute)
+	})
+
+	t.Run("Function output too large", func(t *testing.T) {
+		data := map[string]any{"Chunk": strings.Repeat("x", 1<<20), "List": make([]string, 1<<20)}
+
+		for name, text := range map[string]string{
+			"indent":          `{{ indent 2000000000 "x" }}`,
+			"indent lines":    `{{ indent 1000 (replace "x" "\n" .Chunk) }}`,
+			"nindent":         `{{ nindent 2000000000 "x" }}`,
+			"replace":         `{{ replace "x" .Chunk .Chunk }}`,
+			"replace empty":   `{{ replace "" "abcd" .Chunk }}`,
+			"join":            `{{ join .Chunk .List }}`,
+			"chained replace": `{{ replace "x" "xxxx" .Chunk | replace "x" "xxxx" }}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := templateutil.Render(context.Background(), "", text, data)
+				assert.ErrorIs(t, err, templateutil.ErrTemplateOutputTooLarge)
+				assert.ErrorIs(t, err, templateutil.ErrTemplateExecute)
+			})
+		}
+	})
+
+	t.Run("Range over integer", func(t *testing.T) {
+		for name, text := range map[string]string{
+			"literal":  `{{ range 1000000000 }}{{ end }}`,
+			"declared": `{{ range $i := 1000000000 }}{{ end }}`,
+			"defined":  `{{ define "loop" }}{{ range . }}{{ end }}{{ end }}{{ template "loop" 1000000000 }}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := templateutil.Render(context.Background(), "", text, nil)
+				assert.ErrorIs(t, err, templateutil.ErrTemplateRangeOverInteger)
+				assert.ErrorIs(t, err, templateutil.ErrTemplateExecute)
+			})
+		}
+	})
+
+	t.Run("Too many iterations", func(t *testing.T) {
+		for name, text := range map[string]string{
+			"nested ranges": `{{ $l := splitList "" .Chunk }}{{ range $l }}{{ range $l }}{{ end }}{{ end }}`,
+			"template calls": `{{ define "a" }}{{ template "b" }}{{ template "b" }}{{ end }}` +
+				`{{ define "b" }}{{ template "c" }}{{ template "c" }}{{ end }}` +
+				`{{ define "c" }}{{ template "d" }}{{ template "d" }}{{ end }}` +
+				`{{ define "d" }}{{ template "e" }}{{ template "e" }}{{ end }}` +
+				`{{ define "e" }}{{ template "f" }}{{ template "f" }}{{ end }}` +
+				`{{ define "f" }}{{ template "g" }}{{ template "g" }}{{ end }}` +
+				`{{ define "g" }}{{ end }}` +
+				`{{ range .List }}{{ template "a" }}{{ end }}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				data := map[string]any{"Chunk": strings.Repeat("x", 1<<10), "List": make([]int, 1<<10)}
+
+				_, err := templateutil.Render(context.Background(), "", text, data)
+				assert.ErrorIs(t, err, templateutil.ErrTemplateTooManyIterations)
+				assert.ErrorIs(t, err, templateutil.ErrTemplateExecute)
+			})
+		}
+	})
+
+	t.Run("Ranges within the limits", func(t *testing.T) {
+		text := `{{ define "item" }}{{ . }}{{ end }}` +
+			`{{ range $i, $e := .List }}{{ if $i }},{{ end }}{{ template "item" $e }}{{ end }}` +
+			`{{ range $k, $v := .Map }};{{ $k }}={{ $v }}{{ end }}`
+
+		out, err := templateutil.Render(context.Background(), "", text, data)
+		require.NoError(t, err)
+		assert.Equal(t, "a,b;key=value", string(out))
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := templateutil.Render(ctx, "", `{{ .Slow }}`, slowData{})
+		assert.ErrorIs(t, err, templateutil.ErrTemplateTimeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
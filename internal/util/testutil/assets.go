@@ -207,6 +207,8 @@ func NewTypesWebhookConfig() types.WebhookConfig {
 }
 
 func MakeContentComparable(content types.Content) types.Content {
+	content.SpecHash = ""
+
 	if content.ObjectRef != nil {
 		content.ObjectRef.JSONPath = &jsonpath.JSONPath{}
 	}
@@ -35,6 +35,13 @@ import (
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
+// Defines values for MachineEventType.
+const (
+	InstallFailed    MachineEventType = "install-failed"
+	InstallStarted   MachineEventType = "install-started"
+	InstallSucceeded MachineEventType = "install-succeeded"
+)
+
 // Defines values for BuildarchSelector.
 const (
 	BuildarchSelectorArm32 BuildarchSelector = "arm32"
@@ -65,6 +72,18 @@ type Error struct {
 	Message string `json:"message"`
 }
 
+// MachineEvent A provisioning event reported by a machine.
+type MachineEvent struct {
+	// Message An optional human-readable message, e.g. the reason of a failure.
+	Message *string `json:"message,omitempty"`
+
+	// Type The type of the event.
+	Type MachineEventType `json:"type"`
+}
+
+// MachineEventType The type of the event.
+type MachineEventType string
+
 // UUID defines model for UUID.
 type UUID = openapi_types.UUID
 
@@ -74,9 +93,39 @@ type Content = string
 // IPXE An iPXE manifest.
 type IPXE = string
 
+// AssetSelector defines model for assetSelector.
+type AssetSelector = string
+
 // BuildarchSelector defines model for buildarchSelector.
 type BuildarchSelector string
 
+// Expires defines model for expires.
+type Expires = int64
+
+// HostnameSelector defines model for hostnameSelector.
+type HostnameSelector = string
+
+// Kid defines model for kid.
+type Kid = string
+
+// MacSelector defines model for macSelector.
+type MacSelector = string
+
+// ManufacturerSelector defines model for manufacturerSelector.
+type ManufacturerSelector = string
+
+// PlatformSelector defines model for platformSelector.
+type PlatformSelector = string
+
+// ProductSelector defines model for productSelector.
+type ProductSelector = string
+
+// SerialSelector defines model for serialSelector.
+type SerialSelector = string
+
+// Signature defines model for signature.
+type Signature = string
+
 // UuidSelector defines model for uuidSelector.
 type UuidSelector = UUID
 
@@ -92,6 +141,9 @@ type N403 = Error
 // N404 defines model for 404.
 type N404 = Error
 
+// N410 defines model for 410.
+type N410 = Error
+
 // N500 defines model for 500.
 type N500 = Error
 
@@ -102,6 +154,15 @@ type N503 = Error
 type GetContentByIDParams struct {
 	Uuid      *UuidSelector                 `form:"uuid,omitempty" json:"uuid,omitempty"`
 	Buildarch GetContentByIDParamsBuildarch `form:"buildarch" json:"buildarch"`
+
+	// Expires Expiry of a signed content URL, as a Unix timestamp in seconds.
+	Expires *Expires `form:"expires,omitempty" json:"expires,omitempty"`
+
+	// Kid Identifier of the key that signed the content URL.
+	Kid *Kid `form:"kid,omitempty" json:"kid,omitempty"`
+
+	// Signature Base64url-encoded HMAC-SHA256 signature of the content URL.
+	Signature *Signature `form:"signature,omitempty" json:"signature,omitempty"`
 }
 
 // GetContentByIDParamsBuildarch defines parameters for GetContentByID.
@@ -111,11 +172,35 @@ type GetContentByIDParamsBuildarch string
 type GetIPXEBySelectorsParams struct {
 	Uuid      *UuidSelector                     `form:"uuid,omitempty" json:"uuid,omitempty"`
 	Buildarch GetIPXEBySelectorsParamsBuildarch `form:"buildarch" json:"buildarch"`
+
+	// Mac MAC address of the booting network interface, e.g. `52-54-00-12-34-56`.
+	Mac *MacSelector `form:"mac,omitempty" json:"mac,omitempty"`
+
+	// Serial Serial number of the machine.
+	Serial *SerialSelector `form:"serial,omitempty" json:"serial,omitempty"`
+
+	// Hostname Hostname of the machine.
+	Hostname *HostnameSelector `form:"hostname,omitempty" json:"hostname,omitempty"`
+
+	// Asset Asset tag of the machine.
+	Asset *AssetSelector `form:"asset,omitempty" json:"asset,omitempty"`
+
+	// Product Product name of the machine.
+	Product *ProductSelector `form:"product,omitempty" json:"product,omitempty"`
+
+	// Manufacturer Manufacturer of the machine.
+	Manufacturer *ManufacturerSelector `form:"manufacturer,omitempty" json:"manufacturer,omitempty"`
+
+	// Platform Firmware platform of the machine, e.g. `pcbios` or `efi`.
+	Platform *PlatformSelector `form:"platform,omitempty" json:"platform,omitempty"`
 }
 
 // GetIPXEBySelectorsParamsBuildarch defines parameters for GetIPXEBySelectors.
 type GetIPXEBySelectorsParamsBuildarch string
 
+// PostMachineEventJSONRequestBody defines body for PostMachineEvent for application/json ContentType.
+type PostMachineEventJSONRequestBody = MachineEvent
+
 // RequestEditorFn  is the function signature for the RequestEditor callback function
 type RequestEditorFn func(ctx context.Context, req *http.Request) error
 
@@ -197,6 +282,11 @@ type ClientInterface interface {
 
 	// GetIPXEBySelectors request
 	GetIPXEBySelectors(ctx context.Context, params *GetIPXEBySelectorsParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PostMachineEventWithBody request with any body
+	PostMachineEventWithBody(ctx context.Context, uuid UUID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PostMachineEvent(ctx context.Context, uuid UUID, body PostMachineEventJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
 }
 
 func (c *Client) GetIPXEBootstrap(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
@@ -235,6 +325,30 @@ func (c *Client) GetIPXEBySelectors(ctx context.Context, params *GetIPXEBySelect
 	return c.Client.Do(req)
 }
 
+func (c *Client) PostMachineEventWithBody(ctx context.Context, uuid UUID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostMachineEventRequestWithBody(c.Server, uuid, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostMachineEvent(ctx context.Context, uuid UUID, body PostMachineEventJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostMachineEventRequest(c.Server, uuid, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 // NewGetIPXEBootstrapRequest generates requests for GetIPXEBootstrap
 func NewGetIPXEBootstrapRequest(server string) (*http.Request, error) {
 	var err error
@@ -319,6 +433,54 @@ func NewGetContentByIDRequest(server string, contentID UUID, params *GetContentB
 			}
 		}
 
+		if params.Expires != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "expires", runtime.ParamLocationQuery, *params.Expires); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Kid != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "kid", runtime.ParamLocationQuery, *params.Kid); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Signature != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "signature", runtime.ParamLocationQuery, *params.Signature); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
 		queryURL.RawQuery = queryValues.Encode()
 	}
 
@@ -380,6 +542,118 @@ func NewGetIPXEBySelectorsRequest(server string, params *GetIPXEBySelectorsParam
 			}
 		}
 
+		if params.Mac != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "mac", runtime.ParamLocationQuery, *params.Mac); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Serial != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "serial", runtime.ParamLocationQuery, *params.Serial); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Hostname != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "hostname", runtime.ParamLocationQuery, *params.Hostname); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Asset != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "asset", runtime.ParamLocationQuery, *params.Asset); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Product != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "product", runtime.ParamLocationQuery, *params.Product); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Manufacturer != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "manufacturer", runtime.ParamLocationQuery, *params.Manufacturer); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Platform != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "platform", runtime.ParamLocationQuery, *params.Platform); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
 		queryURL.RawQuery = queryValues.Encode()
 	}
 
@@ -391,6 +665,53 @@ func NewGetIPXEBySelectorsRequest(server string, params *GetIPXEBySelectorsParam
 	return req, nil
 }
 
+// NewPostMachineEventRequest calls the generic PostMachineEvent builder with application/json body
+func NewPostMachineEventRequest(server string, uuid UUID, body PostMachineEventJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPostMachineEventRequestWithBody(server, uuid, "application/json", bodyReader)
+}
+
+// NewPostMachineEventRequestWithBody generates requests for PostMachineEvent with any type of body
+func NewPostMachineEventRequestWithBody(server string, uuid UUID, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "uuid", runtime.ParamLocationPath, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/machines/%s/events", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
 func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
 	for _, r := range c.RequestEditors {
 		if err := r(ctx, req); err != nil {
@@ -442,6 +763,11 @@ type ClientWithResponsesInterface interface {
 
 	// GetIPXEBySelectorsWithResponse request
 	GetIPXEBySelectorsWithResponse(ctx context.Context, params *GetIPXEBySelectorsParams, reqEditors ...RequestEditorFn) (*GetIPXEBySelectorsResponse, error)
+
+	// PostMachineEventWithBodyWithResponse request with any body
+	PostMachineEventWithBodyWithResponse(ctx context.Context, uuid UUID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostMachineEventResponse, error)
+
+	PostMachineEventWithResponse(ctx context.Context, uuid UUID, body PostMachineEventJSONRequestBody, reqEditors ...RequestEditorFn) (*PostMachineEventResponse, error)
 }
 
 type GetIPXEBootstrapResponse struct {
@@ -478,6 +804,7 @@ type GetContentByIDResponse struct {
 	JSON401      *N401
 	JSON403      *N403
 	JSON404      *N404
+	JSON410      *N410
 	JSON500      *N500
 	JSON503      *N503
 }
@@ -525,6 +852,33 @@ func (r GetIPXEBySelectorsResponse) StatusCode() int {
 	return 0
 }
 
+type PostMachineEventResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON400      *N400
+	JSON401      *N401
+	JSON403      *N403
+	JSON404      *N404
+	JSON500      *N500
+	JSON503      *N503
+}
+
+// Status returns HTTPResponse.Status
+func (r PostMachineEventResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PostMachineEventResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 // GetIPXEBootstrapWithResponse request returning *GetIPXEBootstrapResponse
 func (c *ClientWithResponses) GetIPXEBootstrapWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetIPXEBootstrapResponse, error) {
 	rsp, err := c.GetIPXEBootstrap(ctx, reqEditors...)
@@ -552,6 +906,23 @@ func (c *ClientWithResponses) GetIPXEBySelectorsWithResponse(ctx context.Context
 	return ParseGetIPXEBySelectorsResponse(rsp)
 }
 
+// PostMachineEventWithBodyWithResponse request with arbitrary body returning *PostMachineEventResponse
+func (c *ClientWithResponses) PostMachineEventWithBodyWithResponse(ctx context.Context, uuid UUID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostMachineEventResponse, error) {
+	rsp, err := c.PostMachineEventWithBody(ctx, uuid, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostMachineEventResponse(rsp)
+}
+
+func (c *ClientWithResponses) PostMachineEventWithResponse(ctx context.Context, uuid UUID, body PostMachineEventJSONRequestBody, reqEditors ...RequestEditorFn) (*PostMachineEventResponse, error) {
+	rsp, err := c.PostMachineEvent(ctx, uuid, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostMachineEventResponse(rsp)
+}
+
 // ParseGetIPXEBootstrapResponse parses an HTTP response from a GetIPXEBootstrapWithResponse call
 func ParseGetIPXEBootstrapResponse(rsp *http.Response) (*GetIPXEBootstrapResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -655,6 +1026,13 @@ func ParseGetContentByIDResponse(rsp *http.Response) (*GetContentByIDResponse, e
 		}
 		response.JSON404 = &dest
 
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 410:
+		var dest N410
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON410 = &dest
+
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
 		var dest N500
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
@@ -735,34 +1113,106 @@ func ParseGetIPXEBySelectorsResponse(rsp *http.Response) (*GetIPXEBySelectorsRes
 	return response, nil
 }
 
+// ParsePostMachineEventResponse parses an HTTP response from a PostMachineEventWithResponse call
+func ParsePostMachineEventResponse(rsp *http.Response) (*PostMachineEventResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PostMachineEventResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 400:
+		var dest N400
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON400 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 401:
+		var dest N401
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON401 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 403:
+		var dest N403
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON403 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
+		var dest N404
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON404 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
+		var dest N500
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON500 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 503:
+		var dest N503
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON503 = &dest
+
+	}
+
+	return response, nil
+}
+
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/+xX227bPBJ+FS6bS5mSD3FbAcHCadzCQA9B0iwK1EVAS2OLrUSqJOXEa/jdF0NKtpJ4",
-	"GyOb7o8f6JVlaTjzzfCb05omqiiVBGkNjde05JoXYEG7f7NK5CnXSXYJOSRWaXwpJI3pzwr0igZU8gJo",
-	"vBOkAdXwsxIaUhpbXUFATZJBwfEkyKqg8Vcq+q+GNKC3r4bXwwENKNdFv+d/hwP6LaB2VaJWY7WQC7rZ",
-	"BLSqRPoYCJShbXtHGuY0pi/CnY+h/2rCq6vJGd2gag2mVNKA83gQRfiTKGlBWnzkZZmLhFuhZPjdKOkc",
-	"ueVFmYOXTIHGgygKaAHG8AUiOeUpwTCAsQEpc+AGSJJB8oOsVKWJkGVl6eZQqGOtlfZYUzCJFiWCqc1c",
-	"eDOobRB1n4a928Z+JXllM6XFvyHdgi+1WooUyJLnIiUoANLWmr075hn8GdWGG7VzpYv62ZBCGCPkgiiM",
-	"n8Phfe4/zed+2+e3Ss9EmoIM8IJIqohUlmR8CaQE7SwrSawiPEnAGGIzYYgGoyqdwDM4vrXvXRo8zaVB",
-	"26XPGTQUhHSLldxw43ybq0qmz3FlxJSQiLloGxH3bBw/LamO7ybVRFrQkufEgF6CJoCYtgy1ekX4ggtJ",
-	"cm5BP4NrVxJuS0gwfGKfae9Z/2me3aHfJeilSIBUki+5yPksh9/o1x5rDNW2nGg9Wri1YZlz4Tw5zHRz",
-	"fJ/xymXQvMrzFdFgtYAlpKQ+4XCI8y/jZwDh1ByOAMVJwaWYg0Ec2zi7tuCDif1RqxK0Fb5b+NtcU1+o",
-	"aEyFtK6T1Q0MebPwl7a97fWe7rZrmV+9zp38rhuq2XdIXKF3vavNK9rt9WFwPHzZgVevZ51uL+13+OB4",
-	"2Bn0hsPuoPtyEEURDXY461Z5D8kdDtzLc7lqLikgwBaMcDKrLJcQioUUrkgrTZJcVWlHSGFbsQxaQJdc",
-	"Cy5tTOaJMlO5BI2lNSZdNmDRVJbcmJs0nkpCKgPauCdCOgRbfEwSpcG/IcSY7HrXqa5/wKqR9ieMyTra",
-	"cDIajUaMsanc52/DtfvO3qdD24UX/xDlLUzlVBqw5PLzxXj0gRiLeeRf/Wt8cTn59JH0X7Ne1BtE3W6P",
-	"9dE7/Pjm08e3k3dXF+9JZm1p4jCsNbNEFZg5c7FgYiEb/aejy3Fb2k1ahmEklGFzSJXmpVZIDab0Iiy1",
-	"SkNjNfDChEdrD29THwuP1jW4TeinLzTzA7SEnByta1ub0KvteCOd3aFOLpbQ8fIdr4DgZev0pMASVaNC",
-	"KaaVsnNzXen85GDN/gzzmpkoFqQhF5sLbexMKbt7VebcIqOZSE8KsDzffarj6I1vQ76ZSo+WdDpIKOJA",
-	"H4zOneXFHYAYP0S1j16Y2TjENMWMJy6x6ml1lMMtl6kG8oFnacZVJWhAK53TmDaXvRA2q2aOGbwRLxrp",
-	"0GS89MXlLn0/43QicEoBMjqfkLnShNeUvnQNDAmdiwSkgTaikicZkB6LHgC5ublh3H12JKvPmvD95M34",
-	"4+W402MRy2yRIxgrrMsTZ290PqEBrdMcCxWLWIRSqgTJS0Fj2mcR69OAltxmrqqGGFCGSYb/FuCihmXX",
-	"ddVJSmP6Duzk/Mv4VClrrOYlvTfG9x5MHH9BCwmabWKfjS3cEIV20/tjst3W1PuYbL81Tj4mO2gNar+W",
-	"PfZ4jw/BgEKulVZFwfWKxvSiDteWkT5VcbhOMi5krniKf6Y0RAb8M+czyM3JkucVmCnFLOML47ZIJMg3",
-	"1N4MHOG6fpicbX5FnTde6nQ1OXO82628X9cPhkDxswIiUtx35gI0UXOXWNuJJfC7KLJ3t4pucfxyHz5g",
-	"Pw32S+0wh3c24wPkH+70m2+/JXv+tynwT+r899RJV5IXIuEYPd5EjcxWRFhDJmeslSTNJfg8Oaimrhpi",
-	"mIfJ8Xfl4p9K/n+o5E3AkImmxaH7BXuz+U8AAAD//yxObOx2FAAA",
+	"H4sIAAAAAAAC/+xZe2/bRhL/KnPb/MmXHnZbAcFBSdxWQJIadn0oEAfJiByJW5O7zO5Sts7Qdz/skpSo",
+	"l8X6kuIO6F+iuLMzv5md1w4fWSzzQgoSRrPRIytQYU6GlPuHWpO5poxiI5V9kZCOFS8Ml4KN2Ngug8E5",
+	"yBmYlCDHOOWCAuYxbgm+lKSWzGMCc2Kjih3zmI5TytHyM8vCLmijuJiz1cpj05JnCao4bYs9xGxNyDym",
+	"6EvJFSVsZFRJbQEkypyNPjA++OGceezhh/NP50PmMVT5oF/9ng/ZR+8AEnoouCK9r/aFXVhanRE0nwtK",
+	"IJbCkDBwc/XWA9SAcCP4AxiekzaYF8AFaIqlSPQx4zTi2uhnUuVo2IhxYRzsGiUXhuakHMxUamNZHD+m",
+	"X2qKjqfUMDxxUHc82Rc1SUgYPuOkGmF3tASTomksZd+1rHUMhOX+tPwc4+Mqvxu/BkwSRVo3QKZSGi7m",
+	"IMjcS3UH1oZqhjF5QME8gM9nff9s6EeR3+v7g6F/dv75GLgc45PgRDnD2JSK1BMoW1QdD6fN+ASGIkNj",
+	"Hei4/J+4yu9RETSkOyAayxTxlEv9GaSCzzTjR+3SsDkFTMmkjJ/IK5cVAfwJp615npCsSXHMjgu+dusg",
+	"ynza+UQqnqcE87lAe2j7Ml+hpvNhqTKfRCwTSuCXd+PX/vUv4/7ZOaw3Nmg6xM5G2NOgypInp7Kspdli",
+	"80LRjI3Yd+GmboTVqg5vbiZv2MqyVqQLKXSVPYdRZH9q5PYRiyLjMVoLhH9oa4ZHRg+YFxlVlAmx0TCK",
+	"PJaT1jgnZ6cEbJ4nbTwoMkJNEKcU38FSlgq4KErDVl2hXiglVYV19zgSuKrEWG7DqPc87L029huBpUml",
+	"4v+mZA2+UHLBE4IFZjwBS2BzZ8W5Ukd/BX3GteCGbVVS3LOGnGttc6K09nM4Kp0Hz9N50Nb5J6mmPElI",
+	"ePaAIJEgpIEUFwQFKSdZCjASMI5tmjYp16BIy1LF9BUUX8uvVBo+T6VhW6XfUmpckJI1VrhH7XSbyVIk",
+	"X+PIQBcU2yraEsJ3ZAx7zwuqXtRBIycMMinmpAAXyDOcZl/jUP60vLPn5Y6z7dwxEYaUwAw0qQUpIItv",
+	"HYhGLQHnyAVkaEh9BTVvBD0UFFsd+SHRlWaD52m2FWXXpBY8JijF2mzfUK8D0oKqvlU7LeNqs71KKFmQ",
+	"Mpz0Bv12S+s68N2WtqXdoaK1afU/VDw39JsuXk7/oNjl73dV8b5Y1DbejTSXhG0msmmQLBUoKqSyRzdd",
+	"Ararf+ssNgfA54JbbiOYIc9sgythRiZObaWe8XlbQ20wy/yKjq28HQu11N5BKUC6Z8wgLXMUviJMrPGh",
+	"3lM3asZFF2opqsuJlVQqCjYYGkM2Lx4PxKddaboNZxGne3OPqpXQBq2NmLd5U8YxUbL1rlb1o3fiIN3q",
+	"oeNzHUU7DFivP6Dh2fn3Pv3w49Tv9ZOBj8Ozc3/YPz/vDXvfD6MoYt7GzeoGZk//VtztWnvZNFm1WRGm",
+	"pUFBYXPWtl7GmSwTnwtuIEfBZ6TNlo+wBSqOwoxgFkt9KxaktHOTXjAMoltRoNb3yehWAJSalHZPAL5r",
+	"ekcQS0XVGwCt00+b/uHTHS0b6mqH1qmvNMJ4PB4HQXArDunLL3+/OOhaduGICt/9gxcPdCtuhSYD179d",
+	"XYzfgTbW86pX/7q4up78+h4GPwb9qD+Mer1+MAiiavH1r+9/mvx8c/UWUmMKPQrDmnMQyzysoiPgc9Hw",
+	"fzW+vmhTuwu+DqwlpA5mlEiFhZLWNQKp5qFt+UNtFGGuwxePFbxVvS188ViDW4XVpd+KuSMlKIMXj7Ws",
+	"VVix9Ssh/maTn/EF+RW9XzEAe9gqeZnbjFqjslSBktLM9KdSZS87c672BBXngOdzaJwrmHGljb2tbl41",
+	"F6uAJy9zMphtlmo7VsLXJl/digot+L67RTnQndG5vZhvAbT2s6gOuZeNZ9taNgUNYxdY9R1inNEDikQR",
+	"vMM0SVGWnHmsVBkbseaw59yk5dR5BjbkeUMd6hSLqjbspiuubfNgU9X4cgIzqQBrl7529dY6dMZjEpra",
+	"iAqMU4J+EO0Bub+/D9AtOyer9+rw7eT1xfvrC78fREFq8szlUG5cnDh548sJ81gd5jZRBVEQWSpZkMCC",
+	"sxEbBFEwYB4r0KQu5YfWoIENMvtvTs5qtia4JmCSsBH7mczk8veLV1IabRQWbOdy1d9rkAw9mLDIkLsG",
+	"olvFtxocLPila85nZZYtQZFRnBa2qdnOGa7Bjo7JWMMNLdHmTnWKtte6i5yiHbSa/FO0w1Zf+TTtWRS1",
+	"OrVTtIOqEyrzHNWSjdhVba61R1ahahuEOEUuMomuW7hlofWAf2Y4pUy/XGBWkr5lNspwrl3RtQ7y0XIP",
+	"63MOH+uHyZvVU67zuqJ6tZy8Yd7WcPfD417Pyr+UBHxvgldLWk8brPduBgRrHE+OYTtMDbzDVBvM4da8",
+	"ogP9/ii5w6ZmDNuB9I4nXcg245jVx28SvM327vG7PtL/w8itL74naHvRXx3lyVJgzmO0lsb1mG66BG40",
+	"TN4ErXiuF+uQ7pT+l40P6/04/l8Im/Y0vgP5ziC2w469zxwd9mx/vuqwYXcy3UnxA5P+LqJ2p/PfKDn8",
+	"Xdn/gsreGMyGu24F6oECXs8TdPho43IVuht29elV6gMJ4FJqszXEOFXGbyZvjn252K7c9c34vyraH6v9",
+	"pM0rmSw7zLO6cd9SeLVa7aJc7YXK8MDHnG0Pj6VK6u+P9VTjb+c+7NyFVAbw0GjMTZW2Pw7OlMxhcnAw",
+	"0i559SZto2C1+s8Aqo20xXYgAAA=",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
@@ -37,6 +37,13 @@ import (
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
+// Defines values for MachineEventType.
+const (
+	InstallFailed    MachineEventType = "install-failed"
+	InstallStarted   MachineEventType = "install-started"
+	InstallSucceeded MachineEventType = "install-succeeded"
+)
+
 // Defines values for BuildarchSelector.
 const (
 	BuildarchSelectorArm32 BuildarchSelector = "arm32"
@@ -67,6 +74,18 @@ type Error struct {
 	Message string `json:"message"`
 }
 
+// MachineEvent A provisioning event reported by a machine.
+type MachineEvent struct {
+	// Message An optional human-readable message, e.g. the reason of a failure.
+	Message *string `json:"message,omitempty"`
+
+	// Type The type of the event.
+	Type MachineEventType `json:"type"`
+}
+
+// MachineEventType The type of the event.
+type MachineEventType string
+
 // UUID defines model for UUID.
 type UUID = openapi_types.UUID
 
@@ -76,9 +95,39 @@ type Content = string
 // IPXE An iPXE manifest.
 type IPXE = string
 
+// AssetSelector defines model for assetSelector.
+type AssetSelector = string
+
 // BuildarchSelector defines model for buildarchSelector.
 type BuildarchSelector string
 
+// Expires defines model for expires.
+type Expires = int64
+
+// HostnameSelector defines model for hostnameSelector.
+type HostnameSelector = string
+
+// Kid defines model for kid.
+type Kid = string
+
+// MacSelector defines model for macSelector.
+type MacSelector = string
+
+// ManufacturerSelector defines model for manufacturerSelector.
+type ManufacturerSelector = string
+
+// PlatformSelector defines model for platformSelector.
+type PlatformSelector = string
+
+// ProductSelector defines model for productSelector.
+type ProductSelector = string
+
+// SerialSelector defines model for serialSelector.
+type SerialSelector = string
+
+// Signature defines model for signature.
+type Signature = string
+
 // UuidSelector defines model for uuidSelector.
 type UuidSelector = UUID
 
@@ -94,6 +143,9 @@ type N403 = Error
 // N404 defines model for 404.
 type N404 = Error
 
+// N410 defines model for 410.
+type N410 = Error
+
 // N500 defines model for 500.
 type N500 = Error
 
@@ -104,6 +156,15 @@ type N503 = Error
 type GetContentByIDParams struct {
 	Uuid      *UuidSelector                 `form:"uuid,omitempty" json:"uuid,omitempty"`
 	Buildarch GetContentByIDParamsBuildarch `form:"buildarch" json:"buildarch"`
+
+	// Expires Expiry of a signed content URL, as a Unix timestamp in seconds.
+	Expires *Expires `form:"expires,omitempty" json:"expires,omitempty"`
+
+	// Kid Identifier of the key that signed the content URL.
+	Kid *Kid `form:"kid,omitempty" json:"kid,omitempty"`
+
+	// Signature Base64url-encoded HMAC-SHA256 signature of the content URL.
+	Signature *Signature `form:"signature,omitempty" json:"signature,omitempty"`
 }
 
 // GetContentByIDParamsBuildarch defines parameters for GetContentByID.
@@ -113,11 +174,35 @@ type GetContentByIDParamsBuildarch string
 type GetIPXEBySelectorsParams struct {
 	Uuid      *UuidSelector                     `form:"uuid,omitempty" json:"uuid,omitempty"`
 	Buildarch GetIPXEBySelectorsParamsBuildarch `form:"buildarch" json:"buildarch"`
+
+	// Mac MAC address of the booting network interface, e.g. `52-54-00-12-34-56`.
+	Mac *MacSelector `form:"mac,omitempty" json:"mac,omitempty"`
+
+	// Serial Serial number of the machine.
+	Serial *SerialSelector `form:"serial,omitempty" json:"serial,omitempty"`
+
+	// Hostname Hostname of the machine.
+	Hostname *HostnameSelector `form:"hostname,omitempty" json:"hostname,omitempty"`
+
+	// Asset Asset tag of the machine.
+	Asset *AssetSelector `form:"asset,omitempty" json:"asset,omitempty"`
+
+	// Product Product name of the machine.
+	Product *ProductSelector `form:"product,omitempty" json:"product,omitempty"`
+
+	// Manufacturer Manufacturer of the machine.
+	Manufacturer *ManufacturerSelector `form:"manufacturer,omitempty" json:"manufacturer,omitempty"`
+
+	// Platform Firmware platform of the machine, e.g. `pcbios` or `efi`.
+	Platform *PlatformSelector `form:"platform,omitempty" json:"platform,omitempty"`
 }
 
 // GetIPXEBySelectorsParamsBuildarch defines parameters for GetIPXEBySelectors.
 type GetIPXEBySelectorsParamsBuildarch string
 
+// PostMachineEventJSONRequestBody defines body for PostMachineEvent for application/json ContentType.
+type PostMachineEventJSONRequestBody = MachineEvent
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
 	// Retrieve an iPXE config to chainload to "/ipxe?labels=values"
@@ -129,6 +214,9 @@ type ServerInterface interface {
 	// Retrieve an iPXE manifest by selectors
 	// (GET /ipxe)
 	GetIPXEBySelectors(w http.ResponseWriter, r *http.Request, params GetIPXEBySelectorsParams)
+	// Report a provisioning event of a machine, e.g. from Ignition or cloud-init.
+	// (POST /machines/{uuid}/events)
+	PostMachineEvent(w http.ResponseWriter, r *http.Request, uuid UUID)
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -194,6 +282,30 @@ func (siw *ServerInterfaceWrapper) GetContentByID(w http.ResponseWriter, r *http
 		return
 	}
 
+	// ------------- Optional query parameter "expires" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "expires", r.URL.Query(), &params.Expires)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "expires", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "kid" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "kid", r.URL.Query(), &params.Kid)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "kid", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "signature" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "signature", r.URL.Query(), &params.Signature)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "signature", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.GetContentByID(w, r, contentID, params)
 	}))
@@ -236,6 +348,62 @@ func (siw *ServerInterfaceWrapper) GetIPXEBySelectors(w http.ResponseWriter, r *
 		return
 	}
 
+	// ------------- Optional query parameter "mac" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "mac", r.URL.Query(), &params.Mac)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "mac", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "serial" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "serial", r.URL.Query(), &params.Serial)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "serial", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "hostname" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "hostname", r.URL.Query(), &params.Hostname)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "hostname", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "asset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "asset", r.URL.Query(), &params.Asset)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "asset", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "product" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "product", r.URL.Query(), &params.Product)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "product", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "manufacturer" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "manufacturer", r.URL.Query(), &params.Manufacturer)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "manufacturer", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "platform" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "platform", r.URL.Query(), &params.Platform)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "platform", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.GetIPXEBySelectors(w, r, params)
 	}))
@@ -247,6 +415,31 @@ func (siw *ServerInterfaceWrapper) GetIPXEBySelectors(w http.ResponseWriter, r *
 	handler.ServeHTTP(w, r)
 }
 
+// PostMachineEvent operation middleware
+func (siw *ServerInterfaceWrapper) PostMachineEvent(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "uuid" -------------
+	var uuid UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uuid", r.PathValue("uuid"), &uuid, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uuid", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostMachineEvent(w, r, uuid)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 type UnescapedCookieParamError struct {
 	ParamName string
 	Err       error
@@ -370,6 +563,7 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 	m.HandleFunc("GET "+options.BaseURL+"/boot.ipxe", wrapper.GetIPXEBootstrap)
 	m.HandleFunc("GET "+options.BaseURL+"/content/{contentID}", wrapper.GetContentByID)
 	m.HandleFunc("GET "+options.BaseURL+"/ipxe", wrapper.GetIPXEBySelectors)
+	m.HandleFunc("POST "+options.BaseURL+"/machines/{uuid}/events", wrapper.PostMachineEvent)
 
 	return m
 }
@@ -382,14 +576,12 @@ type N403JSONResponse Error
 
 type N404JSONResponse Error
 
+type N410JSONResponse Error
+
 type N500JSONResponse Error
 
 type N503JSONResponse Error
 
-type ContentTextResponse Content
-
-type IPXETextResponse IPXE
-
 type GetIPXEBootstrapRequestObject struct {
 }
 
@@ -516,6 +708,15 @@ func (response GetContentByID404JSONResponse) VisitGetContentByIDResponse(w http
 	return json.NewEncoder(w).Encode(response)
 }
 
+type GetContentByID410JSONResponse struct{ N410JSONResponse }
+
+func (response GetContentByID410JSONResponse) VisitGetContentByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(410)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 type GetContentByID500JSONResponse struct{ N500JSONResponse }
 
 func (response GetContentByID500JSONResponse) VisitGetContentByIDResponse(w http.ResponseWriter) error {
@@ -606,6 +807,77 @@ func (response GetIPXEBySelectors503JSONResponse) VisitGetIPXEBySelectorsRespons
 	return json.NewEncoder(w).Encode(response)
 }
 
+type PostMachineEventRequestObject struct {
+	Uuid UUID `json:"uuid"`
+	Body *PostMachineEventJSONRequestBody
+}
+
+type PostMachineEventResponseObject interface {
+	VisitPostMachineEventResponse(w http.ResponseWriter) error
+}
+
+type PostMachineEvent204Response struct {
+}
+
+func (response PostMachineEvent204Response) VisitPostMachineEventResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type PostMachineEvent400JSONResponse struct{ N400JSONResponse }
+
+func (response PostMachineEvent400JSONResponse) VisitPostMachineEventResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostMachineEvent401JSONResponse struct{ N401JSONResponse }
+
+func (response PostMachineEvent401JSONResponse) VisitPostMachineEventResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostMachineEvent403JSONResponse struct{ N403JSONResponse }
+
+func (response PostMachineEvent403JSONResponse) VisitPostMachineEventResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostMachineEvent404JSONResponse struct{ N404JSONResponse }
+
+func (response PostMachineEvent404JSONResponse) VisitPostMachineEventResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostMachineEvent500JSONResponse struct{ N500JSONResponse }
+
+func (response PostMachineEvent500JSONResponse) VisitPostMachineEventResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostMachineEvent503JSONResponse struct{ N503JSONResponse }
+
+func (response PostMachineEvent503JSONResponse) VisitPostMachineEventResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 // StrictServerInterface represents all server handlers.
 type StrictServerInterface interface {
 	// Retrieve an iPXE config to chainload to "/ipxe?labels=values"
@@ -617,6 +889,9 @@ type StrictServerInterface interface {
 	// Retrieve an iPXE manifest by selectors
 	// (GET /ipxe)
 	GetIPXEBySelectors(ctx context.Context, request GetIPXEBySelectorsRequestObject) (GetIPXEBySelectorsResponseObject, error)
+	// Report a provisioning event of a machine, e.g. from Ignition or cloud-init.
+	// (POST /machines/{uuid}/events)
+	PostMachineEvent(ctx context.Context, request PostMachineEventRequestObject) (PostMachineEventResponseObject, error)
 }
 
 type StrictHandlerFunc = strictnethttp.StrictHTTPHandlerFunc
@@ -725,34 +1000,78 @@ func (sh *strictHandler) GetIPXEBySelectors(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// PostMachineEvent operation middleware
+func (sh *strictHandler) PostMachineEvent(w http.ResponseWriter, r *http.Request, uuid UUID) {
+	var request PostMachineEventRequestObject
+
+	request.Uuid = uuid
+
+	var body PostMachineEventJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PostMachineEvent(ctx, request.(PostMachineEventRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostMachineEvent")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PostMachineEventResponseObject); ok {
+		if err := validResponse.VisitPostMachineEventResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/+xX227bPBJ+FS6bS5mSD3FbAcHCadzCQA9B0iwK1EVAS2OLrUSqJOXEa/jdF0NKtpJ4",
-	"GyOb7o8f6JVlaTjzzfCb05omqiiVBGkNjde05JoXYEG7f7NK5CnXSXYJOSRWaXwpJI3pzwr0igZU8gJo",
-	"vBOkAdXwsxIaUhpbXUFATZJBwfEkyKqg8Vcq+q+GNKC3r4bXwwENKNdFv+d/hwP6LaB2VaJWY7WQC7rZ",
-	"BLSqRPoYCJShbXtHGuY0pi/CnY+h/2rCq6vJGd2gag2mVNKA83gQRfiTKGlBWnzkZZmLhFuhZPjdKOkc",
-	"ueVFmYOXTIHGgygKaAHG8AUiOeUpwTCAsQEpc+AGSJJB8oOsVKWJkGVl6eZQqGOtlfZYUzCJFiWCqc1c",
-	"eDOobRB1n4a928Z+JXllM6XFvyHdgi+1WooUyJLnIiUoANLWmr075hn8GdWGG7VzpYv62ZBCGCPkgiiM",
-	"n8Phfe4/zed+2+e3Ss9EmoIM8IJIqohUlmR8CaQE7SwrSawiPEnAGGIzYYgGoyqdwDM4vrXvXRo8zaVB",
-	"26XPGTQUhHSLldxw43ybq0qmz3FlxJSQiLloGxH3bBw/LamO7ybVRFrQkufEgF6CJoCYtgy1ekX4ggtJ",
-	"cm5BP4NrVxJuS0gwfGKfae9Z/2me3aHfJeilSIBUki+5yPksh9/o1x5rDNW2nGg9Wri1YZlz4Tw5zHRz",
-	"fJ/xymXQvMrzFdFgtYAlpKQ+4XCI8y/jZwDh1ByOAMVJwaWYg0Ec2zi7tuCDif1RqxK0Fb5b+NtcU1+o",
-	"aEyFtK6T1Q0MebPwl7a97fWe7rZrmV+9zp38rhuq2XdIXKF3vavNK9rt9WFwPHzZgVevZ51uL+13+OB4",
-	"2Bn0hsPuoPtyEEURDXY461Z5D8kdDtzLc7lqLikgwBaMcDKrLJcQioUUrkgrTZJcVWlHSGFbsQxaQJdc",
-	"Cy5tTOaJMlO5BI2lNSZdNmDRVJbcmJs0nkpCKgPauCdCOgRbfEwSpcG/IcSY7HrXqa5/wKqR9ieMyTra",
-	"cDIajUaMsanc52/DtfvO3qdD24UX/xDlLUzlVBqw5PLzxXj0gRiLeeRf/Wt8cTn59JH0X7Ne1BtE3W6P",
-	"9dE7/Pjm08e3k3dXF+9JZm1p4jCsNbNEFZg5c7FgYiEb/aejy3Fb2k1ahmEklGFzSJXmpVZIDab0Iiy1",
-	"SkNjNfDChEdrD29THwuP1jW4TeinLzTzA7SEnByta1ub0KvteCOd3aFOLpbQ8fIdr4DgZev0pMASVaNC",
-	"KaaVsnNzXen85GDN/gzzmpkoFqQhF5sLbexMKbt7VebcIqOZSE8KsDzffarj6I1vQ76ZSo+WdDpIKOJA",
-	"H4zOneXFHYAYP0S1j16Y2TjENMWMJy6x6ml1lMMtl6kG8oFnacZVJWhAK53TmDaXvRA2q2aOGbwRLxrp",
-	"0GS89MXlLn0/43QicEoBMjqfkLnShNeUvnQNDAmdiwSkgTaikicZkB6LHgC5ublh3H12JKvPmvD95M34",
-	"4+W402MRy2yRIxgrrMsTZ290PqEBrdMcCxWLWIRSqgTJS0Fj2mcR69OAltxmrqqGGFCGSYb/FuCihmXX",
-	"ddVJSmP6Duzk/Mv4VClrrOYlvTfG9x5MHH9BCwmabWKfjS3cEIV20/tjst3W1PuYbL81Tj4mO2gNar+W",
-	"PfZ4jw/BgEKulVZFwfWKxvSiDteWkT5VcbhOMi5krniKf6Y0RAb8M+czyM3JkucVmCnFLOML47ZIJMg3",
-	"1N4MHOG6fpicbX5FnTde6nQ1OXO82628X9cPhkDxswIiUtx35gI0UXOXWNuJJfC7KLJ3t4pucfxyHz5g",
-	"Pw32S+0wh3c24wPkH+70m2+/JXv+tynwT+r899RJV5IXIuEYPd5EjcxWRFhDJmeslSTNJfg8Oaimrhpi",
-	"mIfJ8Xfl4p9K/n+o5E3AkImmxaH7BXuz+U8AAAD//yxObOx2FAAA",
+	"H4sIAAAAAAAC/+xZe2/bRhL/KnPb/MmXHnZbAcFBSdxWQJIadn0oEAfJiByJW5O7zO5Sts7Qdz/skpSo",
+	"l8X6kuIO6F+iuLMzv5md1w4fWSzzQgoSRrPRIytQYU6GlPuHWpO5poxiI5V9kZCOFS8Ml4KN2Ngug8E5",
+	"yBmYlCDHOOWCAuYxbgm+lKSWzGMCc2Kjih3zmI5TytHyM8vCLmijuJiz1cpj05JnCao4bYs9xGxNyDym",
+	"6EvJFSVsZFRJbQEkypyNPjA++OGceezhh/NP50PmMVT5oF/9ng/ZR+8AEnoouCK9r/aFXVhanRE0nwtK",
+	"IJbCkDBwc/XWA9SAcCP4AxiekzaYF8AFaIqlSPQx4zTi2uhnUuVo2IhxYRzsGiUXhuakHMxUamNZHD+m",
+	"X2qKjqfUMDxxUHc82Rc1SUgYPuOkGmF3tASTomksZd+1rHUMhOX+tPwc4+Mqvxu/BkwSRVo3QKZSGi7m",
+	"IMjcS3UH1oZqhjF5QME8gM9nff9s6EeR3+v7g6F/dv75GLgc45PgRDnD2JSK1BMoW1QdD6fN+ASGIkNj",
+	"Hei4/J+4yu9RETSkOyAayxTxlEv9GaSCzzTjR+3SsDkFTMmkjJ/IK5cVAfwJp615npCsSXHMjgu+dusg",
+	"ynza+UQqnqcE87lAe2j7Ml+hpvNhqTKfRCwTSuCXd+PX/vUv4/7ZOaw3Nmg6xM5G2NOgypInp7Kspdli",
+	"80LRjI3Yd+GmboTVqg5vbiZv2MqyVqQLKXSVPYdRZH9q5PYRiyLjMVoLhH9oa4ZHRg+YFxlVlAmx0TCK",
+	"PJaT1jgnZ6cEbJ4nbTwoMkJNEKcU38FSlgq4KErDVl2hXiglVYV19zgSuKrEWG7DqPc87L029huBpUml",
+	"4v+mZA2+UHLBE4IFZjwBS2BzZ8W5Ukd/BX3GteCGbVVS3LOGnGttc6K09nM4Kp0Hz9N50Nb5J6mmPElI",
+	"ePaAIJEgpIEUFwQFKSdZCjASMI5tmjYp16BIy1LF9BUUX8uvVBo+T6VhW6XfUmpckJI1VrhH7XSbyVIk",
+	"X+PIQBcU2yraEsJ3ZAx7zwuqXtRBIycMMinmpAAXyDOcZl/jUP60vLPn5Y6z7dwxEYaUwAw0qQUpIItv",
+	"HYhGLQHnyAVkaEh9BTVvBD0UFFsd+SHRlWaD52m2FWXXpBY8JijF2mzfUK8D0oKqvlU7LeNqs71KKFmQ",
+	"Mpz0Bv12S+s68N2WtqXdoaK1afU/VDw39JsuXk7/oNjl73dV8b5Y1DbejTSXhG0msmmQLBUoKqSyRzdd",
+	"Ararf+ssNgfA54JbbiOYIc9sgythRiZObaWe8XlbQ20wy/yKjq28HQu11N5BKUC6Z8wgLXMUviJMrPGh",
+	"3lM3asZFF2opqsuJlVQqCjYYGkM2Lx4PxKddaboNZxGne3OPqpXQBq2NmLd5U8YxUbL1rlb1o3fiIN3q",
+	"oeNzHUU7DFivP6Dh2fn3Pv3w49Tv9ZOBj8Ozc3/YPz/vDXvfD6MoYt7GzeoGZk//VtztWnvZNFm1WRGm",
+	"pUFBYXPWtl7GmSwTnwtuIEfBZ6TNlo+wBSqOwoxgFkt9KxaktHOTXjAMoltRoNb3yehWAJSalHZPAL5r",
+	"ekcQS0XVGwCt00+b/uHTHS0b6mqH1qmvNMJ4PB4HQXArDunLL3+/OOhaduGICt/9gxcPdCtuhSYD179d",
+	"XYzfgTbW86pX/7q4up78+h4GPwb9qD+Mer1+MAiiavH1r+9/mvx8c/UWUmMKPQrDmnMQyzysoiPgc9Hw",
+	"fzW+vmhTuwu+DqwlpA5mlEiFhZLWNQKp5qFt+UNtFGGuwxePFbxVvS188ViDW4XVpd+KuSMlKIMXj7Ws",
+	"VVix9Ssh/maTn/EF+RW9XzEAe9gqeZnbjFqjslSBktLM9KdSZS87c672BBXngOdzaJwrmHGljb2tbl41",
+	"F6uAJy9zMphtlmo7VsLXJl/digot+L67RTnQndG5vZhvAbT2s6gOuZeNZ9taNgUNYxdY9R1inNEDikQR",
+	"vMM0SVGWnHmsVBkbseaw59yk5dR5BjbkeUMd6hSLqjbspiuubfNgU9X4cgIzqQBrl7529dY6dMZjEpra",
+	"iAqMU4J+EO0Bub+/D9AtOyer9+rw7eT1xfvrC78fREFq8szlUG5cnDh548sJ81gd5jZRBVEQWSpZkMCC",
+	"sxEbBFEwYB4r0KQu5YfWoIENMvtvTs5qtia4JmCSsBH7mczk8veLV1IabRQWbOdy1d9rkAw9mLDIkLsG",
+	"olvFtxocLPila85nZZYtQZFRnBa2qdnOGa7Bjo7JWMMNLdHmTnWKtte6i5yiHbSa/FO0w1Zf+TTtWRS1",
+	"OrVTtIOqEyrzHNWSjdhVba61R1ahahuEOEUuMomuW7hlofWAf2Y4pUy/XGBWkr5lNspwrl3RtQ7y0XIP",
+	"63MOH+uHyZvVU67zuqJ6tZy8Yd7WcPfD417Pyr+UBHxvgldLWk8brPduBgRrHE+OYTtMDbzDVBvM4da8",
+	"ogP9/ii5w6ZmDNuB9I4nXcg245jVx28SvM327vG7PtL/w8itL74naHvRXx3lyVJgzmO0lsb1mG66BG40",
+	"TN4ErXiuF+uQ7pT+l40P6/04/l8Im/Y0vgP5ziC2w469zxwd9mx/vuqwYXcy3UnxA5P+LqJ2p/PfKDn8",
+	"Xdn/gsreGMyGu24F6oECXs8TdPho43IVuht29elV6gMJ4FJqszXEOFXGbyZvjn252K7c9c34vyraH6v9",
+	"pM0rmSw7zLO6cd9SeLVa7aJc7YXK8MDHnG0Pj6VK6u+P9VTjb+c+7NyFVAbw0GjMTZW2Pw7OlMxhcnAw",
+	"0i559SZto2C1+s8Aqo20xXYgAAA=",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
@@ -18,10 +18,13 @@
 package v1alpha1
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
 
 	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -34,8 +37,34 @@ const (
 
 	UUIDPrefix      = "uuid"
 	BuildarchPrefix = "buildarch"
+
+	// Subject selector prefixes. Each of them maps to the iPXE setting of the same name.
+
+	MACPrefix          = "mac"
+	SerialPrefix       = "serial"
+	HostnamePrefix     = "hostname"
+	AssetPrefix        = "asset"
+	ProductPrefix      = "product"
+	ManufacturerPrefix = "manufacturer"
+
+	// PlatformKey is the machine selector key of the firmware platform, e.g. `efi`. It is not a subject selector.
+	PlatformKey = "platform"
+
+	hashedLabelValuePrefix = "sha256-"
 )
 
+// SubjectPrefixes lists the subject label prefixes ordered by precedence, i.e. from the most to the least specific
+// match key.
+var SubjectPrefixes = []string{ //nolint:gochecknoglobals
+	UUIDPrefix,
+	MACPrefix,
+	SerialPrefix,
+	HostnamePrefix,
+	AssetPrefix,
+	ProductPrefix,
+	ManufacturerPrefix,
+}
+
 var (
 	GroupVersion  = schema.GroupVersion{Group: Group, Version: Version}
 	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
@@ -66,6 +95,34 @@ func SetUUIDLabelSelector(obj client.Object, id uuid.UUID, value string) {
 	obj.GetLabels()[NewUUIDLabelSelector(id)] = value
 }
 
+// NewSubjectLabelSelector returns a new label selector for a subject attribute, e.g. a MAC address or a serial number.
+//
+// Values are normalized first: MAC addresses are lower-cased and use hyphens as separator, i.e. the format returned by
+// iPXE's `${mac:hexhyp}`. Values that cannot be used as the name part of a label key, such as serial numbers
+// containing spaces, are replaced by a truncated sha256 digest of the value.
+func NewSubjectLabelSelector(prefix, value string) string {
+	return LabelSelector(subjectLabelKey(prefix, value), prefix)
+}
+
+// NormalizeMAC returns the MAC address in the lower-cased and hyphen-separated form.
+func NormalizeMAC(mac string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(mac)), ":", "-")
+}
+
+func subjectLabelKey(prefix, value string) string {
+	if prefix == MACPrefix {
+		value = NormalizeMAC(value)
+	}
+
+	if len(validation.IsValidLabelValue(value)) == 0 && value != "" {
+		return value
+	}
+
+	sum := sha256.Sum256([]byte(value))
+
+	return hashedLabelValuePrefix + hex.EncodeToString(sum[:])[:validation.LabelValueMaxLength-len(hashedLabelValuePrefix)]
+}
+
 // IsUUIDLabelSelector returns true if the given key is a UUID label selector.
 func IsUUIDLabelSelector(key string) bool {
 	return strings.Contains(key, LabelSelector("", UUIDPrefix))
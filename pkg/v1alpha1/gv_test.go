@@ -17,6 +17,7 @@
 package v1alpha1
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -149,3 +150,39 @@ func TestIsUUIDLabelSelector(t *testing.T) {
 		})
 	}
 }
+
+func TestNewSubjectLabelSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		value    string
+		expected string
+	}{
+		{
+			name:     "MAC is normalized",
+			prefix:   MACPrefix,
+			value:    "52:54:00:AB:CD:EF",
+			expected: "mac.shaper.amahdha.com/52-54-00-ab-cd-ef",
+		},
+		{
+			name:     "Valid label value is kept as is",
+			prefix:   SerialPrefix,
+			value:    "SN-0042",
+			expected: "serial.shaper.amahdha.com/SN-0042",
+		},
+		{
+			name:     "Invalid label value is hashed",
+			prefix:   ManufacturerPrefix,
+			value:    "Dell Inc.",
+			expected: "manufacturer.shaper.amahdha.com/sha256-",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewSubjectLabelSelector(tt.prefix, tt.value)
+			assert.True(t, strings.HasPrefix(result, tt.expected), result)
+			assert.LessOrEqual(t, len(result)-len(LabelSelector("", tt.prefix)), 63)
+		})
+	}
+}
@@ -25,6 +25,8 @@ func init() {
 var (
 	// DefaultAssignmentLabel is used to query default assignments.
 	DefaultAssignmentLabel = LabelSelector("default-assignment")
+	// MachineSelectorAssignmentLabel is used to query assignments specifying a machine selector.
+	MachineSelectorAssignmentLabel = LabelSelector("machine-selector")
 
 	// BuildarchList Label Selector
 
@@ -60,6 +62,65 @@ var (
 	}
 )
 
+const (
+	// AssignmentConditionAmbiguous is true when other assignments with the same priority may match the same machines,
+	// i.e. they select a common subject, or an equal machine selector, for a common buildarch. The tie is broken by
+	// name.
+	AssignmentConditionAmbiguous = "Ambiguous"
+
+	// AssignmentReasonPriorityTie is the reason of the AssignmentConditionAmbiguous condition when it is true.
+	AssignmentReasonPriorityTie = "PriorityTie"
+	// AssignmentReasonNoPriorityTie is the reason of the AssignmentConditionAmbiguous condition when it is false.
+	AssignmentReasonNoPriorityTie = "NoPriorityTie"
+
+	// AssignmentConditionProfileFound is true when the profile referenced by the assignment exists.
+	AssignmentConditionProfileFound = "ProfileFound"
+	// AssignmentConditionContentResolvable is true when every additional content of the referenced profile resolves.
+	AssignmentConditionContentResolvable = "ContentResolvable"
+	// AssignmentConditionReady is true when the assignment can be served to machines, i.e. its profile exists and its
+	// content resolves.
+	AssignmentConditionReady = "Ready"
+
+	// AssignmentReasonProfileFound is the reason of the AssignmentConditionProfileFound condition when the profile
+	// exists.
+	AssignmentReasonProfileFound = "ProfileFound"
+	// AssignmentReasonProfileNotFound is the reason of the AssignmentConditionProfileFound and
+	// AssignmentConditionReady conditions when the profile does not exist.
+	AssignmentReasonProfileNotFound = "ProfileNotFound"
+	// AssignmentReasonContentResolved is the reason of the AssignmentConditionContentResolvable condition when every
+	// content resolves.
+	AssignmentReasonContentResolved = "ContentResolved"
+	// AssignmentReasonContentUnresolvable is the reason of the AssignmentConditionContentResolvable and
+	// AssignmentConditionReady conditions when a content cannot be resolved.
+	AssignmentReasonContentUnresolvable = "ContentUnresolvable"
+	// AssignmentReasonReady is the reason of the AssignmentConditionReady condition when the assignment is ready.
+	AssignmentReasonReady = "Ready"
+
+	// MaxLastServedMachines is the maximum number of machines reported in the status of an assignment.
+	MaxLastServedMachines = 10
+)
+
+// BootMode defines what is served to a machine matching an assignment.
+type BootMode string
+
+const (
+	// BootModeAlways serves the assigned profile on every boot.
+	BootModeAlways BootMode = "always"
+	// BootModeProvisionOnce serves the assigned profile until the machine is provisioned, then instructs it to boot
+	// from its local disk.
+	BootModeProvisionOnce BootMode = "provision-once"
+)
+
+// ProfileKind is the kind of the profile referenced by an assignment.
+type ProfileKind string
+
+const (
+	// ProfileKindProfile references a Profile in the namespace of the assignment.
+	ProfileKindProfile ProfileKind = "Profile"
+	// ProfileKindClusterProfile references a cluster-scoped ClusterProfile.
+	ProfileKindClusterProfile ProfileKind = "ClusterProfile"
+)
+
 // Buildarch is the build architecture of the machine.
 type Buildarch string
 
@@ -95,19 +156,53 @@ const (
 //   subjectSelectors:
 //     buildarch: # please note only 1 buildarch mat be specified at a time.
 //       - arm64
-//     serialNumber:
+//     serialList:
 //       - c4a94672-05a1-4eda-a186-b4aa4544b146
-//     uuid:
+//     macList:
+//       - 52:54:00:12:34:56
+//     uuidList:
 //       - 47c6da67-7477-4970-aa03-84e48ff4f6ad
 //       - 3f5f3c39-584e-4c7c-b6ff-137e1aaa7175
+//   # machineSelector metav1.LabelSelector
+//   # selects subjects using their attributes, e.g. `manufacturer`, `product` or `platform`.
+//   machineSelector:
+//     matchLabels:
+//       platform: efi
+//     matchExpressions:
+//       - key: product
+//         operator: In
+//         values: [r640, r650]
+//   # priority int32
+//   # the matching assignment with the highest priority is selected.
+//   priority: 10
+//   # bootMode BootMode
+//   # `provision-once` serves the profile until the machine is provisioned, then boots it from its local disk.
+//   bootMode: provision-once
+//   # parameters []Parameter
+//   # override the parameters of the profile, i.e. the `{{ .Params.NAME }}` template variables.
+//   parameters:
+//     - name: channel
+//       value: beta
 //   # profileName string
 //   profileName: 819f1859-a669-410b-adfc-d0bc128e2d7a
+//   # profileKind ProfileKind
+//   # either `Profile`, i.e. a Profile in the namespace of the assignment, or `ClusterProfile`.
+//   profileKind: ClusterProfile
 // status:
-//   conditions: []
+//   conditions:
+//     - type: Ready
+//       status: "True"
+//       reason: Ready
+//   lastServedMachines:
+//     - name: 47c6da67-7477-4970-aa03-84e48ff4f6ad
+//       lastBootTime: "2024-01-02T00:00:00Z"
 
 type (
 	//+kubebuilder:object:root=true
 	//+kubebuilder:subresource:status
+	//+kubebuilder:printcolumn:name="Profile",type=string,JSONPath=`.spec.profileName`
+	//+kubebuilder:printcolumn:name="Kind",type=string,JSONPath=`.spec.profileKind`,priority=1
+	//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 
 	// Assignment is the Schema for the assignments API
 	Assignment struct {
@@ -134,12 +229,57 @@ type (
 		SubjectSelectors SubjectSelectors `json:"subjectSelectors"`
 		// ProfileName is the name of the profile to assign to the machine.
 		ProfileName string `json:"profileName"`
+		// ProfileKind is either `Profile` or `ClusterProfile`. Defaults to `Profile`.
+		// A Profile is looked up in the namespace of the assignment, a ClusterProfile is cluster-scoped.
+		// +kubebuilder:validation:Enum=Profile;ClusterProfile
+		// +optional
+		ProfileKind ProfileKind `json:"profileKind,omitempty"`
 		// IsDefault is true if this assignment is the default assignment.
 		IsDefault bool `json:"isDefault"`
+		// MachineSelector selects machines by their attributes. The available keys are `uuid`, `buildarch`, `mac`,
+		// `serial`, `hostname`, `asset`, `product`, `manufacturer` and `platform`.
+		// MAC addresses are lowercase and hyphen-separated, e.g. `52-54-00-12-34-56`.
+		// +optional
+		MachineSelector *metav1.LabelSelector `json:"machineSelector,omitempty"`
+		// Priority is used to select an assignment when many of them match a machine. The assignment with the highest
+		// priority wins.
+		// +optional
+		Priority int32 `json:"priority,omitempty"`
+		// BootMode is either `always` or `provision-once`. Defaults to `always`.
+		// With `provision-once`, the profile is served until the machine is provisioned, i.e. until it fetches an
+		// exposed content of the profile; then a script booting from the local disk is served instead. Annotate the
+		// Machine with `shaper.amahdha.com/reprovision` to provision it again.
+		// +kubebuilder:validation:Enum=always;provision-once
+		// +optional
+		BootMode BootMode `json:"bootMode,omitempty"`
+		// Parameters override the parameters of the profile, i.e. the template variables available as `.Params.NAME`.
+		// +optional
+		Parameters []Parameter `json:"parameters,omitempty"`
+		// AllowedCIDRs restricts the clients served through this assignment to the given source CIDRs, e.g.
+		// `10.0.1.0/24`. Requests for the iPXE script or the content of a machine selected by this assignment from
+		// another source are rejected. All sources are allowed when empty.
+		// +optional
+		AllowedCIDRs []string `json:"allowedCIDRs,omitempty"`
 	}
 
 	// AssignmentStatus defines the observed state of Assignment
-	AssignmentStatus struct{}
+	AssignmentStatus struct {
+		// Conditions represent the latest available observations of the assignment's state.
+		// +optional
+		Conditions []metav1.Condition `json:"conditions,omitempty"`
+		// LastServedMachines are the machines that were last served through this assignment, most recent first.
+		// +optional
+		LastServedMachines []ServedMachine `json:"lastServedMachines,omitempty"`
+	}
+
+	// ServedMachine is a machine served through an assignment.
+	ServedMachine struct {
+		// Name is the name of the Machine resource, i.e. the UUID of the machine.
+		Name string `json:"name"`
+		// LastBootTime is the time the machine was last served.
+		// +optional
+		LastBootTime *metav1.Time `json:"lastBootTime,omitempty"`
+	}
 
 	// SubjectSelectors is a map of selectors that are used to match a machine.
 	SubjectSelectors struct {
@@ -147,9 +287,43 @@ type (
 		BuildarchList []Buildarch `json:"buildarch"`
 		// UUIDList is a list of UUIDs to match.
 		UUIDList []string `json:"uuidList"`
+		// MACList is a list of MAC addresses to match, e.g. `52:54:00:12:34:56` or `52-54-00-12-34-56`.
+		MACList []string `json:"macList,omitempty"`
+		// SerialList is a list of serial numbers to match.
+		SerialList []string `json:"serialList,omitempty"`
+		// HostnameList is a list of hostnames to match.
+		HostnameList []string `json:"hostnameList,omitempty"`
+		// AssetList is a list of asset tags to match.
+		AssetList []string `json:"assetList,omitempty"`
+		// ProductList is a list of product names to match.
+		ProductList []string `json:"productList,omitempty"`
+		// ManufacturerList is a list of manufacturers to match.
+		ManufacturerList []string `json:"manufacturerList,omitempty"`
 	}
 )
 
+// ByPrefix returns the values of each specified subject selector keyed by their label prefix, e.g. `mac`.
+// Buildarch is not a subject selector and is therefore omitted.
+func (s SubjectSelectors) ByPrefix() map[string][]string {
+	out := make(map[string][]string)
+
+	for prefix, values := range map[string][]string{
+		UUIDPrefix:         s.UUIDList,
+		MACPrefix:          s.MACList,
+		SerialPrefix:       s.SerialList,
+		HostnamePrefix:     s.HostnameList,
+		AssetPrefix:        s.AssetList,
+		ProductPrefix:      s.ProductList,
+		ManufacturerPrefix: s.ManufacturerList,
+	} {
+		if len(values) > 0 {
+			out[prefix] = values
+		}
+	}
+
+	return out
+}
+
 // GetBuildarchList returns the list of build architectures for the assignment.
 func (a *Assignment) GetBuildarchList() []Buildarch {
 	out := make([]Buildarch, 0)
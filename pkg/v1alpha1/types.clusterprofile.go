@@ -0,0 +1,78 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+) //nolint:depguard
+
+//nolint:gochecknoinits
+func init() {
+	SchemeBuilder.Register(&ClusterProfile{}, &ClusterProfileList{})
+}
+
+// apiVersion: shaper.amahdha.com/v1alpha1
+// kind: ClusterProfile
+// metadata:
+//   name: your-cluster-profile
+// spec:
+//   # Same as the spec of a Profile. Assignments of any namespace reference it with `profileKind: ClusterProfile`.
+//   ipxeTemplate: |
+//     #!ipxe
+//     chain {{ .AdditionalContent.ignitionFile }}
+//   additionalContent:
+//     - name: ignitionFile
+//       exposed: true
+//       inline: |
+//         YOUR IGNITION CONFIG HERE
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Template",type=string,JSONPath=`.status.conditions[?(@.type=="TemplateValid")].status`
+//+kubebuilder:printcolumn:name="Content",type=string,JSONPath=`.status.conditions[?(@.type=="ContentResolvable")].status`
+
+// ClusterProfile is the Schema for the cluster-scoped profiles API. It is shared by the assignments of every namespace.
+type ClusterProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProfileSpec   `json:"spec,omitempty"`
+	Status ProfileStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterProfileList contains a list of ClusterProfile
+type ClusterProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClusterProfile `json:"items"`
+}
+
+// AsProfile returns a copy of the cluster profile as a Profile without namespace, hence a ClusterProfile is validated,
+// merged and converted like a Profile.
+func (in *ClusterProfile) AsProfile() *Profile {
+	out := &Profile{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec:       *in.Spec.DeepCopy(),
+		Status:     *in.Status.DeepCopy(),
+	}
+
+	out.Namespace = ""
+
+	return out
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	SchemeBuilder.Register(&Machine{}, &MachineList{})
+}
+
+// ReprovisionAnnotation is set on a Machine to serve its profile again although it is already provisioned. It is
+// removed once the profile is served.
+var ReprovisionAnnotation = LabelSelector("reprovision")
+
+const (
+	// MachineConditionInstalled reports the installation events sent by the machine to the API server. It is Unknown
+	// while the installation is in progress, True when it succeeded and False when it failed.
+	MachineConditionInstalled = "Installed"
+
+	// MachineReasonInstallStarted is the reason of the MachineConditionInstalled condition when the machine reported
+	// the `install-started` event.
+	MachineReasonInstallStarted = "InstallStarted"
+	// MachineReasonInstallSucceeded is the reason of the MachineConditionInstalled condition when the machine reported
+	// the `install-succeeded` event.
+	MachineReasonInstallSucceeded = "InstallSucceeded"
+	// MachineReasonInstallFailed is the reason of the MachineConditionInstalled condition when the machine reported
+	// the `install-failed` event.
+	MachineReasonInstallFailed = "InstallFailed"
+)
+
+// MachinePhase is the provisioning phase of a Machine.
+type MachinePhase string
+
+const (
+	// MachinePhaseProvisioning means the machine was served a profile with the `provision-once` boot mode and did
+	// not complete its provisioning yet.
+	MachinePhaseProvisioning MachinePhase = "Provisioning"
+	// MachinePhaseProvisioned means the machine completed its provisioning. Machines matching an assignment with
+	// the `provision-once` boot mode boot from their local disk.
+	MachinePhaseProvisioned MachinePhase = "Provisioned"
+)
+
+// apiVersion: shaper.amahdha.com/v1alpha1
+// kind: Machine
+// metadata:
+//   # the name of a machine is its UUID.
+//   name: 47c6da67-7477-4970-aa03-84e48ff4f6ad
+//   labels:
+//     # user-defined labels can be matched by the machineSelector of an Assignment.
+//     rack: r42
+// spec: {}
+// status:
+//   attributes:
+//     uuid: 47c6da67-7477-4970-aa03-84e48ff4f6ad
+//     buildarch: x86_64
+//     mac: 52-54-00-12-34-56
+//     platform: efi
+//   clientIP: 10.0.0.42
+//   firstBootTime: "2024-01-01T00:00:00Z"
+//   lastBootTime: "2024-01-02T00:00:00Z"
+//   assignmentName: dc1-servers
+//   profileName: flatcar-linux
+//   phase: Provisioned
+//   conditions:
+//     - type: Installed
+//       status: "True"
+//       reason: InstallSucceeded
+
+type (
+	//+kubebuilder:object:root=true
+	//+kubebuilder:subresource:status
+	//+kubebuilder:printcolumn:name="MAC",type=string,JSONPath=`.status.attributes.mac`
+	//+kubebuilder:printcolumn:name="Buildarch",type=string,JSONPath=`.status.attributes.buildarch`
+	//+kubebuilder:printcolumn:name="Client IP",type=string,JSONPath=`.status.clientIP`
+	//+kubebuilder:printcolumn:name="Profile",type=string,JSONPath=`.status.profileName`
+	//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+	//+kubebuilder:printcolumn:name="Installed",type=string,JSONPath=`.status.conditions[?(@.type=="Installed")].status`
+	//+kubebuilder:printcolumn:name="Last Boot",type=date,JSONPath=`.status.lastBootTime`
+
+	// Machine is the Schema for the machines API. Machines are created by the API server the first time a host
+	// requests an iPXE script and are keyed by the UUID of the host.
+	Machine struct {
+		metav1.TypeMeta   `json:",inline"`
+		metav1.ObjectMeta `json:"metadata,omitempty"`
+
+		Spec   MachineSpec   `json:"spec,omitempty"`
+		Status MachineStatus `json:"status,omitempty"`
+	}
+
+	//+kubebuilder:object:root=true
+
+	// MachineList contains a list of Machine
+	MachineList struct {
+		metav1.TypeMeta `json:",inline"`
+		metav1.ListMeta `json:"metadata,omitempty"`
+
+		Items []Machine `json:"items"`
+	}
+
+	// MachineSpec defines the desired state of Machine
+	MachineSpec struct{}
+
+	// MachineStatus defines the observed state of Machine
+	MachineStatus struct {
+		// Attributes are the iPXE attributes reported by the machine during its last boot.
+		// +optional
+		Attributes MachineAttributes `json:"attributes,omitempty"`
+		// ClientIP is the IP address of the machine as seen by the API server during its last boot.
+		// +optional
+		ClientIP string `json:"clientIP,omitempty"`
+		// FirstBootTime is the time the machine requested an iPXE script for the first time.
+		// +optional
+		FirstBootTime *metav1.Time `json:"firstBootTime,omitempty"`
+		// LastBootTime is the time the machine requested an iPXE script for the last time.
+		// +optional
+		LastBootTime *metav1.Time `json:"lastBootTime,omitempty"`
+		// AssignmentName is the name of the Assignment served during the last boot.
+		// +optional
+		AssignmentName string `json:"assignmentName,omitempty"`
+		// AssignmentNamespace is the namespace of the Assignment served during the last boot.
+		// +optional
+		AssignmentNamespace string `json:"assignmentNamespace,omitempty"`
+		// ProfileName is the name of the Profile served during the last boot.
+		// +optional
+		ProfileName string `json:"profileName,omitempty"`
+		// Phase is the provisioning phase of the machine. It is only set for machines served an Assignment with the
+		// `provision-once` boot mode.
+		// +kubebuilder:validation:Enum=Provisioning;Provisioned
+		// +optional
+		Phase MachinePhase `json:"phase,omitempty"`
+		// Conditions represent the provisioning events reported by the machine, e.g. through
+		// `POST /machines/{uuid}/events`.
+		// +optional
+		Conditions []metav1.Condition `json:"conditions,omitempty"`
+	}
+
+	// MachineAttributes are the iPXE attributes reported by a machine.
+	MachineAttributes struct {
+		// UUID is the SMBIOS UUID of the machine.
+		UUID string `json:"uuid,omitempty"`
+		// Buildarch is the build architecture of the machine.
+		Buildarch string `json:"buildarch,omitempty"`
+		// MAC is the MAC address of the booting network interface.
+		MAC string `json:"mac,omitempty"`
+		// Platform is the firmware platform of the machine, e.g. `pcbios` or `efi`.
+		Platform string `json:"platform,omitempty"`
+		// Manufacturer is the manufacturer of the machine.
+		Manufacturer string `json:"manufacturer,omitempty"`
+		// Product is the product name of the machine.
+		Product string `json:"product,omitempty"`
+		// Serial is the serial number of the machine.
+		Serial string `json:"serial,omitempty"`
+		// Hostname is the hostname of the machine.
+		Hostname string `json:"hostname,omitempty"`
+		// Asset is the asset tag of the machine.
+		Asset string `json:"asset,omitempty"`
+	}
+)
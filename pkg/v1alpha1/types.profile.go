@@ -31,6 +31,10 @@ func init() {
 //     assign/ipxe/buildarch: aarch64
 //     assign/extrinsic/region: us-cal
 // spec:
+//   # baseProfileRef: ProfileReference.
+//   # the ipxeTemplate overrides the one of the base profile; additionalContent and parameters are merged by name.
+//   baseProfileRef:
+//     name: your-base-profile
 //   # ipxe: string.
 //   ipxe: |
 //     command ... \
@@ -38,6 +42,19 @@ func init() {
 //       --ignition-url "{{ .AdditionalContent.ignitionFile }}" \
 //       --or-cloud-init "{{ .AdditionalContent.cloudInit }}" \
 //       --secret-token "{{ .AdditionalContent.secretToken }}"
+//   # parameters: []Parameter.
+//   # default values of the `{{ .Params.NAME }}` template variables; the parameters of an Assignment override them.
+//   parameters:
+//     - name: channel
+//       value: stable
+//     - name: joinToken
+//       objectRef:
+//         group: ""
+//         version: v1
+//         resource: secrets
+//         namespace: your-namespace
+//         name: your-secret
+//         jsonpath: .data.token
 //   # additionalContent: []AdditionalContent.
 //   additionalContent:
 //     - name: parameter-0
@@ -63,6 +80,8 @@ func init() {
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Template",type=string,JSONPath=`.status.conditions[?(@.type=="TemplateValid")].status`
+//+kubebuilder:printcolumn:name="Content",type=string,JSONPath=`.status.conditions[?(@.type=="ContentResolvable")].status`
 
 // Profile is the Schema for the profiles API
 type Profile struct {
@@ -75,19 +94,75 @@ type Profile struct {
 
 // ProfileSpec defines the desired state of Profile
 type ProfileSpec struct {
-	// IPXETemplate is the iPXE script template.
-	IPXETemplate string `json:"ipxeTemplate"`
+	// BaseProfileRef references a profile of the same namespace this profile inherits from; the base of a
+	// ClusterProfile is a ClusterProfile. The IPXETemplate of this profile overrides the one of the base, and the
+	// AdditionalContent and Parameters are merged by name: the ones of this profile override the ones of the base.
+	// +optional
+	BaseProfileRef *ProfileReference `json:"baseProfileRef,omitempty"`
+
+	// IPXETemplate is the iPXE script template. It is required unless it is inherited from a base profile.
+	// +optional
+	IPXETemplate string `json:"ipxeTemplate,omitempty"`
 
 	// AdditionalContent can be templated into the IPXETemplate using the content's key.
 	AdditionalContent []AdditionalContent `json:"additionalContent,omitempty"`
+
+	// Parameters are the default values of the template variables available as `.Params.NAME` to the IPXETemplate
+	// and to the inline and objectRef additional content. The parameters of an Assignment override them.
+	// +optional
+	Parameters []Parameter `json:"parameters,omitempty"`
+
+	// ExternalBaseURL overrides the base URL of the shaper API used in the URLs of the exposed additional content and
+	// available as `.BaseURL`, e.g. when the installed OS reaches the shaper API through another address than iPXE.
+	// It is inherited from the base profile when empty.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +optional
+	ExternalBaseURL string `json:"externalBaseURL,omitempty"`
 }
 
 // ProfileStatus defines the observed state of Profile
 type ProfileStatus struct {
 	// ExposedAdditionalContent maps content names to their UUIDs for exposed content
 	ExposedAdditionalContent map[string]string `json:"exposedAdditionalContent,omitempty"`
+
+	// Conditions report the result of a dry-run rendering of the profile.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+const (
+	// ProfileConditionTemplateValid is true when the IPXETemplate of the profile parses.
+	ProfileConditionTemplateValid = "TemplateValid"
+	// ProfileConditionContentResolvable is true when every additional content of the profile resolves and transforms.
+	ProfileConditionContentResolvable = "ContentResolvable"
+	// ProfileConditionButaneValid is true when every additional content transformed from butane to ignition
+	// translates. It is only set when the profile has such content.
+	ProfileConditionButaneValid = "ButaneValid"
+
+	// ProfileReasonTemplateParsed is the reason of the ProfileConditionTemplateValid condition when the template parses.
+	ProfileReasonTemplateParsed = "TemplateParsed"
+	// ProfileReasonTemplateInvalid is the reason of the ProfileConditionTemplateValid condition when the template cannot
+	// be parsed.
+	ProfileReasonTemplateInvalid = "TemplateInvalid"
+	// ProfileReasonContentResolved is the reason of the ProfileConditionContentResolvable condition when every content
+	// resolves.
+	ProfileReasonContentResolved = "ContentResolved"
+	// ProfileReasonContentUnresolvable is the reason of the ProfileConditionContentResolvable condition when a content
+	// cannot be resolved or transformed, and of the ProfileConditionButaneValid condition when a butane content cannot
+	// be resolved.
+	ProfileReasonContentUnresolvable = "ContentUnresolvable"
+	// ProfileReasonButaneTranslated is the reason of the ProfileConditionButaneValid condition when every butane content
+	// translates.
+	ProfileReasonButaneTranslated = "ButaneTranslated"
+	// ProfileReasonButaneInvalid is the reason of the ProfileConditionButaneValid condition when a butane content cannot
+	// be translated.
+	ProfileReasonButaneInvalid = "ButaneInvalid"
+	// ProfileReasonBaseProfileInvalid is the reason of the ProfileConditionTemplateValid and
+	// ProfileConditionContentResolvable conditions when the profile cannot be merged with its base profiles, e.g. a
+	// base profile cannot be found or the profiles inherit from each other.
+	ProfileReasonBaseProfileInvalid = "BaseProfileInvalid"
+)
+
 //+kubebuilder:object:root=true
 
 // ProfileList contains a list of Profile
@@ -125,6 +200,24 @@ type (
 		Webhook *WebhookConfig `json:"webhook,omitempty"`
 	}
 
+	// ProfileReference references a profile of the same namespace, or a ClusterProfile.
+	ProfileReference struct {
+		// Name of the referenced profile.
+		Name string `json:"name"`
+	}
+
+	// Parameter is a template variable available as `.Params.NAME`.
+	Parameter struct {
+		// Name of the parameter. It must be a valid template identifier, e.g. `ntpServer`.
+		Name string `json:"name"`
+
+		// Value of the parameter.
+		Value *string `json:"value,omitempty"`
+
+		// ObjectRef is a reference to a resource holding the value of the parameter, e.g. a ConfigMap or a Secret.
+		ObjectRef *ObjectRef `json:"objectRef,omitempty"`
+	}
+
 	// Transformer is a transformation that can be applied to a piece of content.
 	Transformer struct {
 		// ButaneToIgnition transforms a butane yaml document into a proper ignition one.
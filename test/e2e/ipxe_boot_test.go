@@ -914,6 +914,7 @@ func TestInlineContentResolution_E2E(t *testing.T) {
 	// iPXE template (minimal, just for Profile creation)
 	ipxeTemplate := `#!ipxe
 echo TC3 Inline Content Test
+echo Content: {{ index .AdditionalContent "inline-test-content" }}
 shell`
 
 	// Additional content with inline source
@@ -996,6 +997,7 @@ func TestObjectRefConfigMapResolution_E2E(t *testing.T) {
 	// iPXE template (minimal, just for Profile creation)
 	ipxeTemplate := `#!ipxe
 echo TC4 ObjectRef ConfigMap Test
+echo Content: {{ index .AdditionalContent "configmap-ref-content" }}
 shell`
 
 	// Cleanup function (order matters: Profile first, then ConfigMap)
@@ -1096,6 +1098,7 @@ func TestObjectRefSecretResolution_E2E(t *testing.T) {
 	// iPXE template (minimal, just for Profile creation)
 	ipxeTemplate := `#!ipxe
 echo TC4b ObjectRef Secret Test
+echo Content: {{ index .AdditionalContent "secret-ref-content" }}
 shell`
 
 	// Cleanup function (order matters: Profile first, then Secret)
@@ -1201,6 +1204,7 @@ storage:
 	// iPXE template (minimal, just for Profile creation)
 	ipxeTemplate := `#!ipxe
 echo TC5 Butane-to-Ignition Test
+echo Content: {{ index .AdditionalContent "butane-ignition-content" }}
 shell`
 
 	// Additional content with Butane source and transformation